@@ -4,11 +4,24 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"auth-service/internal/database"
 	"auth-service/internal/handlers"
+	"auth-service/internal/middlewares"
+	"auth-service/internal/oidc"
+	"auth-service/internal/ratelimit"
+	"shared/jwtauth"
+	"shared/mailer"
+	"shared/passpolicy"
+	"shared/tokenversion"
+
 	"github.com/gorilla/mux"
 )
 
+// serviceTokenTTL — срок действия сервисных токенов для обращений к другим сервисам
+const serviceTokenTTL = 5 * time.Minute
+
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -23,9 +36,95 @@ func enableCORS(next http.Handler) http.Handler {
 }
 
 func main() {
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	jwtConfig, err := jwtauth.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load JWT configuration: %v", err)
+	}
+	signer, err := jwtauth.NewSigner(jwtConfig)
+	if err != nil {
+		log.Fatalf("Failed to create token signer: %v", err)
+	}
+	if !jwtConfig.Keys[jwtConfig.ActiveKeyID].IsAsymmetric() {
+		log.Printf("Warning: tokens are signed with a shared HMAC secret; set JWT_PRIVATE_KEYS to publish keys via JWKS")
+	}
+
+	// Запросы к Users Service подписываются сервисным токеном auth_service
+	handlers.UseServiceTokens(jwtauth.NewServiceTokenSource(signer, handlers.ServiceName, serviceTokenTTL))
+
+	// Другим сервисам (posts-service, users-service) сервисные токены выдаются
+	// по секретам из SERVICE_CLIENTS. Переменная необязательна: без неё
+	// /service-token отклоняет все запросы.
+	serviceClients, err := handlers.ServiceClientsFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	m, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	policy, err := passpolicy.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure password policy: %v", err)
+	}
+
+	oidcConfigs, err := oidc.ConfigsFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load OIDC providers: %v", err)
+	}
+	providers := make(map[string]*oidc.Provider, len(oidcConfigs))
+	for _, cfg := range oidcConfigs {
+		providers[cfg.Name] = oidc.NewProvider(cfg)
+	}
+
+	limiter, err := ratelimit.NewFromEnv(db)
+	if err != nil {
+		log.Fatalf("Failed to configure login rate limiter: %v", err)
+	}
+
+	verifier := jwtauth.NewVerifier(jwtConfig)
+	// Токены, выданные до смены пароля, отклоняются
+	versions, err := tokenversion.NewFromEnv(db)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	verifier.AddCheck(versions.Check)
+	requireAuth := middlewares.AuthMiddleware(verifier)
+
 	r := mux.NewRouter()
-	r.HandleFunc("/login", handlers.Login()).Methods("POST")
-	r.HandleFunc("/register", handlers.RegisterUser()).Methods("POST")
+	r.HandleFunc("/login", handlers.Login(db, signer, limiter)).Methods("POST")
+	r.HandleFunc("/login/mfa", handlers.LoginMFA(db, signer, verifier, limiter)).Methods("POST")
+	r.HandleFunc("/register", handlers.RegisterUser(policy)).Methods("POST")
+	r.HandleFunc("/refresh", handlers.Refresh(db, signer)).Methods("POST")
+	r.HandleFunc("/logout", handlers.Logout(db)).Methods("POST")
+	r.HandleFunc("/password/forgot", handlers.ForgotPassword(db, m, limiter)).Methods("POST")
+	r.HandleFunc("/password/reset", handlers.ResetPassword(db)).Methods("POST")
+	r.HandleFunc("/.well-known/jwks.json", handlers.JWKS(jwtConfig)).Methods("GET")
+	r.HandleFunc("/service-token", handlers.IssueServiceToken(signer, serviceClients, serviceTokenTTL)).Methods("POST")
+	r.HandleFunc("/oidc/{provider}/authorize", handlers.OIDCAuthorize(db, providers)).Methods("GET")
+	r.HandleFunc("/oidc/{provider}/callback", handlers.OIDCCallback(db, signer, providers)).Methods("GET")
+
+	r.Handle("/password/change", requireAuth(handlers.ChangePassword(db, signer, limiter))).Methods("POST")
+
+	r.Handle("/mfa/totp/enroll", requireAuth(handlers.EnrollTOTP())).Methods("POST")
+	r.Handle("/mfa/totp/confirm", requireAuth(handlers.ConfirmTOTP())).Methods("POST")
+	r.Handle("/mfa/totp/disable", requireAuth(handlers.DisableTOTP())).Methods("POST")
+
+	r.Handle("/api-keys", requireAuth(handlers.CreateAPIKey(db))).Methods("POST")
+	r.Handle("/api-keys", requireAuth(handlers.ListAPIKeys(db))).Methods("GET")
+	r.Handle("/api-keys/{id:[0-9]+}", requireAuth(handlers.RenameAPIKey(db))).Methods("PATCH")
+	r.Handle("/api-keys/{id:[0-9]+}", requireAuth(handlers.RevokeAPIKey(db))).Methods("DELETE")
 
 	corsHandler := enableCORS(r)
 
@@ -0,0 +1,42 @@
+// Команда mock-oidc запускает локальный провайдер OpenID Connect для разработки.
+// auth_service подключается к нему переменными
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:9096
+//	OIDC_MOCK_CLIENT_ID=yandexcloud
+//	OIDC_MOCK_CLIENT_SECRET=mock-secret
+//	OIDC_MOCK_REDIRECT_URL=http://localhost:8081/oidc/mock/callback
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"auth-service/internal/oidc/mock"
+)
+
+func envOrDefault(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func main() {
+	port := envOrDefault("PORT", "9096")
+
+	server, err := mock.New(mock.Config{
+		Issuer:        envOrDefault("MOCK_OIDC_ISSUER", "http://localhost:"+port),
+		ClientID:      envOrDefault("MOCK_OIDC_CLIENT_ID", "yandexcloud"),
+		ClientSecret:  envOrDefault("MOCK_OIDC_CLIENT_SECRET", "mock-secret"),
+		Email:         envOrDefault("MOCK_OIDC_EMAIL", "mock.user@example.com"),
+		EmailVerified: os.Getenv("MOCK_OIDC_EMAIL_UNVERIFIED") != "true",
+	})
+	if err != nil {
+		log.Fatalf("Failed to start mock OIDC provider: %v", err)
+	}
+
+	log.Printf("Mock OIDC provider running on port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, server.Handler()))
+}
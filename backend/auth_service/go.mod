@@ -5,8 +5,11 @@ go 1.21
 require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gorilla/mux v1.8.1
+	github.com/lib/pq v1.10.9
 	github.com/sirupsen/logrus v1.9.3
-	golang.org/x/crypto v0.30.0
+	shared v0.0.0
 )
 
 require golang.org/x/sys v0.28.0 // indirect
+
+replace shared => ../shared
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKey описывает API-ключ пользователя без самого ключа
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// CreateAPIKey сохраняет хэш нового ключа и возвращает его ID и время создания
+func CreateAPIKey(db *sql.DB, userID int, name, prefix, keyHash, scopes string) (int, time.Time, error) {
+	var (
+		id        int
+		createdAt time.Time
+	)
+	err := db.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, userID, name, prefix, keyHash, scopes).Scan(&id, &createdAt)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return id, createdAt, nil
+}
+
+// ListAPIKeys возвращает действующие ключи пользователя
+func ListAPIKeys(db *sql.DB, userID int) ([]APIKey, error) {
+	rows, err := db.Query(`
+		SELECT id, name, prefix, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var (
+			key      APIKey
+			scopes   string
+			lastUsed sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &lastUsed); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		key.Scopes = strings.Fields(scopes)
+		if lastUsed.Valid {
+			key.LastUsedAt = &lastUsed.Time
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RenameAPIKey меняет название ключа. Возвращает false, если у пользователя нет такого ключа.
+func RenameAPIKey(db *sql.DB, userID, keyID int, name string) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE api_keys SET name = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`, name, keyID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to rename api key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeAPIKey отзывает ключ. Возвращает false, если у пользователя нет такого ключа.
+func RevokeAPIKey(db *sql.DB, userID, keyID int) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE api_keys SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, keyID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "github.com/lib/pq"
+)
+
+// Connect подключается к базе данных и возвращает соединение
+func Connect() (*sql.DB, error) {
+	host := os.Getenv("POSTGRES_HOST")
+	user := os.Getenv("POSTGRES_USER")
+	password := os.Getenv("POSTGRES_PASSWORD")
+	dbname := os.Getenv("POSTGRES_DB")
+
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", host, user, password, dbname)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrations содержит таблицы, которыми владеет auth_service.
+// Выражения идемпотентны и выполняются при каждом старте сервиса.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id          SERIAL PRIMARY KEY,
+		user_id     INT NOT NULL,
+		family_id   TEXT NOT NULL,
+		token_hash  TEXT NOT NULL UNIQUE,
+		expires_at  TIMESTAMPTZ NOT NULL,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+		rotated_at  TIMESTAMPTZ,
+		revoked_at  TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS sessions_family_id_idx ON sessions (family_id)`,
+	`CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id)`,
+	`CREATE TABLE IF NOT EXISTS password_resets (
+		id          SERIAL PRIMARY KEY,
+		user_id     INT NOT NULL,
+		token_hash  TEXT NOT NULL UNIQUE,
+		expires_at  TIMESTAMPTZ NOT NULL,
+		used_at     TIMESTAMPTZ,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS password_resets_user_id_idx ON password_resets (user_id)`,
+	`CREATE TABLE IF NOT EXISTS rate_limit_events (
+		key          TEXT NOT NULL,
+		occurred_at  TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS rate_limit_events_key_idx ON rate_limit_events (key, occurred_at)`,
+	`CREATE INDEX IF NOT EXISTS rate_limit_events_occurred_at_idx ON rate_limit_events (occurred_at)`,
+	`CREATE TABLE IF NOT EXISTS login_audit (
+		id          SERIAL PRIMARY KEY,
+		account     TEXT NOT NULL,
+		ip          TEXT NOT NULL,
+		event       TEXT NOT NULL,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS login_audit_account_idx ON login_audit (account, created_at)`,
+	`CREATE TABLE IF NOT EXISTS api_keys (
+		id            SERIAL PRIMARY KEY,
+		user_id       INT NOT NULL,
+		name          TEXT NOT NULL,
+		prefix        TEXT NOT NULL,
+		key_hash      TEXT NOT NULL UNIQUE,
+		scopes        TEXT NOT NULL,
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_used_at  TIMESTAMPTZ,
+		revoked_at    TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id)`,
+	`CREATE TABLE IF NOT EXISTS oidc_states (
+		state_hash     TEXT PRIMARY KEY,
+		provider       TEXT NOT NULL,
+		code_verifier  TEXT NOT NULL,
+		nonce          TEXT NOT NULL,
+		expires_at     TIMESTAMPTZ NOT NULL,
+		created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE TABLE IF NOT EXISTS used_mfa_tokens (
+		jti         TEXT PRIMARY KEY,
+		expires_at  TIMESTAMPTZ NOT NULL
+	)`,
+}
+
+// Migrate создаёт недостающие таблицы и индексы
+func Migrate(db *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply migration: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// События журнала входов
+const (
+	LoginEventLockout = "lockout"
+)
+
+// InsertLoginAudit добавляет запись в журнал входов
+func InsertLoginAudit(db *sql.DB, account, ip, event string) error {
+	_, err := db.Exec(`
+		INSERT INTO login_audit (account, ip, event) VALUES ($1, $2, $3)
+	`, account, ip, event)
+	if err != nil {
+		return fmt.Errorf("failed to insert login audit record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UseMFAToken отмечает промежуточный токен входа с идентификатором jti как
+// использованный и удаляет истёкшие отметки. Возвращает false, если токен уже
+// был использован: обменять его на токены сессии можно только один раз.
+func UseMFAToken(db *sql.DB, jti string, expiresAt time.Time) (bool, error) {
+	if _, err := db.Exec("DELETE FROM used_mfa_tokens WHERE expires_at < now()"); err != nil {
+		return false, fmt.Errorf("failed to prune used mfa tokens: %w", err)
+	}
+	res, err := db.Exec(`
+		INSERT INTO used_mfa_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark mfa token as used: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark mfa token as used: %w", err)
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUseMFATokenOnlyOnce(t *testing.T) {
+	db := openTestDB(t)
+	jti := uniqueName("jti")
+	t.Cleanup(func() { db.Exec("DELETE FROM used_mfa_tokens WHERE jti = $1", jti) })
+
+	fresh, err := UseMFAToken(db, jti, time.Now().Add(time.Minute))
+	if err != nil || !fresh {
+		t.Fatalf("first use = %v, %v; want true", fresh, err)
+	}
+	fresh, err = UseMFAToken(db, jti, time.Now().Add(time.Minute))
+	if err != nil || fresh {
+		t.Fatalf("second use = %v, %v; want false", fresh, err)
+	}
+}
+
+func TestUseMFATokenPrunesExpired(t *testing.T) {
+	db := openTestDB(t)
+	stale := uniqueName("stale")
+	if _, err := db.Exec("INSERT INTO used_mfa_tokens (jti, expires_at) VALUES ($1, $2)", stale, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("insert stale token: %v", err)
+	}
+
+	jti := uniqueName("jti")
+	t.Cleanup(func() { db.Exec("DELETE FROM used_mfa_tokens WHERE jti IN ($1, $2)", jti, stale) })
+	if _, err := UseMFAToken(db, jti, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("UseMFAToken: %v", err)
+	}
+
+	var n int
+	db.QueryRow("SELECT count(*) FROM used_mfa_tokens WHERE jti = $1", stale).Scan(&n)
+	if n != 0 {
+		t.Error("expired mark was not pruned")
+	}
+}
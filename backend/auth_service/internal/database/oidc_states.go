@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOIDCStateInvalid возвращается для неизвестного, истёкшего или уже использованного state
+var ErrOIDCStateInvalid = errors.New("invalid or expired oidc state")
+
+// OIDCState хранит параметры начатого входа через внешнего провайдера
+type OIDCState struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+}
+
+// CreateOIDCState сохраняет параметры входа под хэшем state и удаляет истёкшие записи
+func CreateOIDCState(db *sql.DB, stateHash string, state OIDCState, expiresAt time.Time) error {
+	if _, err := db.Exec("DELETE FROM oidc_states WHERE expires_at < now()"); err != nil {
+		return fmt.Errorf("failed to prune oidc states: %w", err)
+	}
+	_, err := db.Exec(`
+		INSERT INTO oidc_states (state_hash, provider, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, stateHash, state.Provider, state.CodeVerifier, state.Nonce, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oidc state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeOIDCState удаляет state и возвращает его параметры. Каждый state
+// можно использовать один раз и только с тем провайдером, для которого он выдан.
+func ConsumeOIDCState(db *sql.DB, stateHash, provider string) (*OIDCState, error) {
+	var (
+		state OIDCState
+		fresh bool
+	)
+	err := db.QueryRow(`
+		DELETE FROM oidc_states
+		WHERE state_hash = $1
+		RETURNING provider, code_verifier, nonce, expires_at > now()
+	`, stateHash).Scan(&state.Provider, &state.CodeVerifier, &state.Nonce, &fresh)
+	if err == sql.ErrNoRows {
+		return nil, ErrOIDCStateInvalid
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to consume oidc state: %w", err)
+	}
+	if !fresh || state.Provider != provider {
+		return nil, ErrOIDCStateInvalid
+	}
+	return &state, nil
+}
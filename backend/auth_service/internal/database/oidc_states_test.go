@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsumeOIDCState(t *testing.T) {
+	db := openTestDB(t)
+	hash := uniqueName("state")
+	want := OIDCState{Provider: "mock", CodeVerifier: "verifier", Nonce: "nonce"}
+	if err := CreateOIDCState(db, hash, want, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("CreateOIDCState: %v", err)
+	}
+
+	got, err := ConsumeOIDCState(db, hash, "mock")
+	if err != nil || *got != want {
+		t.Fatalf("ConsumeOIDCState = %+v, %v; want %+v", got, err, want)
+	}
+	if _, err := ConsumeOIDCState(db, hash, "mock"); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("replayed state: err = %v", err)
+	}
+}
+
+func TestConsumeOIDCStateRejections(t *testing.T) {
+	db := openTestDB(t)
+
+	// state, выданный для одного провайдера, не принимается в callback другого
+	other := uniqueName("state")
+	CreateOIDCState(db, other, OIDCState{Provider: "google", CodeVerifier: "v", Nonce: "n"}, time.Now().Add(time.Minute))
+	if _, err := ConsumeOIDCState(db, other, "mock"); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("provider mismatch: err = %v", err)
+	}
+	// ...и сгорает после такой попытки
+	if _, err := ConsumeOIDCState(db, other, "google"); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("state survived a mismatched attempt: err = %v", err)
+	}
+
+	expired := uniqueName("state")
+	CreateOIDCState(db, expired, OIDCState{Provider: "mock"}, time.Now().Add(-time.Second))
+	if _, err := ConsumeOIDCState(db, expired, "mock"); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("expired state: err = %v", err)
+	}
+}
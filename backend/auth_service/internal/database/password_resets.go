@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrResetTokenInvalid возвращается для неизвестного, истёкшего или уже использованного токена сброса
+var ErrResetTokenInvalid = errors.New("invalid or expired reset token")
+
+// CreatePasswordReset сохраняет хэш нового токена сброса пароля.
+// Ранее выданные неиспользованные токены пользователя аннулируются.
+func CreatePasswordReset(db *sql.DB, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE password_resets SET used_at = now()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return fmt.Errorf("failed to invalidate previous reset tokens: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO password_resets (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UsePasswordReset блокирует действующий токен сброса, вызывает apply с ID
+// пользователя и помечает токен использованным, только если apply завершился
+// успешно. Параллельные попытки использовать тот же токен ждут блокировки
+// и затем получают ErrResetTokenInvalid.
+func UsePasswordReset(db *sql.DB, tokenHash string, apply func(userID int) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id, userID int
+	err = tx.QueryRow(`
+		SELECT id, user_id FROM password_resets
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		FOR UPDATE
+	`, tokenHash).Scan(&id, &userID)
+	if err == sql.ErrNoRows {
+		return ErrResetTokenInvalid
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch reset token: %w", err)
+	}
+
+	if err := apply(userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE password_resets SET used_at = now() WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to mark reset token as used: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUsePasswordResetOnce(t *testing.T) {
+	db := openTestDB(t)
+	hash := uniqueName("reset")
+	t.Cleanup(func() { db.Exec("DELETE FROM password_resets WHERE user_id = 501") })
+	if err := CreatePasswordReset(db, 501, hash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("CreatePasswordReset: %v", err)
+	}
+
+	var got int
+	if err := UsePasswordReset(db, hash, func(userID int) error { got = userID; return nil }); err != nil {
+		t.Fatalf("UsePasswordReset: %v", err)
+	}
+	if got != 501 {
+		t.Errorf("apply called for user %d, want 501", got)
+	}
+
+	err := UsePasswordReset(db, hash, func(int) error {
+		t.Error("apply called for a used token")
+		return nil
+	})
+	if !errors.Is(err, ErrResetTokenInvalid) {
+		t.Errorf("second use: err = %v, want ErrResetTokenInvalid", err)
+	}
+}
+
+func TestUsePasswordResetKeepsTokenWhenApplyFails(t *testing.T) {
+	db := openTestDB(t)
+	hash := uniqueName("reset")
+	t.Cleanup(func() { db.Exec("DELETE FROM password_resets WHERE user_id = 502") })
+	CreatePasswordReset(db, 502, hash, time.Now().Add(time.Hour))
+
+	// Например, новый пароль не прошёл политику: токен можно использовать снова
+	weak := errors.New("weak password")
+	if err := UsePasswordReset(db, hash, func(int) error { return weak }); !errors.Is(err, weak) {
+		t.Fatalf("err = %v, want the apply error", err)
+	}
+	if err := UsePasswordReset(db, hash, func(int) error { return nil }); err != nil {
+		t.Errorf("token is not usable after a failed apply: %v", err)
+	}
+}
+
+func TestPasswordResetInvalidTokens(t *testing.T) {
+	db := openTestDB(t)
+	t.Cleanup(func() { db.Exec("DELETE FROM password_resets WHERE user_id = 503") })
+	noop := func(int) error { return nil }
+
+	expired := uniqueName("expired")
+	CreatePasswordReset(db, 503, expired, time.Now().Add(-time.Minute))
+	if err := UsePasswordReset(db, expired, noop); !errors.Is(err, ErrResetTokenInvalid) {
+		t.Errorf("expired token: err = %v", err)
+	}
+
+	// Новый запрос сброса аннулирует выданную ранее ссылку
+	first, second := uniqueName("first"), uniqueName("second")
+	CreatePasswordReset(db, 503, first, time.Now().Add(time.Hour))
+	CreatePasswordReset(db, 503, second, time.Now().Add(time.Hour))
+	if err := UsePasswordReset(db, first, noop); !errors.Is(err, ErrResetTokenInvalid) {
+		t.Errorf("superseded token: err = %v", err)
+	}
+	if err := UsePasswordReset(db, second, noop); err != nil {
+		t.Errorf("latest token: %v", err)
+	}
+
+	if err := UsePasswordReset(db, uniqueName("unknown"), noop); !errors.Is(err, ErrResetTokenInvalid) {
+		t.Errorf("unknown token: err = %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrSessionNotFound возвращается, если refresh-токен неизвестен
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionExpired возвращается, если срок действия refresh-токена истёк
+	ErrSessionExpired = errors.New("session expired")
+	// ErrSessionReused возвращается при повторном предъявлении уже использованного
+	// или отозванного refresh-токена; всё семейство сессий при этом отзывается
+	ErrSessionReused = errors.New("refresh token reuse detected")
+)
+
+// Session представляет одно звено в цепочке ротации refresh-токенов
+type Session struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	ExpiresAt time.Time
+}
+
+// CreateSession сохраняет хэш нового refresh-токена
+func CreateSession(db *sql.DB, userID int, familyID, tokenHash string, expiresAt time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO sessions (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, userID, familyID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// RotateSession помечает сессию с данным хэшем как использованную и создаёт
+// следующую сессию в том же семействе. Если токен уже был использован или
+// отозван, всё семейство отзывается и возвращается ErrSessionReused.
+func RotateSession(db *sql.DB, tokenHash, newTokenHash string, expiresAt time.Time) (*Session, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		current   Session
+		rotatedAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT id, user_id, family_id, expires_at, rotated_at, revoked_at
+		FROM sessions
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(&current.ID, &current.UserID, &current.FamilyID, &current.ExpiresAt, &rotatedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+
+	if rotatedAt.Valid || revokedAt.Valid {
+		// Повторное использование: токен мог быть украден, отзываем всё семейство
+		if _, err := tx.Exec(`
+			UPDATE sessions SET revoked_at = now()
+			WHERE family_id = $1 AND revoked_at IS NULL
+		`, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session family: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, ErrSessionReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrSessionExpired
+	}
+
+	if _, err := tx.Exec("UPDATE sessions SET rotated_at = now() WHERE id = $1", current.ID); err != nil {
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	next := Session{UserID: current.UserID, FamilyID: current.FamilyID, ExpiresAt: expiresAt}
+	err = tx.QueryRow(`
+		INSERT INTO sessions (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, next.UserID, next.FamilyID, newTokenHash, next.ExpiresAt).Scan(&next.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &next, nil
+}
+
+// RevokeSessionFamily отзывает все сессии семейства, к которому относится
+// refresh-токен с данным хэшем
+func RevokeSessionFamily(db *sql.DB, tokenHash string) error {
+	res, err := db.Exec(`
+		UPDATE sessions SET revoked_at = now()
+		WHERE family_id = (SELECT family_id FROM sessions WHERE token_hash = $1)
+		  AND revoked_at IS NULL
+	`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session family: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions отзывает все активные сессии пользователя
+func RevokeUserSessions(db *sql.DB, userID int) error {
+	_, err := db.Exec(`
+		UPDATE sessions SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return nil
+}
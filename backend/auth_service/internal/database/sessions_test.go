@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestDB подключается к базе из TEST_POSTGRES_DSN и применяет миграции;
+// без переменной тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return db
+}
+
+// uniqueName возвращает строку, не пересекающуюся с данными других прогонов
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+}
+
+// newSession создаёт сессию нового семейства и удаляет его после теста
+func newSession(t *testing.T, db *sql.DB, userID int, expiresAt time.Time) (family, hash string) {
+	t.Helper()
+	family, hash = uniqueName("family"), uniqueName("hash")
+	if err := CreateSession(db, userID, family, hash, expiresAt); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM sessions WHERE family_id = $1", family) })
+	return family, hash
+}
+
+// activeSessions возвращает число неотозванных сессий семейства
+func activeSessions(t *testing.T, db *sql.DB, family string) int {
+	t.Helper()
+	var n int
+	err := db.QueryRow("SELECT count(*) FROM sessions WHERE family_id = $1 AND revoked_at IS NULL", family).Scan(&n)
+	if err != nil {
+		t.Fatalf("count sessions: %v", err)
+	}
+	return n
+}
+
+func TestRotateSessionContinuesFamily(t *testing.T) {
+	db := openTestDB(t)
+	family, hash := newSession(t, db, 42, time.Now().Add(time.Hour))
+
+	next, err := RotateSession(db, hash, uniqueName("next"), time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("RotateSession: %v", err)
+	}
+	if next.UserID != 42 || next.FamilyID != family {
+		t.Errorf("next session = user %d family %q, want user 42 family %q", next.UserID, next.FamilyID, family)
+	}
+	if next.ID == 0 {
+		t.Error("next session has no ID")
+	}
+}
+
+func TestRotateSessionReuseRevokesFamily(t *testing.T) {
+	db := openTestDB(t)
+	family, first := newSession(t, db, 7, time.Now().Add(time.Hour))
+
+	second := uniqueName("second")
+	if _, err := RotateSession(db, first, second, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("first rotation: %v", err)
+	}
+
+	// Украденный первый токен предъявляется повторно
+	if _, err := RotateSession(db, first, uniqueName("stolen"), time.Now().Add(time.Hour)); !errors.Is(err, ErrSessionReused) {
+		t.Fatalf("reuse: err = %v, want ErrSessionReused", err)
+	}
+	if n := activeSessions(t, db, family); n != 0 {
+		t.Errorf("%d sessions of the family are still active after reuse", n)
+	}
+
+	// Действующий до этого токен тоже больше не принимается
+	if _, err := RotateSession(db, second, uniqueName("third"), time.Now().Add(time.Hour)); !errors.Is(err, ErrSessionReused) {
+		t.Errorf("rotation of the revoked successor: err = %v, want ErrSessionReused", err)
+	}
+}
+
+func TestRotateSessionRejectsUnknownAndExpired(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := RotateSession(db, uniqueName("missing"), uniqueName("next"), time.Now().Add(time.Hour)); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("unknown token: err = %v, want ErrSessionNotFound", err)
+	}
+
+	family, hash := newSession(t, db, 7, time.Now().Add(-time.Minute))
+	if _, err := RotateSession(db, hash, uniqueName("next"), time.Now().Add(time.Hour)); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("expired token: err = %v, want ErrSessionExpired", err)
+	}
+	// Истёкший токен не продлевает семейство
+	var n int
+	db.QueryRow("SELECT count(*) FROM sessions WHERE family_id = $1", family).Scan(&n)
+	if n != 1 {
+		t.Errorf("family has %d sessions after a rejected rotation, want 1", n)
+	}
+}
+
+func TestRevokeSessionFamily(t *testing.T) {
+	db := openTestDB(t)
+	family, first := newSession(t, db, 9, time.Now().Add(time.Hour))
+	second := uniqueName("second")
+	if _, err := RotateSession(db, first, second, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("RotateSession: %v", err)
+	}
+
+	// Выход по любому токену семейства отзывает всю цепочку
+	if err := RevokeSessionFamily(db, first); err != nil {
+		t.Fatalf("RevokeSessionFamily: %v", err)
+	}
+	if n := activeSessions(t, db, family); n != 0 {
+		t.Errorf("%d sessions still active after logout", n)
+	}
+	if _, err := RotateSession(db, second, uniqueName("third"), time.Now().Add(time.Hour)); !errors.Is(err, ErrSessionReused) {
+		t.Errorf("refresh after logout: err = %v, want ErrSessionReused", err)
+	}
+
+	if err := RevokeSessionFamily(db, uniqueName("missing")); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("unknown token: err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	db := openTestDB(t)
+	userID := int(time.Now().UnixNano() % 1000000000)
+	familyA, _ := newSession(t, db, userID, time.Now().Add(time.Hour))
+	familyB, _ := newSession(t, db, userID, time.Now().Add(time.Hour))
+	other, _ := newSession(t, db, userID+1, time.Now().Add(time.Hour))
+
+	if err := RevokeUserSessions(db, userID); err != nil {
+		t.Fatalf("RevokeUserSessions: %v", err)
+	}
+	if activeSessions(t, db, familyA)+activeSessions(t, db, familyB) != 0 {
+		t.Error("sessions of the user are still active")
+	}
+	if activeSessions(t, db, other) != 1 {
+		t.Error("session of another user was revoked")
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/database"
+	"auth-service/internal/middlewares"
+	"shared/apikeys"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const maxAPIKeyNameLength = 100
+
+// CreateAPIKeyRequest представляет запрос на выпуск API-ключа
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// RenameAPIKeyRequest представляет новое название ключа
+type RenameAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateAPIKey выпускает API-ключ. Сам ключ возвращается только в этом ответе.
+func CreateAPIKey(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		name, ok := validAPIKeyName(req.Name)
+		if !ok {
+			http.Error(w, "Name is required and must be at most 100 characters", http.StatusBadRequest)
+			return
+		}
+		if len(req.Scopes) == 0 {
+			http.Error(w, "At least one scope is required", http.StatusBadRequest)
+			return
+		}
+		scopes, err := apikeys.ParseScopes(req.Scopes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		key, prefix, hash, err := apikeys.Generate()
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate api key")
+			http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+			return
+		}
+
+		id, createdAt, err := database.CreateAPIKey(db, userID, name, prefix, hash, apikeys.JoinScopes(scopes))
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to store api key")
+			http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"key_id":  id,
+			"prefix":  prefix,
+		}).Info("Auth-Service: API key created")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         id,
+			"name":       name,
+			"prefix":     prefix,
+			"scopes":     scopes,
+			"created_at": createdAt.Format(time.RFC3339),
+			"key":        key,
+		})
+	}
+}
+
+// ListAPIKeys возвращает действующие ключи пользователя без самих ключей
+func ListAPIKeys(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		keys, err := database.ListAPIKeys(db, userID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to list api keys")
+			http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	}
+}
+
+// RenameAPIKey меняет название ключа
+func RenameAPIKey(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+		keyID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+			return
+		}
+
+		var req RenameAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		name, ok := validAPIKeyName(req.Name)
+		if !ok {
+			http.Error(w, "Name is required and must be at most 100 characters", http.StatusBadRequest)
+			return
+		}
+
+		found, err := database.RenameAPIKey(db, userID, keyID, name)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to rename api key")
+			http.Error(w, "Failed to rename API key", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "API key renamed"})
+	}
+}
+
+// RevokeAPIKey отзывает ключ; после этого сервисы перестают его принимать
+func RevokeAPIKey(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+		keyID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+			return
+		}
+
+		found, err := database.RevokeAPIKey(db, userID, keyID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to revoke api key")
+			http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"key_id":  keyID,
+		}).Info("Auth-Service: API key revoked")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "API key revoked"})
+	}
+}
+
+// validAPIKeyName обрезает пробелы и проверяет длину названия
+func validAPIKeyName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	return name, name != "" && len([]rune(name)) <= maxAPIKeyNameLength
+}
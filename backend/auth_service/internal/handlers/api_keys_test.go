@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/apikeys"
+
+	"github.com/gorilla/mux"
+)
+
+// withKeyID подставляет {id} маршрута /api-keys/{id}
+func withKeyID(h http.Handler, id int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, mux.SetURLVars(r, map[string]string{"id": fmt.Sprint(id)}))
+	})
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	h := asUser(CreateAPIKey(nil), 1)
+	tests := []struct {
+		name string
+		req  CreateAPIKeyRequest
+	}{
+		{"blank name", CreateAPIKeyRequest{Name: "  ", Scopes: []string{"posts:read"}}},
+		{"long name", CreateAPIKeyRequest{Name: strings.Repeat("к", maxAPIKeyNameLength+1), Scopes: []string{"posts:read"}}},
+		{"no scopes", CreateAPIKeyRequest{Name: "ci"}},
+		{"unknown scope", CreateAPIKeyRequest{Name: "ci", Scopes: []string{"posts:read", "admin"}}},
+	}
+	for _, tt := range tests {
+		if rec := doJSON(h, http.MethodPost, "/api-keys", tt.req); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tt.name, rec.Code)
+		}
+	}
+
+	if rec := doJSON(CreateAPIKey(nil), http.MethodPost, "/api-keys", CreateAPIKeyRequest{Name: "ci", Scopes: []string{"posts:read"}}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", rec.Code)
+	}
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db := openTestDB(t)
+	owner := int(time.Now().UnixNano()%1000000) + 1000000
+	t.Cleanup(func() { db.Exec("DELETE FROM api_keys WHERE user_id IN ($1, $2)", owner, owner+1) })
+
+	rec := doJSON(asUser(CreateAPIKey(db), owner), http.MethodPost, "/api-keys",
+		CreateAPIKeyRequest{Name: " deploy bot ", Scopes: []string{"posts:write", "posts:read", "posts:write"}})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID     int      `json:"id"`
+		Name   string   `json:"name"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+		Key    string   `json:"key"`
+	}
+	decodeBody(t, rec, &created)
+	if created.Name != "deploy bot" || !strings.HasPrefix(created.Key, created.Prefix) || len(created.Scopes) != 2 {
+		t.Errorf("created = %+v", created)
+	}
+
+	// Ключ хранится только в виде хэша
+	var stored string
+	db.QueryRow("SELECT key_hash FROM api_keys WHERE id = $1", created.ID).Scan(&stored)
+	if stored != apikeys.Hash(created.Key) {
+		t.Error("stored hash does not match the key")
+	}
+
+	rec = doJSON(asUser(ListAPIKeys(db), owner), http.MethodGet, "/api-keys", nil)
+	if strings.Contains(rec.Body.String(), created.Key) {
+		t.Error("list response contains the key itself")
+	}
+	if !strings.Contains(rec.Body.String(), created.Prefix) {
+		t.Errorf("list response lacks the key: %s", rec.Body)
+	}
+
+	// Чужой ключ не переименовать и не отозвать
+	if rec := doJSON(asUser(withKeyID(RevokeAPIKey(db), created.ID), owner+1), http.MethodDelete, "/api-keys", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("revoke by another user: status %d, want 404", rec.Code)
+	}
+	if rec := doJSON(asUser(withKeyID(RenameAPIKey(db), created.ID), owner), http.MethodPatch, "/api-keys", RenameAPIKeyRequest{Name: "renamed"}); rec.Code != http.StatusOK {
+		t.Errorf("rename: status %d", rec.Code)
+	}
+	if rec := doJSON(asUser(withKeyID(RevokeAPIKey(db), created.ID), owner), http.MethodDelete, "/api-keys", nil); rec.Code != http.StatusOK {
+		t.Fatalf("revoke: status %d", rec.Code)
+	}
+
+	rec = doJSON(asUser(ListAPIKeys(db), owner), http.MethodGet, "/api-keys", nil)
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("revoked key is still listed: %s", rec.Body)
+	}
+	if rec := doJSON(asUser(withKeyID(RenameAPIKey(db), created.ID), owner), http.MethodPatch, "/api-keys", RenameAPIKeyRequest{Name: "again"}); rec.Code != http.StatusNotFound {
+		t.Errorf("rename of a revoked key: status %d, want 404", rec.Code)
+	}
+}
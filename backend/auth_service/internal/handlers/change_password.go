@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"auth-service/internal/database"
+	"auth-service/internal/middlewares"
+	"auth-service/internal/ratelimit"
+	"shared/jwtauth"
+	"shared/passpolicy"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChangePasswordRequest представляет данные для смены пароля
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePassword меняет пароль вошедшего пользователя после проверки текущего.
+// Все сессии и выданные токены отзываются, клиент получает новую пару токенов.
+// Попытки угадать текущий пароль ограничиваются так же, как вход.
+func ChangePassword(db *sql.DB, signer *jwtauth.Signer, limiter *ratelimit.Limiter) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req ChangePasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		if req.CurrentPassword == "" || req.NewPassword == "" {
+			http.Error(w, "Current and new passwords are required", http.StatusBadRequest)
+			return
+		}
+
+		ip := clientIP(r)
+		account := fmt.Sprintf("password:%d", userID)
+		if !allowLogin(w, logger, limiter, ip, account) {
+			return
+		}
+
+		err := updateUserPassword(userID, passwordChange{NewPassword: req.NewPassword, CurrentPassword: req.CurrentPassword})
+		if errors.Is(err, errPasswordChangeDenied) {
+			logger.WithField("user_id", userID).Warn("Auth-Service: Wrong current password on password change")
+			recordLoginFailure(db, logger, limiter, ip, account)
+			http.Error(w, "Current password is incorrect", http.StatusForbidden)
+			return
+		} else if verr, ok := passpolicy.AsValidationError(err); ok {
+			// Users Service проверяет политику после текущего пароля: он был верным
+			recordLoginSuccess(logger, limiter, account)
+			passpolicy.WriteError(w, verr)
+			return
+		} else if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Auth-Service: Failed to change password")
+			http.Error(w, "Failed to change password", http.StatusInternalServerError)
+			return
+		}
+		recordLoginSuccess(logger, limiter, account)
+
+		if err := database.RevokeUserSessions(db, userID); err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Auth-Service: Failed to revoke sessions after password change")
+		}
+		go notifyPasswordChanged(logger, userID)
+
+		// Старые токены отозваны новой версией, поэтому текущему клиенту выдаём новые
+		user, err := fetchUserByID(userID)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Auth-Service: Failed to fetch user after password change")
+			http.Error(w, "Password changed, please log in again", http.StatusInternalServerError)
+			return
+		}
+		tokens, err := issueTokens(db, signer, *user)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate token")
+			http.Error(w, "Password changed, please log in again", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithField("user_id", userID).Info("Auth-Service: Password changed")
+
+		if err := writeTokenResponse(w, "Password changed", *user, tokens); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to send response to client")
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"auth-service/internal/database"
+	"shared/jwtauth"
+
+	_ "github.com/lib/pq"
+)
+
+// openTestDB подключается к базе из TEST_POSTGRES_DSN и применяет миграции;
+// без переменной тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return db
+}
+
+// testJWTConfig возвращает конфигурацию с одним HMAC-ключом
+func testJWTConfig() *jwtauth.Config {
+	return &jwtauth.Config{
+		Keys:        map[string]jwtauth.Key{"test": jwtauth.NewHMACKey("test", []byte("test-secret"))},
+		ActiveKeyID: "test",
+		Issuer:      "auth-service",
+		Audience:    "yandexcloud-api",
+		Leeway:      time.Second,
+	}
+}
+
+func testSigner(t *testing.T, cfg *jwtauth.Config) *jwtauth.Signer {
+	t.Helper()
+	signer, err := jwtauth.NewSigner(cfg)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return signer
+}
+
+// fakeUsersService подменяет Users Service: маршруты регистрируются тестом,
+// USERS_SERVICE_URL указывает на сервер до конца теста
+type fakeUsersService struct {
+	mux *http.ServeMux
+}
+
+func newFakeUsersService(t *testing.T) *fakeUsersService {
+	t.Helper()
+	f := &fakeUsersService{mux: http.NewServeMux()}
+	srv := httptest.NewServer(f.mux)
+	t.Cleanup(srv.Close)
+	t.Setenv("USERS_SERVICE_URL", srv.URL)
+	return f
+}
+
+// handle регистрирует обработчик пути Users Service
+func (f *fakeUsersService) handle(path string, h http.HandlerFunc) {
+	f.mux.HandleFunc(path, h)
+}
+
+// user отдаёт пользователя по GET /users/{id}
+func (f *fakeUsersService) user(u authUser) {
+	f.handle(fmt.Sprintf("/users/%d", u.ID), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, u)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// doJSON вызывает обработчик с JSON-телом и возвращает записанный ответ
+func doJSON(h http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBody разбирает JSON-ответ
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"shared/jwtauth"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JWKS публикует открытые ключи, которыми другие сервисы проверяют токены
+func JWKS(cfg *jwtauth.Config) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	// Набор ключей меняется только при перезапуске сервиса
+	set := jwtauth.PublicJWKS(cfg)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(set); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to encode JWKS")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shared/jwtauth"
+)
+
+func TestJWKSPublishesOnlyPublicKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testJWTConfig()
+	cfg.Keys["ed"] = jwtauth.Key{ID: "ed", Method: jwtauth.SigningMethodEdDSA, SignKey: priv, VerifyKey: pub}
+	cfg.ActiveKeyID = "ed"
+
+	rec := httptest.NewRecorder()
+	JWKS(cfg)(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc == "" {
+		t.Error("JWKS response is not cacheable")
+	}
+
+	var set jwtauth.JWKS
+	decodeBody(t, rec, &set)
+	if len(set.Keys) != 1 || set.Keys[0].Kid != "ed" {
+		t.Fatalf("published keys = %+v, want only ed (the HMAC key is secret)", set.Keys)
+	}
+	for _, jwk := range set.Keys {
+		if jwk.Kty == "oct" {
+			t.Errorf("symmetric key %q published", jwk.Kid)
+		}
+	}
+}
+
+// Другой сервис проверяет токены auth_service только по опубликованному JWKS,
+// в том числе после смены ключа подписи
+func TestTokensVerifiableThroughJWKS(t *testing.T) {
+	newKey := func(kid string) jwtauth.Key {
+		pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+		return jwtauth.Key{ID: kid, Method: jwtauth.SigningMethodEdDSA, SignKey: priv, VerifyKey: pub}
+	}
+	oldKey, newKeyV2 := newKey("v1"), newKey("v2")
+
+	cfg := testJWTConfig()
+	cfg.Keys = map[string]jwtauth.Key{"v1": oldKey}
+	cfg.ActiveKeyID = "v1"
+	oldToken, _, err := signAccessToken(testSigner(t, cfg), authUser{ID: 8, Role: "user"})
+	if err != nil {
+		t.Fatalf("signAccessToken: %v", err)
+	}
+
+	// Ротация: подписывает v2, v1 ещё опубликован для выданных токенов
+	cfg.Keys["v2"] = newKeyV2
+	cfg.ActiveKeyID = "v2"
+	newToken, _, err := signAccessToken(testSigner(t, cfg), authUser{ID: 8, Role: "user"})
+	if err != nil {
+		t.Fatalf("signAccessToken: %v", err)
+	}
+
+	srv := httptest.NewServer(JWKS(cfg))
+	defer srv.Close()
+	verifier := jwtauth.NewVerifierWithKeys(&jwtauth.Config{Issuer: cfg.Issuer, Audience: cfg.Audience, Leeway: time.Second},
+		jwtauth.NewJWKSClient(srv.URL, time.Minute))
+
+	for name, token := range map[string]string{"v1": oldToken, "v2": newToken} {
+		claims, err := verifier.Verify(token)
+		if err != nil || claims.UserID != 8 {
+			t.Errorf("%s token: claims %+v, err %v", name, claims, err)
+		}
+	}
+}
@@ -1,15 +1,16 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"os"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"auth-service/internal/ratelimit"
+	"shared/jwtauth"
+
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // LoginRequest представляет данные для запроса входа
@@ -18,115 +19,90 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-// Login обрабатывает вход пользователя
-func Login() http.HandlerFunc {
-    logger := logrus.New()
-    logger.SetFormatter(&logrus.JSONFormatter{})
-    logger.SetOutput(os.Stdout)
+// Login обрабатывает вход пользователя. Попытки ограничиваются limiter по IP
+// и по email, после серии неудач учётная запись временно блокируется.
+func Login(db *sql.DB, signer *jwtauth.Signer, limiter *ratelimit.Limiter) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
 
 	logger.Printf("Auth-Service: Request to login")
 
-    return func(w http.ResponseWriter, r *http.Request) {
-        logger.Info("Auth-Service: Login request received")
-
-        var req LoginRequest
-        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-            logger.WithError(err).Warn("Auth-Service: Invalid request payload")
-            http.Error(w, "Invalid request payload", http.StatusBadRequest)
-            return
-        }
-
-        if req.Email == "" || req.Password == "" {
-            logger.Warn("Auth-Service: Email and password are required")
-            http.Error(w, "Email and password are required", http.StatusBadRequest)
-            return
-        }
-
-        userServiceURL := os.Getenv("USERS_SERVICE_URL")
-        if userServiceURL == "" {
-            logger.Error("Auth-Service: Users service URL is not configured")
-            http.Error(w, "Users service URL is not configured", http.StatusInternalServerError)
-            return
-        }
-
-        resp, err := http.Get(fmt.Sprintf("%s/users/by_email?email=%s", userServiceURL, req.Email))
-        if err != nil {
-            logger.WithFields(logrus.Fields{
-                "email":       req.Email,
-                "service_url": userServiceURL,
-                "error":       err.Error(),
-            }).Error("Auth-Service: Error during user fetch")
-            http.Error(w, "Invalid email or password", http.StatusForbidden)
-            return
-        }
-        defer resp.Body.Close()
-
-        if resp.StatusCode == http.StatusNotFound {
-            logger.WithField("email", req.Email).Warn("Auth-Service: User not found")
-            http.Error(w, "Invalid email or password", http.StatusForbidden)
-            return
-        }
-
-        if resp.StatusCode != http.StatusOK {
-            logger.WithField("status_code", resp.StatusCode).Warn("Auth-Service: Unexpected response from user service")
-            http.Error(w, "Invalid email or password", http.StatusForbidden)
-            return
-        }
-
-        var user struct {
-            ID           int    `json:"id"`
-            Username     string `json:"username"`
-            Email        string `json:"email"`
-            PasswordHash string `json:"password_hash"`
-        }
-        if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-            logger.WithError(err).Error("Auth-Service: Failed to parse user data")
-            http.Error(w, "Failed to parse user data", http.StatusInternalServerError)
-            return
-        }
-
-        if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-            logger.WithField("email", req.Email).Warn("Auth-Service: Invalid email or password")
-            http.Error(w, "Invalid email or password", http.StatusForbidden)
-            return
-        }
-
-        token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-            "user_id": user.ID,
-            "email":   user.Email,
-            "exp":     time.Now().Add(72 * time.Hour).Unix(),
-        })
-
-        secret := os.Getenv("JWT_SECRET")
-        if secret == "" {
-            logger.Error("Auth-Service: JWT_SECRET is not configured")
-            http.Error(w, "JWT_SECRET is not configured", http.StatusInternalServerError)
-            return
-        }
-
-        tokenStr, err := token.SignedString([]byte(secret))
-        if err != nil {
-            logger.WithError(err).Error("Auth-Service: Failed to generate token")
-            http.Error(w, "Failed to generate token", http.StatusInternalServerError)
-            return
-        }
-
-        logger.WithFields(logrus.Fields{
-            "user_id":   user.ID,
-            "username":  user.Username,
-            "email":     user.Email,
-            "token_exp": time.Now().Add(72 * time.Hour).Format(time.RFC3339),
-        }).Info("Auth-Service: Login successful")
-
-        w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(map[string]interface{}{
-            "message": "Login successful",
-            "user": map[string]interface{}{
-                "id":       user.ID,
-                "username": user.Username,
-                "email":    user.Email,
-            },
-            "token": tokenStr,
-        })
-    }
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("Auth-Service: Login request received")
+
+		var req LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.WithError(err).Warn("Auth-Service: Invalid request payload")
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if req.Email == "" || req.Password == "" {
+			logger.Warn("Auth-Service: Email and password are required")
+			http.Error(w, "Email and password are required", http.StatusBadRequest)
+			return
+		}
+
+		ip := clientIP(r)
+		account := loginAccount(req.Email)
+		if !allowLogin(w, logger, limiter, ip, account) {
+			return
+		}
+
+		user, err := verifyCredentials(req.Email, req.Password)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to verify credentials")
+			http.Error(w, "Failed to verify credentials", http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			logger.WithField("email", req.Email).Warn("Auth-Service: Invalid email or password")
+			recordLoginFailure(db, logger, limiter, ip, account)
+			http.Error(w, "Invalid email or password", http.StatusForbidden)
+			return
+		}
+		recordLoginSuccess(logger, limiter, account)
+
+		authenticated := user.authUser
+
+		// Пароль верный, но требуется второй фактор: выдаём только промежуточный токен
+		if user.TOTPEnabled {
+			mfaToken, err := signMFAPendingToken(signer, authenticated)
+			if err != nil {
+				logger.WithError(err).Error("Auth-Service: Failed to generate mfa token")
+				http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+				return
+			}
+
+			logger.WithField("user_id", user.ID).Info("Auth-Service: Password accepted, awaiting second factor")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message":      "Second factor required",
+				"mfa_required": true,
+				"mfa_token":    mfaToken,
+			})
+			return
+		}
+
+		tokens, err := issueTokens(db, signer, authenticated)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate token")
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":   user.ID,
+			"username":  user.Username,
+			"email":     user.Email,
+			"token_exp": tokens.AccessExpiresAt.Format(time.RFC3339),
+		}).Info("Auth-Service: Login successful")
+
+		if err := writeTokenResponse(w, "Login successful", authenticated, tokens); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to send response to client")
+		}
+	}
 }
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"auth-service/internal/database"
+	"auth-service/internal/ratelimit"
+
+	"github.com/sirupsen/logrus"
+)
+
+// clientIP возвращает адрес клиента. X-Forwarded-For учитывается только при
+// TRUST_PROXY_HEADERS=true, иначе клиент может подставить любой адрес.
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginAccount нормализует email, чтобы регистр не обходил лимиты
+func loginAccount(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// allowLogin проверяет лимиты и при отказе отвечает 429 с заголовком Retry-After.
+// Возвращает false, если обработку запроса нужно прекратить.
+func allowLogin(w http.ResponseWriter, logger *logrus.Logger, limiter *ratelimit.Limiter, ip, account string) bool {
+	decision, err := limiter.Allow(ip, account)
+	if err != nil {
+		logger.WithError(err).Error("Auth-Service: Failed to check login rate limit")
+		http.Error(w, "Failed to process login", http.StatusInternalServerError)
+		return false
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	logger.WithFields(logrus.Fields{
+		"account":     account,
+		"ip":          ip,
+		"reason":      decision.Reason,
+		"retry_after": decision.RetryAfter.String(),
+	}).Warn("Auth-Service: Login attempt rejected by rate limiter")
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+	http.Error(w, "Too many login attempts, try again later", http.StatusTooManyRequests)
+	return false
+}
+
+// recordLoginFailure подтверждает неудачу попытки, допущенной allowLogin, и при
+// блокировке пишет запись в журнал. Попытка, после которой не вызван ни он, ни
+// recordLoginSuccess (например, из-за сбоя), остаётся учтённой как неудача.
+func recordLoginFailure(db *sql.DB, logger *logrus.Logger, limiter *ratelimit.Limiter, ip, account string) {
+	locked, err := limiter.Failure(account)
+	if err != nil {
+		logger.WithError(err).Error("Auth-Service: Failed to record login failure")
+	}
+	if !locked {
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"account":  account,
+		"ip":       ip,
+		"duration": limiter.LockoutDuration().String(),
+	}).Warn("Auth-Service: Account temporarily locked after repeated failures")
+
+	if err := database.InsertLoginAudit(db, account, ip, database.LoginEventLockout); err != nil {
+		logger.WithError(err).Error("Auth-Service: Failed to write login audit record")
+	}
+}
+
+// recordLoginSuccess сбрасывает счётчик неудач вместе с текущей попыткой
+func recordLoginSuccess(logger *logrus.Logger, limiter *ratelimit.Limiter, account string) {
+	if err := limiter.Success(account); err != nil {
+		logger.WithError(err).Error("Auth-Service: Failed to reset login failures")
+	}
+}
@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"auth-service/internal/database"
+	"auth-service/internal/middlewares"
+	"auth-service/internal/ratelimit"
+	"auth-service/internal/totp"
+	"shared/jwtauth"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMFAPendingTTL = 5 * time.Minute
+	recoveryCodeCount    = 10
+)
+
+// TOTPCodeRequest представляет код из приложения-аутентификатора
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// LoginMFARequest представляет второй шаг входа: промежуточный токен
+// и либо код TOTP, либо одноразовый код восстановления
+type LoginMFARequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func mfaPendingTTL() time.Duration {
+	return durationFromEnv("MFA_PENDING_TTL", defaultMFAPendingTTL)
+}
+
+// signMFAPendingToken выпускает промежуточный токен, который принимает только
+// /login/mfa. Идентификатор jti позволяет обменять токен на сессию один раз.
+func signMFAPendingToken(signer *jwtauth.Signer, user authUser) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	claims := jwtauth.Claims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		TokenVersion: user.TokenVersion,
+		Purpose:      jwtauth.PurposePendingMFA,
+	}
+	claims.Id = jti
+	token, _, err := signer.Sign(claims, mfaPendingTTL())
+	return token, err
+}
+
+// generateRecoveryCodes возвращает набор одноразовых кодов вида xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode приводит введённый пользователем код к формату хранения
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// verifyTOTP проверяет код и фиксирует использованный шаг, чтобы код нельзя было предъявить повторно
+func verifyTOTP(userID int, secret, code string) (bool, error) {
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return advanceTOTPStep(userID, step)
+}
+
+// EnrollTOTP генерирует новый секрет и возвращает otpauth URI. TOTP не
+// включается, пока пользователь не подтвердит его первым кодом.
+func EnrollTOTP() http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "YandexCloud"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+		email, _ := r.Context().Value(middlewares.EmailKey).(string)
+
+		state, err := fetchMFAState(userID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch mfa state")
+			http.Error(w, "Failed to fetch mfa state", http.StatusInternalServerError)
+			return
+		}
+		if state.Enabled {
+			http.Error(w, "TOTP is already enabled", http.StatusConflict)
+			return
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate totp secret")
+			http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+
+		if err := updateMFAState(userID, secret, false); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to store totp secret")
+			http.Error(w, "Failed to store secret", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithField("user_id", userID).Info("Auth-Service: TOTP enrollment started")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string]string{
+			"secret":      secret,
+			"otpauth_uri": totp.URI(issuer, email, secret),
+		})
+	}
+}
+
+// ConfirmTOTP включает TOTP после проверки первого кода и выдаёт коды восстановления
+func ConfirmTOTP() http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req TOTPCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		state, err := fetchMFAState(userID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch mfa state")
+			http.Error(w, "Failed to fetch mfa state", http.StatusInternalServerError)
+			return
+		}
+		if state.Enabled {
+			http.Error(w, "TOTP is already enabled", http.StatusConflict)
+			return
+		}
+		if state.Secret == "" {
+			http.Error(w, "TOTP enrollment has not been started", http.StatusBadRequest)
+			return
+		}
+
+		// Шаг фиксируется, как при входе: код подтверждения нельзя повторно
+		// предъявить на /login/mfa в том же временном окне
+		valid, err := verifyTOTP(userID, state.Secret, req.Code)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to verify totp code")
+			http.Error(w, "Failed to verify code", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			logger.WithField("user_id", userID).Warn("Auth-Service: Invalid totp code during enrollment")
+			http.Error(w, "Invalid code", http.StatusForbidden)
+			return
+		}
+
+		codes, err := generateRecoveryCodes()
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate recovery codes")
+			http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+			return
+		}
+
+		// Коды сохраняются до включения TOTP: иначе сбой оставил бы
+		// пользователя со вторым фактором, но без кодов восстановления
+		if err := storeRecoveryCodes(userID, codes); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to store recovery codes")
+			http.Error(w, "Failed to store recovery codes", http.StatusInternalServerError)
+			return
+		}
+		if err := updateMFAState(userID, state.Secret, true); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to enable totp")
+			http.Error(w, "Failed to enable TOTP", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithField("user_id", userID).Info("Auth-Service: TOTP enabled")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":        "TOTP enabled",
+			"recovery_codes": codes,
+		})
+	}
+}
+
+// DisableTOTP выключает TOTP; требуется действующий код из приложения
+func DisableTOTP() http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req TOTPCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		state, err := fetchMFAState(userID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch mfa state")
+			http.Error(w, "Failed to fetch mfa state", http.StatusInternalServerError)
+			return
+		}
+		if !state.Enabled {
+			http.Error(w, "TOTP is not enabled", http.StatusBadRequest)
+			return
+		}
+
+		valid, err := verifyTOTP(userID, state.Secret, req.Code)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to verify totp code")
+			http.Error(w, "Failed to verify code", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			logger.WithField("user_id", userID).Warn("Auth-Service: Invalid totp code on disable")
+			http.Error(w, "Invalid code", http.StatusForbidden)
+			return
+		}
+
+		if err := updateMFAState(userID, "", false); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to disable totp")
+			http.Error(w, "Failed to disable TOTP", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithField("user_id", userID).Info("Auth-Service: TOTP disabled")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "TOTP disabled"})
+	}
+}
+
+// LoginMFA завершает вход пользователя с включённым TOTP
+func LoginMFA(db *sql.DB, signer *jwtauth.Signer, verifier *jwtauth.Verifier, limiter *ratelimit.Limiter) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LoginMFARequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.WithError(err).Warn("Auth-Service: Invalid request payload")
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+			http.Error(w, "MFA token and code are required", http.StatusBadRequest)
+			return
+		}
+
+		claims, err := verifier.VerifyPurpose(req.MFAToken, jwtauth.PurposePendingMFA)
+		if err == nil && claims.Id == "" {
+			err = fmt.Errorf("mfa token has no jti")
+		}
+		if err != nil {
+			logger.WithError(err).Warn("Auth-Service: Invalid mfa token")
+			http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+			return
+		}
+
+		// Коды второго фактора подбираются так же, как пароли: лимиты ведутся по пользователю
+		ip := clientIP(r)
+		account := fmt.Sprintf("mfa:%d", claims.UserID)
+		if !allowLogin(w, logger, limiter, ip, account) {
+			return
+		}
+
+		state, err := fetchMFAState(claims.UserID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch mfa state")
+			http.Error(w, "Failed to fetch mfa state", http.StatusInternalServerError)
+			return
+		}
+		// TOTP могли выключить после выдачи токена; коды восстановления без
+		// включённого второго фактора тоже не принимаются
+		if !state.Enabled {
+			logger.WithField("user_id", claims.UserID).Warn("Auth-Service: MFA login without enabled totp")
+			http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+			return
+		}
+
+		var valid bool
+		if req.RecoveryCode != "" {
+			valid, err = consumeRecoveryCode(claims.UserID, normalizeRecoveryCode(req.RecoveryCode))
+		} else {
+			valid, err = verifyTOTP(claims.UserID, state.Secret, req.Code)
+		}
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to verify second factor")
+			http.Error(w, "Failed to verify code", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			logger.WithField("user_id", claims.UserID).Warn("Auth-Service: Invalid second factor")
+			recordLoginFailure(db, logger, limiter, ip, account)
+			http.Error(w, "Invalid code", http.StatusForbidden)
+			return
+		}
+		recordLoginSuccess(logger, limiter, account)
+
+		fresh, err := database.UseMFAToken(db, claims.Id, time.Unix(claims.ExpiresAt, 0))
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to use mfa token")
+			http.Error(w, "Failed to verify code", http.StatusInternalServerError)
+			return
+		}
+		if !fresh {
+			logger.WithField("user_id", claims.UserID).Warn("Auth-Service: MFA token reused")
+			http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := fetchUserByID(claims.UserID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch user")
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+			return
+		}
+
+		tokens, err := issueTokens(db, signer, *user)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate token")
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":       user.ID,
+			"recovery_code": req.RecoveryCode != "",
+		}).Info("Auth-Service: MFA login successful")
+
+		if err := writeTokenResponse(w, "Login successful", *user, tokens); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to send response to client")
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/middlewares"
+	"auth-service/internal/ratelimit"
+	"auth-service/internal/totp"
+	"shared/jwtauth"
+)
+
+// fakeMFAUser хранит настройки TOTP одного пользователя так, как их хранит Users Service
+type fakeMFAUser struct {
+	mu       sync.Mutex
+	user     authUser
+	password string
+	state    mfaState
+	recovery map[string]bool
+	calls    []string
+}
+
+// newFakeMFAUser регистрирует в фейковом Users Service маршруты входа и MFA для user
+func newFakeMFAUser(t *testing.T, user authUser, password string) *fakeMFAUser {
+	t.Helper()
+	f := &fakeMFAUser{user: user, password: password, recovery: map[string]bool{}}
+	users := newFakeUsersService(t)
+	users.user(user)
+
+	users.handle("/users/verify-credentials", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["email"] != f.user.Email || req["password"] != f.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		writeJSON(w, http.StatusOK, credentialsUser{authUser: f.user, TOTPEnabled: f.state.Enabled})
+	})
+
+	prefix := fmt.Sprintf("/users/%d", user.ID)
+	users.handle(prefix+"/mfa", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.Method == http.MethodPut {
+			var req struct {
+				Secret  string `json:"secret"`
+				Enabled bool   `json:"enabled"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			f.state.Secret, f.state.Enabled = req.Secret, req.Enabled
+			f.calls = append(f.calls, "enable")
+		}
+		writeJSON(w, http.StatusOK, f.state)
+	})
+	users.handle(prefix+"/mfa/step", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Step int64 `json:"step"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if req.Step <= f.state.LastStep {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.state.LastStep = req.Step
+		w.WriteHeader(http.StatusNoContent)
+	})
+	users.handle(prefix+"/recovery-codes", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Codes []string `json:"codes"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.recovery = map[string]bool{}
+		for _, c := range req.Codes {
+			f.recovery[c] = true
+		}
+		f.calls = append(f.calls, "recovery-codes")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	users.handle(prefix+"/recovery-codes/consume", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !f.recovery[req["code"]] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.recovery, req["code"])
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return f
+}
+
+// enable включает TOTP с новым секретом в обход обработчиков
+func (f *fakeMFAUser) enable(t *testing.T) string {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	f.state = mfaState{Secret: secret, Enabled: true}
+	f.mu.Unlock()
+	return secret
+}
+
+// asUser добавляет в запрос пользователя, как это делает AuthMiddleware
+func asUser(h http.Handler, userID int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), middlewares.UserIDKey, userID)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func testLimiter() *ratelimit.Limiter {
+	cfg := ratelimit.DefaultConfig()
+	return ratelimit.New(ratelimit.NewMemoryStore(time.Hour), cfg)
+}
+
+func TestEnrollAndConfirmTOTP(t *testing.T) {
+	f := newFakeMFAUser(t, authUser{ID: 5, Email: "ann@example.com"}, "pw")
+
+	rec := doJSON(asUser(EnrollTOTP(), 5), http.MethodPost, "/mfa/totp/enroll", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("enroll: status %d: %s", rec.Code, rec.Body)
+	}
+	var enrolled map[string]string
+	decodeBody(t, rec, &enrolled)
+	if enrolled["secret"] == "" || f.state.Enabled {
+		t.Fatalf("enroll: secret %q, enabled %v; TOTP must stay off until confirmed", enrolled["secret"], f.state.Enabled)
+	}
+
+	confirm := asUser(ConfirmTOTP(), 5)
+	if rec := doJSON(confirm, http.MethodPost, "/mfa/totp/confirm", TOTPCodeRequest{Code: "000000"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("wrong code: status %d, want 403", rec.Code)
+	}
+
+	code := currentCode(t, enrolled["secret"])
+	rec = doJSON(confirm, http.MethodPost, "/mfa/totp/confirm", TOTPCodeRequest{Code: code})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("confirm: status %d: %s", rec.Code, rec.Body)
+	}
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	decodeBody(t, rec, &confirmed)
+	if len(confirmed.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(confirmed.RecoveryCodes), recoveryCodeCount)
+	}
+	if !f.state.Enabled {
+		t.Error("TOTP is not enabled after confirmation")
+	}
+	// Коды восстановления сохраняются раньше, чем включается второй фактор
+	if want := []string{"enable", "recovery-codes", "enable"}; fmt.Sprint(f.calls) != fmt.Sprint(want) {
+		t.Errorf("users service calls = %v, want %v", f.calls, want)
+	}
+	// Код подтверждения израсходован: его шаг зафиксирован
+	if ok, _ := verifyTOTP(5, enrolled["secret"], code); ok {
+		t.Error("confirmation code can be used again")
+	}
+}
+
+func TestLoginWithTOTPReturnsOnlyPendingToken(t *testing.T) {
+	f := newFakeMFAUser(t, authUser{ID: 6, Email: "bob@example.com", Role: "user"}, "secret-pw")
+	f.enable(t)
+	cfg := testJWTConfig()
+
+	rec := doJSON(Login(nil, testSigner(t, cfg), testLimiter()), http.MethodPost, "/login",
+		LoginRequest{Email: "bob@example.com", Password: "secret-pw"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp map[string]interface{}
+	decodeBody(t, rec, &resp)
+	if resp["mfa_required"] != true || resp["access_token"] != nil || resp["refresh_token"] != nil {
+		t.Fatalf("response = %v, want only an mfa token", resp)
+	}
+
+	// Промежуточный токен не открывает доступ к API
+	verifier := jwtauth.NewVerifier(cfg)
+	mfaToken := resp["mfa_token"].(string)
+	if _, err := verifier.Verify(mfaToken); err == nil {
+		t.Error("pending MFA token accepted as an access token")
+	}
+	claims, err := verifier.VerifyPurpose(mfaToken, jwtauth.PurposePendingMFA)
+	if err != nil || claims.UserID != 6 || claims.Id == "" {
+		t.Errorf("pending token claims %+v, err %v", claims, err)
+	}
+}
+
+func TestLoginMFARejectsBadTokens(t *testing.T) {
+	f := newFakeMFAUser(t, authUser{ID: 7, Email: "eve@example.com"}, "pw")
+	secret := f.enable(t)
+	cfg := testJWTConfig()
+	signer := testSigner(t, cfg)
+	handler := LoginMFA(nil, signer, jwtauth.NewVerifier(cfg), testLimiter())
+
+	access, _, err := signAccessToken(signer, f.user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := signMFAPendingToken(signer, f.user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  LoginMFARequest
+		want int
+	}{
+		{"no code", LoginMFARequest{MFAToken: pending}, http.StatusBadRequest},
+		{"access token instead of pending", LoginMFARequest{MFAToken: access, Code: currentCode(t, secret)}, http.StatusUnauthorized},
+		{"garbage token", LoginMFARequest{MFAToken: "x.y.z", Code: currentCode(t, secret)}, http.StatusUnauthorized},
+		{"wrong code", LoginMFARequest{MFAToken: pending, Code: "000000"}, http.StatusForbidden},
+		{"unknown recovery code", LoginMFARequest{MFAToken: pending, RecoveryCode: "aaaaa-bbbbb"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if rec := doJSON(handler, http.MethodPost, "/login/mfa", tt.req); rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	// Второй фактор выключили после выдачи промежуточного токена
+	f.mu.Lock()
+	f.state.Enabled = false
+	f.mu.Unlock()
+	if rec := doJSON(handler, http.MethodPost, "/login/mfa", LoginMFARequest{MFAToken: pending, Code: currentCode(t, secret)}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("disabled totp: status %d, want 401", rec.Code)
+	}
+}
+
+func TestLoginMFATokenIsSingleUse(t *testing.T) {
+	db := openTestDB(t)
+	f := newFakeMFAUser(t, authUser{ID: 8, Email: "kim@example.com", Role: "user"}, "pw")
+	f.enable(t)
+	f.recovery = map[string]bool{"abcde-fghij": true, "klmno-pqrst": true}
+	cfg := testJWTConfig()
+	signer := testSigner(t, cfg)
+	handler := LoginMFA(db, signer, jwtauth.NewVerifier(cfg), testLimiter())
+
+	pending, err := signMFAPendingToken(signer, f.user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := doJSON(handler, http.MethodPost, "/login/mfa", LoginMFARequest{MFAToken: pending, RecoveryCode: " ABCDE-FGHIJ "})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first exchange: status %d: %s", rec.Code, rec.Body)
+	}
+	var tokens map[string]interface{}
+	decodeBody(t, rec, &tokens)
+	if tokens["access_token"] == nil || tokens["refresh_token"] == nil {
+		t.Errorf("response has no session tokens: %v", tokens)
+	}
+	if f.recovery["abcde-fghij"] {
+		t.Error("recovery code was not consumed")
+	}
+
+	// Перехваченный промежуточный токен с другим кодом уже не обменять
+	rec = doJSON(handler, http.MethodPost, "/login/mfa", LoginMFARequest{MFAToken: pending, RecoveryCode: "klmno-pqrst"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("second exchange: status %d, want 401", rec.Code)
+	}
+}
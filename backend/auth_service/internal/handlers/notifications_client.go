@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// notificationPasswordChanged — тип уведомления о смене пароля
+const notificationPasswordChanged = "password_changed"
+
+// sendSecurityNotification отправляет пользователю уведомление безопасности
+// через Notifications Service от имени auth_service
+func sendSecurityNotification(userID int, kind, message string) error {
+	notificationsURL := os.Getenv("NOTIFICATIONS_SERVICE_URL")
+	if notificationsURL == "" {
+		return fmt.Errorf("NOTIFICATIONS_SERVICE_URL not set")
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"userId":  userID,
+		"type":    kind,
+		"message": message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, notificationsURL+"/notifications/security", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if serviceTokens != nil {
+		token, err := serviceTokens.Token()
+		if err != nil {
+			return fmt.Errorf("failed to sign service token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifications service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to send notification: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyPasswordChanged сообщает пользователю о смене пароля. Сбой отправки
+// только логируется: пароль к этому моменту уже изменён.
+func notifyPasswordChanged(logger *logrus.Logger, userID int) {
+	err := sendSecurityNotification(userID, notificationPasswordChanged,
+		"Your password was changed. If it wasn't you, reset your password immediately.")
+	if err != nil {
+		logger.WithError(err).WithField("user_id", userID).Error("Auth-Service: Failed to send password change notification")
+	}
+}
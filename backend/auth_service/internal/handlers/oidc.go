@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"auth-service/internal/database"
+	"auth-service/internal/oidc"
+	"shared/jwtauth"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	oidcStateTTL                   = 10 * time.Minute
+	defaultOIDCFrontendRedirectURL = "http://localhost:3000/oauth/callback"
+)
+
+// errOIDCLogin — ошибка входа, код которой показывается пользователю
+type errOIDCLogin string
+
+func (e errOIDCLogin) Error() string { return string(e) }
+
+const (
+	errEmailNotVerified   errOIDCLogin = "email_not_verified"
+	errLocalUnverified    errOIDCLogin = "account_email_unverified"
+	errIdentityLinked     errOIDCLogin = "identity_linked_elsewhere"
+	errOIDCInvalidRequest errOIDCLogin = "invalid_request"
+)
+
+// oidcFrontendRedirect возвращает страницу фронтенда, принимающую результат входа
+func oidcFrontendRedirect() string {
+	if v := os.Getenv("OIDC_FRONTEND_REDIRECT_URL"); v != "" {
+		return v
+	}
+	return defaultOIDCFrontendRedirectURL
+}
+
+// redirectWithFragment возвращает пользователя на фронтенд. Результат передаётся
+// во фрагменте URL: он не уходит на сервер и не попадает в логи прокси.
+func redirectWithFragment(w http.ResponseWriter, r *http.Request, values url.Values) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, oidcFrontendRedirect()+"#"+values.Encode(), http.StatusFound)
+}
+
+// OIDCAuthorize начинает вход через провайдера: сохраняет state, nonce и
+// PKCE code_verifier и перенаправляет пользователя на страницу провайдера
+func OIDCAuthorize(db *sql.DB, providers map[string]*oidc.Provider) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := providers[mux.Vars(r)["provider"]]
+		if !ok {
+			http.Error(w, "Unknown provider", http.StatusNotFound)
+			return
+		}
+
+		state, err := oidc.RandomString(32)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate oidc state")
+			http.Error(w, "Failed to start login", http.StatusInternalServerError)
+			return
+		}
+		nonce, err := oidc.RandomString(32)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate oidc nonce")
+			http.Error(w, "Failed to start login", http.StatusInternalServerError)
+			return
+		}
+		verifier, challenge, err := oidc.NewPKCE()
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate pkce verifier")
+			http.Error(w, "Failed to start login", http.StatusInternalServerError)
+			return
+		}
+
+		authURL, err := provider.AuthCodeURL(state, nonce, challenge)
+		if err != nil {
+			logger.WithError(err).WithField("provider", provider.Name()).Error("Auth-Service: Provider is unavailable")
+			http.Error(w, "Provider is unavailable", http.StatusBadGateway)
+			return
+		}
+
+		if err := database.CreateOIDCState(db, hashToken(state), database.OIDCState{
+			Provider:     provider.Name(),
+			CodeVerifier: verifier,
+			Nonce:        nonce,
+		}, time.Now().Add(oidcStateTTL)); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to store oidc state")
+			http.Error(w, "Failed to start login", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// OIDCCallback завершает вход через провайдера: обменивает код на ID-токен,
+// находит или создаёт пользователя и возвращает токены на фронтенд
+func OIDCCallback(db *sql.DB, signer *jwtauth.Signer, providers map[string]*oidc.Provider) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	fail := func(w http.ResponseWriter, r *http.Request, code string) {
+		redirectWithFragment(w, r, url.Values{"error": {code}})
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := providers[mux.Vars(r)["provider"]]
+		if !ok {
+			http.Error(w, "Unknown provider", http.StatusNotFound)
+			return
+		}
+
+		q := r.URL.Query()
+		if providerErr := q.Get("error"); providerErr != "" {
+			logger.WithFields(logrus.Fields{
+				"provider": provider.Name(),
+				"error":    providerErr,
+			}).Warn("Auth-Service: Provider returned an error")
+			fail(w, r, "access_denied")
+			return
+		}
+
+		code, stateParam := q.Get("code"), q.Get("state")
+		if code == "" || stateParam == "" {
+			fail(w, r, string(errOIDCInvalidRequest))
+			return
+		}
+
+		state, err := database.ConsumeOIDCState(db, hashToken(stateParam), provider.Name())
+		if errors.Is(err, database.ErrOIDCStateInvalid) {
+			logger.WithField("provider", provider.Name()).Warn("Auth-Service: Invalid oidc state")
+			fail(w, r, string(errOIDCInvalidRequest))
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to consume oidc state")
+			fail(w, r, "server_error")
+			return
+		}
+
+		identity, err := provider.Exchange(code, state.CodeVerifier, state.Nonce)
+		if err != nil {
+			logger.WithError(err).WithField("provider", provider.Name()).Warn("Auth-Service: Failed to exchange authorization code")
+			fail(w, r, "exchange_failed")
+			return
+		}
+
+		user, err := resolveOIDCUser(provider.Name(), identity)
+		var loginErr errOIDCLogin
+		if errors.As(err, &loginErr) {
+			logger.WithFields(logrus.Fields{
+				"provider": provider.Name(),
+				"reason":   string(loginErr),
+			}).Warn("Auth-Service: OIDC login rejected")
+			fail(w, r, string(loginErr))
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to resolve oidc user")
+			fail(w, r, "server_error")
+			return
+		}
+
+		// Второй фактор обязателен и при входе через провайдера
+		mfa, err := fetchMFAState(user.ID)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch mfa state")
+			fail(w, r, "server_error")
+			return
+		}
+		if mfa.Enabled {
+			mfaToken, err := signMFAPendingToken(signer, *user)
+			if err != nil {
+				logger.WithError(err).Error("Auth-Service: Failed to generate mfa token")
+				fail(w, r, "server_error")
+				return
+			}
+			redirectWithFragment(w, r, url.Values{"mfa_token": {mfaToken}})
+			return
+		}
+
+		tokens, err := issueTokens(db, signer, *user)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate token")
+			fail(w, r, "server_error")
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":  user.ID,
+			"provider": provider.Name(),
+		}).Info("Auth-Service: OIDC login successful")
+
+		userJSON, err := json.Marshal(user)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to encode user")
+			fail(w, r, "server_error")
+			return
+		}
+
+		redirectWithFragment(w, r, url.Values{
+			"user":          {string(userJSON)},
+			"token":         {tokens.AccessToken},
+			"refresh_token": {tokens.RefreshToken},
+			"expires_in":    {strconv.Itoa(int(time.Until(tokens.AccessExpiresAt).Seconds()))},
+		})
+	}
+}
+
+// resolveOIDCUser находит пользователя по привязанному аккаунту. Если аккаунт
+// не привязан, он привязывается к пользователю с тем же email — только когда
+// адрес подтверждён и провайдером, и у нас; иначе создаётся новый пользователь.
+func resolveOIDCUser(provider string, identity *oidc.Identity) (*authUser, error) {
+	user, err := fetchUserByIdentity(provider, identity.Subject)
+	if err != nil || user != nil {
+		return user, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errEmailNotVerified
+	}
+	link := identityRequest{
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Username: identity.PreferredUsername,
+	}
+
+	existing, err := fetchUserByEmail(identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return createOIDCUser(link)
+	}
+
+	// Иначе чужой аккаунт, зарегистрированный на этот адрес без подтверждения,
+	// получил бы доступ владельца адреса
+	if !existing.EmailVerified {
+		return nil, errLocalUnverified
+	}
+	if err := linkIdentity(existing.ID, link); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"auth-service/internal/oidc"
+	"auth-service/internal/oidc/mock"
+	"shared/jwtauth"
+
+	"github.com/gorilla/mux"
+)
+
+// fakeIdentities — Users Service с привязками внешних аккаунтов и пользователями по email
+type fakeIdentities struct {
+	mu      sync.Mutex
+	byEmail map[string]authUser
+	links   map[string]int // provider|subject → user ID
+	created []identityRequest
+}
+
+func newFakeIdentities(t *testing.T, users ...authUser) *fakeIdentities {
+	t.Helper()
+	f := &fakeIdentities{byEmail: map[string]authUser{}, links: map[string]int{}}
+	for _, u := range users {
+		f.byEmail[u.Email] = u
+	}
+	fake := newFakeUsersService(t)
+
+	fake.handle("/users/by_identity", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id, ok := f.links[r.URL.Query().Get("provider")+"|"+r.URL.Query().Get("subject")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for _, u := range f.byEmail {
+			if u.ID == id {
+				writeJSON(w, http.StatusOK, u)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	fake.handle("/users/by_email", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		u, ok := f.byEmail[r.URL.Query().Get("email")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, u)
+	})
+	fake.handle("/users/oidc", func(w http.ResponseWriter, r *http.Request) {
+		var req identityRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		u := authUser{ID: 1000 + len(f.created), Username: req.Username, Email: req.Email, EmailVerified: true, Role: "user"}
+		f.created = append(f.created, req)
+		f.byEmail[u.Email] = u
+		f.links[req.Provider+"|"+req.Subject] = u.ID
+		writeJSON(w, http.StatusCreated, u)
+	})
+	fake.handle("/users/", func(w http.ResponseWriter, r *http.Request) {
+		// POST /users/{id}/identities и GET /users/{id}/mfa
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch parts[2] {
+		case "identities":
+			var req identityRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			id, _ := strconv.Atoi(parts[1])
+			f.mu.Lock()
+			f.links[req.Provider+"|"+req.Subject] = id
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case "mfa":
+			writeJSON(w, http.StatusOK, mfaState{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return f
+}
+
+func TestResolveOIDCUser(t *testing.T) {
+	verified := authUser{ID: 1, Email: "ann@example.com", EmailVerified: true}
+	unverified := authUser{ID: 2, Email: "bob@example.com"}
+
+	tests := []struct {
+		name     string
+		identity oidc.Identity
+		wantID   int
+		wantErr  error
+	}{
+		{"links verified local account", oidc.Identity{Subject: "a", Email: "ann@example.com", EmailVerified: true}, 1, nil},
+		{"provider email not verified", oidc.Identity{Subject: "b", Email: "ann@example.com"}, 0, errEmailNotVerified},
+		{"local email not verified", oidc.Identity{Subject: "c", Email: "bob@example.com", EmailVerified: true}, 0, errLocalUnverified},
+		{"creates new user", oidc.Identity{Subject: "d", Email: "new@example.com", EmailVerified: true, PreferredUsername: "newbie"}, 1000, nil},
+	}
+	for _, tt := range tests {
+		f := newFakeIdentities(t, verified, unverified)
+		user, err := resolveOIDCUser("mock", &tt.identity)
+		if err != tt.wantErr {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr == nil && user.ID != tt.wantID {
+			t.Errorf("%s: user %d, want %d", tt.name, user.ID, tt.wantID)
+		}
+		if tt.wantErr != nil && len(f.links) != 0 {
+			t.Errorf("%s: identity linked despite the error", tt.name)
+		}
+	}
+
+	// Привязанный аккаунт находится по subject, даже если email у провайдера сменился
+	f := newFakeIdentities(t, verified)
+	f.links["mock|a"] = 1
+	user, err := resolveOIDCUser("mock", &oidc.Identity{Subject: "a", Email: "changed@example.com"})
+	if err != nil || user.ID != 1 {
+		t.Errorf("linked identity: %+v, %v", user, err)
+	}
+}
+
+// TestOIDCLoginWithMockProvider проходит вход целиком: authorize → страница
+// mock-провайдера → callback → токены во фрагменте редиректа на фронтенд
+func TestOIDCLoginWithMockProvider(t *testing.T) {
+	db := openTestDB(t)
+	newFakeIdentities(t, authUser{ID: 1, Email: "ann@example.com", EmailVerified: true, Role: "user"})
+	t.Setenv("OIDC_FRONTEND_REDIRECT_URL", "http://frontend/oauth/callback")
+
+	var provider http.Handler
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { provider.ServeHTTP(w, r) }))
+	defer idp.Close()
+	m, err := mock.New(mock.Config{Issuer: idp.URL, ClientID: "yc", ClientSecret: "s", Email: "ann@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider = m.Handler()
+
+	cfg := testJWTConfig()
+	providers := map[string]*oidc.Provider{}
+	router := mux.NewRouter()
+	router.HandleFunc("/oidc/{provider}/authorize", OIDCAuthorize(db, providers))
+	router.HandleFunc("/oidc/{provider}/callback", OIDCCallback(db, testSigner(t, cfg), providers))
+	auth := httptest.NewServer(router)
+	defer auth.Close()
+	providers["mock"] = oidc.NewProvider(oidc.Config{
+		Name: "mock", Issuer: idp.URL, ClientID: "yc", ClientSecret: "s",
+		RedirectURL: auth.URL + "/oidc/mock/callback", Scopes: []string{"openid", "email"},
+	})
+
+	// Браузер проходит редиректы до фронтенда
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host == "frontend" {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}}
+	resp, err := client.Get(auth.URL + "/oidc/mock/authorize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || loc.Host != "frontend" {
+		t.Fatalf("final redirect = %q", resp.Header.Get("Location"))
+	}
+	result, _ := url.ParseQuery(loc.Fragment)
+	if result.Get("error") != "" {
+		t.Fatalf("login failed: %s", result.Get("error"))
+	}
+	claims, err := jwtauth.NewVerifier(cfg).Verify(result.Get("token"))
+	if err != nil || claims.UserID != 1 {
+		t.Errorf("access token claims %+v, err %v", claims, err)
+	}
+	if result.Get("refresh_token") == "" {
+		t.Error("no refresh token")
+	}
+	if loc.RawQuery != "" {
+		t.Errorf("tokens leaked into the query string: %q", loc.RawQuery)
+	}
+}
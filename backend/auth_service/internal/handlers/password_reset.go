@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-service/internal/database"
+	"auth-service/internal/ratelimit"
+	"shared/mailer"
+	"shared/passpolicy"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultResetTokenTTL = time.Hour
+
+// ForgotPasswordRequest представляет запрос на сброс пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest представляет установку нового пароля по токену сброса
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func resetTokenTTL() time.Duration {
+	return durationFromEnv("RESET_TOKEN_TTL", defaultResetTokenTTL)
+}
+
+// resetLink формирует ссылку на страницу сброса пароля во фронтенде
+func resetLink(token string) string {
+	base := os.Getenv("PASSWORD_RESET_URL")
+	if base == "" {
+		base = "http://localhost:3000/reset-password"
+	}
+	return base + "?token=" + url.QueryEscape(token)
+}
+
+// resetAccount — ключ лимитера для запросов сброса пароля. Отдельное
+// пространство имён не даёт запросам сброса блокировать вход в учётную запись.
+func resetAccount(email string) string {
+	return "reset:" + loginAccount(email)
+}
+
+// allowPasswordReset ограничивает запросы сброса тем же лимитером, что и вход:
+// по IP и по email. Каждый запрос учитывается как попытка для email, поэтому
+// повторные письма на один адрес откладываются, а затем блокируются.
+// Возвращает false, если ответ уже отправлен.
+func allowPasswordReset(w http.ResponseWriter, logger *logrus.Logger, limiter *ratelimit.Limiter, ip, email string) bool {
+	account := resetAccount(email)
+	decision, err := limiter.Allow(ip, account)
+	if err != nil {
+		logger.WithError(err).Error("Auth-Service: Failed to check password reset rate limit")
+		http.Error(w, "Failed to process password reset", http.StatusInternalServerError)
+		return false
+	}
+	if !decision.Allowed {
+		logger.WithFields(logrus.Fields{
+			"ip":          ip,
+			"reason":      decision.Reason,
+			"retry_after": decision.RetryAfter.String(),
+		}).Warn("Auth-Service: Password reset request rejected by rate limiter")
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		http.Error(w, "Too many password reset requests, try again later", http.StatusTooManyRequests)
+		return false
+	}
+
+	if _, err := limiter.Failure(account); err != nil {
+		logger.WithError(err).Error("Auth-Service: Failed to record password reset request")
+	}
+	return true
+}
+
+// ForgotPassword отправляет письмо со ссылкой для сброса пароля. Ответ всегда
+// одинаковый и отправляется до поиска пользователя, чтобы по нему нельзя было
+// узнать, зарегистрирован ли email. Запросы ограничиваются по IP и по email.
+func ForgotPassword(db *sql.DB, m mailer.Mailer, limiter *ratelimit.Limiter) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	sendReset := func(email string) {
+		user, err := fetchUserByEmail(email)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to fetch user for password reset")
+			return
+		}
+		if user == nil {
+			logger.Info("Auth-Service: Password reset requested for unknown email")
+			return
+		}
+
+		token, err := randomToken(32)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate reset token")
+			return
+		}
+
+		ttl := resetTokenTTL()
+		if err := database.CreatePasswordReset(db, user.ID, hashToken(token), time.Now().Add(ttl)); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to store reset token")
+			return
+		}
+
+		err = m.Send(mailer.Message{
+			To:      user.Email,
+			Subject: "Сброс пароля",
+			Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+				"Ссылка действует %s и может быть использована один раз. "+
+				"Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+				user.Username, resetLink(token), ttl),
+		})
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Auth-Service: Failed to send reset email")
+			return
+		}
+
+		logger.WithField("user_id", user.ID).Info("Auth-Service: Password reset email sent")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		email := strings.TrimSpace(req.Email)
+		if email == "" {
+			http.Error(w, "Email is required", http.StatusBadRequest)
+			return
+		}
+
+		if !allowPasswordReset(w, logger, limiter, clientIP(r), email) {
+			return
+		}
+
+		go sendReset(email)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "If the email is registered, a password reset link has been sent",
+		})
+	}
+}
+
+// ResetPassword устанавливает новый пароль по одноразовому токену сброса
+// и отзывает все сессии пользователя
+func ResetPassword(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if req.Token == "" || req.NewPassword == "" {
+			http.Error(w, "Token and new password are required", http.StatusBadRequest)
+			return
+		}
+
+		var userID int
+		err := database.UsePasswordReset(db, hashToken(req.Token), func(id int) error {
+			userID = id
+			return updateUserPassword(id, passwordChange{NewPassword: req.NewPassword, ResetToken: req.Token})
+		})
+		if errors.Is(err, database.ErrResetTokenInvalid) || errors.Is(err, errPasswordChangeDenied) {
+			logger.Warn("Auth-Service: Invalid or expired reset token")
+			http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+			return
+		} else if verr, ok := passpolicy.AsValidationError(err); ok {
+			// Токен остаётся действительным: транзакция откатилась
+			logger.WithField("user_id", userID).Warn("Auth-Service: New password does not meet the policy")
+			passpolicy.WriteError(w, verr)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to reset password")
+			http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+			return
+		}
+
+		// Старый пароль мог быть скомпрометирован: завершаем все сессии
+		if err := database.RevokeUserSessions(db, userID); err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Auth-Service: Failed to revoke sessions after reset")
+		}
+
+		logger.WithField("user_id", userID).Info("Auth-Service: Password reset successful")
+		go notifyPasswordChanged(logger, userID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Password has been reset"})
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"shared/mailer"
+)
+
+// mailbox собирает письма, отправленные в фоне
+type mailbox chan mailer.Message
+
+func (m mailbox) Send(msg mailer.Message) error {
+	m <- msg
+	return nil
+}
+
+func (m mailbox) wait(t *testing.T) mailer.Message {
+	t.Helper()
+	select {
+	case msg := <-m:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was sent")
+		return mailer.Message{}
+	}
+}
+
+func TestForgotPasswordDoesNotRevealAccounts(t *testing.T) {
+	users := newFakeUsersService(t)
+	users.handle("/users/by_email", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := ForgotPassword(nil, make(mailbox, 1), testLimiter())
+
+	rec := doJSON(handler, http.MethodPost, "/password/forgot", ForgotPasswordRequest{Email: "nobody@example.com"})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d, want 202", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "If the email is registered") {
+		t.Errorf("body = %q", rec.Body)
+	}
+
+	if rec := doJSON(handler, http.MethodPost, "/password/forgot", ForgotPasswordRequest{Email: "  "}); rec.Code != http.StatusBadRequest {
+		t.Errorf("blank email: status %d, want 400", rec.Code)
+	}
+}
+
+func TestForgotPasswordIsThrottled(t *testing.T) {
+	users := newFakeUsersService(t)
+	users.handle("/users/by_email", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	limiter := testLimiter()
+	handler := ForgotPassword(nil, make(mailbox, 10), limiter)
+
+	// Первые запросы проходят, затем письма на тот же адрес откладываются
+	var last int
+	for i := 0; i < 4; i++ {
+		rec := doJSON(handler, http.MethodPost, "/password/forgot", ForgotPasswordRequest{Email: "Victim@Example.com"})
+		last = rec.Code
+		if last == http.StatusTooManyRequests {
+			if rec.Header().Get("Retry-After") == "" {
+				t.Error("429 without Retry-After")
+			}
+			break
+		}
+	}
+	if last != http.StatusTooManyRequests {
+		t.Fatalf("request flood was not throttled, last status %d", last)
+	}
+
+	// Регистр и пробелы не обходят лимит
+	if rec := doJSON(handler, http.MethodPost, "/password/forgot", ForgotPasswordRequest{Email: " victim@example.com "}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("normalized email: status %d, want 429", rec.Code)
+	}
+	// Другой адрес и вход в учётную запись не затронуты
+	if rec := doJSON(handler, http.MethodPost, "/password/forgot", ForgotPasswordRequest{Email: "other@example.com"}); rec.Code != http.StatusAccepted {
+		t.Errorf("other email: status %d, want 202", rec.Code)
+	}
+	if d, _ := limiter.Allow("192.0.2.10", loginAccount("victim@example.com")); !d.Allowed {
+		t.Error("password reset requests blocked the login")
+	}
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	db := openTestDB(t)
+	user := authUser{ID: 900001, Username: "ann", Email: "ann@example.com"}
+	t.Cleanup(func() { db.Exec("DELETE FROM password_resets WHERE user_id = $1", user.ID) })
+
+	var changes []passwordChange
+	users := newFakeUsersService(t)
+	users.handle("/users/by_email", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, user)
+	})
+	users.handle("/users/900001/password", func(w http.ResponseWriter, r *http.Request) {
+		var c passwordChange
+		json.NewDecoder(r.Body).Decode(&c)
+		changes = append(changes, c)
+		writeJSON(w, http.StatusOK, map[string]string{})
+	})
+
+	box := make(mailbox, 1)
+	rec := doJSON(ForgotPassword(db, box, testLimiter()), http.MethodPost, "/password/forgot", ForgotPasswordRequest{Email: user.Email})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("forgot: status %d", rec.Code)
+	}
+
+	msg := box.wait(t)
+	if msg.To != user.Email {
+		t.Errorf("email sent to %q", msg.To)
+	}
+	start := strings.Index(msg.Body, "http")
+	if start < 0 {
+		t.Fatalf("email has no link:\n%s", msg.Body)
+	}
+	link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := link.Query().Get("token")
+
+	reset := ResetPassword(db)
+	rec = doJSON(reset, http.MethodPost, "/password/reset", ResetPasswordRequest{Token: token, NewPassword: "n3w-Passw0rd!"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reset: status %d: %s", rec.Code, rec.Body)
+	}
+	if len(changes) != 1 || changes[0].NewPassword != "n3w-Passw0rd!" || changes[0].ResetToken != token {
+		t.Errorf("users service got %+v", changes)
+	}
+
+	rec = doJSON(reset, http.MethodPost, "/password/reset", ResetPasswordRequest{Token: token, NewPassword: "an0ther-Passw0rd!"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("reused link: status %d, want 400", rec.Code)
+	}
+}
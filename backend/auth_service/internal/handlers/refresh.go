@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"auth-service/internal/database"
+	"shared/jwtauth"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RefreshRequest представляет данные для обновления токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Предъявленный
+// refresh-токен становится недействительным; его повторное использование
+// отзывает всё семейство сессий.
+func Refresh(db *sql.DB, signer *jwtauth.Signer) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.WithError(err).Warn("Auth-Service: Invalid request payload")
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if req.RefreshToken == "" {
+			logger.Warn("Auth-Service: Refresh token is required")
+			http.Error(w, "Refresh token is required", http.StatusBadRequest)
+			return
+		}
+
+		newRefreshToken, err := randomToken(32)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate refresh token")
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		session, err := database.RotateSession(db, hashToken(req.RefreshToken), hashToken(newRefreshToken), time.Now().Add(refreshTokenTTL()))
+		switch {
+		case errors.Is(err, database.ErrSessionReused):
+			logger.Warn("Auth-Service: Refresh token reuse detected, session family revoked")
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		case errors.Is(err, database.ErrSessionNotFound), errors.Is(err, database.ErrSessionExpired):
+			logger.WithError(err).Warn("Auth-Service: Invalid refresh token")
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		case err != nil:
+			logger.WithError(err).Error("Auth-Service: Failed to rotate session")
+			http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+			return
+		}
+
+		user, err := fetchUserByID(session.UserID)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", session.UserID).Warn("Auth-Service: Failed to fetch user for refresh")
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		accessToken, accessExp, err := signAccessToken(signer, *user)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to generate token")
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":   user.ID,
+			"token_exp": accessExp.Format(time.RFC3339),
+		}).Info("Auth-Service: Token refreshed")
+
+		tokens := &tokenPair{
+			AccessToken:     accessToken,
+			AccessExpiresAt: accessExp,
+			RefreshToken:    newRefreshToken,
+		}
+		if err := writeTokenResponse(w, "Token refreshed", *user, tokens); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to send response to client")
+		}
+	}
+}
+
+// Logout отзывает семейство сессий, к которому относится refresh-токен
+func Logout(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.WithError(err).Warn("Auth-Service: Invalid request payload")
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if req.RefreshToken == "" {
+			logger.Warn("Auth-Service: Refresh token is required")
+			http.Error(w, "Refresh token is required", http.StatusBadRequest)
+			return
+		}
+
+		err := database.RevokeSessionFamily(db, hashToken(req.RefreshToken))
+		if err != nil && !errors.Is(err, database.ErrSessionNotFound) {
+			logger.WithError(err).Error("Auth-Service: Failed to revoke session")
+			http.Error(w, "Failed to logout", http.StatusInternalServerError)
+			return
+		}
+
+		// Неизвестный или уже отозванный токен не считается ошибкой: результат тот же
+		logger.Info("Auth-Service: Logout successful")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Logout successful"})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"shared/jwtauth"
+)
+
+func TestRefreshRequiresToken(t *testing.T) {
+	// До обращения к базе запрос отклоняется, поэтому база не нужна
+	h := Refresh(nil, testSigner(t, testJWTConfig()))
+
+	if rec := doJSON(h, http.MethodPost, "/refresh", map[string]string{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty token: status %d, want 400", rec.Code)
+	}
+	if rec := doJSON(Logout(nil), http.MethodPost, "/logout", map[string]string{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("logout without token: status %d, want 400", rec.Code)
+	}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	db := openTestDB(t)
+	cfg := testJWTConfig()
+	signer := testSigner(t, cfg)
+	user := authUser{ID: 3, Username: "alice", Email: "alice@example.com", Role: "user"}
+	newFakeUsersService(t).user(user)
+
+	issued, err := issueTokens(db, signer, user)
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM sessions WHERE family_id = (SELECT family_id FROM sessions WHERE token_hash = $1)", hashToken(issued.RefreshToken))
+	})
+
+	refresh := Refresh(db, signer)
+	rec := doJSON(refresh, http.MethodPost, "/refresh", RefreshRequest{RefreshToken: issued.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first refresh: status %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	decodeBody(t, rec, &body)
+	if body.RefreshToken == "" || body.RefreshToken == issued.RefreshToken {
+		t.Fatalf("refresh token was not rotated: %q", body.RefreshToken)
+	}
+	claims, err := jwtauth.NewVerifier(cfg).Verify(body.Token)
+	if err != nil || claims.UserID != user.ID {
+		t.Fatalf("new access token: claims %+v, err %v", claims, err)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+
+	// Повтор старого токена отзывает и новый
+	if rec := doJSON(refresh, http.MethodPost, "/refresh", RefreshRequest{RefreshToken: issued.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("reused token: status %d, want 401", rec.Code)
+	}
+	if rec := doJSON(refresh, http.MethodPost, "/refresh", RefreshRequest{RefreshToken: body.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("successor after reuse: status %d, want 401", rec.Code)
+	}
+}
+
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	db := openTestDB(t)
+	signer := testSigner(t, testJWTConfig())
+	user := authUser{ID: 4, Username: "bob", Email: "bob@example.com", Role: "user"}
+	newFakeUsersService(t).user(user)
+
+	issued, err := issueTokens(db, signer, user)
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM sessions WHERE token_hash = $1", hashToken(issued.RefreshToken)) })
+
+	if rec := doJSON(Logout(db), http.MethodPost, "/logout", RefreshRequest{RefreshToken: issued.RefreshToken}); rec.Code != http.StatusOK {
+		t.Fatalf("logout: status %d", rec.Code)
+	}
+	// Повторный выход с тем же токеном не считается ошибкой
+	if rec := doJSON(Logout(db), http.MethodPost, "/logout", RefreshRequest{RefreshToken: issued.RefreshToken}); rec.Code != http.StatusOK {
+		t.Errorf("second logout: status %d, want 200", rec.Code)
+	}
+	if rec := doJSON(Refresh(db, signer), http.MethodPost, "/refresh", RefreshRequest{RefreshToken: issued.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout: status %d, want 401", rec.Code)
+	}
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"os"
 	"strings"
 
+	"shared/passpolicy"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,8 +20,9 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 }
 
-// RegisterUser обрабатывает регистрацию пользователя
-func RegisterUser() http.HandlerFunc {
+// RegisterUser обрабатывает регистрацию пользователя. Пароль проверяется по
+// политике здесь, чтобы не нагружать Users Service заведомо отклонёнными запросами.
+func RegisterUser(policy *passpolicy.Policy) http.HandlerFunc {
 	// Инициализация логгера
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
@@ -32,10 +36,9 @@ func RegisterUser() http.HandlerFunc {
 			return
 		}
 
-		// Очистка входных данных
+		// Очистка входных данных. Пароль не меняется: пробелы в нём значимы
 		req.Username = strings.TrimSpace(req.Username)
 		req.Email = strings.TrimSpace(req.Email)
-		req.Password = strings.TrimSpace(req.Password)
 
 		// Проверка обязательных полей
 		if req.Username == "" || req.Email == "" || req.Password == "" {
@@ -44,29 +47,16 @@ func RegisterUser() http.HandlerFunc {
 			return
 		}
 
-		// Получение URL сервиса пользователей
-		userServiceURL := os.Getenv("USERS_SERVICE_URL")
-		if userServiceURL == "" {
-			logger.Error("Auth-Service: Users service URL is not configured")
-			http.Error(w, "Users service URL is not configured", http.StatusInternalServerError)
-			return
-		}
-
-		// Подготовка запроса
-		reqBody, err := json.Marshal(req)
-		if err != nil {
-			logger.WithError(err).Error("Auth-Service: Failed to encode request payload")
-			http.Error(w, "Failed to encode request", http.StatusInternalServerError)
+		if verr, ok := passpolicy.AsValidationError(policy.Check(req.Password, req.Username, req.Email)); ok {
+			logger.WithField("username", req.Username).Warn("Auth-Service: Password does not meet the policy")
+			passpolicy.WriteError(w, verr)
 			return
 		}
 
 		// Выполнение запроса к сервису пользователей
-		resp, err := http.Post(userServiceURL+"/users/register", "application/json", bytes.NewReader(reqBody))
+		resp, err := callUsersService(http.MethodPost, "/users/register", req)
 		if err != nil {
-			logger.WithFields(logrus.Fields{
-				"service_url": userServiceURL,
-				"error":       err.Error(),
-			}).Error("Auth-Service: Failed to register user")
+			logger.WithError(err).Error("Auth-Service: Failed to register user")
 			http.Error(w, "Failed to register user", http.StatusInternalServerError)
 			return
 		}
@@ -76,12 +66,19 @@ func RegisterUser() http.HandlerFunc {
 		if resp.StatusCode != http.StatusCreated {
 			logger.WithField("status_code", resp.StatusCode).Warn("Auth-Service: Failed to register user")
 
+			body, _ := io.ReadAll(resp.Body)
+			if verr := passpolicy.ReadError(bytes.NewReader(body)); verr != nil {
+				passpolicy.WriteError(w, verr)
+				return
+			}
 			var errResp map[string]interface{}
-			if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil {
-				http.Error(w, errResp["message"].(string), resp.StatusCode)
-			} else {
-				http.Error(w, "Failed to register user", resp.StatusCode)
+			if err := json.Unmarshal(body, &errResp); err == nil {
+				if message, ok := errResp["message"].(string); ok {
+					http.Error(w, message, resp.StatusCode)
+					return
+				}
 			}
+			http.Error(w, "Failed to register user", resp.StatusCode)
 			return
 		}
 
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"shared/jwtauth"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ServiceClients — сервисы, которым auth_service выдаёт сервисные токены:
+// имя сервиса → SHA-256 его секрета
+type ServiceClients map[string][sha256.Size]byte
+
+// ServiceClientsFromEnv читает SERVICE_CLIENTS — список name:secret через
+// запятую, например "posts-service:s3cret". Ключи подписи есть только у
+// auth_service; остальные сервисы получают по секрету сервисный токен.
+func ServiceClientsFromEnv() (ServiceClients, error) {
+	clients := make(ServiceClients)
+	for _, entry := range strings.Split(os.Getenv("SERVICE_CLIENTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, secret, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || secret == "" {
+			return nil, fmt.Errorf("invalid SERVICE_CLIENTS entry: expected name:secret")
+		}
+		if _, exists := clients[name]; exists {
+			return nil, fmt.Errorf("duplicate service %q in SERVICE_CLIENTS", name)
+		}
+		clients[name] = sha256.Sum256([]byte(secret))
+	}
+	return clients, nil
+}
+
+// verify сравнивает секрет сервиса name с настроенным за постоянное время
+func (c ServiceClients) verify(name, secret string) bool {
+	want, ok := c[name]
+	got := sha256.Sum256([]byte(secret))
+	return ok && subtle.ConstantTimeCompare(want[:], got[:]) == 1
+}
+
+// IssueServiceToken выдаёт сервисный токен сроком ttl. Сервис передаёт своё
+// имя и секрет из SERVICE_CLIENTS в заголовке Authorization: Basic.
+func IssueServiceToken(signer *jwtauth.Signer, clients ServiceClients, ttl time.Duration) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, secret, ok := r.BasicAuth()
+		if !ok || !clients.verify(name, secret) {
+			logger.WithField("service", name).Warn("Auth-Service: Invalid service credentials")
+			w.Header().Set("WWW-Authenticate", `Basic realm="service"`)
+			http.Error(w, "Invalid service credentials", http.StatusUnauthorized)
+			return
+		}
+
+		token, expiresAt, err := signer.SignService(name, ttl)
+		if err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to sign service token")
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      token,
+			"expires_in": int(time.Until(expiresAt).Seconds()),
+		}); err != nil {
+			logger.WithError(err).Error("Auth-Service: Failed to encode service token")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shared/jwtauth"
+)
+
+func TestServiceClientsFromEnv(t *testing.T) {
+	t.Setenv("SERVICE_CLIENTS", " posts-service:one , users-service:two:with-colon ")
+	clients, err := ServiceClientsFromEnv()
+	if err != nil {
+		t.Fatalf("ServiceClientsFromEnv: %v", err)
+	}
+	if !clients.verify("posts-service", "one") || !clients.verify("users-service", "two:with-colon") {
+		t.Error("configured secrets are rejected")
+	}
+	if clients.verify("posts-service", "two:with-colon") || clients.verify("mail-service", "one") {
+		t.Error("wrong secret or unknown service accepted")
+	}
+
+	// Переменная необязательна: без неё сервисные токены никому не выдаются
+	t.Setenv("SERVICE_CLIENTS", "")
+	if clients, err := ServiceClientsFromEnv(); err != nil || len(clients) != 0 {
+		t.Errorf("empty SERVICE_CLIENTS: %v, %v", clients, err)
+	}
+
+	for _, bad := range []string{"posts-service", "posts-service:", ":secret", "a:1,a:2"} {
+		t.Setenv("SERVICE_CLIENTS", bad)
+		if _, err := ServiceClientsFromEnv(); err == nil {
+			t.Errorf("SERVICE_CLIENTS=%q accepted", bad)
+		}
+	}
+}
+
+func TestIssueServiceToken(t *testing.T) {
+	t.Setenv("SERVICE_CLIENTS", "posts-service:s3cret")
+	clients, _ := ServiceClientsFromEnv()
+	cfg := testJWTConfig()
+	srv := httptest.NewServer(IssueServiceToken(testSigner(t, cfg), clients, 10*time.Minute))
+	defer srv.Close()
+
+	for _, creds := range [][2]string{{"posts-service", "wrong"}, {"users-service", "s3cret"}, {"", ""}} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+		if creds[0] != "" {
+			req.SetBasicAuth(creds[0], creds[1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("credentials %q: status %d, want 401", creds, resp.StatusCode)
+		}
+	}
+
+	// Так сервисы получают токен на деле: через клиент из shared/jwtauth
+	mux := http.NewServeMux()
+	mux.Handle("/service-token", IssueServiceToken(testSigner(t, cfg), clients, 10*time.Minute))
+	auth := httptest.NewServer(mux)
+	defer auth.Close()
+
+	token, err := jwtauth.NewServiceTokenClient(auth.URL, "posts-service", "s3cret").Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if name, err := jwtauth.NewVerifier(cfg).VerifyService(token); err != nil || name != "posts-service" {
+		t.Errorf("VerifyService = %q, %v", name, err)
+	}
+}
+
+func TestUsersServiceCallsCarryServiceToken(t *testing.T) {
+	cfg := testJWTConfig()
+	UseServiceTokens(jwtauth.NewServiceTokenSource(testSigner(t, cfg), ServiceName, time.Minute))
+	t.Cleanup(func() { UseServiceTokens(nil) })
+
+	var caller string
+	users := newFakeUsersService(t)
+	users.handle("/users/12", func(w http.ResponseWriter, r *http.Request) {
+		caller, _ = jwtauth.NewVerifier(cfg).VerifyService(jwtauth.BearerToken(r))
+		writeJSON(w, http.StatusOK, authUser{ID: 12})
+	})
+
+	if _, err := fetchUserByID(12); err != nil {
+		t.Fatalf("fetchUserByID: %v", err)
+	}
+	if caller != ServiceName {
+		t.Errorf("users service saw caller %q, want %q", caller, ServiceName)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"auth-service/internal/database"
+
+	"shared/jwtauth"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// authUser представляет данные пользователя, попадающие в токены и ответы
+type authUser struct {
+	ID            int    `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	TokenVersion  int    `json:"token_version"`
+}
+
+// tokenPair представляет выданную пару access/refresh токенов
+type tokenPair struct {
+	AccessToken     string
+	AccessExpiresAt time.Time
+	RefreshToken    string
+}
+
+// durationFromEnv читает длительность из переменной окружения или возвращает значение по умолчанию
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+func accessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+func refreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+// randomToken возвращает криптографически случайную строку из n байт
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken возвращает SHA-256 хэш токена; в базе хранятся только хэши
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// signAccessToken выпускает короткоживущий access-токен
+func signAccessToken(signer *jwtauth.Signer, user authUser) (string, time.Time, error) {
+	return signer.Sign(jwtauth.Claims{
+		UserID:        user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          user.Role,
+		TokenVersion:  user.TokenVersion,
+	}, accessTokenTTL())
+}
+
+// issueTokens выпускает access-токен и refresh-токен, открывающий новое семейство сессий
+func issueTokens(db *sql.DB, signer *jwtauth.Signer, user authUser) (*tokenPair, error) {
+	accessToken, accessExp, err := signAccessToken(signer, user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session family: %w", err)
+	}
+
+	expiresAt := time.Now().Add(refreshTokenTTL())
+	if err := database.CreateSession(db, user.ID, familyID, hashToken(refreshToken), expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{
+		AccessToken:     accessToken,
+		AccessExpiresAt: accessExp,
+		RefreshToken:    refreshToken,
+	}, nil
+}
+
+// writeTokenResponse отправляет клиенту выданные токены вместе с данными пользователя
+func writeTokenResponse(w http.ResponseWriter, message string, user authUser, tokens *tokenPair) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       message,
+		"user":          user,
+		"token":         tokens.AccessToken,
+		"expires_in":    int(time.Until(tokens.AccessExpiresAt).Seconds()),
+		"refresh_token": tokens.RefreshToken,
+	})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"testing"
+
+	"shared/jwtauth"
+)
+
+func TestAccessTokenCarriesEmailVerification(t *testing.T) {
+	cfg := testJWTConfig()
+	signer := testSigner(t, cfg)
+	verifier := jwtauth.NewVerifier(cfg)
+
+	for _, verified := range []bool{true, false} {
+		token, _, err := signAccessToken(signer, authUser{ID: 3, Email: "ann@example.com", EmailVerified: verified, Role: "user"})
+		if err != nil {
+			t.Fatalf("signAccessToken: %v", err)
+		}
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if claims.EmailVerified != verified {
+			t.Errorf("email_verified claim = %v, want %v", claims.EmailVerified, verified)
+		}
+	}
+}
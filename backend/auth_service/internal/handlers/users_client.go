@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"shared/jwtauth"
+	"shared/passpolicy"
+)
+
+// ServiceName — имя auth_service в сервисных токенах
+const ServiceName = "auth-service"
+
+// serviceTokens подписывает запросы к Users Service; задаётся при старте через UseServiceTokens
+var serviceTokens *jwtauth.ServiceTokenSource
+
+// UseServiceTokens задаёт источник сервисных токенов для запросов к Users Service
+func UseServiceTokens(src *jwtauth.ServiceTokenSource) {
+	serviceTokens = src
+}
+
+// mfaState представляет настройки TOTP пользователя, хранящиеся в Users Service
+type mfaState struct {
+	Secret       string `json:"secret"`
+	Enabled      bool   `json:"enabled"`
+	LastStep     int64  `json:"last_step"`
+	RecoveryLeft int    `json:"recovery_codes_left"`
+}
+
+// callUsersService выполняет запрос к Users Service с JSON-телом
+func callUsersService(method, path string, body interface{}) (*http.Response, error) {
+	userServiceURL := os.Getenv("USERS_SERVICE_URL")
+	if userServiceURL == "" {
+		return nil, fmt.Errorf("USERS_SERVICE_URL not set")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, userServiceURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if serviceTokens != nil {
+		token, err := serviceTokens.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign service token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("users service request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// credentialsUser — пользователь, чьи учётные данные подтвердил Users Service
+type credentialsUser struct {
+	authUser
+	TOTPEnabled bool `json:"totp_enabled"`
+}
+
+// verifyCredentials проверяет email и пароль в Users Service. Возвращает nil
+// без ошибки, если пользователь не найден или пароль неверный.
+func verifyCredentials(email, password string) (*credentialsUser, error) {
+	resp, err := callUsersService(http.MethodPost, "/users/verify-credentials", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to verify credentials: status %d", resp.StatusCode)
+	}
+
+	var user credentialsUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user data: %w", err)
+	}
+	return &user, nil
+}
+
+// fetchUserByID запрашивает данные пользователя из Users Service
+func fetchUserByID(userID int) (*authUser, error) {
+	resp, err := callUsersService(http.MethodGet, fmt.Sprintf("/users/%d", userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch user: status %d", resp.StatusCode)
+	}
+
+	var user authUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user data: %w", err)
+	}
+	return &user, nil
+}
+
+// fetchUserByEmail ищет пользователя по email. Возвращает nil без ошибки, если пользователь не найден.
+func fetchUserByEmail(email string) (*authUser, error) {
+	resp, err := callUsersService(http.MethodGet, "/users/by_email?email="+url.QueryEscape(email), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch user: status %d", resp.StatusCode)
+	}
+
+	var user authUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user data: %w", err)
+	}
+	return &user, nil
+}
+
+// identityRequest описывает внешний аккаунт для Users Service
+type identityRequest struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+}
+
+// fetchUserByIdentity ищет пользователя по привязанному внешнему аккаунту.
+// Возвращает nil без ошибки, если аккаунт не привязан.
+func fetchUserByIdentity(provider, subject string) (*authUser, error) {
+	q := url.Values{"provider": {provider}, "subject": {subject}}
+	resp, err := callUsersService(http.MethodGet, "/users/by_identity?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch user: status %d", resp.StatusCode)
+	}
+
+	var user authUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user data: %w", err)
+	}
+	return &user, nil
+}
+
+// linkIdentity привязывает внешний аккаунт к пользователю
+func linkIdentity(userID int, identity identityRequest) error {
+	resp, err := callUsersService(http.MethodPost, fmt.Sprintf("/users/%d/identities", userID), identity)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return errIdentityLinked
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to link identity: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// createOIDCUser создаёт пользователя без пароля с привязанным внешним аккаунтом
+func createOIDCUser(identity identityRequest) (*authUser, error) {
+	resp, err := callUsersService(http.MethodPost, "/users/oidc", identity)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return nil, errIdentityLinked
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to create user: status %d", resp.StatusCode)
+	}
+
+	var user authUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user data: %w", err)
+	}
+	return &user, nil
+}
+
+// errPasswordChangeDenied — Users Service не принял текущий пароль или токен сброса
+var errPasswordChangeDenied = errors.New("current password or reset token rejected")
+
+// passwordChange — новый пароль и подтверждение: текущий пароль или токен сброса
+type passwordChange struct {
+	NewPassword     string `json:"new_password"`
+	CurrentPassword string `json:"current_password,omitempty"`
+	ResetToken      string `json:"reset_token,omitempty"`
+}
+
+// updateUserPassword устанавливает новый пароль пользователя.
+// Если пароль нарушает политику, возвращается *passpolicy.ValidationError
+func updateUserPassword(userID int, change passwordChange) error {
+	resp, err := callUsersService(http.MethodPatch, fmt.Sprintf("/users/%d/password", userID), change)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return errPasswordChangeDenied
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		if verr := passpolicy.ReadError(resp.Body); verr != nil {
+			return verr
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to update password: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// fetchMFAState запрашивает настройки TOTP пользователя
+func fetchMFAState(userID int) (*mfaState, error) {
+	resp, err := callUsersService(http.MethodGet, fmt.Sprintf("/users/%d/mfa", userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch mfa state: status %d", resp.StatusCode)
+	}
+
+	var state mfaState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode mfa state: %w", err)
+	}
+	return &state, nil
+}
+
+// updateMFAState сохраняет секрет TOTP и признак его включения
+func updateMFAState(userID int, secret string, enabled bool) error {
+	resp, err := callUsersService(http.MethodPut, fmt.Sprintf("/users/%d/mfa", userID), map[string]interface{}{
+		"secret":  secret,
+		"enabled": enabled,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to update mfa state: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// advanceTOTPStep фиксирует использованный шаг TOTP. Возвращает false,
+// если код с этим шагом уже был принят ранее.
+func advanceTOTPStep(userID int, step int64) (bool, error) {
+	resp, err := callUsersService(http.MethodPost, fmt.Sprintf("/users/%d/mfa/step", userID), map[string]int64{"step": step})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to update totp step: status %d", resp.StatusCode)
+}
+
+// storeRecoveryCodes заменяет коды восстановления пользователя
+func storeRecoveryCodes(userID int, codes []string) error {
+	resp, err := callUsersService(http.MethodPut, fmt.Sprintf("/users/%d/recovery-codes", userID), map[string][]string{"codes": codes})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to store recovery codes: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// consumeRecoveryCode погашает код восстановления. Возвращает false, если код не подошёл.
+func consumeRecoveryCode(userID int, code string) (bool, error) {
+	resp, err := callUsersService(http.MethodPost, fmt.Sprintf("/users/%d/recovery-codes/consume", userID), map[string]string{"code": code})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to consume recovery code: status %d", resp.StatusCode)
+}
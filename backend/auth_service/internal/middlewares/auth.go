@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"shared/jwtauth"
+)
+
+type ContextKey string
+
+const (
+	UserIDKey ContextKey = "user_id"
+	EmailKey  ContextKey = "email"
+)
+
+// AuthMiddleware проверяет access-токен и кладёт user_id и email в контекст
+func AuthMiddleware(verifier *jwtauth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.BearerToken(r)
+			if tokenString == "" {
+				http.Error(w, "Authorization token missing", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := verifier.Verify(tokenString)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, EmailKey, claims.Email)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/jwtauth"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// leeway — допустимое расхождение часов с провайдером
+const leeway = time.Minute
+
+// Identity — пользователь, подтверждённый провайдером
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+// verifyIDToken проверяет подпись ID-токена ключами провайдера, iss, aud, exp и nonce.
+// Claims разбираются как MapClaims: aud по спецификации может быть массивом.
+func verifyIDToken(raw string, keys jwtauth.KeyProvider, issuer, clientID, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+		}
+		return key.VerifyKey, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	now := time.Now()
+	if iss, _ := claims["iss"].(string); iss != issuer {
+		return nil, fmt.Errorf("invalid id_token: unexpected issuer %q", iss)
+	}
+	if !hasAudience(claims["aud"], clientID) {
+		return nil, errors.New("invalid id_token: unexpected audience")
+	}
+	if !claims.VerifyExpiresAt(now.Add(-leeway).Unix(), true) {
+		return nil, errors.New("invalid id_token: token is expired")
+	}
+	if got, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	if identity.Subject == "" {
+		return nil, errors.New("invalid id_token: missing sub")
+	}
+	return identity, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if s, _ := a.(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
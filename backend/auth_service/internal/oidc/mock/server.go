@@ -0,0 +1,231 @@
+// Package mock — локальный провайдер OpenID Connect для разработки и проверки
+// входа через внешний аккаунт без настоящего провайдера. Страница входа сразу
+// подтверждает вход пользователя из login_hint или Config.Email.
+package mock
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/jwtauth"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	keyID   = "mock-oidc"
+	codeTTL = time.Minute
+	idTTL   = 5 * time.Minute
+)
+
+// Config описывает единственного клиента и пользователя по умолчанию
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Email        string
+	// EmailVerified выставляется в claim email_verified
+	EmailVerified bool
+}
+
+// grant — выданный код авторизации
+type grant struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	email       string
+	expiresAt   time.Time
+}
+
+// Server реализует discovery, /authorize, /token и /jwks
+type Server struct {
+	cfg  Config
+	key  jwtauth.Key
+	jwks jwtauth.JWKS
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+// New создаёт провайдер с ключом RSA, сгенерированным при запуске
+func New(cfg Config) (*Server, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	key := jwtauth.Key{ID: keyID, Method: jwt.SigningMethodRS256, SignKey: priv, VerifyKey: &priv.PublicKey}
+	return &Server{
+		cfg:   cfg,
+		key:   key,
+		jwks:  jwtauth.PublicJWKS(&jwtauth.Config{Keys: map[string]jwtauth.Key{keyID: key}}),
+		codes: make(map[string]grant),
+	}, nil
+}
+
+// Handler возвращает маршруты провайдера
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.keys)
+	return mux
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.cfg.Issuer,
+		"authorization_endpoint":                s.cfg.Issuer + "/authorize",
+		"token_endpoint":                        s.cfg.Issuer + "/token",
+		"jwks_uri":                              s.cfg.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) keys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.jwks)
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("client_id") != s.cfg.ClientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	back := redirectURI.Query()
+	back.Set("state", q.Get("state"))
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		back.Set("error", "invalid_request")
+		redirectURI.RawQuery = back.Encode()
+		http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+		return
+	}
+
+	email := q.Get("login_hint")
+	if email == "" {
+		email = s.cfg.Email
+	}
+
+	code, err := randomString()
+	if err != nil {
+		http.Error(w, "failed to issue code", http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	s.codes[code] = grant{
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		email:       email,
+		expiresAt:   time.Now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+
+	back.Set("code", code)
+	redirectURI.RawQuery = back.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID != s.cfg.ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.cfg.ClientSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	// Код одноразовый: удаляется при первой же попытке обмена
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	g, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+	if !ok || time.Now().After(g.expiresAt) || g.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.cfg.Issuer,
+		"aud":                s.cfg.ClientID,
+		"sub":                "mock|" + strings.ToLower(g.email),
+		"email":              g.email,
+		"email_verified":     s.cfg.EmailVerified,
+		"preferred_username": strings.SplitN(g.email, "@", 2)[0],
+		"nonce":              g.nonce,
+		"iat":                now.Unix(),
+		"exp":                now.Add(idTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(s.key.Method, claims)
+	token.Header["kid"] = s.key.ID
+	idToken, err := token.SignedString(s.key.SignKey)
+	if err != nil {
+		http.Error(w, "failed to sign id_token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   int(idTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,216 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"auth-service/internal/oidc/mock"
+)
+
+// mockProvider запускает mock-провайдер и возвращает настроенный на него клиент
+func mockProvider(t *testing.T, emailVerified bool) (*Provider, *httptest.Server) {
+	t.Helper()
+	var handler http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	m, err := mock.New(mock.Config{
+		Issuer:        srv.URL,
+		ClientID:      "yandexcloud",
+		ClientSecret:  "client-secret",
+		Email:         "Ann@Example.com",
+		EmailVerified: emailVerified,
+	})
+	if err != nil {
+		t.Fatalf("mock.New: %v", err)
+	}
+	handler = m.Handler()
+
+	return NewProvider(Config{
+		Name:         "mock",
+		Issuer:       srv.URL,
+		ClientID:     "yandexcloud",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/oidc/mock/callback",
+		Scopes:       []string{"openid", "email"},
+	}), srv
+}
+
+// authorize проходит страницу входа провайдера и возвращает параметры редиректа обратно
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query()
+}
+
+func TestNewPKCE(t *testing.T) {
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE: %v", err)
+	}
+	// RFC 7636: 43–128 символов из unreserved
+	if len(verifier) < 43 || len(verifier) > 128 || strings.ContainsAny(verifier, "+/=") {
+		t.Errorf("verifier %q is not a valid code_verifier", verifier)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Error("challenge is not the S256 of the verifier")
+	}
+
+	other, _, _ := NewPKCE()
+	if other == verifier {
+		t.Error("two verifiers are equal")
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	p, _ := mockProvider(t, true)
+	verifier, challenge, _ := NewPKCE()
+
+	authURL, err := p.AuthCodeURL("state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	q, _ := url.Parse(authURL)
+	params := q.Query()
+	if params.Get("code_challenge") != challenge || params.Get("code_challenge_method") != "S256" ||
+		params.Get("scope") != "openid email" || params.Get("redirect_uri") != "http://localhost:8080/oidc/mock/callback" {
+		t.Errorf("authorization request = %v", params)
+	}
+
+	back := authorize(t, authURL)
+	if back.Get("state") != "state-1" || back.Get("code") == "" {
+		t.Fatalf("redirect back = %v", back)
+	}
+
+	identity, err := p.Exchange(back.Get("code"), verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{Subject: "mock|ann@example.com", Email: "Ann@Example.com", EmailVerified: true, PreferredUsername: "Ann"}
+	if *identity != want {
+		t.Errorf("identity = %+v, want %+v", *identity, want)
+	}
+
+	// Код авторизации одноразовый
+	if _, err := p.Exchange(back.Get("code"), verifier, "nonce-1"); err == nil {
+		t.Error("authorization code exchanged twice")
+	}
+}
+
+func TestExchangeRejectsTampering(t *testing.T) {
+	p, _ := mockProvider(t, true)
+
+	start := func() (code, verifier string) {
+		verifier, challenge, _ := NewPKCE()
+		authURL, err := p.AuthCodeURL("state", "nonce", challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return authorize(t, authURL).Get("code"), verifier
+	}
+
+	// Перехваченный код бесполезен без code_verifier
+	code, _ := start()
+	other, _, _ := NewPKCE()
+	if _, err := p.Exchange(code, other, "nonce"); err == nil {
+		t.Error("code exchanged with a wrong code_verifier")
+	}
+
+	// Ответ провайдера для другого входа
+	code, verifier := start()
+	if _, err := p.Exchange(code, verifier, "other-nonce"); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Errorf("nonce mismatch: err = %v", err)
+	}
+
+	code, verifier = start()
+	bad := NewProvider(Config{Name: "mock", Issuer: p.cfg.Issuer, ClientID: "yandexcloud", ClientSecret: "wrong", RedirectURL: p.cfg.RedirectURL})
+	if _, err := bad.Exchange(code, verifier, "nonce"); err == nil {
+		t.Error("exchange with a wrong client secret succeeded")
+	}
+}
+
+func TestAuthorizeRequiresPKCE(t *testing.T) {
+	_, srv := mockProvider(t, true)
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {"yandexcloud"},
+		"redirect_uri":  {"http://localhost:8080/oidc/mock/callback"},
+		"state":         {"s"},
+	}
+	back := authorize(t, srv.URL+"/authorize?"+q.Encode())
+	if back.Get("error") != "invalid_request" || back.Get("code") != "" {
+		t.Errorf("request without code_challenge: %v", back)
+	}
+}
+
+func TestDiscoveryIssuerMustMatch(t *testing.T) {
+	p, srv := mockProvider(t, true)
+	p.cfg.Issuer = srv.URL + "/"
+	if _, err := p.AuthCodeURL("s", "n", "c"); err == nil {
+		t.Error("discovery document of another issuer accepted")
+	}
+}
+
+func TestHasAudience(t *testing.T) {
+	tests := []struct {
+		aud  interface{}
+		want bool
+	}{
+		{"client", true},
+		{"other", false},
+		{[]interface{}{"other", "client"}, true},
+		{[]interface{}{"other"}, false},
+		{nil, false},
+		{42.0, false},
+	}
+	for _, tt := range tests {
+		if got := hasAudience(tt.aud, "client"); got != tt.want {
+			t.Errorf("hasAudience(%v) = %v, want %v", tt.aud, got, tt.want)
+		}
+	}
+}
+
+func TestConfigsFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "google, mock")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "id")
+	t.Setenv("OIDC_GOOGLE_REDIRECT_URL", "http://localhost/cb")
+	t.Setenv("OIDC_MOCK_ISSUER", "http://mock")
+	t.Setenv("OIDC_MOCK_CLIENT_ID", "id")
+	t.Setenv("OIDC_MOCK_REDIRECT_URL", "")
+
+	if _, err := ConfigsFromEnv(); err == nil {
+		t.Error("provider without a redirect URL accepted")
+	}
+
+	t.Setenv("OIDC_MOCK_REDIRECT_URL", "http://localhost/mock")
+	configs, err := ConfigsFromEnv()
+	if err != nil || len(configs) != 2 || configs[1].Name != "mock" || configs[0].Issuer != "https://accounts.google.com" {
+		t.Errorf("configs = %+v, %v", configs, err)
+	}
+
+	t.Setenv("OIDC_PROVIDERS", "")
+	if configs, err := ConfigsFromEnv(); err != nil || len(configs) != 0 {
+		t.Errorf("no providers: %v, %v", configs, err)
+	}
+}
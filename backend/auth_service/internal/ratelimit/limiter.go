@@ -0,0 +1,253 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config задаёт пороги ограничителя
+type Config struct {
+	// IPLimit попыток входа с одного IP за IPWindow
+	IPLimit  int
+	IPWindow time.Duration
+
+	// FailureWindow — окно, в котором считаются неудачные попытки по учётной записи
+	FailureWindow time.Duration
+
+	// После BackoffAfter неудач каждая следующая попытка откладывается на
+	// BackoffBase * 2^(неудачи - BackoffAfter), но не более BackoffMax
+	BackoffAfter int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+
+	// После LockoutThreshold неудач учётная запись блокируется на LockoutDuration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// DefaultConfig возвращает пороги по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		IPLimit:          20,
+		IPWindow:         time.Minute,
+		FailureWindow:    15 * time.Minute,
+		BackoffAfter:     3,
+		BackoffBase:      time.Second,
+		BackoffMax:       time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	}
+}
+
+// retention возвращает, сколько хранилищу нужно помнить события
+func (c Config) retention() time.Duration {
+	r := c.IPWindow
+	if c.FailureWindow > r {
+		r = c.FailureWindow
+	}
+	if c.LockoutDuration > r {
+		r = c.LockoutDuration
+	}
+	return r
+}
+
+// Reason объясняет, почему попытка отклонена
+type Reason string
+
+const (
+	ReasonIP      Reason = "ip_rate_limited"
+	ReasonBackoff Reason = "backoff"
+	ReasonLocked  Reason = "account_locked"
+)
+
+// Decision — результат проверки попытки входа
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Reason     Reason
+}
+
+// Limiter ограничивает попытки входа по IP и по учётной записи
+type Limiter struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+// New создаёт Limiter поверх хранилища
+func New(store Store, cfg Config) *Limiter {
+	return &Limiter{store: store, cfg: cfg, now: time.Now}
+}
+
+// NewFromEnv создаёт Limiter по переменным окружения. RATE_LIMIT_BACKEND
+// выбирает хранилище: memory (по умолчанию) или postgres — для нескольких реплик.
+// Пороги переопределяются переменными LOGIN_IP_LIMIT, LOGIN_IP_WINDOW,
+// LOGIN_FAILURE_WINDOW, LOGIN_BACKOFF_AFTER, LOGIN_BACKOFF_BASE, LOGIN_BACKOFF_MAX,
+// LOGIN_LOCKOUT_THRESHOLD и LOGIN_LOCKOUT_DURATION.
+func NewFromEnv(db *sql.DB) (*Limiter, error) {
+	cfg := DefaultConfig()
+	var err error
+	if cfg.IPLimit, err = intFromEnv("LOGIN_IP_LIMIT", cfg.IPLimit); err != nil {
+		return nil, err
+	}
+	if cfg.IPWindow, err = durationFromEnv("LOGIN_IP_WINDOW", cfg.IPWindow); err != nil {
+		return nil, err
+	}
+	if cfg.FailureWindow, err = durationFromEnv("LOGIN_FAILURE_WINDOW", cfg.FailureWindow); err != nil {
+		return nil, err
+	}
+	if cfg.BackoffAfter, err = intFromEnv("LOGIN_BACKOFF_AFTER", cfg.BackoffAfter); err != nil {
+		return nil, err
+	}
+	if cfg.BackoffBase, err = durationFromEnv("LOGIN_BACKOFF_BASE", cfg.BackoffBase); err != nil {
+		return nil, err
+	}
+	if cfg.BackoffMax, err = durationFromEnv("LOGIN_BACKOFF_MAX", cfg.BackoffMax); err != nil {
+		return nil, err
+	}
+	if cfg.LockoutThreshold, err = intFromEnv("LOGIN_LOCKOUT_THRESHOLD", cfg.LockoutThreshold); err != nil {
+		return nil, err
+	}
+	if cfg.LockoutDuration, err = durationFromEnv("LOGIN_LOCKOUT_DURATION", cfg.LockoutDuration); err != nil {
+		return nil, err
+	}
+
+	var store Store
+	switch backend := os.Getenv("RATE_LIMIT_BACKEND"); backend {
+	case "", "memory":
+		store = NewMemoryStore(cfg.retention())
+	case "postgres":
+		store = NewPostgresStore(db, cfg.retention())
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", backend)
+	}
+	return New(store, cfg), nil
+}
+
+func ipKey(ip string) string           { return "ip:" + ip }
+func failureKey(account string) string { return "fail:" + account }
+func lockKey(account string) string    { return "lock:" + account }
+
+// Allow проверяет, можно ли сейчас обработать попытку входа, и учитывает её
+// в лимите по IP. Допущенная попытка сразу считается неудачей учётной записи,
+// пока Success её не снимет: иначе параллельные попытки проходили бы все до
+// того, как первая из них успеет записать неудачу. Проверка и запись каждого
+// счётчика атомарны (Store.Reserve). Попытка, отклонённая задержкой по учётной
+// записи, остаётся в лимите по IP.
+func (l *Limiter) Allow(ip, account string) (Decision, error) {
+	now := l.now()
+
+	lock, err := l.store.Window(lockKey(account), now.Add(-l.cfg.LockoutDuration))
+	if err != nil {
+		return Decision{}, err
+	}
+	if lock.Count > 0 {
+		return denied(ReasonLocked, lock.Newest.Add(l.cfg.LockoutDuration).Sub(now)), nil
+	}
+
+	attempts, ok, err := l.store.Reserve(ipKey(ip), now, now.Add(-l.cfg.IPWindow), func(w Window) bool {
+		return w.Count < l.cfg.IPLimit
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	if !ok {
+		return denied(ReasonIP, attempts.Oldest.Add(l.cfg.IPWindow).Sub(now)), nil
+	}
+
+	failures, ok, err := l.store.Reserve(failureKey(account), now, now.Add(-l.cfg.FailureWindow), func(w Window) bool {
+		delay := l.backoff(w.Count)
+		return delay == 0 || !now.Before(w.Newest.Add(delay))
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	if !ok {
+		return denied(ReasonBackoff, failures.Newest.Add(l.backoff(failures.Count)).Sub(now)), nil
+	}
+	return Decision{Allowed: true}, nil
+}
+
+// Failure подтверждает неудачу попытки, допущенной Allow: она уже учтена,
+// поэтому здесь только проверяется порог блокировки. Возвращает true, если
+// после неё учётная запись заблокирована.
+func (l *Limiter) Failure(account string) (bool, error) {
+	now := l.now()
+
+	failures, err := l.store.Window(failureKey(account), now.Add(-l.cfg.FailureWindow))
+	if err != nil {
+		return false, err
+	}
+	if failures.Count < l.cfg.LockoutThreshold {
+		return false, nil
+	}
+
+	if err := l.store.Add(lockKey(account), now); err != nil {
+		return false, err
+	}
+	// После разблокировки отсчёт неудач начинается заново
+	if err := l.store.Reset(failureKey(account)); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Success сбрасывает счётчик неудач, в том числе попытку, учтённую Allow,
+// после успешного входа
+func (l *Limiter) Success(account string) error {
+	return l.store.Reset(failureKey(account))
+}
+
+// LockoutDuration возвращает длительность блокировки учётной записи
+func (l *Limiter) LockoutDuration() time.Duration {
+	return l.cfg.LockoutDuration
+}
+
+// backoff возвращает задержку перед следующей попыткой после failures неудач
+func (l *Limiter) backoff(failures int) time.Duration {
+	if failures < l.cfg.BackoffAfter {
+		return 0
+	}
+	delay := l.cfg.BackoffBase
+	for i := l.cfg.BackoffAfter; i < failures; i++ {
+		delay *= 2
+		if delay >= l.cfg.BackoffMax {
+			return l.cfg.BackoffMax
+		}
+	}
+	return delay
+}
+
+func denied(reason Reason, retryAfter time.Duration) Decision {
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return Decision{Reason: reason, RetryAfter: retryAfter}
+}
+
+func intFromEnv(name string, fallback int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return n, nil
+}
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return d, nil
+}
@@ -0,0 +1,238 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock — часы, которые тест двигает сам
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func testConfig() Config {
+	return Config{
+		IPLimit:          3,
+		IPWindow:         time.Minute,
+		FailureWindow:    15 * time.Minute,
+		BackoffAfter:     2,
+		BackoffBase:      time.Second,
+		BackoffMax:       8 * time.Second,
+		LockoutThreshold: 5,
+		LockoutDuration:  15 * time.Minute,
+	}
+}
+
+func newTestLimiter(cfg Config) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(NewMemoryStore(cfg.retention()), cfg)
+	l.now = c.Now
+	return l, c
+}
+
+func mustAllow(t *testing.T, l *Limiter, ip, account string) Decision {
+	t.Helper()
+	d, err := l.Allow(ip, account)
+	if err != nil {
+		t.Fatalf("Allow(%s, %s): %v", ip, account, err)
+	}
+	return d
+}
+
+// failLogin — попытка входа с неверным паролем: Allow и затем Failure
+func failLogin(t *testing.T, l *Limiter, ip, account string) bool {
+	t.Helper()
+	if d := mustAllow(t, l, ip, account); !d.Allowed {
+		t.Fatalf("attempt denied before failing: %+v", d)
+	}
+	locked, err := l.Failure(account)
+	if err != nil {
+		t.Fatalf("Failure(%s): %v", account, err)
+	}
+	return locked
+}
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	l, _ := newTestLimiter(testConfig())
+	want := map[int]time.Duration{
+		0: 0, 1: 0,
+		2: time.Second, 3: 2 * time.Second, 4: 4 * time.Second,
+		5: 8 * time.Second, 6: 8 * time.Second, 100: 8 * time.Second,
+	}
+	for failures, delay := range want {
+		if got := l.backoff(failures); got != delay {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, delay)
+		}
+	}
+}
+
+func TestIPLimit(t *testing.T) {
+	l, c := newTestLimiter(testConfig())
+	for i := 0; i < 3; i++ {
+		// Разные учётные записи: срабатывает только лимит по IP
+		if d := mustAllow(t, l, "10.0.0.1", fmt.Sprintf("user%d", i)); !d.Allowed {
+			t.Fatalf("attempt %d denied: %+v", i, d)
+		}
+	}
+
+	d := mustAllow(t, l, "10.0.0.1", "user9")
+	if d.Allowed || d.Reason != ReasonIP || d.RetryAfter != time.Minute {
+		t.Errorf("fourth attempt = %+v, want ip limit for a minute", d)
+	}
+	if d := mustAllow(t, l, "10.0.0.2", "user9"); !d.Allowed {
+		t.Errorf("other IP denied: %+v", d)
+	}
+
+	c.Advance(time.Minute + time.Second)
+	if d := mustAllow(t, l, "10.0.0.1", "user9"); !d.Allowed {
+		t.Errorf("attempt after the window denied: %+v", d)
+	}
+}
+
+func TestBackoffAfterFailures(t *testing.T) {
+	l, c := newTestLimiter(testConfig())
+	failLogin(t, l, "10.0.0.1", "alice")
+	failLogin(t, l, "10.0.0.2", "alice")
+
+	d := mustAllow(t, l, "10.0.0.3", "alice")
+	if d.Allowed || d.Reason != ReasonBackoff || d.RetryAfter != time.Second {
+		t.Fatalf("third attempt = %+v, want a one second backoff", d)
+	}
+
+	// Короче секунды Retry-After не бывает
+	c.Advance(500 * time.Millisecond)
+	if d := mustAllow(t, l, "10.0.0.3", "alice"); d.Allowed || d.RetryAfter != time.Second {
+		t.Errorf("attempt during backoff = %+v", d)
+	}
+
+	// Отклонённые задержкой попытки расходуют лимит IP 10.0.0.3
+	c.Advance(500 * time.Millisecond)
+	failLogin(t, l, "10.0.0.4", "alice")
+	if d := mustAllow(t, l, "10.0.0.4", "alice"); d.Allowed || d.RetryAfter != 2*time.Second {
+		t.Errorf("attempt after three failures = %+v, want a two second backoff", d)
+	}
+}
+
+func TestSuccessClearsFailures(t *testing.T) {
+	l, _ := newTestLimiter(testConfig())
+	failLogin(t, l, "10.0.0.1", "alice")
+	if d := mustAllow(t, l, "10.0.0.1", "alice"); !d.Allowed {
+		t.Fatalf("second attempt denied: %+v", d)
+	}
+	if err := l.Success("alice"); err != nil {
+		t.Fatalf("Success: %v", err)
+	}
+
+	w, _ := l.store.Window(failureKey("alice"), time.Time{})
+	if w.Count != 0 {
+		t.Errorf("failures after Success = %d, want 0", w.Count)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	cfg := testConfig()
+	cfg.BackoffAfter = 100 // задержки не мешают дойти до порога
+	l, c := newTestLimiter(cfg)
+
+	for i := 1; i < cfg.LockoutThreshold; i++ {
+		if failLogin(t, l, "10.0.0.1", "alice") {
+			t.Fatalf("locked after %d failures", i)
+		}
+		c.Advance(30 * time.Second) // лимит по IP не мешает
+	}
+	if !failLogin(t, l, "10.0.0.1", "alice") {
+		t.Fatalf("not locked after %d failures", cfg.LockoutThreshold)
+	}
+
+	d := mustAllow(t, l, "10.0.0.9", "alice")
+	if d.Allowed || d.Reason != ReasonLocked || d.RetryAfter != cfg.LockoutDuration {
+		t.Errorf("attempt while locked = %+v", d)
+	}
+	c.Advance(10 * time.Minute)
+	if d := mustAllow(t, l, "10.0.0.9", "alice"); d.Reason != ReasonLocked || d.RetryAfter != 5*time.Minute {
+		t.Errorf("attempt later while locked = %+v", d)
+	}
+
+	// После блокировки счётчик неудач начинается заново
+	c.Advance(5*time.Minute + time.Second)
+	if d := mustAllow(t, l, "10.0.0.9", "alice"); !d.Allowed {
+		t.Errorf("attempt after lockout = %+v", d)
+	}
+}
+
+// Параллельные попытки с разных IP не должны проскочить мимо задержки,
+// пока ни одна из них не успела записать неудачу
+func TestParallelAttemptsAreReserved(t *testing.T) {
+	cfg := testConfig()
+	l, _ := newTestLimiter(cfg)
+
+	const attempts = 50
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			d, err := l.Allow(fmt.Sprintf("10.0.%d.%d", i/250, i%250), "alice")
+			if err != nil {
+				t.Errorf("Allow: %v", err)
+				return
+			}
+			if d.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if allowed != cfg.BackoffAfter {
+		t.Errorf("%d of %d parallel attempts allowed, want %d", allowed, attempts, cfg.BackoffAfter)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore(time.Minute)
+	for i := 0; i < 3; i++ {
+		if err := s.Add("k", start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	if w, _ := s.Window("k", start.Add(time.Second)); w.Count != 2 || !w.Oldest.Equal(start.Add(time.Second)) || !w.Newest.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Window() = %+v", w)
+	}
+	if w, _ := s.Window("other", start); w != (Window{}) {
+		t.Errorf("Window() of an unknown key = %+v", w)
+	}
+
+	w, ok, err := s.Reserve("k", start.Add(3*time.Second), start, func(w Window) bool { return w.Count < 3 })
+	if err != nil || ok || w.Count != 3 {
+		t.Errorf("Reserve() over the limit = %+v, %v, %v", w, ok, err)
+	}
+	if _, ok, _ := s.Reserve("k", start.Add(3*time.Second), start, func(w Window) bool { return w.Count < 4 }); !ok {
+		t.Error("Reserve() under the limit was refused")
+	}
+	if w, _ := s.Window("k", start); w.Count != 4 {
+		t.Errorf("Reserve() did not record the event: %+v", w)
+	}
+
+	// Событие после retention вытесняет устаревшие
+	s.Add("k", start.Add(2*time.Minute))
+	if w, _ := s.Window("k", start); w.Count != 1 {
+		t.Errorf("Window() after retention = %+v, want 1 event", w)
+	}
+
+	s.Reset("k")
+	if w, _ := s.Window("k", start); w.Count != 0 {
+		t.Errorf("Window() after Reset = %+v", w)
+	}
+}
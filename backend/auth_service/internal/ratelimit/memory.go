@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит события в памяти процесса. Подходит для одной реплики
+// и локальной разработки.
+type MemoryStore struct {
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	retention time.Duration
+	adds      int
+}
+
+// sweepEvery — через сколько добавлений удалять ключи, по которым давно не было событий
+const sweepEvery = 1000
+
+// NewMemoryStore создаёт MemoryStore; события старше retention удаляются
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		events:    make(map[string][]time.Time),
+		retention: retention,
+	}
+}
+
+// Add реализует Store
+func (s *MemoryStore) Add(key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(key, at)
+	return nil
+}
+
+// add регистрирует событие; вызывается под s.mu
+func (s *MemoryStore) add(key string, at time.Time) {
+	cutoff := at.Add(-s.retention)
+	s.events[key] = append(prune(s.events[key], cutoff), at)
+
+	s.adds++
+	if s.adds%sweepEvery == 0 {
+		for k, events := range s.events {
+			if events = prune(events, cutoff); len(events) == 0 {
+				delete(s.events, k)
+			} else {
+				s.events[k] = events
+			}
+		}
+	}
+}
+
+// Window реализует Store
+func (s *MemoryStore) Window(key string, since time.Time) (Window, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.window(key, since), nil
+}
+
+// window возвращает окно событий; вызывается под s.mu
+func (s *MemoryStore) window(key string, since time.Time) Window {
+	events := prune(s.events[key], since)
+	if len(events) == 0 {
+		return Window{}
+	}
+	return Window{Count: len(events), Oldest: events[0], Newest: events[len(events)-1]}
+}
+
+// Reserve реализует Store
+func (s *MemoryStore) Reserve(key string, at, since time.Time, allow func(Window) bool) (Window, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.window(key, since)
+	if !allow(w) {
+		return w, false, nil
+	}
+	s.add(key, at)
+	return w, true, nil
+}
+
+// Reset реализует Store
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.events, key)
+	return nil
+}
+
+// prune возвращает события не раньше since; срез упорядочен по времени
+func prune(events []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(events) && events[i].Before(since) {
+		i++
+	}
+	return events[i:]
+}
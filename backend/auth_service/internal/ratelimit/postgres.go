@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// PostgresStore хранит события в таблице rate_limit_events, общей для всех
+// реплик auth_service. Таблица создаётся миграциями пакета database.
+type PostgresStore struct {
+	db        *sql.DB
+	retention time.Duration
+	adds      atomic.Int64
+}
+
+// NewPostgresStore создаёт PostgresStore; события старше retention удаляются
+func NewPostgresStore(db *sql.DB, retention time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, retention: retention}
+}
+
+// Add реализует Store
+func (s *PostgresStore) Add(key string, at time.Time) error {
+	if _, err := s.db.Exec(`
+		INSERT INTO rate_limit_events (key, occurred_at) VALUES ($1, $2)
+	`, key, at); err != nil {
+		return fmt.Errorf("failed to record rate limit event: %w", err)
+	}
+
+	// Старые события чистятся время от времени, а не на каждой вставке
+	if s.adds.Add(1)%sweepEvery == 0 {
+		if _, err := s.db.Exec(`
+			DELETE FROM rate_limit_events WHERE occurred_at < $1
+		`, at.Add(-s.retention)); err != nil {
+			return fmt.Errorf("failed to prune rate limit events: %w", err)
+		}
+	}
+	return nil
+}
+
+// Window реализует Store
+func (s *PostgresStore) Window(key string, since time.Time) (Window, error) {
+	return window(s.db, key, since)
+}
+
+// Reserve реализует Store. Реплики упорядочиваются транзакционной
+// advisory-блокировкой по ключу: строк для SELECT ... FOR UPDATE у ещё не
+// случившихся событий нет.
+func (s *PostgresStore) Reserve(key string, at, since time.Time, allow func(Window) bool) (Window, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Window{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+		return Window{}, false, fmt.Errorf("failed to lock rate limit key: %w", err)
+	}
+	w, err := window(tx, key, since)
+	if err != nil {
+		return Window{}, false, err
+	}
+	if !allow(w) {
+		return w, false, nil
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO rate_limit_events (key, occurred_at) VALUES ($1, $2)
+	`, key, at); err != nil {
+		return Window{}, false, fmt.Errorf("failed to record rate limit event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Window{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return w, true, nil
+}
+
+// querier — *sql.DB или *sql.Tx
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func window(q querier, key string, since time.Time) (Window, error) {
+	var (
+		w      Window
+		oldest sql.NullTime
+		newest sql.NullTime
+	)
+	err := q.QueryRow(`
+		SELECT COUNT(*), MIN(occurred_at), MAX(occurred_at)
+		FROM rate_limit_events
+		WHERE key = $1 AND occurred_at >= $2
+	`, key, since).Scan(&w.Count, &oldest, &newest)
+	if err != nil {
+		return Window{}, fmt.Errorf("failed to query rate limit events: %w", err)
+	}
+	w.Oldest = oldest.Time
+	w.Newest = newest.Time
+	return w, nil
+}
+
+// Reset реализует Store
+func (s *PostgresStore) Reset(key string) error {
+	if _, err := s.db.Exec("DELETE FROM rate_limit_events WHERE key = $1", key); err != nil {
+		return fmt.Errorf("failed to reset rate limit events: %w", err)
+	}
+	return nil
+}
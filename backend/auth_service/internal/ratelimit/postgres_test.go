@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/database"
+)
+
+// openTestDB подключается к базе из TEST_POSTGRES_DSN; без неё тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return db
+}
+
+func TestPostgresStoreReserveIsAtomic(t *testing.T) {
+	db := openTestDB(t)
+	key := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	t.Cleanup(func() { db.Exec("DELETE FROM rate_limit_events WHERE key = $1", key) })
+
+	// Две реплики с отдельными хранилищами поверх одной базы
+	stores := []*PostgresStore{NewPostgresStore(db, time.Hour), NewPostgresStore(db, time.Hour)}
+	since := time.Now().Add(-time.Hour)
+
+	const limit, attempts = 3, 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(s *PostgresStore) {
+			defer wg.Done()
+			_, ok, err := s.Reserve(key, time.Now(), since, func(w Window) bool { return w.Count < limit })
+			if err != nil {
+				t.Errorf("Reserve: %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}(stores[i%len(stores)])
+	}
+	wg.Wait()
+
+	if reserved != limit {
+		t.Errorf("%d of %d parallel reservations succeeded, want %d", reserved, attempts, limit)
+	}
+	if w, err := stores[0].Window(key, since); err != nil || w.Count != limit {
+		t.Errorf("Window() = %+v, %v; want %d events", w, err, limit)
+	}
+}
@@ -0,0 +1,27 @@
+// Package ratelimit ограничивает частоту попыток входа по IP и по учётной записи.
+package ratelimit
+
+import "time"
+
+// Window описывает события по ключу внутри скользящего окна
+type Window struct {
+	Count  int
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Store хранит отметки времени событий по ключам. Реализации должны быть
+// безопасны для конкурентного использования; Postgres-реализация позволяет
+// нескольким репликам auth_service видеть общие счётчики.
+type Store interface {
+	// Add регистрирует событие по ключу
+	Add(key string, at time.Time) error
+	// Window возвращает события по ключу, произошедшие после since
+	Window(key string, since time.Time) (Window, error)
+	// Reserve атомарно относительно других вызовов с тем же ключом берёт
+	// окно событий после since и, если allow его принимает, регистрирует
+	// событие at. Возвращает окно до регистрации и решение allow.
+	Reserve(key string, at, since time.Time, allow func(Window) bool) (Window, bool, error)
+	// Reset удаляет все события по ключу
+	Reset(key string) error
+}
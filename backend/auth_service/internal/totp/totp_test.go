@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret — секрет "12345678901234567890" из приложения B RFC 6238 в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	// Восьмизначные значения RFC, усечённые до Digits младших цифр
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", unix, err)
+		}
+		if got != want {
+			t.Errorf("Code at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateAcceptsOneStepOfSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+
+	for delta := int64(-2); delta <= 2; delta++ {
+		code, _ := Code(rfcSecret, step+delta)
+		got, ok := Validate(rfcSecret, code, now)
+		wantOK := delta >= -Skew && delta <= Skew
+		if ok != wantOK {
+			t.Errorf("code of step %+d: ok = %v, want %v", delta, ok, wantOK)
+			continue
+		}
+		// Шаг возвращается для защиты от повторного предъявления кода
+		if ok && got != step+delta {
+			t.Errorf("code of step %+d: step = %d, want %d", delta, got, step+delta)
+		}
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Code(rfcSecret, Step(now))
+
+	if _, ok := Validate(rfcSecret, " "+code+"\n", now); !ok {
+		t.Error("code with surrounding whitespace was rejected")
+	}
+	if _, ok := Validate(strings.ToLower(rfcSecret), code, now); !ok {
+		t.Error("lowercase secret was rejected")
+	}
+
+	for _, bad := range []string{"", "000000", code[:Digits-1], code + "0", "abcdef"} {
+		if _, ok := Validate(rfcSecret, bad, now); ok {
+			t.Errorf("code %q was accepted", bad)
+		}
+	}
+	if _, ok := Validate("not base32!", code, now); ok {
+		t.Error("code was accepted for an invalid secret")
+	}
+}
+
+func TestGenerateSecretRoundTrip(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Fatal("two generated secrets are equal")
+	}
+
+	now := time.Now()
+	code, err := Code(a, Step(now))
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	if _, ok := Validate(a, code, now); !ok {
+		t.Error("code for a generated secret was rejected")
+	}
+}
+
+func TestURI(t *testing.T) {
+	raw := URI("Yandex Cloud", "ann@example.com", rfcSecret)
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Errorf("URI = %q, want otpauth://totp/...", raw)
+	}
+	if u.Path != "/Yandex Cloud:ann@example.com" {
+		t.Errorf("label = %q", u.Path)
+	}
+	q := u.Query()
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "Yandex Cloud" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("query = %v", q)
+	}
+}
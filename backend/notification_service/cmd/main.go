@@ -5,7 +5,12 @@ import (
 	"net/http"
 	"notifications_service/internal/database"
 	"notifications_service/internal/handlers"
+	"notifications_service/internal/middlewares"
 	"os"
+	"shared/apikeys"
+	"shared/jwtauth"
+	"shared/tokenversion"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -23,6 +28,16 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
 	// Подключение к базе данных
 	db, err := database.Connect()
@@ -31,15 +46,69 @@ func main() {
 	}
 	defer db.Close()
 
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	verifier, err := jwtauth.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load JWT configuration: %v", err)
+	}
+	// Токены, выданные до смены пароля, отклоняются
+	versions, err := tokenversion.NewFromEnv(db)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	verifier.AddCheck(versions.Check)
+
+	// TRUSTED_SERVICES — сервисы, которым разрешено отправлять уведомления безопасности
+	trustedServices := splitList(os.Getenv("TRUSTED_SERVICES"))
+	if len(trustedServices) == 0 {
+		trustedServices = []string{"auth-service"}
+	}
+
+	// POSTS_SERVICES — сервисы, которым разрешено управлять уведомлениями о
+	// постах без токена пользователя (упоминания, удалённые комментарии)
+	postsServices := splitList(os.Getenv("POSTS_SERVICES"))
+	if len(postsServices) == 0 {
+		postsServices = []string{"posts-service"}
+	}
+
+	// USERS_SERVICES — сервисы, которым разрешено сообщать о новых подписчиках
+	usersServices := splitList(os.Getenv("USERS_SERVICES"))
+	if len(usersServices) == 0 {
+		usersServices = []string{"users-service"}
+	}
+
 	// Создаем маршрутизатор
 	r := mux.NewRouter()
 
+	// Служебные маршруты: уведомления безопасности от auth_service, подписки
+	// от users_service, а также упоминания и удалённые комментарии, о которых
+	// сообщает posts_service
+	requirePostsService := middlewares.ServiceMiddleware(verifier, postsServices)
+	r.Handle("/notifications/security", middlewares.ServiceMiddleware(verifier, trustedServices)(handlers.CreateSecurityNotification(db))).Methods("POST")
+	r.Handle("/notifications/follows", middlewares.ServiceMiddleware(verifier, usersServices)(handlers.CreateFollowNotification(db))).Methods("POST")
+	r.Handle("/notifications/mentions", requirePostsService(handlers.CreateMentionNotification(db))).Methods("POST")
+	r.Handle("/notifications/comments", requirePostsService(handlers.DeleteCommentNotifications(db))).Methods("DELETE")
+
+	// Остальные маршруты требуют токен пользователя: posts_service передаёт токен того, кто совершил действие
+	api := r.NewRoute().Subrouter()
+	api.Use(middlewares.AuthMiddleware(verifier, apikeys.NewAuthenticator(db)))
+
+	// Области доступа, которые нужны API-ключам на каждом маршруте
+	read := apikeys.RequireScope(apikeys.NotificationsRead)
+	write := apikeys.RequireScope(apikeys.NotificationsWrite)
+	// Создание и удаление уведомлений — побочный эффект лайков в posts_service,
+	// поэтому для них достаточно области posts:write
+	postsWrite := apikeys.RequireScope(apikeys.PostsWrite)
+
 	// Маршруты для уведомлений
-	r.HandleFunc("/notifications", handlers.CreateNotification(db)).Methods("POST")
-	r.HandleFunc("/notifications", handlers.FetchNotifications(db)).Methods("GET")
-	r.HandleFunc("/notifications", handlers.DeleteNotification(db)).Methods("DELETE")
-	r.HandleFunc("/notifications/read", handlers.MarkNotificationAsRead(db)).Methods("PATCH")
-	r.HandleFunc("/notifications/{userId}/clear", handlers.ClearNotifications(db)).Methods("DELETE")
+	api.Handle("/notifications", postsWrite(handlers.CreateNotification(db))).Methods("POST")
+	api.Handle("/notifications", read(handlers.FetchNotifications(db))).Methods("GET")
+	api.Handle("/notifications", postsWrite(handlers.DeleteNotification(db))).Methods("DELETE")
+	api.Handle("/notifications/read", write(handlers.MarkNotificationAsRead(db))).Methods("PATCH")
+	api.Handle("/notifications/{userId}/clear", write(handlers.ClearNotifications(db))).Methods("DELETE")
 
 	// Применяем CORS middleware
 	corsHandler := enableCORS(r)
@@ -3,7 +3,11 @@ module notifications_service
 go 1.23.2
 
 require (
-	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gorilla/mux v1.8.1
 	github.com/lib/pq v1.10.9
+	shared v0.0.0
 )
+
+require github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
+
+replace shared => ../shared
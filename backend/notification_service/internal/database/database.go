@@ -6,8 +6,9 @@ import (
 	"log"
 	"notifications_service/internal/models"
 	"os"
+	"shared/pagination"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var db *sql.DB
@@ -31,8 +32,34 @@ func Connect() (*sql.DB, error) {
 	return db, nil
 }
 
-// GetNotifications извлекает уведомления для указанного пользователя из базы данных
-func GetNotifications(db *sql.DB, userId string) ([]models.Notification, error) {
+// migrations дополняет таблицы уведомлений. Выражения идемпотентны и
+// выполняются при каждом старте сервиса.
+var migrations = []string{
+	// actor_id — пользователь, совершивший действие, для уведомлений без
+	// отдельной таблицы вроде notification_like (например, о подписке)
+	`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS actor_id INT REFERENCES users (id) ON DELETE CASCADE`,
+	`CREATE INDEX IF NOT EXISTS notifications_user_created_idx ON notifications (user_id, created_at DESC, id DESC)`,
+	// Пост и комментарий для уведомлений о комментариях и упоминаниях (у них
+	// только пост). Таблица комментариев
+	// принадлежит posts_service, поэтому comment_id без внешнего ключа.
+	`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS post_id INT REFERENCES posts (id) ON DELETE CASCADE`,
+	`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS comment_id INT`,
+}
+
+// Migrate применяет недостающие изменения схемы
+func Migrate(db *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply migration: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetNotifications извлекает страницу уведомлений пользователя, новые сверху.
+// Выбирается page.Limit+1 записей, чтобы понять, есть ли следующая страница.
+func GetNotifications(db *sql.DB, userId string, page pagination.Request) ([]models.Notification, error) {
+	after, afterID := page.Args()
 	rows, err := db.Query(`
 		SELECT 
 			n.id, 
@@ -41,15 +68,18 @@ func GetNotifications(db *sql.DB, userId string) ([]models.Notification, error)
 			n.is_read, 
 			n.created_at, 
 			n.type,
-			COALESCE(nl.liker_id, 0) AS liker_id, 
-			COALESCE(nl.post_id, 0) AS post_id,
+			COALESCE(nl.liker_id, n.actor_id) AS liker_id, 
+			COALESCE(nl.post_id, n.post_id) AS post_id,
+			n.comment_id,
 			COALESCE(u.username, '') AS liker_username
 		FROM notifications n
 		LEFT JOIN notification_like nl ON n.id = nl.notification_id
-		LEFT JOIN users u ON nl.liker_id = u.id
-		WHERE n.user_id = $1 
-		ORDER BY n.created_at DESC
-	`, userId)
+		LEFT JOIN users u ON COALESCE(nl.liker_id, n.actor_id) = u.id
+		WHERE n.user_id = $1
+		  AND ($2::timestamptz IS NULL OR (n.created_at, n.id) < ($2, $3))
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $4
+	`, userId, after, afterID, page.Limit+1)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +88,8 @@ func GetNotifications(db *sql.DB, userId string) ([]models.Notification, error)
 	var notifications []models.Notification
 	for rows.Next() {
 		var notification models.Notification
-		var likerID, postID sql.NullInt64 // Лайки могут быть NULL для других типов уведомлений
-		var likerUsername sql.NullString  // Имя пользователя может быть NULL
+		var likerID, postID, commentID sql.NullInt64 // Лайки могут быть NULL для других типов уведомлений
+		var likerUsername sql.NullString             // Имя пользователя может быть NULL
 
 		if err := rows.Scan(
 			&notification.ID,
@@ -70,6 +100,7 @@ func GetNotifications(db *sql.DB, userId string) ([]models.Notification, error)
 			&notification.Type,
 			&likerID,
 			&postID,
+			&commentID,
 			&likerUsername,
 		); err != nil {
 			return nil, err
@@ -84,6 +115,10 @@ func GetNotifications(db *sql.DB, userId string) ([]models.Notification, error)
 			post := int(postID.Int64)
 			notification.PostID = &post
 		}
+		if commentID.Valid {
+			comment := int(commentID.Int64)
+			notification.CommentID = &comment
+		}
 		if likerUsername.Valid {
 			notification.LikerUsername = likerUsername.String
 		}
@@ -99,8 +134,35 @@ func MarkAsRead(db *sql.DB, id string) error {
 	return err
 }
 
-// DeleteNotification удаляет уведомление пользователя
-func DeleteNotification(db *sql.DB, userID int, likerID int, postID int, notificationType string) error {
+// GetNotificationOwner возвращает ID получателя уведомления или 0, если уведомление не найдено
+func GetNotificationOwner(db *sql.DB, id string) (int, error) {
+	var userID int
+	err := db.QueryRow("SELECT user_id FROM notifications WHERE id = $1", id).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// DeleteNotification удаляет уведомление пользователя. Уведомления о лайках
+// ищутся по посту, о комментариях — по комментарию, остальные — по
+// пользователю, совершившему действие.
+func DeleteNotification(db *sql.DB, userID int, likerID int, postID int, commentID int, notificationType string) error {
+	if notificationType == "comment" {
+		_, err := db.Exec(`
+			DELETE FROM notifications
+			WHERE user_id = $1 AND actor_id = $2 AND comment_id = $3 AND type = $4
+		`, userID, likerID, commentID, notificationType)
+		return err
+	}
+	if notificationType != "like" {
+		_, err := db.Exec(`
+			DELETE FROM notifications
+			WHERE user_id = $1 AND actor_id = $2 AND type = $3
+		`, userID, likerID, notificationType)
+		return err
+	}
+
 	_, err := db.Exec(`
 		DELETE FROM notifications n
 		USING notification_like nl
@@ -113,16 +175,57 @@ func DeleteNotification(db *sql.DB, userID int, likerID int, postID int, notific
 	return err
 }
 
+// DeleteCommentNotifications удаляет уведомления об удалённых комментариях
+func DeleteCommentNotifications(db *sql.DB, commentIDs []int) error {
+	ids := make([]int64, len(commentIDs))
+	for i, id := range commentIDs {
+		ids[i] = int64(id)
+	}
+	_, err := db.Exec("DELETE FROM notifications WHERE type = 'comment' AND comment_id = ANY($1)", pq.Array(ids))
+	return err
+}
+
 // ClearNotifications очищает все уведомления пользователя
 func ClearNotifications(db *sql.DB, userId string) error {
 	_, err := db.Exec("DELETE FROM notifications WHERE user_id = $1", userId)
 	return err
 }
 
+// GetUsername возвращает имя пользователя или пустую строку, если его нет
+func GetUsername(db *sql.DB, userID int) (string, error) {
+	var username string
+	err := db.QueryRow("SELECT username FROM users WHERE id = $1", userID).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return username, err
+}
+
 // AddNotification добавляет новое уведомление в базу данных с учетом новых полей
-func AddNotification(db *sql.DB, notification models.Notification, likerID int, postID int) error {
-	// Проверяем, не является ли лайкер автором поста
-	if notification.Type == "like" {
+func AddNotification(db *sql.DB, notification models.Notification, likerID int, postID int, commentID int) error {
+	// Упоминает пользователей в посте только его автор. Проверка подтверждает
+	// лишь авторство: что получатель действительно упомянут в тексте,
+	// гарантирует posts_service — единственный, кто присылает упоминания.
+	if notification.Type == "mention" {
+		var postAuthorID int
+		err := db.QueryRow("SELECT author_id FROM posts WHERE id = $1", postID).Scan(&postAuthorID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("post not found")
+			}
+			return fmt.Errorf("failed to retrieve post author: %w", err)
+		}
+
+		if likerID != postAuthorID {
+			return fmt.Errorf("notification not added: only the post author can mention users")
+		}
+		if likerID == notification.UserID {
+			return fmt.Errorf("notification not added: author cannot send notification to themselves")
+		}
+	}
+
+	// Проверяем, не является ли лайкер или комментатор автором поста
+	if notification.Type == "like" || notification.Type == "comment" {
 		var postAuthorID int
 		err := db.QueryRow("SELECT author_id FROM posts WHERE id = $1", postID).Scan(&postAuthorID)
 		if err != nil {
@@ -136,13 +239,29 @@ func AddNotification(db *sql.DB, notification models.Notification, likerID int,
 			return fmt.Errorf("notification not added: author cannot send notification to themselves")
 		}
 	}
+	if notification.Type == "follow" && likerID == notification.UserID {
+		return fmt.Errorf("notification not added: author cannot send notification to themselves")
+	}
+
+	// Для уведомлений о лайках автор и пост хранятся в notification_like
+	var actorID, commentPostID, commentRef interface{}
+	if likerID > 0 && notification.Type != "like" {
+		actorID = likerID
+	}
+	if notification.Type == "comment" {
+		commentPostID, commentRef = postID, commentID
+	}
+	if notification.Type == "mention" {
+		commentPostID = postID
+	}
 
 	// Вставляем запись в таблицу notifications
 	var notificationID int
 	err := db.QueryRow(`
-        INSERT INTO notifications (user_id, message, is_read, created_at, type)
-        VALUES ($1, $2, $3, $4, $5) RETURNING id
-    `, notification.UserID, notification.Message, notification.IsRead, notification.CreatedAt, notification.Type).Scan(&notificationID)
+        INSERT INTO notifications (user_id, message, is_read, created_at, type, actor_id, post_id, comment_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id
+    `, notification.UserID, notification.Message, notification.IsRead, notification.CreatedAt, notification.Type,
+		actorID, commentPostID, commentRef).Scan(&notificationID)
 	if err != nil {
 		return fmt.Errorf("failed to insert notification: %w", err)
 	}
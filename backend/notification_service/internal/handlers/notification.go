@@ -6,9 +6,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"log"
+	"fmt"
 	"net/http"
 	"notifications_service/internal/database"
 	"notifications_service/internal/models"
+	"shared/authz"
+	"shared/pagination"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -16,11 +20,35 @@ import (
 
 // CreateNotificationRequest представляет структуру входящих данных для создания уведомления
 type CreateNotificationRequest struct {
-	UserID  int    `json:"userId"`  // ID пользователя, которому адресовано уведомление
-	LikerID int    `json:"likerId"` // ID пользователя, который поставил лайк
-	PostID  int    `json:"postId"`  // ID поста, к которому относится уведомление
-	Type    string `json:"type"`    // Тип уведомления (например, "like", "comment")
-	Message string `json:"message"` // Текст уведомления
+	UserID    int    `json:"userId"`    // ID пользователя, которому адресовано уведомление
+	LikerID   int    `json:"likerId"`   // ID пользователя, совершившего действие (поставил лайк, подписался)
+	PostID    int    `json:"postId"`    // ID поста, к которому относится уведомление
+	CommentID int    `json:"commentId"` // ID комментария для уведомлений типа "comment"
+	Type      string `json:"type"`      // Тип уведомления (например, "like", "follow", "comment", "mention")
+}
+
+// userNotificationTypes — типы уведомлений, которые создаются от имени
+// пользователя. Подписки и упоминания сюда не входят: их присылают
+// users_service и posts_service с сервисным токеном (CreateFollowNotification,
+// CreateMentionNotification), уведомления безопасности — доверенные сервисы.
+var userNotificationTypes = map[string]bool{
+	"like":    true,
+	"comment": true,
+}
+
+// serviceNotificationTypes — типы, которые создаются только сервисами
+var serviceNotificationTypes = map[string]bool{
+	"follow":  true,
+	"mention": true,
+}
+
+// notificationMessages — шаблоны текста уведомлений. Текст формирует сервер
+// по имени того, кто совершил действие, а не передаёт клиент.
+var notificationMessages = map[string]string{
+	"like":    "%s liked your post",
+	"follow":  "%s started following you",
+	"comment": "%s commented on your post",
+	"mention": "%s mentioned you in a post",
 }
 
 // CreateNotification обрабатывает запросы на создание нового уведомления
@@ -33,39 +61,138 @@ func CreateNotification(db *sql.DB) http.HandlerFunc {
 		}
 		defer r.Body.Close()
 
+		if serviceNotificationTypes[req.Type] {
+			http.Error(w, "Notification type is reserved for services", http.StatusForbidden)
+			return
+		}
+
 		// Валидация входных данных
-		if req.UserID <= 0 || req.Type == "" {
+		if req.UserID <= 0 || !userNotificationTypes[req.Type] ||
+			(req.Type == "comment" && (req.PostID <= 0 || req.CommentID <= 0)) ||
+			(req.Type == "mention" && req.PostID <= 0) {
 			http.Error(w, "Invalid notification data", http.StatusBadRequest)
 			return
 		}
 
-		notification := models.Notification{
-			UserID:    req.UserID,
-			Message:   req.Message,
-			IsRead:    false,
-			Type:      req.Type,
-			CreatedAt: time.Now(),
+		// Уведомление создаётся от имени того, кто совершил действие
+		if !authz.IsSelfOr(r.Context(), req.LikerID, authz.NotificationsManageAny) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
 		}
 
-		// Добавляем уведомление в базу данных
-		if err := database.AddNotification(db, notification, req.LikerID, req.PostID); err != nil {
-			log.Printf("Failed to add notification: %v", err)
+		addNotification(w, db, req)
+	}
+}
+
+// addNotification формирует текст, сохраняет проверенное уведомление и отвечает 201
+func addNotification(w http.ResponseWriter, db *sql.DB, req CreateNotificationRequest) {
+	actor, err := database.GetUsername(db, req.LikerID)
+	if err != nil {
+		log.Printf("Failed to fetch notification actor: %v", err)
+		http.Error(w, "Failed to add notification", http.StatusInternalServerError)
+		return
+	}
+	if actor == "" {
+		http.Error(w, "Invalid notification data", http.StatusBadRequest)
+		return
+	}
+
+	notification := models.Notification{
+		UserID:    req.UserID,
+		Message:   fmt.Sprintf(notificationMessages[req.Type], actor),
+		IsRead:    false,
+		Type:      req.Type,
+		CreatedAt: time.Now(),
+	}
 
-			if err.Error() == "notification not added: author cannot send notification to themselves" {
-				http.Error(w, "Notification not added: Author cannot send notification to themselves", http.StatusBadRequest)
-				return
-			}
+	// Добавляем уведомление в базу данных
+	if err := database.AddNotification(db, notification, req.LikerID, req.PostID, req.CommentID); err != nil {
+		log.Printf("Failed to add notification: %v", err)
 
-			http.Error(w, "Failed to add notification", http.StatusInternalServerError)
+		if err.Error() == "notification not added: author cannot send notification to themselves" {
+			http.Error(w, "Notification not added: Author cannot send notification to themselves", http.StatusBadRequest)
+			return
+		}
+		if err.Error() == "notification not added: only the post author can mention users" {
+			http.Error(w, "Notification not added: Only the post author can mention users", http.StatusForbidden)
 			return
 		}
 
-		w.WriteHeader(http.StatusCreated)
-		log.Println("Notification successfully created")
+		http.Error(w, "Failed to add notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	log.Println("Notification successfully created")
+}
+
+// maxRetractedComments — сколько уведомлений о комментариях снимается одним запросом
+const maxRetractedComments = 1000
+
+// DeleteCommentNotifications удаляет уведомления об удалённых комментариях.
+// Комментарий может удалить автор поста или модератор, поэтому маршрут
+// доступен только доверенным сервисам, а не пользователям.
+func DeleteCommentNotifications(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			CommentIDs []int `json:"commentIds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		if len(req.CommentIDs) == 0 || len(req.CommentIDs) > maxRetractedComments {
+			http.Error(w, "Invalid or missing notification data", http.StatusBadRequest)
+			return
+		}
+
+		if err := database.DeleteCommentNotifications(db, req.CommentIDs); err != nil {
+			log.Printf("Failed to delete comment notifications: %v", err)
+			http.Error(w, "Failed to delete notification", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		log.Printf("Notifications for %d deleted comments removed", len(req.CommentIDs))
+	}
+}
+
+// CreateMentionNotification создаёт уведомление об упоминании. Упоминания
+// присылает только posts_service: он сам находит упомянутых пользователей в
+// тексте поста, а пользователь мог бы указать любого получателя.
+func CreateMentionNotification(db *sql.DB) http.HandlerFunc {
+	return createServiceNotification(db, "mention")
+}
+
+// CreateFollowNotification создаёт уведомление о новом подписчике. Его
+// присылает только users_service после того, как подписка создана.
+func CreateFollowNotification(db *sql.DB) http.HandlerFunc {
+	return createServiceNotification(db, "follow")
+}
+
+// createServiceNotification принимает от сервиса уведомление типа notificationType
+func createServiceNotification(db *sql.DB, notificationType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateNotificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		if req.UserID <= 0 || req.LikerID <= 0 || req.Type != notificationType ||
+			(req.Type == "mention" && req.PostID <= 0) {
+			http.Error(w, "Invalid notification data", http.StatusBadRequest)
+			return
+		}
+
+		addNotification(w, db, req)
 	}
 }
 
-// FetchNotifications обрабатывает запросы на получение уведомлений пользователя
+// FetchNotifications возвращает страницу уведомлений пользователя
 func FetchNotifications(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.URL.Query().Get("userId")
@@ -74,8 +201,18 @@ func FetchNotifications(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		if !canAccess(r, userID) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+
 		// Извлекаем уведомления из базы данных
-		notifications, err := database.GetNotifications(db, userID)
+		notifications, err := database.GetNotifications(db, userID, page)
 		if err != nil {
 			log.Printf("Failed to fetch notifications: %v", err)
 			http.Error(w, "Failed to fetch notifications", http.StatusInternalServerError)
@@ -85,7 +222,9 @@ func FetchNotifications(db *sql.DB) http.HandlerFunc {
 		// Отправляем список уведомлений как JSON
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(notifications)
+		json.NewEncoder(w).Encode(pagination.NewPage(notifications, page.Limit, func(n models.Notification) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: n.CreatedAt, ID: n.ID}
+		}))
 	}
 }
 
@@ -98,6 +237,21 @@ func MarkNotificationAsRead(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		ownerID, err := database.GetNotificationOwner(db, id)
+		if err != nil {
+			log.Printf("Failed to fetch notification owner: %v", err)
+			http.Error(w, "Failed to mark notification as read", http.StatusInternalServerError)
+			return
+		}
+		if ownerID == 0 {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		if !authz.IsSelfOr(r.Context(), ownerID, authz.NotificationsManageAny) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
 		// Отметим уведомление как прочитанное
 		if err := database.MarkAsRead(db, id); err != nil {
 			log.Printf("Failed to mark notification as read: %v", err)
@@ -120,6 +274,11 @@ func ClearNotifications(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		if !canAccess(r, userID) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
 		// Очистим все уведомления для пользователя
 		if err := database.ClearNotifications(db, userID); err != nil {
 			log.Printf("Failed to clear notifications: %v", err)
@@ -136,10 +295,11 @@ func ClearNotifications(db *sql.DB) http.HandlerFunc {
 func DeleteNotification(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var deleteRequest struct {
-			UserID  int    `json:"userId"`
-			LikerID int    `json:"likerId"`
-			PostID  int    `json:"postId"`
-			Type    string `json:"type"`
+			UserID    int    `json:"userId"`
+			LikerID   int    `json:"likerId"`
+			PostID    int    `json:"postId"`
+			CommentID int    `json:"commentId"`
+			Type      string `json:"type"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&deleteRequest); err != nil {
@@ -148,13 +308,24 @@ func DeleteNotification(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Валидация входных данных
-		if deleteRequest.UserID <= 0 || deleteRequest.LikerID <= 0 || deleteRequest.PostID <= 0 || deleteRequest.Type == "" {
+		// Пост нужен только для уведомлений о лайках
+		if deleteRequest.UserID <= 0 || deleteRequest.LikerID <= 0 || deleteRequest.Type == "" ||
+			(deleteRequest.Type == "like" && deleteRequest.PostID <= 0) ||
+			(deleteRequest.Type == "comment" && deleteRequest.CommentID <= 0) {
 			http.Error(w, "Invalid or missing notification data", http.StatusBadRequest)
 			return
 		}
 
+		// Уведомление о комментарии может снять и получатель: комментарии
+		// к своему посту удаляет его автор
+		recipient := deleteRequest.Type == "comment" && authz.IsSelfOr(r.Context(), deleteRequest.UserID, authz.NotificationsManageAny)
+		if !recipient && !authz.IsSelfOr(r.Context(), deleteRequest.LikerID, authz.NotificationsManageAny) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
 		// Удаляем конкретное уведомление с учетом параметров
-		if err := database.DeleteNotification(db, deleteRequest.UserID, deleteRequest.LikerID, deleteRequest.PostID, deleteRequest.Type); err != nil {
+		if err := database.DeleteNotification(db, deleteRequest.UserID, deleteRequest.LikerID, deleteRequest.PostID, deleteRequest.CommentID, deleteRequest.Type); err != nil {
 			log.Printf("Failed to delete notification: %v", err)
 			http.Error(w, "Failed to delete notification", http.StatusInternalServerError)
 			return
@@ -164,3 +335,58 @@ func DeleteNotification(db *sql.DB) http.HandlerFunc {
 		log.Println("Notifications deleted")
 	}
 }
+
+// canAccess проверяет, что уведомления пользователя userID запрашивает он сам или администратор
+func canAccess(r *http.Request, userID string) bool {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return false
+	}
+	return authz.IsSelfOr(r.Context(), id, authz.NotificationsManageAny)
+}
+
+// securityNotificationTypes — типы уведомлений, которые могут отправлять сервисы
+var securityNotificationTypes = map[string]bool{
+	"password_changed": true,
+}
+
+// CreateSecurityNotificationRequest — уведомление безопасности от другого сервиса
+type CreateSecurityNotificationRequest struct {
+	UserID  int    `json:"userId"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// CreateSecurityNotification создаёт уведомление безопасности, например о смене
+// пароля. Доступен только доверенным сервисам.
+func CreateSecurityNotification(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateSecurityNotificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		if req.UserID <= 0 || !securityNotificationTypes[req.Type] || req.Message == "" {
+			http.Error(w, "Invalid notification data", http.StatusBadRequest)
+			return
+		}
+
+		notification := models.Notification{
+			UserID:    req.UserID,
+			Message:   req.Message,
+			IsRead:    false,
+			Type:      req.Type,
+			CreatedAt: time.Now(),
+		}
+		if err := database.AddNotification(db, notification, 0, 0, 0); err != nil {
+			log.Printf("Failed to add security notification: %v", err)
+			http.Error(w, "Failed to add notification", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		log.Printf("Security notification %q created for user %d", req.Type, req.UserID)
+	}
+}
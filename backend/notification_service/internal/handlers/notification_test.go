@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"notifications_service/internal/database"
+	"shared/authz"
+
+	_ "github.com/lib/pq"
+)
+
+// post отправляет JSON-запрос в обработчик от имени пользователя userID;
+// userID 0 означает запрос без пользователя (от сервиса)
+func post(h http.HandlerFunc, userID int, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	r := httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewReader(data))
+	if userID != 0 {
+		r = r.WithContext(authz.WithSubject(r.Context(), authz.Subject{UserID: userID, Role: authz.RoleUser}))
+	}
+	rec := httptest.NewRecorder()
+	h(rec, r)
+	return rec
+}
+
+func TestNotificationMessagesCoverAllTypes(t *testing.T) {
+	for _, types := range []map[string]bool{userNotificationTypes, serviceNotificationTypes} {
+		for typ := range types {
+			tmpl, ok := notificationMessages[typ]
+			if !ok {
+				t.Errorf("no message template for %q", typ)
+				continue
+			}
+			if strings.Count(tmpl, "%s") != 1 {
+				t.Errorf("template for %q = %q, want exactly one %%s for the actor", typ, tmpl)
+			}
+		}
+	}
+}
+
+func TestCreateNotificationRejectsServiceTypes(t *testing.T) {
+	// Подписки и упоминания пользователь не может создать даже от своего имени
+	for _, typ := range []string{"follow", "mention"} {
+		rec := post(CreateNotification(nil), 3, CreateNotificationRequest{UserID: 4, LikerID: 3, PostID: 1, Type: typ})
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("type %q: status %d, want 403", typ, rec.Code)
+		}
+	}
+}
+
+func TestCreateNotificationOnBehalfOfAnotherUser(t *testing.T) {
+	rec := post(CreateNotification(nil), 3, CreateNotificationRequest{UserID: 4, LikerID: 5, PostID: 1, Type: "like"})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status %d, want 403", rec.Code)
+	}
+}
+
+func TestServiceNotificationAcceptsOnlyItsType(t *testing.T) {
+	rec := post(CreateFollowNotification(nil), 0, CreateNotificationRequest{UserID: 4, LikerID: 3, PostID: 1, Type: "mention"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("mention on the follow route: status %d, want 400", rec.Code)
+	}
+	rec = post(CreateMentionNotification(nil), 0, CreateNotificationRequest{UserID: 4, LikerID: 3, Type: "mention"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("mention without a post: status %d, want 400", rec.Code)
+	}
+}
+
+// TestFollowNotificationMessageBuiltByServer нужна база с таблицами users и
+// notifications; без TEST_POSTGRES_DSN тест пропускается
+func TestFollowNotificationMessageBuiltByServer(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	newUser := func(prefix string) (int, string) {
+		name := fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+		var id int
+		if err := db.QueryRow(`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x') RETURNING id`,
+			name, name+"@example.com").Scan(&id); err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+		t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", id) })
+		return id, name
+	}
+	followee, _ := newUser("followee")
+	follower, followerName := newUser("follower")
+
+	// Лишнее поле message в запросе игнорируется
+	body := map[string]interface{}{"userId": followee, "likerId": follower, "type": "follow", "message": "you won a prize"}
+	if rec := post(CreateFollowNotification(db), 0, body); rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var message string
+	if err := db.QueryRow("SELECT message FROM notifications WHERE user_id = $1 AND actor_id = $2", followee, follower).Scan(&message); err != nil {
+		t.Fatalf("select notification: %v", err)
+	}
+	if want := followerName + " started following you"; message != want {
+		t.Errorf("message = %q, want %q", message, want)
+	}
+
+	// Несуществующий автор действия не даёт создать уведомление
+	body["likerId"] = follower + 1000000
+	if rec := post(CreateFollowNotification(db), 0, body); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown actor: status %d, want 400", rec.Code)
+	}
+}
+
+func TestFetchNotificationsChecksPageBeforeDatabase(t *testing.T) {
+	for _, query := range []string{"limit=-1", "cursor=%21%21"} {
+		r := httptest.NewRequest(http.MethodGet, "/notifications?userId=3&"+query, nil)
+		r = r.WithContext(authz.WithSubject(r.Context(), authz.Subject{UserID: 3, Role: authz.RoleUser}))
+		rec := httptest.NewRecorder()
+		FetchNotifications(nil)(rec, r)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
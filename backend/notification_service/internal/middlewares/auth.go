@@ -5,45 +5,98 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"shared/apikeys"
+	"shared/authz"
+	"shared/jwtauth"
 )
 
 type ContextKey string
 
-const UserIDKey ContextKey = "user_id"
+const (
+	UserIDKey ContextKey = "user_id"
+	// ServiceKey — имя сервиса, вызвавшего маршрут с сервисным токеном
+	ServiceKey ContextKey = "service"
+)
+
+// AuthMiddleware проверяет access-токен или API-ключ и кладёт user_id и роль в контекст.
+// Области доступа ключа проверяет apikeys.RequireScope на маршрутах.
+func AuthMiddleware(verifier *jwtauth.Verifier, keys *apikeys.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.BearerToken(r)
+			if tokenString == "" {
+				http.Error(w, "Authorization token missing", http.StatusUnauthorized)
+				return
+			}
+
+			if apikeys.IsKey(tokenString) {
+				principal, err := keys.Authenticate(tokenString)
+				if err != nil {
+					log.Printf("AuthMiddleware: %v", err)
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+				role, err := authz.ParseRole(principal.Role)
+				if err != nil {
+					log.Printf("AuthMiddleware: %v", err)
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+
+				ctx := authz.WithSubject(r.Context(), authz.Subject{UserID: principal.UserID, Role: role})
+				ctx = apikeys.WithPrincipal(ctx, principal)
+				ctx = context.WithValue(ctx, UserIDKey, principal.UserID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			claims, err := verifier.Verify(tokenString)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			subject, err := authz.SubjectFromClaims(claims)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := authz.WithSubject(r.Context(), subject)
+			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")
-		if tokenString == "" {
-			http.Error(w, "Authorization token missing", http.StatusUnauthorized)
-			return
-		}
+// ServiceMiddleware пропускает только сервисные токены сервисов из allowed.
+// Через такие маршруты auth_service отправляет уведомления безопасности.
+func ServiceMiddleware(verifier *jwtauth.Verifier, allowed []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.BearerToken(r)
+			if tokenString == "" {
+				http.Error(w, "Authorization token missing", http.StatusUnauthorized)
+				return
+			}
 
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
-		}
+			service, err := verifier.VerifyService(tokenString)
+			if err != nil {
+				log.Printf("ServiceMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
 
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Секрет лучше вынести в переменные окружения
-			return []byte("akunamotata"), nil
+			for _, name := range allowed {
+				if name == service {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ServiceKey, service)))
+					return
+				}
+			}
+			log.Printf("ServiceMiddleware: service %q is not allowed to call %s %s", service, r.Method, r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
 		})
-		if err != nil || !token.Valid {
-			log.Println("AuthMiddleware: Invalid token")
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
-
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			log.Println("AuthMiddleware: user_id not found in claims")
-			http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
-			return
-		}
-		userID := int(userIDFloat)
-
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	}
 }
@@ -12,5 +12,6 @@ type Notification struct {
 	CreatedAt     time.Time `json:"createdAt"`
 	LikerID       *int      `json:"likerId,omitempty"`
 	LikerUsername string    `json:"likerUsername,omitempty"`
+	CommentID     *int      `json:"commentId,omitempty"`
 	Type          string    `json:"type"`
 }
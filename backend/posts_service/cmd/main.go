@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 
 	"posts_service/internal/database"
+	"posts_service/internal/feed"
 	"posts_service/internal/handlers"
 	"posts_service/internal/middlewares"
+	"posts_service/internal/scheduler"
+	"shared/apikeys"
+	"shared/jwtauth"
+	"shared/tokenversion"
 
 	"github.com/gorilla/mux"
 )
@@ -33,22 +40,98 @@ func main() {
 	}
 	defer db.Close()
 
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	feedStrategy, err := feed.NewFromEnv(db)
+	if err != nil {
+		log.Fatalf("Failed to configure feed: %v", err)
+	}
+	log.Printf("Feed strategy: %s", feedStrategy.Name())
+
+	// Запросы не от имени пользователя (поиск упомянутых пользователей,
+	// уведомления об упоминаниях в отложенных постах, снятие уведомлений об
+	// удалённых комментариях) подписываются сервисным токеном, который выдаёт
+	// auth_service. Для этого нужны AUTH_SERVICE_URL и SERVICE_CLIENT_SECRET,
+	// а в SERVICE_CLIENTS у auth_service — запись posts-service с тем же
+	// секретом. Без них сервис работает, но эти запросы не выполняются.
+	serviceTokens, err := jwtauth.ServiceTokenClientFromEnv(handlers.ServiceName)
+	switch {
+	case errors.Is(err, jwtauth.ErrServiceClientNotConfigured):
+		log.Printf("Warning: AUTH_SERVICE_URL and SERVICE_CLIENT_SECRET are not set; mentions are not resolved and comment notifications are not retracted")
+	case err != nil:
+		log.Fatalf("Invalid configuration: %v", err)
+	default:
+		handlers.UseServiceTokens(serviceTokens)
+	}
+
+	// Отложенные посты публикуются в фоне; реплики не мешают друг другу
+	publisher, err := scheduler.NewFromEnv(db, feedStrategy, handlers.NotifyPendingMentions(db))
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	go publisher.Run(context.Background())
+	log.Printf("Publish scheduler interval: %s", publisher.Interval())
+
+	verifier, err := jwtauth.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load JWT configuration: %v", err)
+	}
+	// Токены, выданные до смены пароля, отклоняются
+	versions, err := tokenversion.NewFromEnv(db)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	verifier.AddCheck(versions.Check)
+
+	unverifiedPolicy, err := middlewares.UnverifiedPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	r := mux.NewRouter()
 
-	r.Use(middlewares.AuthMiddleware)
+	r.Use(middlewares.AuthMiddleware(verifier, apikeys.NewAuthenticator(db)))
+	r.Use(middlewares.RequireVerifiedEmail(unverifiedPolicy))
+
+	// Области доступа, которые нужны API-ключам на каждом маршруте
+	read := apikeys.RequireScope(apikeys.PostsRead)
+	write := apikeys.RequireScope(apikeys.PostsWrite)
 
 	// Маршруты для постов
-	r.HandleFunc("/posts", handlers.CreatePost(db)).Methods("POST")
-	r.HandleFunc("/posts", handlers.FetchPosts(db)).Methods("GET")
-	r.HandleFunc("/posts/{id}", handlers.FetchPostById(db)).Methods("GET")
-	r.HandleFunc("/posts/{id}", handlers.DeletePost(db)).Methods("DELETE")
+	r.Handle("/posts", write(handlers.CreatePost(db, feedStrategy))).Methods("POST")
+	r.Handle("/posts", read(handlers.FetchPosts(db))).Methods("GET")
+	// Регистрируется до /posts/{id}, иначе "search" разбирался бы как ID
+	r.Handle("/posts/search", read(handlers.SearchPosts(db))).Methods("GET")
+	r.Handle("/posts/{id}", read(handlers.FetchPostById(db))).Methods("GET")
+	r.Handle("/posts/{id}", write(handlers.UpdatePost(db, feedStrategy))).Methods("PATCH")
+	r.Handle("/posts/{id}", write(handlers.DeletePost(db))).Methods("DELETE")
+	r.Handle("/posts/{id}/revisions", read(handlers.ListRevisions(db))).Methods("GET")
+	r.Handle("/posts/{id}/revisions/diff", read(handlers.DiffRevisions(db))).Methods("GET")
+
+	// Комментарии и ответы на них
+	r.Handle("/posts/{id}/comments", write(handlers.CreateComment(db))).Methods("POST")
+	r.Handle("/posts/{id}/comments", read(handlers.FetchComments(db))).Methods("GET")
+	r.Handle("/posts/{id}/comments/{commentId}", write(handlers.UpdateComment(db))).Methods("PATCH")
+	r.Handle("/posts/{id}/comments/{commentId}", write(handlers.DeleteComment(db))).Methods("DELETE")
+
+	// Хэштеги: посты с тегом и популярные теги
+	r.Handle("/tags/trending", read(handlers.TrendingTags(db))).Methods("GET")
+	r.Handle("/tags/{tag}/posts", read(handlers.FetchTagPosts(db))).Methods("GET")
 
 	// Маршруты для лайков
-	r.HandleFunc("/likes", handlers.ToggleLike(db)).Methods("POST", "DELETE")
-	r.HandleFunc("/likes", handlers.GetLikesForPost(db)).Methods("GET")
+	r.Handle("/likes", write(handlers.ToggleLike(db))).Methods("POST", "DELETE")
+	r.Handle("/likes", read(handlers.GetLikesForPost(db))).Methods("GET")
+
+	// Персональная лента и подписки на авторов
+	r.Handle("/feed", read(handlers.FetchFeed(feedStrategy))).Methods("GET")
+	r.Handle("/subscriptions/{authorId}", read(handlers.GetSubscription(db))).Methods("GET")
+	r.Handle("/subscriptions/{authorId}", write(handlers.Subscribe(db, feedStrategy))).Methods("POST")
+	r.Handle("/subscriptions/{authorId}", write(handlers.Unsubscribe(db, feedStrategy))).Methods("DELETE")
 
 	// Маршрут для получения постов конкретного пользователя
-	r.HandleFunc("/profile/{username}/posts", handlers.FetchUserPosts(db)).Methods("GET")
+	r.Handle("/profile/{username}/posts", read(handlers.FetchUserPosts(db))).Methods("GET")
 
 	corsHandler := enableCORS(r)
 
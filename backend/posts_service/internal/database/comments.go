@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/pagination"
+)
+
+// ErrCommentNotFound возвращается, если комментарий удалён, не существовал или
+// относится к другому посту
+var ErrCommentNotFound = errors.New("comment not found")
+
+// Comment — комментарий к посту. У ответа ParentID указывает на комментарий
+// верхнего уровня: ответы вкладываются только на один уровень.
+type Comment struct {
+	ID             int        `json:"id"`
+	PostID         int        `json:"postId"`
+	ParentID       *int       `json:"parentId,omitempty"`
+	AuthorID       int        `json:"authorId"`
+	AuthorUsername string     `json:"authorUsername"`
+	Content        string     `json:"content"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+	Edited         bool       `json:"edited"`
+	RepliesCount   int        `json:"repliesCount"`
+}
+
+// commentColumns — поля комментария в порядке, который читает scanComment
+const commentColumns = `
+            comments.id, comments.post_id, comments.parent_id, comments.author_id, users.username,
+            comments.content, comments.created_at, comments.updated_at,
+            (SELECT count(*) FROM comments AS replies WHERE replies.parent_id = comments.id)`
+
+func scanComment(row rowScanner, c *Comment) error {
+	var parentID sql.NullInt64
+	var updatedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.PostID, &parentID, &c.AuthorID, &c.AuthorUsername,
+		&c.Content, &c.CreatedAt, &updatedAt, &c.RepliesCount); err != nil {
+		return err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+	if updatedAt.Valid {
+		c.UpdatedAt = &updatedAt.Time
+		c.Edited = true
+	}
+	return nil
+}
+
+// CreateComment добавляет комментарий к посту. Ответ на ответ прикрепляется к
+// тому же комментарию верхнего уровня. Если пост не найден или ещё не
+// опубликован, возвращается ErrPostNotFound; если не найден родительский
+// комментарий — ErrCommentNotFound.
+func CreateComment(db *sql.DB, postID int, parentID *int, authorID int, content string) (*Comment, error) {
+	var parent interface{}
+	if parentID != nil {
+		var rootID sql.NullInt64
+		var parentPostID int
+		err := db.QueryRow("SELECT post_id, parent_id FROM comments WHERE id = $1", *parentID).Scan(&parentPostID, &rootID)
+		if err == sql.ErrNoRows || (err == nil && parentPostID != postID) {
+			return nil, ErrCommentNotFound
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to fetch parent comment: %w", err)
+		}
+		parent = *parentID
+		if rootID.Valid {
+			parent = rootID.Int64
+		}
+	}
+
+	var id int
+	err := db.QueryRow(`
+        INSERT INTO comments (post_id, parent_id, author_id, content)
+        SELECT posts.id, $2, $3, $4 FROM posts WHERE posts.id = $1 AND posts.status = 'published'
+        RETURNING id
+    `, postID, parent, authorID, content).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrPostNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to insert comment: %w", err)
+	}
+
+	comment, err := FetchComment(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// FetchComment возвращает комментарий по ID или nil, если его нет
+func FetchComment(db *sql.DB, id int) (*Comment, error) {
+	var c Comment
+	err := scanComment(db.QueryRow(`
+        SELECT `+commentColumns+`
+        FROM comments
+        JOIN users ON users.id = comments.author_id
+        WHERE comments.id = $1
+    `, id), &c)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch comment: %w", err)
+	}
+	return &c, nil
+}
+
+// FetchComments возвращает страницу комментариев верхнего уровня, новые сверху.
+// Выбирается page.Limit+1 записей.
+func FetchComments(db *sql.DB, postID int, page pagination.Request) ([]Comment, error) {
+	after, afterID := page.Args()
+	return queryComments(db, `
+        SELECT `+commentColumns+`
+        FROM comments
+        JOIN users ON users.id = comments.author_id
+        WHERE comments.post_id = $1 AND comments.parent_id IS NULL
+          AND ($2::timestamptz IS NULL OR (comments.created_at, comments.id) < ($2, $3))
+        ORDER BY comments.created_at DESC, comments.id DESC
+        LIMIT $4
+    `, postID, after, afterID, page.Limit+1)
+}
+
+// FetchReplies возвращает страницу ответов на комментарий в порядке написания,
+// чтобы разговор читался сверху вниз. Выбирается page.Limit+1 записей.
+func FetchReplies(db *sql.DB, parentID int, page pagination.Request) ([]Comment, error) {
+	after, afterID := page.Args()
+	return queryComments(db, `
+        SELECT `+commentColumns+`
+        FROM comments
+        JOIN users ON users.id = comments.author_id
+        WHERE comments.parent_id = $1
+          AND ($2::timestamptz IS NULL OR (comments.created_at, comments.id) > ($2, $3))
+        ORDER BY comments.created_at, comments.id
+        LIMIT $4
+    `, parentID, after, afterID, page.Limit+1)
+}
+
+func queryComments(db *sql.DB, query string, args ...interface{}) ([]Comment, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		var c Comment
+		if err := scanComment(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan comment row: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return comments, nil
+}
+
+// UpdateComment заменяет текст комментария, если с его создания прошло не
+// больше window. Возвращает false, если окно редактирования закрылось.
+func UpdateComment(db *sql.DB, id int, content string, window time.Duration) (bool, error) {
+	res, err := db.Exec(`
+        UPDATE comments SET content = $2, updated_at = now()
+        WHERE id = $1 AND created_at > now() - $3::float8 * interval '1 second'
+    `, id, content, window.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to update comment: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update comment: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeletedComment — комментарий, удалённый вместе с веткой ответов
+type DeletedComment struct {
+	ID       int
+	AuthorID int
+}
+
+// DeleteComment удаляет комментарий вместе с ответами на него и возвращает
+// все удалённые комментарии
+func DeleteComment(db *sql.DB, id int) ([]DeletedComment, error) {
+	rows, err := db.Query(`
+        DELETE FROM comments
+        WHERE id = $1 OR parent_id = $1
+        RETURNING id, author_id
+    `, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted []DeletedComment
+	for rows.Next() {
+		var d DeletedComment
+		if err := rows.Scan(&d.ID, &d.AuthorID); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted comment: %w", err)
+		}
+		deleted = append(deleted, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"shared/pagination"
+)
+
+func TestCommentThreads(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	reader, _ := newUser(t, db, "reader")
+	post := newPost(t, db, author, "post")
+	other := newPost(t, db, author, "other post")
+
+	root, err := CreateComment(db, post.ID, nil, reader, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	reply, err := CreateComment(db, post.ID, &root.ID, author, "reply")
+	if err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	nested, err := CreateComment(db, post.ID, &reply.ID, reader, "reply to reply")
+	if err != nil {
+		t.Fatalf("nested reply: %v", err)
+	}
+	if nested.ParentID == nil || *nested.ParentID != root.ID {
+		t.Errorf("nested reply parent = %v, want %d", nested.ParentID, root.ID)
+	}
+
+	// Родитель из другого поста считается несуществующим
+	if _, err := CreateComment(db, other.ID, &root.ID, reader, "x"); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("parent from another post: err = %v, want ErrCommentNotFound", err)
+	}
+
+	top, err := FetchComments(db, post.ID, pagination.Request{Limit: 10})
+	if err != nil {
+		t.Fatalf("FetchComments: %v", err)
+	}
+	if len(top) != 1 || top[0].ID != root.ID || top[0].RepliesCount != 2 {
+		t.Errorf("top-level comments = %+v, want only %d with 2 replies", top, root.ID)
+	}
+	replies, err := FetchReplies(db, root.ID, pagination.Request{Limit: 10})
+	if err != nil {
+		t.Fatalf("FetchReplies: %v", err)
+	}
+	if len(replies) != 2 || replies[0].ID != reply.ID || replies[1].ID != nested.ID {
+		t.Errorf("replies = %+v, want %d then %d", replies, reply.ID, nested.ID)
+	}
+
+	deleted, err := DeleteComment(db, root.ID)
+	if err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if len(deleted) != 3 {
+		t.Errorf("deleted %+v, want the comment and both replies", deleted)
+	}
+	if c, _ := FetchComment(db, nested.ID); c != nil {
+		t.Error("reply survived deletion of its thread")
+	}
+}
+
+func TestCreateCommentRequiresPublishedPost(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	draft, err := CreatePost(db, "draft", "draft", author, StatusDraft, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+
+	if _, err := CreateComment(db, draft.ID, nil, author, "early"); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("comment on a draft: err = %v, want ErrPostNotFound", err)
+	}
+	if _, err := CreateComment(db, draft.ID+1000000, nil, author, "lost"); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("comment on a missing post: err = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestUpdateCommentWindow(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	post := newPost(t, db, author, "post")
+	c, err := CreateComment(db, post.ID, nil, author, "typo")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if ok, err := UpdateComment(db, c.ID, "fixed", time.Minute); err != nil || !ok {
+		t.Fatalf("UpdateComment within the window = %v, %v", ok, err)
+	}
+	if got, _ := FetchComment(db, c.ID); got.Content != "fixed" || !got.Edited {
+		t.Errorf("after edit: content %q, edited %v", got.Content, got.Edited)
+	}
+
+	db.Exec("UPDATE comments SET created_at = now() - interval '2 minutes' WHERE id = $1", c.ID)
+	if ok, err := UpdateComment(db, c.ID, "late", time.Minute); err != nil || ok {
+		t.Errorf("UpdateComment after the window = %v, %v; want false", ok, err)
+	}
+}
@@ -2,11 +2,13 @@ package database
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
-	_ "github.com/lib/pq"
+	"shared/pagination"
+
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,70 +29,287 @@ func Connect() (*sql.DB, error) {
 	return db, nil
 }
 
+// migrations создаёт таблицы, которыми владеет posts_service. Выражения
+// идемпотентны и выполняются при каждом старте сервиса.
+var migrations = []string{
+	`CREATE INDEX IF NOT EXISTS posts_author_created_idx ON posts (author_id, created_at DESC, id DESC)`,
+	// Подписки на авторов: их посты попадают в ленту подписчика
+	`CREATE TABLE IF NOT EXISTS subscriptions (
+		subscriber_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		author_id     INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (subscriber_id, author_id),
+		CHECK (subscriber_id <> author_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS subscriptions_author_idx ON subscriptions (author_id)`,
+	// Материализованные ленты для стратегии fan-out-on-write
+	`CREATE TABLE IF NOT EXISTS timelines (
+		user_id    INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		post_id    INT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		author_id  INT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (user_id, post_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS timelines_user_created_idx ON timelines (user_id, created_at DESC, post_id DESC)`,
+	`CREATE INDEX IF NOT EXISTS posts_created_idx ON posts (created_at DESC, id DESC)`,
+	// Время лайка нужно для постраничного списка лайкнувших; старым лайкам достаётся время миграции
+	`ALTER TABLE likes ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
+	`CREATE INDEX IF NOT EXISTS likes_post_created_idx ON likes (post_id, created_at DESC, user_id DESC)`,
+	// Полнотекстовый поиск: заголовок и текст индексируются в двух конфигурациях,
+	// чтобы работала морфология и русского, и английского. Векторы обновляет
+	// триггер — при любой записи, а не только из CreatePost.
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_ru TSVECTOR`,
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_en TSVECTOR`,
+	`CREATE OR REPLACE FUNCTION posts_search_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_ru := setweight(to_tsvector('russian', coalesce(NEW.title, '')), 'A') ||
+			setweight(to_tsvector('russian', coalesce(NEW.content, '')), 'B');
+		NEW.search_en := setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(NEW.content, '')), 'B');
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'posts_search_update' AND tgrelid = 'posts'::regclass) THEN
+			CREATE TRIGGER posts_search_update BEFORE INSERT OR UPDATE OF title, content ON posts
+				FOR EACH ROW EXECUTE FUNCTION posts_search_update();
+		END IF;
+	END
+	$$`,
+	// Посты, созданные до появления триггера
+	`UPDATE posts SET title = title WHERE search_ru IS NULL OR search_en IS NULL`,
+	`CREATE INDEX IF NOT EXISTS posts_search_ru_idx ON posts USING GIN (search_ru)`,
+	`CREATE INDEX IF NOT EXISTS posts_search_en_idx ON posts USING GIN (search_en)`,
+	// Редактирование: updated_at остаётся NULL, пока пост не правили. Ревизия 1 —
+	// исходный текст, каждая правка добавляет следующую.
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ`,
+	`CREATE TABLE IF NOT EXISTS post_revisions (
+		post_id    INT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		revision   INT NOT NULL,
+		title      TEXT NOT NULL,
+		content    TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (post_id, revision)
+	)`,
+	// Исходные ревизии постов, созданных до появления истории
+	`INSERT INTO post_revisions (post_id, revision, title, content, created_at)
+		SELECT id, 1, title, content, created_at FROM posts
+		WHERE NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_revisions.post_id = posts.id)
+		ON CONFLICT DO NOTHING`,
+	// Комментарии: parent_id задаёт ответ на комментарий верхнего уровня,
+	// глубже одного уровня ответы не вкладываются
+	`CREATE TABLE IF NOT EXISTS comments (
+		id         SERIAL PRIMARY KEY,
+		post_id    INT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		parent_id  INT REFERENCES comments (id) ON DELETE CASCADE,
+		author_id  INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		content    TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS comments_post_created_idx ON comments (post_id, created_at DESC, id DESC) WHERE parent_id IS NULL`,
+	`CREATE INDEX IF NOT EXISTS comments_parent_created_idx ON comments (parent_id, created_at, id)`,
+	`CREATE INDEX IF NOT EXISTS comments_post_idx ON comments (post_id)`,
+	// Черновики и отложенная публикация. publish_at задан только у постов в
+	// статусе scheduled; существующие посты считаются опубликованными.
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+		CHECK (status IN ('draft', 'scheduled', 'published'))`,
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ`,
+	`CREATE INDEX IF NOT EXISTS posts_scheduled_idx ON posts (publish_at) WHERE status = 'scheduled'`,
+	// Хэштеги: имена тегов нормализованы (см. пакет hashtags), связь с постами
+	// обновляется при каждой записи текста
+	`CREATE TABLE IF NOT EXISTS tags (
+		id   SERIAL PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	)`,
+	// Таблица создаётся вместе с заполнением по уже существующим постам, поэтому
+	// заполнение выполняется один раз. Регулярное выражение повторяет правила
+	// пакета hashtags; для не-ASCII букв оно зависит от локали базы.
+	`DO $$
+	BEGIN
+		IF to_regclass('post_tags') IS NULL THEN
+			CREATE TABLE post_tags (
+				post_id INT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+				tag_id  INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+				PRIMARY KEY (post_id, tag_id)
+			);
+			CREATE TEMPORARY TABLE legacy_tags ON COMMIT DROP AS
+				SELECT DISTINCT posts.id AS post_id, lower(m[2]) AS name
+				FROM posts, regexp_matches(posts.content, '(^|[^[:alnum:]_])#([[:alnum:]_]+)', 'g') AS m
+				WHERE m[2] ~ '[[:alpha:]]' AND char_length(m[2]) <= 50;
+			INSERT INTO tags (name) SELECT DISTINCT name FROM legacy_tags ON CONFLICT DO NOTHING;
+			INSERT INTO post_tags (post_id, tag_id)
+				SELECT legacy_tags.post_id, tags.id FROM legacy_tags JOIN tags USING (name);
+		END IF;
+	END
+	$$`,
+	`CREATE INDEX IF NOT EXISTS post_tags_tag_idx ON post_tags (tag_id, post_id)`,
+	// Упоминания: post_mentions соответствует текущему тексту поста, username —
+	// имя, каким его написали. mention_notifications помнит, кому уведомление
+	// уже отправлено, даже если упоминание потом убрали правкой.
+	`CREATE TABLE IF NOT EXISTS post_mentions (
+		post_id  INT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		user_id  INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		username TEXT NOT NULL,
+		PRIMARY KEY (post_id, user_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS post_mentions_user_idx ON post_mentions (user_id)`,
+	`CREATE TABLE IF NOT EXISTS mention_notifications (
+		post_id    INT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		user_id    INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (post_id, user_id)
+	)`,
+	// Неудачная отправка повторяется с растущей задержкой: status = 'retry'
+	// и next_attempt_at — время повтора. После постоянной ошибки или
+	// исчерпания попыток status = 'failed'. Старые записи уже отправлены.
+	`ALTER TABLE mention_notifications ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'sent'
+		CHECK (status IN ('sent', 'retry', 'failed'))`,
+	`ALTER TABLE mention_notifications ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 1`,
+	`ALTER TABLE mention_notifications ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ`,
+	`CREATE INDEX IF NOT EXISTS mention_notifications_retry_idx ON mention_notifications (next_attempt_at) WHERE status = 'retry'`,
+	// Посты, упомянутых в которых пользователей не удалось найти при записи
+	// (Users Service был недоступен). Их ищет планировщик; next_attempt_at —
+	// время следующей попытки.
+	`CREATE TABLE IF NOT EXISTS mention_lookups (
+		post_id         INT PRIMARY KEY REFERENCES posts (id) ON DELETE CASCADE,
+		attempts        INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS mention_lookups_next_idx ON mention_lookups (next_attempt_at)`,
+}
+
+// Migrate применяет недостающие изменения схемы
+func Migrate(db *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply migration: %w", err)
+		}
+	}
+	return nil
+}
+
 type Post struct {
-	ID             int           `json:"id"`
-	Title          string        `json:"title"`
-	Content        string        `json:"content"`
-	AuthorID       int           `json:"authorId"`
-	AuthorUsername string        `json:"authorUsername"`
-	Likes          []interface{} `json:"likes"`
+	ID             int        `json:"id"`
+	Title          string     `json:"title"`
+	Content        string     `json:"content"`
+	AuthorID       int        `json:"authorId"`
+	AuthorUsername string     `json:"authorUsername"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+	Edited         bool       `json:"edited"`
+	Status         PostStatus `json:"status"`
+	PublishAt      *time.Time `json:"publishAt,omitempty"`
+	Tags           []string   `json:"tags"`
+	Mentions       []Mention  `json:"mentions"`
+	LikesCount     int        `json:"likesCount"`
+	LikedByMe      bool       `json:"likedByMe"`
+	CommentsCount  int        `json:"commentsCount"`
 }
 
-// FetchPosts возвращает все посты с лайками и информацией об авторе
-func FetchPosts(db *sql.DB) ([]Post, error) {
-	rows, err := db.Query(`
-        SELECT 
-            posts.id, 
-            posts.title, 
-            posts.content, 
-            posts.author_id AS author_id,
-            users.username AS author_username,
-            COALESCE(
-                json_agg(
-                    json_build_object(
-                        'id', likes.user_id, 
-                        'username', liked_users.username
-                    )
-                ) FILTER (WHERE likes.user_id IS NOT NULL), '[]'
-            ) AS likes
-        FROM posts
-        JOIN users ON posts.author_id = users.id
-        LEFT JOIN likes ON posts.id = likes.post_id
-        LEFT JOIN users AS liked_users ON likes.user_id = liked_users.id
-        GROUP BY posts.id, users.username
-		ORDER BY posts.created_at DESC
-    `)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+// postColumns — поля поста в порядке, который читает scanPost. Вместо списка
+// лайков отдаётся их число и отметка текущего пользователя — параметр $1;
+// сам список постранично отдаёт FetchLikes.
+const postColumns = `
+            posts.id,
+            posts.title,
+            posts.content,
+            posts.author_id,
+            users.username,
+            posts.created_at,
+            posts.updated_at,
+            posts.status,
+            posts.publish_at,
+            ARRAY(SELECT tags.name FROM post_tags JOIN tags ON tags.id = post_tags.tag_id
+                  WHERE post_tags.post_id = posts.id ORDER BY tags.name),
+            ARRAY(SELECT post_mentions.username FROM post_mentions
+                  WHERE post_mentions.post_id = posts.id ORDER BY post_mentions.user_id),
+            ARRAY(SELECT post_mentions.user_id FROM post_mentions
+                  WHERE post_mentions.post_id = posts.id ORDER BY post_mentions.user_id),
+            (SELECT count(*) FROM likes WHERE likes.post_id = posts.id),
+            EXISTS(SELECT 1 FROM likes WHERE likes.post_id = posts.id AND likes.user_id = $1),
+            (SELECT count(*) FROM comments WHERE comments.post_id = posts.id)`
+
+// postVisible отбирает посты, которые может видеть пользователь $1:
+// опубликованные и его собственные черновики и отложенные посты
+const postVisible = `(posts.status = 'published' OR posts.author_id = $1)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPost читает поля postColumns; extra — значения столбцов, выбранных после них
+func scanPost(row rowScanner, post *Post, extra ...interface{}) error {
+	var updatedAt, publishAt sql.NullTime
+	var mentionNames []string
+	var mentionIDs []int64
+	dest := append([]interface{}{&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.AuthorUsername,
+		&post.CreatedAt, &updatedAt, &post.Status, &publishAt, pq.Array(&post.Tags), pq.Array(&mentionNames), pq.Array(&mentionIDs),
+		&post.LikesCount, &post.LikedByMe, &post.CommentsCount}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	mentioned := make(map[string]int, len(mentionNames))
+	for i, name := range mentionNames {
+		mentioned[name] = int(mentionIDs[i])
+	}
+	post.Mentions = mentionEntities(post.Content, mentioned)
+	if updatedAt.Valid {
+		post.UpdatedAt = &updatedAt.Time
+		post.Edited = true
 	}
+	if publishAt.Valid {
+		post.PublishAt = &publishAt.Time
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+	return nil
+}
+
+func scanPosts(rows *sql.Rows) ([]Post, error) {
 	defer rows.Close()
 
 	var posts []Post
 	for rows.Next() {
 		var post Post
-		var likesJSON string
-		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.AuthorUsername, &likesJSON)
-		if err != nil {
+		if err := scanPost(rows, &post); err != nil {
 			return nil, fmt.Errorf("failed to scan post row: %w", err)
 		}
-
-		// Декодируем JSON-строку likes в массив объектов
-		if err := json.Unmarshal([]byte(likesJSON), &post.Likes); err != nil {
-			return nil, fmt.Errorf("failed to parse likes JSON: %w", err)
-		}
-
 		posts = append(posts, post)
 	}
-
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error while iterating over rows: %w", err)
 	}
-
 	return posts, nil
 }
 
-// CreatePost добавляет новый пост в базу данных и возвращает его информацию
-func CreatePost(db *sql.DB, title, content string, authorID int) (*Post, error) {
+// FetchPosts возвращает страницу всех опубликованных постов, новые сверху.
+// viewerID — ID пользователя, для которого отмечаются его лайки. Выбирается
+// page.Limit+1 постов.
+func FetchPosts(db *sql.DB, viewerID int, page pagination.Request) ([]Post, error) {
+	after, afterID := page.Args()
+	rows, err := db.Query(`
+        SELECT `+postColumns+`
+        FROM posts
+        JOIN users ON posts.author_id = users.id
+        WHERE posts.status = 'published'
+          AND ($2::timestamptz IS NULL OR (posts.created_at, posts.id) < ($2, $3))
+        ORDER BY posts.created_at DESC, posts.id DESC
+        LIMIT $4
+    `, viewerID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	return scanPosts(rows)
+}
+
+// CreatePost добавляет новый пост в базу данных и возвращает его информацию.
+// publishAt задаётся только для статуса StatusScheduled; mentioned —
+// упомянутые в тексте пользователи (username → ID) или nil, если их не
+// удалось найти: тогда их найдёт планировщик.
+func CreatePost(db *sql.DB, title, content string, authorID int, status PostStatus, publishAt *time.Time, mentioned map[string]int) (*Post, error) {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
@@ -100,35 +319,59 @@ func CreatePost(db *sql.DB, title, content string, authorID int) (*Post, error)
 		"authorID": authorID,
 	}).Info("Inserting post into database")
 
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	var post Post
-	err := db.QueryRow(`
+	err = tx.QueryRow(`
         WITH inserted_post AS (
-            INSERT INTO posts (title, content, author_id)
-            VALUES ($1, $2, $3)
-            RETURNING id, title, content, author_id
+            INSERT INTO posts (title, content, author_id, status, publish_at)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id, title, content, author_id, created_at, status, publish_at
+        ), first_revision AS (
+            INSERT INTO post_revisions (post_id, revision, title, content, created_at)
+            SELECT id, 1, title, content, created_at FROM inserted_post
         )
         SELECT 
             inserted_post.id, 
             inserted_post.title, 
             inserted_post.content, 
             inserted_post.author_id, 
-            users.username AS author_username
+            users.username AS author_username,
+            inserted_post.created_at,
+            inserted_post.status,
+            inserted_post.publish_at
         FROM inserted_post
         JOIN users ON inserted_post.author_id = users.id
-    `, title, content, authorID).Scan(
+    `, title, content, authorID, status, publishAt).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Content,
 		&post.AuthorID,
 		&post.AuthorUsername,
+		&post.CreatedAt,
+		&post.Status,
+		&post.PublishAt,
 	)
 	if err != nil {
 		logger.WithError(err).Error("Failed to insert post into database")
 		return nil, fmt.Errorf("failed to insert post: %w", err)
 	}
 
-	// Новый пост ещё не имеет лайков
-	post.Likes = []interface{}{}
+	if post.Tags, err = setPostTags(tx, post.ID, content); err != nil {
+		return nil, err
+	}
+	if err := setPostMentions(tx, post.ID, mentioned); err != nil {
+		return nil, err
+	}
+	post.Mentions = mentionEntities(content, mentioned)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	logger.WithFields(logrus.Fields{
 		"id":             post.ID,
 		"title":          post.Title,
@@ -140,52 +383,21 @@ func CreatePost(db *sql.DB, title, content string, authorID int) (*Post, error)
 	return &post, nil
 }
 
-// FetchPostByID возвращает пост по ID с информацией о лайках
-func FetchPostByID(db *sql.DB, postID int) (*Post, error) {
+// FetchPostByID возвращает пост по ID. Черновик или отложенный пост находится,
+// только если viewerID — его автор.
+func FetchPostByID(db *sql.DB, postID, viewerID int) (*Post, error) {
 	var post Post
-	var likesJSON string
-
-	err := db.QueryRow(`
-        SELECT 
-            posts.id, 
-            posts.title, 
-            posts.content, 
-            posts.author_id AS author_id,
-            users.username AS author_username,
-            COALESCE(
-                json_agg(
-                    json_build_object(
-                        'id', likes.user_id, 
-                        'username', liked_users.username
-                    )
-                ) FILTER (WHERE likes.user_id IS NOT NULL), '[]'
-            ) AS likes
+	err := scanPost(db.QueryRow(`
+        SELECT `+postColumns+`
         FROM posts
         JOIN users ON posts.author_id = users.id
-        LEFT JOIN likes ON posts.id = likes.post_id
-        LEFT JOIN users AS liked_users ON likes.user_id = liked_users.id
-        WHERE posts.id = $1
-        GROUP BY posts.id, users.username
-    `, postID).Scan(
-		&post.ID,
-		&post.Title,
-		&post.Content,
-		&post.AuthorID,
-		&post.AuthorUsername,
-		&likesJSON,
-	)
-
+        WHERE posts.id = $2 AND `+postVisible+`
+    `, viewerID, postID), &post)
 	if err == sql.ErrNoRows {
 		return nil, nil // Пост не найден
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to fetch post: %w", err)
 	}
-
-	// Декодируем JSON-строку likes в массив объектов
-	if err := json.Unmarshal([]byte(likesJSON), &post.Likes); err != nil {
-		return nil, fmt.Errorf("failed to parse likes JSON: %w", err)
-	}
-
 	return &post, nil
 }
 
@@ -210,55 +422,21 @@ func DeletePost(db *sql.DB, postID int) error {
 	return nil
 }
 
-// FetchUserPosts возвращает список постов конкретного пользователя по его userID.
-func FetchUserPosts(db *sql.DB, userID int) ([]Post, error) {
+// FetchUserPosts возвращает страницу постов пользователя userID, новые сверху.
+// Черновики и отложенные посты видит только сам автор.
+func FetchUserPosts(db *sql.DB, userID, viewerID int, page pagination.Request) ([]Post, error) {
+	after, afterID := page.Args()
 	rows, err := db.Query(`
-        SELECT 
-            posts.id, 
-            posts.title, 
-            posts.content, 
-            posts.author_id AS author_id,
-            users.username AS author_username,
-            COALESCE(
-                json_agg(
-                    json_build_object(
-                        'id', likes.user_id, 
-                        'username', liker.username
-                    )
-                ) FILTER (WHERE likes.user_id IS NOT NULL), '[]'
-            ) AS likes
+        SELECT `+postColumns+`
         FROM posts
         JOIN users ON posts.author_id = users.id
-        LEFT JOIN likes ON posts.id = likes.post_id
-        LEFT JOIN users AS liker ON likes.user_id = liker.id
-        WHERE posts.author_id = $1
-        GROUP BY posts.id, users.username
-        ORDER BY posts.created_at DESC
-    `, userID)
+        WHERE posts.author_id = $2 AND `+postVisible+`
+          AND ($3::timestamptz IS NULL OR (posts.created_at, posts.id) < ($3, $4))
+        ORDER BY posts.created_at DESC, posts.id DESC
+        LIMIT $5
+    `, viewerID, userID, after, afterID, page.Limit+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user posts: %w", err)
 	}
-	defer rows.Close()
-
-	var posts []Post
-	for rows.Next() {
-		var post Post
-		var likesJSON string
-		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.AuthorUsername, &likesJSON)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan post row: %w", err)
-		}
-
-		if err := json.Unmarshal([]byte(likesJSON), &post.Likes); err != nil {
-			return nil, fmt.Errorf("failed to parse likes JSON: %w", err)
-		}
-
-		posts = append(posts, post)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error while iterating over rows: %w", err)
-	}
-
-	return posts, nil
+	return scanPosts(rows)
 }
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// openTestDB подключается к базе из TEST_POSTGRES_DSN, где уже есть базовые
+// таблицы users и posts, и применяет миграции; без переменной тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return db
+}
+
+// newUser создаёт пользователя с уникальным именем и удаляет его после теста
+// вместе с его постами
+func newUser(t *testing.T, db *sql.DB, prefix string) (int, string) {
+	t.Helper()
+	name := fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	var id int
+	err := db.QueryRow(`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x') RETURNING id`,
+		name, name+"@example.com").Scan(&id)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM posts WHERE author_id = $1", id)
+		db.Exec("DELETE FROM users WHERE id = $1", id)
+	})
+	return id, name
+}
+
+// newPost публикует пост автора authorID
+func newPost(t *testing.T, db *sql.DB, authorID int, content string) *Post {
+	t.Helper()
+	post, err := CreatePost(db, "title", content, authorID, StatusPublished, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	return post
+}
+
+// postIDs возвращает ID постов по порядку
+func postIDs(posts []Post) []int {
+	ids := make([]int, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
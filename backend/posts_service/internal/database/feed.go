@@ -0,0 +1,124 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"shared/pagination"
+)
+
+// timelineBackfillLimit — сколько последних постов автора попадает в
+// материализованную ленту при подписке на него
+const timelineBackfillLimit = 100
+
+// FetchFeed собирает ленту при чтении (fan-out-on-read): посты авторов, на
+// которых подписан userID, и его собственные. Выбирается page.Limit+1 постов.
+func FetchFeed(db *sql.DB, userID int, page pagination.Request) ([]Post, error) {
+	after, afterID := page.Args()
+	rows, err := db.Query(`
+        SELECT `+postColumns+`
+        FROM posts
+        JOIN users ON posts.author_id = users.id
+        WHERE (posts.author_id = $1
+               OR posts.author_id IN (SELECT author_id FROM subscriptions WHERE subscriber_id = $1))
+          AND posts.status = 'published'
+          AND ($2::timestamptz IS NULL OR (posts.created_at, posts.id) < ($2, $3))
+        ORDER BY posts.created_at DESC, posts.id DESC
+        LIMIT $4
+    `, userID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	return scanPosts(rows)
+}
+
+// FetchTimeline читает материализованную ленту userID (fan-out-on-write)
+func FetchTimeline(db *sql.DB, userID int, page pagination.Request) ([]Post, error) {
+	after, afterID := page.Args()
+	rows, err := db.Query(`
+        SELECT `+postColumns+`
+        FROM timelines
+        JOIN posts ON posts.id = timelines.post_id
+        JOIN users ON posts.author_id = users.id
+        WHERE timelines.user_id = $1
+          AND ($2::timestamptz IS NULL OR (timelines.created_at, timelines.post_id) < ($2, $3))
+        ORDER BY timelines.created_at DESC, timelines.post_id DESC
+        LIMIT $4
+    `, userID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch timeline: %w", err)
+	}
+	return scanPosts(rows)
+}
+
+// FanOutPost добавляет пост в материализованные ленты автора и его подписчиков
+func FanOutPost(db *sql.DB, post *Post) error {
+	_, err := db.Exec(`
+        INSERT INTO timelines (user_id, post_id, author_id, created_at)
+        SELECT subscriber_id, $1::int, $2::int, $3::timestamptz FROM subscriptions WHERE author_id = $2
+        UNION ALL
+        SELECT $2::int, $1::int, $2::int, $3::timestamptz
+        ON CONFLICT DO NOTHING
+    `, post.ID, post.AuthorID, post.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to fan out post: %w", err)
+	}
+	return nil
+}
+
+// BackfillTimeline добавляет в ленту userID последние посты автора authorID
+func BackfillTimeline(db *sql.DB, userID, authorID int) error {
+	_, err := db.Exec(`
+        INSERT INTO timelines (user_id, post_id, author_id, created_at)
+        SELECT $1, id, author_id, created_at
+        FROM posts
+        WHERE author_id = $2 AND status = 'published'
+        ORDER BY created_at DESC, id DESC
+        LIMIT $3
+        ON CONFLICT DO NOTHING
+    `, userID, authorID, timelineBackfillLimit)
+	if err != nil {
+		return fmt.Errorf("failed to backfill timeline: %w", err)
+	}
+	return nil
+}
+
+// PruneTimeline убирает из ленты userID посты автора authorID после отписки
+func PruneTimeline(db *sql.DB, userID, authorID int) error {
+	_, err := db.Exec("DELETE FROM timelines WHERE user_id = $1 AND author_id = $2", userID, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to prune timeline: %w", err)
+	}
+	return nil
+}
+
+// RebuildTimelines заполняет материализованные ленты по текущим подпискам.
+// Нужна при переходе на fan-out-on-write: посты, опубликованные при другой
+// стратегии, в лентах отсутствуют. Повторный запуск безопасен.
+func RebuildTimelines(db *sql.DB) error {
+	_, err := db.Exec(`
+        INSERT INTO timelines (user_id, post_id, author_id, created_at)
+        SELECT s.subscriber_id, p.id, p.author_id, p.created_at
+        FROM subscriptions s
+        CROSS JOIN LATERAL (
+            SELECT id, author_id, created_at FROM posts
+            WHERE author_id = s.author_id AND status = 'published'
+            ORDER BY created_at DESC, id DESC
+            LIMIT $1
+        ) p
+        UNION ALL
+        SELECT p.author_id, p.id, p.author_id, p.created_at
+        FROM (
+            SELECT id, author_id, created_at,
+                row_number() OVER (PARTITION BY author_id ORDER BY created_at DESC, id DESC) AS n
+            FROM posts
+            WHERE status = 'published'
+        ) p
+        WHERE p.n <= $1
+        ON CONFLICT DO NOTHING
+    `, timelineBackfillLimit)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild timelines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"shared/pagination"
+)
+
+func TestFeedStrategiesAgree(t *testing.T) {
+	db := openTestDB(t)
+	reader, _ := newUser(t, db, "reader")
+	followed, _ := newUser(t, db, "followed")
+	stranger, _ := newUser(t, db, "stranger")
+
+	// Пост до подписки попадает в материализованную ленту через BackfillTimeline
+	early := newPost(t, db, followed, "before subscribe")
+	if _, err := Subscribe(db, reader, followed); err != nil {
+		t.Fatal(err)
+	}
+	if err := BackfillTimeline(db, reader, followed); err != nil {
+		t.Fatal(err)
+	}
+
+	var posts []*Post
+	for _, author := range []int{followed, reader, stranger} {
+		p := newPost(t, db, author, "after subscribe")
+		if err := FanOutPost(db, p); err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, p)
+	}
+
+	page := pagination.Request{Limit: 10}
+	onRead, err := FetchFeed(db, reader, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onWrite, err := FetchTimeline(db, reader, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprint([]int{posts[1].ID, posts[0].ID, early.ID})
+	if got := fmt.Sprint(postIDs(onRead)); got != want {
+		t.Errorf("fan-out-on-read feed = %s, want %s", got, want)
+	}
+	if got := fmt.Sprint(postIDs(onWrite)); got != want {
+		t.Errorf("fan-out-on-write feed = %s, want %s", got, want)
+	}
+
+	// После отписки посты автора пропадают из обеих лент
+	Unsubscribe(db, reader, followed)
+	if err := PruneTimeline(db, reader, followed); err != nil {
+		t.Fatal(err)
+	}
+	onRead, _ = FetchFeed(db, reader, page)
+	onWrite, _ = FetchTimeline(db, reader, page)
+	want = fmt.Sprint([]int{posts[1].ID})
+	if fmt.Sprint(postIDs(onRead)) != want || fmt.Sprint(postIDs(onWrite)) != want {
+		t.Errorf("after unsubscribe: read %v, write %v, want %s", postIDs(onRead), postIDs(onWrite), want)
+	}
+}
+
+func TestFetchFeedSkipsDraftsAndPages(t *testing.T) {
+	db := openTestDB(t)
+	reader, _ := newUser(t, db, "reader")
+	author, _ := newUser(t, db, "author")
+	Subscribe(db, reader, author)
+
+	if _, err := CreatePost(db, "draft", "draft", author, StatusDraft, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for i := 0; i < 3; i++ {
+		ids = append([]int{newPost(t, db, author, "published").ID}, ids...)
+	}
+
+	first, err := FetchFeed(db, reader, pagination.Request{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Лишний пост сообщает о следующей странице
+	if len(first) != 3 {
+		t.Fatalf("first page returned %d posts, want limit+1 = 3", len(first))
+	}
+	last := first[1]
+	second, _ := FetchFeed(db, reader, pagination.Request{Limit: 2, After: &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+	if len(second) != 1 || second[0].ID != ids[2] {
+		t.Errorf("second page = %v, want [%d]", postIDs(second), ids[2])
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"shared/pagination"
+)
+
+// Liker — пользователь, лайкнувший пост
+type Liker struct {
+	ID       int       `json:"id"`
+	Username string    `json:"username"`
+	LikedAt  time.Time `json:"likedAt"`
+}
+
+// FetchLikes возвращает страницу лайкнувших пост, последние сверху.
+// Выбирается page.Limit+1 записей.
+func FetchLikes(db *sql.DB, postID int, page pagination.Request) ([]Liker, error) {
+	after, afterID := page.Args()
+	rows, err := db.Query(`
+        SELECT users.id, users.username, likes.created_at
+        FROM likes
+        JOIN users ON users.id = likes.user_id
+        WHERE likes.post_id = $1
+          AND ($2::timestamptz IS NULL OR (likes.created_at, likes.user_id) < ($2, $3))
+        ORDER BY likes.created_at DESC, likes.user_id DESC
+        LIMIT $4
+    `, postID, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch likes: %w", err)
+	}
+	defer rows.Close()
+
+	var likers []Liker
+	for rows.Next() {
+		var l Liker
+		if err := rows.Scan(&l.ID, &l.Username, &l.LikedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan like row: %w", err)
+		}
+		likers = append(likers, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return likers, nil
+}
+
+// CountLikes возвращает число лайков поста
+func CountLikes(db *sql.DB, postID int) (int, error) {
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM likes WHERE post_id = $1", postID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count likes: %w", err)
+	}
+	return n, nil
+}
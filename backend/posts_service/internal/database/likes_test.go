@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+
+	"shared/pagination"
+)
+
+func TestFetchLikesPages(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	post := newPost(t, db, author, "likeable")
+
+	var likers []int
+	for i := 0; i < 5; i++ {
+		id, _ := newUser(t, db, "liker")
+		if _, err := db.Exec("INSERT INTO likes (post_id, user_id) VALUES ($1, $2)", post.ID, id); err != nil {
+			t.Fatal(err)
+		}
+		likers = append(likers, id)
+	}
+
+	var got []int
+	page := pagination.Request{Limit: 2}
+	for {
+		batch, err := FetchLikes(db, post.ID, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := pagination.NewPage(batch, page.Limit, func(l Liker) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: l.LikedAt, ID: l.ID}
+		})
+		for _, l := range p.Items {
+			got = append(got, l.ID)
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		c, _ := pagination.Decode(p.NextCursor)
+		page.After = &c
+	}
+
+	// Все лайкнувшие, последние сверху, без повторов
+	if len(got) != len(likers) {
+		t.Fatalf("walked %d likers, want %d: %v", len(got), len(likers), got)
+	}
+	for i, id := range got {
+		if want := likers[len(likers)-1-i]; id != want {
+			t.Errorf("position %d: liker %d, want %d", i, id, want)
+		}
+	}
+
+	if n, _ := CountLikes(db, post.ID); n != len(likers) {
+		t.Errorf("CountLikes = %d, want %d", n, len(likers))
+	}
+}
@@ -0,0 +1,312 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"posts_service/internal/mentions"
+
+	"github.com/lib/pq"
+)
+
+// Mention — упоминание пользователя в тексте поста. Offset и Length считаются
+// в символах Unicode и включают символ @.
+type Mention struct {
+	UserID   int    `json:"userId"`
+	Username string `json:"username"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+}
+
+// mentionEntities находит в content упоминания пользователей из mentioned.
+// Имена, которым не нашлось пользователя, остаются простым текстом.
+func mentionEntities(content string, mentioned map[string]int) []Mention {
+	entities := []Mention{}
+	if len(mentioned) == 0 {
+		return entities
+	}
+	for _, m := range mentions.Find(content) {
+		if id, ok := mentioned[m.Username]; ok {
+			entities = append(entities, Mention{UserID: id, Username: m.Username, Offset: m.Offset, Length: m.Length})
+		}
+	}
+	return entities
+}
+
+// setPostMentions заменяет упоминания поста на mentioned (username → ID).
+// nil означает, что пользователей найти не удалось: до тех пор, пока их не
+// найдёт ResolveMentionLookup, пост стоит в очереди mention_lookups.
+func setPostMentions(tx *sql.Tx, postID int, mentioned map[string]int) error {
+	if _, err := tx.Exec("DELETE FROM post_mentions WHERE post_id = $1", postID); err != nil {
+		return fmt.Errorf("failed to remove post mentions: %w", err)
+	}
+	if mentioned == nil {
+		_, err := tx.Exec(`
+            INSERT INTO mention_lookups (post_id) VALUES ($1)
+            ON CONFLICT (post_id) DO UPDATE SET attempts = 0, next_attempt_at = now()
+        `, postID)
+		if err != nil {
+			return fmt.Errorf("failed to queue mention lookup: %w", err)
+		}
+		return nil
+	}
+	if _, err := tx.Exec("DELETE FROM mention_lookups WHERE post_id = $1", postID); err != nil {
+		return fmt.Errorf("failed to remove mention lookup: %w", err)
+	}
+	if len(mentioned) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(mentioned))
+	names := make([]string, 0, len(mentioned))
+	for name, id := range mentioned {
+		ids = append(ids, int64(id))
+		names = append(names, name)
+	}
+	_, err := tx.Exec(`
+        INSERT INTO post_mentions (post_id, user_id, username)
+        SELECT $1, user_id, username FROM unnest($2::int[], $3::text[]) AS m (user_id, username)
+        ON CONFLICT DO NOTHING
+    `, postID, pq.Array(ids), pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("failed to save post mentions: %w", err)
+	}
+	return nil
+}
+
+// MentionLookup — пост, упомянутых в котором пользователей ещё предстоит найти.
+// Attempts — номер текущей попытки, начиная с 1.
+type MentionLookup struct {
+	PostID   int
+	Content  string
+	Attempts int
+}
+
+// ClaimMentionLookups забирает до limit постов из очереди mention_lookups,
+// время попытки которых наступило, и до завершения попытки откладывает их до
+// leaseUntil: если реплика не успеет ни найти пользователей, ни назначить
+// повтор, пост вернётся в очередь. SKIP LOCKED не даёт двум репликам забрать
+// один пост.
+func ClaimMentionLookups(db *sql.DB, limit int, leaseUntil time.Time) ([]MentionLookup, error) {
+	rows, err := db.Query(`
+        WITH due AS (
+            SELECT post_id
+            FROM mention_lookups
+            WHERE next_attempt_at <= now()
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE mention_lookups
+        SET attempts = attempts + 1, next_attempt_at = $2
+        FROM due, posts
+        WHERE mention_lookups.post_id = due.post_id
+          AND posts.id = due.post_id
+        RETURNING mention_lookups.post_id, posts.content, mention_lookups.attempts
+    `, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim mention lookups: %w", err)
+	}
+	defer rows.Close()
+
+	var lookups []MentionLookup
+	for rows.Next() {
+		var l MentionLookup
+		if err := rows.Scan(&l.PostID, &l.Content, &l.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan mention lookup: %w", err)
+		}
+		lookups = append(lookups, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return lookups, nil
+}
+
+// ResolveMentionLookup сохраняет найденных в content пользователей и убирает
+// пост из очереди. Если текст поста успели изменить или пост уже убран из
+// очереди, ничего не меняется и возвращается false: упоминания нового текста
+// сохранила правка.
+func ResolveMentionLookup(db *sql.DB, postID int, content string, mentioned map[string]int) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка поста упорядочивает запись с одновременной правкой
+	var current string
+	err = tx.QueryRow("SELECT content FROM posts WHERE id = $1 FOR UPDATE", postID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to lock post: %w", err)
+	}
+	var queued bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM mention_lookups WHERE post_id = $1)", postID).Scan(&queued)
+	if err != nil {
+		return false, fmt.Errorf("failed to check mention lookup: %w", err)
+	}
+	if current != content || !queued {
+		return false, nil
+	}
+
+	if mentioned == nil {
+		mentioned = map[string]int{}
+	}
+	if err := setPostMentions(tx, postID, mentioned); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// RetryMentionLookup откладывает следующую попытку найти упомянутых пользователей до at
+func RetryMentionLookup(db *sql.DB, postID int, at time.Time) error {
+	_, err := db.Exec("UPDATE mention_lookups SET next_attempt_at = $2 WHERE post_id = $1", postID, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule mention lookup retry: %w", err)
+	}
+	return nil
+}
+
+// DropMentionLookup убирает пост из очереди: упоминания в нём остаются простым текстом
+func DropMentionLookup(db *sql.DB, postID int) error {
+	_, err := db.Exec("DELETE FROM mention_lookups WHERE post_id = $1", postID)
+	if err != nil {
+		return fmt.Errorf("failed to drop mention lookup: %w", err)
+	}
+	return nil
+}
+
+// MentionNotification — уведомление об упоминании, которое нужно отправить.
+// Attempts — номер текущей попытки отправки, начиная с 1.
+type MentionNotification struct {
+	PostID   int
+	UserID   int
+	AuthorID int
+	Attempts int
+}
+
+// ClaimMentionNotifications отмечает уведомления об упоминании в посте как
+// отправленные и возвращает те, которые нужно отправить: пользователям, кого
+// опубликованный пост упоминает и кому уведомление по нему ещё не
+// отправлялось. Автор не уведомляется об упоминании себя. Отметка ставится до
+// отправки, поэтому одновременные правки не уведомят пользователя дважды.
+func ClaimMentionNotifications(db *sql.DB, postID int) ([]MentionNotification, error) {
+	return claimNewMentions(db, postID, mentions.MaxPerPost)
+}
+
+// ClaimPendingMentionNotifications отмечает до limit уведомлений об
+// упоминаниях в опубликованных постах, которые ещё никто не отправлял (например,
+// в постах, опубликованных планировщиком), и возвращает их
+func ClaimPendingMentionNotifications(db *sql.DB, limit int) ([]MentionNotification, error) {
+	return claimNewMentions(db, 0, limit)
+}
+
+// claimNewMentions вставляет до limit отметок для упоминаний в посте postID
+// или, если postID равен 0, во всех постах
+func claimNewMentions(db *sql.DB, postID, limit int) ([]MentionNotification, error) {
+	rows, err := db.Query(`
+        WITH claimed AS (
+            INSERT INTO mention_notifications (post_id, user_id, status, attempts)
+            SELECT post_mentions.post_id, post_mentions.user_id, 'sent', 1
+            FROM post_mentions
+            JOIN posts ON posts.id = post_mentions.post_id
+            WHERE posts.status = 'published'
+              AND post_mentions.user_id <> posts.author_id
+              AND NOT EXISTS (
+                  SELECT 1 FROM mention_notifications
+                  WHERE mention_notifications.post_id = post_mentions.post_id
+                    AND mention_notifications.user_id = post_mentions.user_id
+              )
+              AND ($1 = 0 OR post_mentions.post_id = $1)
+            LIMIT $2
+            ON CONFLICT DO NOTHING
+            RETURNING post_id, user_id, attempts
+        )
+        SELECT claimed.post_id, claimed.user_id, posts.author_id, claimed.attempts
+        FROM claimed
+        JOIN posts ON posts.id = claimed.post_id
+    `, postID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim mention notifications: %w", err)
+	}
+	return scanMentionNotifications(rows)
+}
+
+// ClaimDueMentionNotifications забирает до limit уведомлений, время повтора
+// которых наступило, начиная с самых давно ожидающих. Уведомления, которые
+// раз за разом не удаётся отправить, откладываются всё дальше и не мешают
+// остальным. SKIP LOCKED не даёт двум репликам забрать одно уведомление.
+func ClaimDueMentionNotifications(db *sql.DB, limit int) ([]MentionNotification, error) {
+	rows, err := db.Query(`
+        WITH due AS (
+            SELECT post_id, user_id
+            FROM mention_notifications
+            WHERE status = 'retry' AND next_attempt_at <= now()
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        ), claimed AS (
+            UPDATE mention_notifications
+            SET status = 'sent', attempts = attempts + 1, next_attempt_at = NULL
+            FROM due
+            WHERE mention_notifications.post_id = due.post_id
+              AND mention_notifications.user_id = due.user_id
+            RETURNING mention_notifications.post_id, mention_notifications.user_id, mention_notifications.attempts
+        )
+        SELECT claimed.post_id, claimed.user_id, posts.author_id, claimed.attempts
+        FROM claimed
+        JOIN posts ON posts.id = claimed.post_id
+    `, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due mention notifications: %w", err)
+	}
+	return scanMentionNotifications(rows)
+}
+
+func scanMentionNotifications(rows *sql.Rows) ([]MentionNotification, error) {
+	defer rows.Close()
+
+	var claimed []MentionNotification
+	for rows.Next() {
+		var n MentionNotification
+		if err := rows.Scan(&n.PostID, &n.UserID, &n.AuthorID, &n.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan mention notification: %w", err)
+		}
+		claimed = append(claimed, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return claimed, nil
+}
+
+// RetryMentionNotification откладывает повтор неотправленного уведомления до at
+func RetryMentionNotification(db *sql.DB, postID, userID int, at time.Time) error {
+	_, err := db.Exec(`
+        UPDATE mention_notifications SET status = 'retry', next_attempt_at = $3
+        WHERE post_id = $1 AND user_id = $2
+    `, postID, userID, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule mention notification retry: %w", err)
+	}
+	return nil
+}
+
+// FailMentionNotification отказывается от отправки уведомления. Отметка
+// остаётся, поэтому правки поста его тоже не повторят.
+func FailMentionNotification(db *sql.DB, postID, userID int) error {
+	_, err := db.Exec(`
+        UPDATE mention_notifications SET status = 'failed', next_attempt_at = NULL
+        WHERE post_id = $1 AND user_id = $2
+    `, postID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark mention notification as failed: %w", err)
+	}
+	return nil
+}
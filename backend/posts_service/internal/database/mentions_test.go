@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMentionEntities(t *testing.T) {
+	got := mentionEntities("@ann и @кот, снова @ann; @ghost", map[string]int{"ann": 1, "кот": 2})
+	want := []Mention{
+		{UserID: 1, Username: "ann", Offset: 0, Length: 4},
+		{UserID: 2, Username: "кот", Offset: 7, Length: 4},
+		{UserID: 1, Username: "ann", Offset: 19, Length: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mentionEntities = %+v, want %+v", got, want)
+	}
+	if got := mentionEntities("@ann", nil); got == nil || len(got) != 0 {
+		t.Errorf("mentionEntities without users = %#v, want an empty slice", got)
+	}
+}
+
+// mentionStatus возвращает статус уведомления об упоминании или "" без записи
+func mentionStatus(t *testing.T, db *sql.DB, postID, userID int) string {
+	t.Helper()
+	var status string
+	db.QueryRow("SELECT status FROM mention_notifications WHERE post_id = $1 AND user_id = $2", postID, userID).Scan(&status)
+	return status
+}
+
+func TestClaimMentionNotifications(t *testing.T) {
+	db := openTestDB(t)
+	author, authorName := newUser(t, db, "author")
+	reader, readerName := newUser(t, db, "reader")
+	mentioned := map[string]int{authorName: author, readerName: reader}
+	content := "@" + authorName + " @" + readerName
+
+	draft, err := CreatePost(db, "draft", content, author, StatusDraft, nil, mentioned)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if claimed, _ := ClaimMentionNotifications(db, draft.ID); len(claimed) != 0 {
+		t.Errorf("draft claimed %+v", claimed)
+	}
+
+	post, err := CreatePost(db, "post", content, author, StatusPublished, nil, mentioned)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	claimed, err := ClaimMentionNotifications(db, post.ID)
+	if err != nil {
+		t.Fatalf("ClaimMentionNotifications: %v", err)
+	}
+	// Автор не уведомляется об упоминании себя
+	want := []MentionNotification{{PostID: post.ID, UserID: reader, AuthorID: author, Attempts: 1}}
+	if !reflect.DeepEqual(claimed, want) {
+		t.Errorf("claimed %+v, want %+v", claimed, want)
+	}
+
+	// Правка с тем же упоминанием не уведомляет второй раз
+	if _, err := UpdatePost(db, post.ID, "post", content+" again", mentioned, "", nil); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	if again, _ := ClaimMentionNotifications(db, post.ID); len(again) != 0 {
+		t.Errorf("second claim = %+v", again)
+	}
+}
+
+func TestClaimDueMentionNotifications(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	reader, readerName := newUser(t, db, "reader")
+	post, err := CreatePost(db, "post", "@"+readerName, author, StatusPublished, nil, map[string]int{readerName: reader})
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if _, err := ClaimMentionNotifications(db, post.ID); err != nil {
+		t.Fatalf("ClaimMentionNotifications: %v", err)
+	}
+
+	claimedPost := func() *MentionNotification {
+		due, err := ClaimDueMentionNotifications(db, 1000)
+		if err != nil {
+			t.Fatalf("ClaimDueMentionNotifications: %v", err)
+		}
+		for i := range due {
+			if due[i].PostID == post.ID {
+				return &due[i]
+			}
+		}
+		return nil
+	}
+
+	RetryMentionNotification(db, post.ID, reader, time.Now().Add(time.Hour))
+	if n := claimedPost(); n != nil {
+		t.Errorf("retry claimed before its time: %+v", n)
+	}
+	RetryMentionNotification(db, post.ID, reader, time.Now().Add(-time.Second))
+	if n := claimedPost(); n == nil || n.Attempts != 2 {
+		t.Fatalf("due retry = %+v, want attempt 2", n)
+	}
+	if s := mentionStatus(t, db, post.ID, reader); s != "sent" {
+		t.Errorf("status after claim = %q, want sent", s)
+	}
+
+	FailMentionNotification(db, post.ID, reader)
+	if s := mentionStatus(t, db, post.ID, reader); s != "failed" {
+		t.Errorf("status = %q, want failed", s)
+	}
+	if n := claimedPost(); n != nil {
+		t.Errorf("failed notification claimed again: %+v", n)
+	}
+}
+
+func TestMentionLookups(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	reader, readerName := newUser(t, db, "reader")
+
+	// nil вместо найденных пользователей ставит пост в очередь
+	post, err := CreatePost(db, "post", "hi @"+readerName, author, StatusPublished, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	claim := func() *MentionLookup {
+		lookups, err := ClaimMentionLookups(db, 1000, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("ClaimMentionLookups: %v", err)
+		}
+		for i := range lookups {
+			if lookups[i].PostID == post.ID {
+				return &lookups[i]
+			}
+		}
+		return nil
+	}
+
+	l := claim()
+	if l == nil || l.Attempts != 1 || l.Content != post.Content {
+		t.Fatalf("lookup = %+v", l)
+	}
+	// Пока попытка не завершена, пост отложен до конца аренды
+	if again := claim(); again != nil {
+		t.Errorf("leased lookup claimed again: %+v", again)
+	}
+
+	// Результат для устаревшего текста не сохраняется
+	if ok, err := ResolveMentionLookup(db, post.ID, "old text", map[string]int{readerName: reader}); err != nil || ok {
+		t.Errorf("stale resolve = %v, %v; want false", ok, err)
+	}
+	if ok, err := ResolveMentionLookup(db, post.ID, l.Content, map[string]int{readerName: reader}); err != nil || !ok {
+		t.Fatalf("ResolveMentionLookup = %v, %v", ok, err)
+	}
+	p, _ := FetchPostByID(db, post.ID, 0)
+	if len(p.Mentions) != 1 || p.Mentions[0].UserID != reader || p.Mentions[0].Offset != 3 {
+		t.Errorf("mentions = %+v", p.Mentions)
+	}
+	if ok, _ := ResolveMentionLookup(db, post.ID, l.Content, nil); ok {
+		t.Error("lookup resolved twice")
+	}
+}
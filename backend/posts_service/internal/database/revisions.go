@@ -0,0 +1,146 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPostNotFound возвращается, если пост удалён или не существовал
+var ErrPostNotFound = errors.New("post not found")
+
+// Revision — версия заголовка и текста поста
+type Revision struct {
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UpdatePost заменяет заголовок и текст поста и сохраняет их как новую ревизию.
+// mentioned — упомянутые в новом тексте пользователи (username → ID) или nil,
+// если их не удалось найти: тогда их найдёт планировщик.
+// Если ничего не изменилось, ревизия не создаётся и updated равен false.
+// Непустой status одновременно меняет статус поста (см. setPostStatus); для
+// опубликованного поста возвращается ErrAlreadyPublished, и правка текста
+// тоже не сохраняется.
+func UpdatePost(db *sql.DB, postID int, title, content string, mentioned map[string]int, status PostStatus, publishAt *time.Time) (updated bool, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка строки упорядочивает одновременные правки и номера ревизий
+	// и не даёт планировщику опубликовать пост посреди правки
+	var oldTitle, oldContent string
+	var oldStatus PostStatus
+	err = tx.QueryRow("SELECT title, content, status FROM posts WHERE id = $1 FOR UPDATE", postID).Scan(&oldTitle, &oldContent, &oldStatus)
+	if err == sql.ErrNoRows {
+		return false, ErrPostNotFound
+	} else if err != nil {
+		return false, fmt.Errorf("failed to lock post: %w", err)
+	}
+	if status != "" && oldStatus == StatusPublished {
+		return false, ErrAlreadyPublished
+	}
+
+	if status != "" {
+		if err := setPostStatus(tx, postID, status, publishAt); err != nil {
+			return false, err
+		}
+	}
+	if oldTitle == title && oldContent == content {
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return false, nil
+	}
+
+	var updatedAt time.Time
+	err = tx.QueryRow(`
+        UPDATE posts SET title = $2, content = $3, updated_at = now()
+        WHERE id = $1
+        RETURNING updated_at
+    `, postID, title, content).Scan(&updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update post: %w", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO post_revisions (post_id, revision, title, content, created_at)
+        SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4
+        FROM post_revisions WHERE post_id = $1
+    `, postID, title, content, updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to save revision: %w", err)
+	}
+
+	if content != oldContent {
+		if _, err := setPostTags(tx, postID, content); err != nil {
+			return false, err
+		}
+		if err := setPostMentions(tx, postID, mentioned); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// FetchRevisions возвращает все ревизии поста, последние сверху
+func FetchRevisions(db *sql.DB, postID int) ([]Revision, error) {
+	rows, err := db.Query(`
+        SELECT revision, title, content, created_at
+        FROM post_revisions
+        WHERE post_id = $1
+        ORDER BY revision DESC
+    `, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var rev Revision
+		if err := rows.Scan(&rev.Revision, &rev.Title, &rev.Content, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return revisions, nil
+}
+
+// FetchRevision возвращает ревизию поста по номеру или nil, если её нет
+func FetchRevision(db *sql.DB, postID, revision int) (*Revision, error) {
+	var rev Revision
+	err := db.QueryRow(`
+        SELECT revision, title, content, created_at
+        FROM post_revisions
+        WHERE post_id = $1 AND revision = $2
+    `, postID, revision).Scan(&rev.Revision, &rev.Title, &rev.Content, &rev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch revision: %w", err)
+	}
+	return &rev, nil
+}
+
+// LatestRevision возвращает номер последней ревизии поста, 0 — если ревизий нет
+func LatestRevision(db *sql.DB, postID int) (int, error) {
+	var n int
+	err := db.QueryRow("SELECT COALESCE(MAX(revision), 0) FROM post_revisions WHERE post_id = $1", postID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch latest revision: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdatePostKeepsRevisions(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "editor")
+	post := newPost(t, db, author, "first version")
+
+	if n, _ := LatestRevision(db, post.ID); n != 1 {
+		t.Fatalf("new post has revision %d, want 1", n)
+	}
+
+	updated, err := UpdatePost(db, post.ID, "title", "second version", nil, "", nil)
+	if err != nil || !updated {
+		t.Fatalf("UpdatePost = %v, %v", updated, err)
+	}
+	// Та же правка ещё раз ревизию не создаёт
+	if updated, _ := UpdatePost(db, post.ID, "title", "second version", nil, "", nil); updated {
+		t.Error("unchanged edit reported as an update")
+	}
+
+	revisions, err := FetchRevisions(db, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Content != "second version" || revisions[1].Content != "first version" {
+		t.Errorf("revisions = %+v", revisions)
+	}
+
+	current, _ := FetchPostByID(db, post.ID, 0)
+	if !current.Edited || current.UpdatedAt == nil {
+		t.Errorf("edited post has no edited marker: %+v", current)
+	}
+
+	if rev, err := FetchRevision(db, post.ID, 7); rev != nil || err != nil {
+		t.Errorf("missing revision = %+v, %v", rev, err)
+	}
+	if _, err := UpdatePost(db, -1, "t", "c", nil, "", nil); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("unknown post: err = %v, want ErrPostNotFound", err)
+	}
+}
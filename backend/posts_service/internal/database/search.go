@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"shared/pagination"
+)
+
+// Разметка совпадений в заголовке и фрагменте. Остальной текст не экранируется:
+// клиент выводит его как текст, выделяя только участки между метками.
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>`
+
+// SearchQuery — параметры полнотекстового поиска по постам
+type SearchQuery struct {
+	Text   string     // запрос в синтаксисе websearch_to_tsquery
+	Author string     // username автора; пустая строка — любой автор
+	From   *time.Time // посты, созданные не раньше From
+	To     *time.Time // посты, созданные раньше To
+	After  *pagination.RankCursor
+	Limit  int
+}
+
+// SearchResult — найденный пост с релевантностью и подсвеченными совпадениями
+type SearchResult struct {
+	Post
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"titleHighlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// SearchPosts ищет посты по тексту запроса в русской и английской конфигурациях,
+// релевантность поста — лучшая из двух. Фрагменты строятся в той конфигурации,
+// что дала лучший ранг. Выбирается q.Limit+1 результатов.
+func SearchPosts(db *sql.DB, viewerID int, q SearchQuery) ([]SearchResult, error) {
+	afterRank, afterID := pagination.RankArgs(q.After)
+
+	// ts_headline дорогая, и PostgreSQL вычисляет её уже после LIMIT —
+	// только для постов страницы
+	rows, err := db.Query(`
+        SELECT `+postColumns+`,
+            m.rank,
+            ts_headline(m.config, posts.title, m.query, 'HighlightAll=true, `+headlineOptions+`'),
+            ts_headline(m.config, posts.content, m.query, 'MaxFragments=2, MaxWords=30, MinWords=10, `+headlineOptions+`')
+        FROM (
+            SELECT posts.id AS post_id,
+                GREATEST(r.ru, r.en) AS rank,
+                CASE WHEN r.ru >= r.en THEN 'russian'::regconfig ELSE 'english'::regconfig END AS config,
+                CASE WHEN r.ru >= r.en THEN websearch_to_tsquery('russian', $2) ELSE websearch_to_tsquery('english', $2) END AS query
+            FROM posts
+            CROSS JOIN LATERAL (
+                SELECT ts_rank(posts.search_ru, websearch_to_tsquery('russian', $2)) AS ru,
+                       ts_rank(posts.search_en, websearch_to_tsquery('english', $2)) AS en
+            ) AS r
+            WHERE (posts.search_ru @@ websearch_to_tsquery('russian', $2)
+                OR posts.search_en @@ websearch_to_tsquery('english', $2))
+              AND posts.status = 'published'
+              AND ($3::timestamptz IS NULL OR posts.created_at >= $3)
+              AND ($4::timestamptz IS NULL OR posts.created_at < $4)
+        ) AS m
+        JOIN posts ON posts.id = m.post_id
+        JOIN users ON posts.author_id = users.id
+        WHERE ($5 = '' OR users.username = $5)
+          AND ($6::real IS NULL OR (m.rank, posts.id) < ($6, $7))
+        ORDER BY m.rank DESC, posts.id DESC
+        LIMIT $8
+    `, viewerID, q.Text, q.From, q.To, q.Author, afterRank, afterID, q.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var res SearchResult
+		if err := scanPost(rows, &res.Post, &res.Rank, &res.TitleHighlight, &res.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search row: %w", err)
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"shared/pagination"
+)
+
+func TestSearchPosts(t *testing.T) {
+	db := openTestDB(t)
+	author, authorName := newUser(t, db, "writer")
+	other, _ := newUser(t, db, "other")
+
+	russian := newPost(t, db, author, "Мы запускаем новые серверы в дата-центре")
+	english := newPost(t, db, author, "Running benchmarks on the new servers")
+	foreign := newPost(t, db, other, "Запуск серверов прошёл спокойно")
+	if _, err := CreatePost(db, "draft", "Черновик про серверы", author, StatusDraft, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(q SearchQuery) []int {
+		t.Helper()
+		if q.Limit == 0 {
+			q.Limit = 10
+		}
+		results, err := SearchPosts(db, 0, q)
+		if err != nil {
+			t.Fatalf("SearchPosts(%+v): %v", q, err)
+		}
+		ids := make([]int, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		return ids
+	}
+	contains := func(ids []int, id int) bool {
+		for _, x := range ids {
+			if x == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Русская морфология: «сервер» находит «серверы» и «серверов»
+	ids := search(SearchQuery{Text: "сервер", Author: authorName})
+	if len(ids) != 1 || ids[0] != russian.ID {
+		t.Errorf("russian stemming by author = %v, want [%d]", ids, russian.ID)
+	}
+	if ids := search(SearchQuery{Text: "сервер"}); !contains(ids, foreign.ID) {
+		t.Errorf("search without author filter missed post %d: %v", foreign.ID, ids)
+	}
+	// Английская морфология: «run» находит «Running»
+	if ids := search(SearchQuery{Text: "run", Author: authorName}); len(ids) != 1 || ids[0] != english.ID {
+		t.Errorf("english stemming = %v, want [%d]", ids, english.ID)
+	}
+	// Исключение слова
+	if ids := search(SearchQuery{Text: "servers -benchmarks", Author: authorName}); contains(ids, english.ID) {
+		t.Errorf("excluded word still matched: %v", ids)
+	}
+	// Диапазон дат, в который посты не попадают
+	past := time.Now().Add(-48 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	if ids := search(SearchQuery{Text: "сервер", From: &past, To: &yesterday}); contains(ids, russian.ID) {
+		t.Errorf("date range ignored: %v", ids)
+	}
+
+	results, _ := SearchPosts(db, 0, SearchQuery{Text: "серверы", Author: authorName, Limit: 10})
+	if len(results) == 0 || !strings.Contains(results[0].Snippet, "<mark>") {
+		t.Errorf("snippet has no highlighted match: %+v", results)
+	}
+
+	// Страницы по релевантности не повторяют результаты
+	first, _ := SearchPosts(db, 0, SearchQuery{Text: "сервер", Limit: 1})
+	if len(first) < 2 {
+		t.Fatalf("first page = %d results, want a next page", len(first))
+	}
+	after := pagination.RankCursor{Rank: first[0].Rank, ID: first[0].ID}
+	second, _ := SearchPosts(db, 0, SearchQuery{Text: "сервер", Limit: 1, After: &after})
+	if len(second) == 0 || second[0].ID == first[0].ID {
+		t.Errorf("second page repeats the first: %v", second)
+	}
+}
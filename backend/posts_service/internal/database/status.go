@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostStatus — стадия публикации поста
+type PostStatus string
+
+const (
+	// StatusDraft — черновик, виден только автору
+	StatusDraft PostStatus = "draft"
+	// StatusScheduled — пост будет опубликован планировщиком в момент publish_at
+	StatusScheduled PostStatus = "scheduled"
+	// StatusPublished — пост виден всем
+	StatusPublished PostStatus = "published"
+)
+
+// Valid сообщает, известен ли статус
+func (s PostStatus) Valid() bool {
+	switch s {
+	case StatusDraft, StatusScheduled, StatusPublished:
+		return true
+	}
+	return false
+}
+
+// ErrAlreadyPublished возвращается при попытке сменить статус опубликованного
+// поста: он уже разошёлся по лентам, и на него могли ответить
+var ErrAlreadyPublished = errors.New("post is already published")
+
+// setPostStatus переводит заблокированный в tx неопубликованный пост в статус
+// status. publishAt задаётся только для StatusScheduled. При публикации
+// created_at становится временем публикации, чтобы пост попал в ленты как новый.
+func setPostStatus(tx *sql.Tx, postID int, status PostStatus, publishAt *time.Time) error {
+	_, err := tx.Exec(`
+        UPDATE posts
+        SET status = $2,
+            publish_at = $3,
+            created_at = CASE WHEN $2 = 'published' THEN now() ELSE created_at END
+        WHERE id = $1
+    `, postID, status, publishAt)
+	if err != nil {
+		return fmt.Errorf("failed to update post status: %w", err)
+	}
+	return nil
+}
+
+// PublishDuePosts публикует до limit отложенных постов, время которых
+// наступило, и возвращает их ID. SKIP LOCKED позволяет нескольким репликам
+// запускать планировщик одновременно: каждый пост публикует ровно одна.
+func PublishDuePosts(db *sql.DB, limit int) ([]int, error) {
+	rows, err := db.Query(`
+        UPDATE posts
+        SET status = 'published', publish_at = NULL, created_at = now()
+        WHERE id IN (
+            SELECT id FROM posts
+            WHERE status = 'scheduled' AND publish_at <= now()
+            ORDER BY publish_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        ) AND status = 'scheduled'
+        RETURNING id
+    `, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish scheduled posts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan published post: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return ids, nil
+}
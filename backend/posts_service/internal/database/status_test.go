@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newScheduledPost создаёт отложенный пост, время публикации которого уже наступило
+func newScheduledPost(t *testing.T, db *sql.DB, authorID int) *Post {
+	t.Helper()
+	publishAt := time.Now().Add(time.Hour)
+	post, err := CreatePost(db, "later", "scheduled", authorID, StatusScheduled, &publishAt, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	db.Exec("UPDATE posts SET publish_at = now() - interval '1 second' WHERE id = $1", post.ID)
+	return post
+}
+
+func TestPostStatusValid(t *testing.T) {
+	for _, s := range []PostStatus{StatusDraft, StatusScheduled, StatusPublished} {
+		if !s.Valid() {
+			t.Errorf("%q is not valid", s)
+		}
+	}
+	for _, s := range []PostStatus{"", "deleted", "Published"} {
+		if s.Valid() {
+			t.Errorf("%q is valid", s)
+		}
+	}
+}
+
+func TestUnpublishedPostsVisibleOnlyToAuthor(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	reader, _ := newUser(t, db, "reader")
+	draft, err := CreatePost(db, "draft", "draft", author, StatusDraft, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+
+	if p, _ := FetchPostByID(db, draft.ID, author); p == nil || p.Status != StatusDraft {
+		t.Errorf("author sees %+v, want the draft", p)
+	}
+	if p, _ := FetchPostByID(db, draft.ID, reader); p != nil {
+		t.Error("draft is visible to another user")
+	}
+
+	// Черновик публикуется правкой, после чего статус больше не меняется
+	if _, err := UpdatePost(db, draft.ID, "draft", "draft", nil, StatusPublished, nil); err != nil {
+		t.Fatalf("publish draft: %v", err)
+	}
+	if p, _ := FetchPostByID(db, draft.ID, reader); p == nil {
+		t.Error("published post is not visible")
+	}
+	if _, err := UpdatePost(db, draft.ID, "draft", "draft", nil, StatusDraft, nil); !errors.Is(err, ErrAlreadyPublished) {
+		t.Errorf("unpublish: err = %v, want ErrAlreadyPublished", err)
+	}
+}
+
+func TestPublishDuePosts(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	due := newScheduledPost(t, db, author)
+	publishAt := time.Now().Add(time.Hour)
+	future, err := CreatePost(db, "future", "future", author, StatusScheduled, &publishAt, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+
+	ids, err := PublishDuePosts(db, 1000)
+	if err != nil {
+		t.Fatalf("PublishDuePosts: %v", err)
+	}
+	if !containsID(ids, due.ID) || containsID(ids, future.ID) {
+		t.Errorf("published %v, want %d and not %d", ids, due.ID, future.ID)
+	}
+	p, _ := FetchPostByID(db, due.ID, 0)
+	if p == nil || p.Status != StatusPublished || p.PublishAt != nil {
+		t.Errorf("published post = %+v", p)
+	}
+	if p, _ := FetchPostByID(db, future.ID, 0); p != nil {
+		t.Error("future post was published early")
+	}
+}
+
+func TestPublishDuePostsConcurrently(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	want := make([]int, 20)
+	for i := range want {
+		want[i] = newScheduledPost(t, db, author).ID
+	}
+
+	// Несколько реплик забирают посты одновременно: каждый пост публикуется один раз
+	var mu sync.Mutex
+	var got []int
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				ids, err := PublishDuePosts(db, 3)
+				if err != nil {
+					t.Errorf("PublishDuePosts: %v", err)
+					return
+				}
+				if len(ids) == 0 {
+					return
+				}
+				mu.Lock()
+				got = append(got, ids...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[int]int)
+	for _, id := range got {
+		seen[id]++
+	}
+	for _, id := range want {
+		if seen[id] != 1 {
+			t.Errorf("post %d published %d times", id, seen[id])
+		}
+	}
+}
+
+func containsID(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// UserExists сообщает, есть ли пользователь с таким ID
+func UserExists(db *sql.DB, userID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+	return exists, nil
+}
+
+// Subscribe подписывает subscriberID на посты authorID. created равен false,
+// если подписка уже была.
+func Subscribe(db *sql.DB, subscriberID, authorID int) (created bool, err error) {
+	res, err := db.Exec(`
+		INSERT INTO subscriptions (subscriber_id, author_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, subscriberID, authorID)
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Unsubscribe отменяет подписку. removed равен false, если подписки не было.
+func Unsubscribe(db *sql.DB, subscriberID, authorID int) (removed bool, err error) {
+	res, err := db.Exec("DELETE FROM subscriptions WHERE subscriber_id = $1 AND author_id = $2", subscriberID, authorID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// IsSubscribed сообщает, подписан ли subscriberID на authorID
+func IsSubscribed(db *sql.DB, subscriberID, authorID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM subscriptions WHERE subscriber_id = $1 AND author_id = $2)",
+		subscriberID, authorID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check subscription: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"posts_service/internal/hashtags"
+	"shared/pagination"
+
+	"github.com/lib/pq"
+)
+
+// TrendingTag — тег с весом за окно времени
+type TrendingTag struct {
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+	Posts int     `json:"posts"`
+}
+
+// setPostTags заменяет теги поста хэштегами из content и возвращает их
+func setPostTags(tx *sql.Tx, postID int, content string) ([]string, error) {
+	tags := hashtags.Extract(content)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	// Отдельные выражения, а не один запрос с CTE: следующее выражение видит
+	// теги, которые параллельная транзакция добавила и зафиксировала, пока
+	// INSERT ждал её на конфликте
+	if len(tags) > 0 {
+		_, err := tx.Exec("INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", pq.Array(tags))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save tags: %w", err)
+		}
+	}
+	_, err := tx.Exec(`
+        DELETE FROM post_tags
+        WHERE post_id = $1
+          AND tag_id NOT IN (SELECT id FROM tags WHERE name = ANY($2))
+    `, postID, pq.Array(tags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove post tags: %w", err)
+	}
+	_, err = tx.Exec(`
+        INSERT INTO post_tags (post_id, tag_id)
+        SELECT $1, id FROM tags WHERE name = ANY($2)
+        ON CONFLICT DO NOTHING
+    `, postID, pq.Array(tags))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save post tags: %w", err)
+	}
+	return tags, nil
+}
+
+// FetchTagPosts возвращает страницу опубликованных постов с тегом tag, новые
+// сверху. tag должен быть нормализован. Выбирается page.Limit+1 постов.
+func FetchTagPosts(db *sql.DB, viewerID int, tag string, page pagination.Request) ([]Post, error) {
+	after, afterID := page.Args()
+	rows, err := db.Query(`
+        SELECT `+postColumns+`
+        FROM tags
+        JOIN post_tags ON post_tags.tag_id = tags.id
+        JOIN posts ON posts.id = post_tags.post_id
+        JOIN users ON posts.author_id = users.id
+        WHERE tags.name = $2 AND posts.status = 'published'
+          AND ($3::timestamptz IS NULL OR (posts.created_at, posts.id) < ($3, $4))
+        ORDER BY posts.created_at DESC, posts.id DESC
+        LIMIT $5
+    `, viewerID, tag, after, afterID, page.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tag posts: %w", err)
+	}
+	return scanPosts(rows)
+}
+
+// TrendingTags возвращает limit тегов с наибольшим весом среди постов,
+// опубликованных за последние window. Каждый пост добавляет тегу вес,
+// который вдвое уменьшается за каждые halfLife с момента публикации, так что
+// свежие обсуждения поднимаются выше долгих, но затихших.
+func TrendingTags(db *sql.DB, window, halfLife time.Duration, limit int) ([]TrendingTag, error) {
+	rows, err := db.Query(`
+        SELECT tags.name,
+               sum(power(0.5, extract(epoch FROM now() - posts.created_at)::float8 / $2::float8)) AS score,
+               count(*)
+        FROM posts
+        JOIN post_tags ON post_tags.post_id = posts.id
+        JOIN tags ON tags.id = post_tags.tag_id
+        WHERE posts.status = 'published'
+          AND posts.created_at > now() - $1::float8 * interval '1 second'
+        GROUP BY tags.name
+        ORDER BY score DESC, tags.name
+        LIMIT $3
+    `, window.Seconds(), halfLife.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trending tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []TrendingTag{}
+	for rows.Next() {
+		var t TrendingTag
+		if err := rows.Scan(&t.Name, &t.Score, &t.Posts); err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return tags, nil
+}
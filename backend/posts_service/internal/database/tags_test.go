@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"shared/pagination"
+)
+
+// uniqueTag возвращает тег, которого нет в постах других прогонов
+func uniqueTag(prefix string) string {
+	return fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+}
+
+func TestPostTagsFollowContent(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	keep, drop, add := uniqueTag("keep"), uniqueTag("drop"), uniqueTag("add")
+
+	post := newPost(t, db, author, fmt.Sprintf("#%s #%s", keep, drop))
+	if want := []string{keep, drop}; !reflect.DeepEqual(post.Tags, want) {
+		t.Errorf("created post tags = %v, want %v", post.Tags, want)
+	}
+
+	if _, err := UpdatePost(db, post.ID, "title", fmt.Sprintf("#%s #%s", keep, add), nil, "", nil); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	p, _ := FetchPostByID(db, post.ID, 0)
+	if want := []string{add, keep}; !reflect.DeepEqual(p.Tags, want) {
+		t.Errorf("edited post tags = %v, want %v", p.Tags, want)
+	}
+
+	posts, err := FetchTagPosts(db, 0, drop, pagination.Request{Limit: 10})
+	if err != nil {
+		t.Fatalf("FetchTagPosts: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("removed tag still lists %v", postIDs(posts))
+	}
+}
+
+func TestFetchTagPosts(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	tag := uniqueTag("paged")
+
+	var want []int
+	for i := 0; i < 3; i++ {
+		want = append([]int{newPost(t, db, author, fmt.Sprintf("post %d #%s", i, tag)).ID}, want...)
+	}
+	if _, err := CreatePost(db, "draft", "#"+tag, author, StatusDraft, nil, nil); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+
+	first, err := FetchTagPosts(db, author, tag, pagination.Request{Limit: 2})
+	if err != nil {
+		t.Fatalf("FetchTagPosts: %v", err)
+	}
+	// Выбирается на один пост больше страницы, черновик не попадает даже к автору
+	if got := postIDs(first); !reflect.DeepEqual(got, want) {
+		t.Fatalf("first page = %v, want %v", got, want)
+	}
+	last := first[1]
+	rest, err := FetchTagPosts(db, author, tag, pagination.Request{Limit: 2, After: &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+	if err != nil {
+		t.Fatalf("FetchTagPosts after cursor: %v", err)
+	}
+	if got := postIDs(rest); !reflect.DeepEqual(got, want[2:]) {
+		t.Errorf("second page = %v, want %v", got, want[2:])
+	}
+}
+
+func TestTrendingTagsDecay(t *testing.T) {
+	db := openTestDB(t)
+	author, _ := newUser(t, db, "author")
+	fresh, stale, old := uniqueTag("fresh"), uniqueTag("stale"), uniqueTag("old")
+
+	newPost(t, db, author, "#"+fresh)
+	// Два поста суточной давности весят меньше одного свежего при полураспаде 6h
+	for i := 0; i < 2; i++ {
+		p := newPost(t, db, author, "#"+stale)
+		db.Exec("UPDATE posts SET created_at = now() - interval '24 hours' WHERE id = $1", p.ID)
+	}
+	p := newPost(t, db, author, "#"+old)
+	db.Exec("UPDATE posts SET created_at = now() - interval '4 days' WHERE id = $1", p.ID)
+
+	tags, err := TrendingTags(db, 72*time.Hour, 6*time.Hour, 1000)
+	if err != nil {
+		t.Fatalf("TrendingTags: %v", err)
+	}
+	rank := make(map[string]int)
+	for i, tag := range tags {
+		rank[tag.Name] = i + 1
+		if tag.Name == stale && tag.Posts != 2 {
+			t.Errorf("%s counted %d posts, want 2", stale, tag.Posts)
+		}
+	}
+	if rank[fresh] == 0 || rank[stale] == 0 || rank[fresh] > rank[stale] {
+		t.Errorf("rank of fresh tag %d, of stale tag %d", rank[fresh], rank[stale])
+	}
+	if rank[old] != 0 {
+		t.Errorf("tag outside the window is trending at %d", rank[old])
+	}
+}
@@ -0,0 +1,90 @@
+// Package feed строит персональную ленту. Стратегия выбирается переменной
+// FEED_STRATEGY, чтобы сравнить обе под нагрузкой:
+//
+//   - read (по умолчанию) — fan-out-on-read: лента собирается запросом по
+//     подпискам при каждом чтении, запись поста ничего не стоит;
+//   - write — fan-out-on-write: пост при публикации копируется в таблицу
+//     timelines каждого подписчика, чтение — простой проход по индексу.
+package feed
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+
+	"posts_service/internal/database"
+	"shared/pagination"
+)
+
+// Strategy — способ построения ленты. Методы On* вызываются после изменения
+// постов и подписок, чтобы стратегия поддерживала свои данные.
+type Strategy interface {
+	Name() string
+	Page(userID int, page pagination.Request) ([]database.Post, error)
+	OnPostCreated(post *database.Post) error
+	OnSubscribed(subscriberID, authorID int) error
+	OnUnsubscribed(subscriberID, authorID int) error
+}
+
+// NewFromEnv создаёт стратегию по FEED_STRATEGY. Для write при
+// FEED_REBUILD_ON_START=true ленты заполняются по текущим подпискам при старте.
+func NewFromEnv(db *sql.DB) (Strategy, error) {
+	switch kind := os.Getenv("FEED_STRATEGY"); kind {
+	case "", "read":
+		return &fanOutOnRead{db: db}, nil
+	case "write":
+		if v := os.Getenv("FEED_REBUILD_ON_START"); v != "" {
+			rebuild, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FEED_REBUILD_ON_START: %w", err)
+			}
+			if rebuild {
+				if err := database.RebuildTimelines(db); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return &fanOutOnWrite{db: db}, nil
+	default:
+		return nil, fmt.Errorf("unknown FEED_STRATEGY %q", kind)
+	}
+}
+
+type fanOutOnRead struct {
+	db *sql.DB
+}
+
+func (s *fanOutOnRead) Name() string { return "read" }
+
+func (s *fanOutOnRead) Page(userID int, page pagination.Request) ([]database.Post, error) {
+	return database.FetchFeed(s.db, userID, page)
+}
+
+func (s *fanOutOnRead) OnPostCreated(*database.Post) error { return nil }
+func (s *fanOutOnRead) OnSubscribed(int, int) error        { return nil }
+func (s *fanOutOnRead) OnUnsubscribed(int, int) error      { return nil }
+
+type fanOutOnWrite struct {
+	db *sql.DB
+}
+
+func (s *fanOutOnWrite) Name() string { return "write" }
+
+func (s *fanOutOnWrite) Page(userID int, page pagination.Request) ([]database.Post, error) {
+	return database.FetchTimeline(s.db, userID, page)
+}
+
+func (s *fanOutOnWrite) OnPostCreated(post *database.Post) error {
+	return database.FanOutPost(s.db, post)
+}
+
+// OnSubscribed переносит в ленту недавние посты автора, иначе они появились
+// бы только с его следующей публикацией
+func (s *fanOutOnWrite) OnSubscribed(subscriberID, authorID int) error {
+	return database.BackfillTimeline(s.db, subscriberID, authorID)
+}
+
+func (s *fanOutOnWrite) OnUnsubscribed(subscriberID, authorID int) error {
+	return database.PruneTimeline(s.db, subscriberID, authorID)
+}
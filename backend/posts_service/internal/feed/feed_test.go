@@ -0,0 +1,41 @@
+package feed
+
+import "testing"
+
+func TestNewFromEnv(t *testing.T) {
+	for env, want := range map[string]string{"": "read", "read": "read", "write": "write"} {
+		t.Setenv("FEED_STRATEGY", env)
+		s, err := NewFromEnv(nil)
+		if err != nil {
+			t.Fatalf("FEED_STRATEGY=%q: %v", env, err)
+		}
+		if s.Name() != want {
+			t.Errorf("FEED_STRATEGY=%q: strategy %q, want %q", env, s.Name(), want)
+		}
+	}
+
+	t.Setenv("FEED_STRATEGY", "push")
+	if _, err := NewFromEnv(nil); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+
+	t.Setenv("FEED_STRATEGY", "write")
+	t.Setenv("FEED_REBUILD_ON_START", "maybe")
+	if _, err := NewFromEnv(nil); err == nil {
+		t.Error("invalid FEED_REBUILD_ON_START accepted")
+	}
+}
+
+func TestFanOutOnReadKeepsNoState(t *testing.T) {
+	// Стратегия чтения не трогает базу при изменениях, поэтому db не нужна
+	s := &fanOutOnRead{}
+	if err := s.OnPostCreated(nil); err != nil {
+		t.Error(err)
+	}
+	if err := s.OnSubscribed(1, 2); err != nil {
+		t.Error(err)
+	}
+	if err := s.OnUnsubscribed(1, 2); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"posts_service/internal/database"
+	"posts_service/internal/middlewares"
+	"shared/authz"
+	"shared/pagination"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxCommentLength — наибольшая длина комментария в символах
+	maxCommentLength = 2000
+	// commentEditWindow — время после публикации, в течение которого автор
+	// может исправить комментарий
+	commentEditWindow = 15 * time.Minute
+)
+
+// commentResponse — комментарий со сроком, до которого его можно править
+type commentResponse struct {
+	database.Comment
+	EditableUntil time.Time `json:"editableUntil"`
+}
+
+func newCommentResponse(c database.Comment) commentResponse {
+	return commentResponse{Comment: c, EditableUntil: c.CreatedAt.Add(commentEditWindow)}
+}
+
+// commentContent проверяет текст комментария и возвращает его без крайних
+// пробелов; текст ошибки пригоден для ответа клиенту
+func commentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", errors.New("Comment cannot be empty")
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		return "", errors.New("Comment is too long")
+	}
+	return content, nil
+}
+
+// postComment находит комментарий из пути запроса и проверяет, что он относится
+// к посту из того же пути. При ошибке ответ уже отправлен и возвращается nil.
+func postComment(db *sql.DB, logger *logrus.Logger, w http.ResponseWriter, r *http.Request) *database.Comment {
+	vars := mux.Vars(r)
+	postID, err := atoiParam(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return nil
+	}
+	commentID, err := atoiParam(vars["commentId"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return nil
+	}
+
+	comment, err := database.FetchComment(db, commentID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to fetch comment")
+		http.Error(w, "Failed to fetch comment", http.StatusInternalServerError)
+		return nil
+	}
+	if comment == nil || comment.PostID != postID {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return nil
+	}
+	return comment
+}
+
+// CreateCommentRequest — новый комментарий; ParentID задаётся для ответа
+type CreateCommentRequest struct {
+	Content  string `json:"content"`
+	ParentID *int   `json:"parentId"`
+}
+
+// CreateComment добавляет комментарий или ответ к посту и уведомляет автора поста
+func CreateComment(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		postID, err := atoiParam(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid post ID", http.StatusBadRequest)
+			return
+		}
+
+		var req CreateCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		content, err := commentContent(req.Content)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ownerID, err := database.GetPostOwner(db, postID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to retrieve post owner")
+			http.Error(w, "Failed to retrieve post owner", http.StatusInternalServerError)
+			return
+		}
+		if ownerID == 0 {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+
+		comment, err := database.CreateComment(db, postID, req.ParentID, userID, content)
+		if errors.Is(err, database.ErrPostNotFound) {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		} else if errors.Is(err, database.ErrCommentNotFound) {
+			http.Error(w, "Parent comment not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Failed to create comment")
+			http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"comment_id": comment.ID,
+			"post_id":    postID,
+			"author_id":  userID,
+		}).Info("Comment created")
+
+		notifyNewComment(logger, requestToken(r), ownerID, comment)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(newCommentResponse(*comment)); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// FetchComments возвращает страницу комментариев верхнего уровня, новые сверху,
+// или, если задан parentId, страницу ответов на комментарий в порядке написания
+func FetchComments(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		postID, err := atoiParam(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid post ID", http.StatusBadRequest)
+			return
+		}
+
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+
+		var comments []database.Comment
+		if v := r.URL.Query().Get("parentId"); v != "" {
+			parentID, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "Invalid parent ID", http.StatusBadRequest)
+				return
+			}
+			parent, err := database.FetchComment(db, parentID)
+			if err != nil {
+				logger.WithError(err).Error("Failed to fetch comment")
+				http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+				return
+			}
+			if parent == nil || parent.PostID != postID {
+				http.Error(w, "Comment not found", http.StatusNotFound)
+				return
+			}
+			comments, err = database.FetchReplies(db, parentID, page)
+		} else {
+			comments, err = database.FetchComments(db, postID, page)
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to fetch comments")
+			http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+			return
+		}
+
+		items := make([]commentResponse, len(comments))
+		for i, c := range comments {
+			items[i] = newCommentResponse(c)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pagination.NewPage(items, page.Limit, func(c commentResponse) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+		})); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// UpdateCommentRequest — новый текст комментария
+type UpdateCommentRequest struct {
+	Content string `json:"content"`
+}
+
+// UpdateComment изменяет текст комментария. Править комментарий может только
+// автор и только в течение commentEditWindow после публикации.
+func UpdateComment(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		comment := postComment(db, logger, w, r)
+		if comment == nil {
+			return
+		}
+		if comment.AuthorID != userID {
+			http.Error(w, "You are not authorized to edit this comment", http.StatusForbidden)
+			return
+		}
+
+		var req UpdateCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		content, err := commentContent(req.Content)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Окно проверяется в самом UPDATE, чтобы не зависеть от часов сервиса
+		updated, err := database.UpdateComment(db, comment.ID, content, commentEditWindow)
+		if err != nil {
+			logger.WithError(err).Error("Failed to update comment")
+			http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+			return
+		}
+		if !updated {
+			http.Error(w, "Comment can no longer be edited", http.StatusForbidden)
+			return
+		}
+
+		if comment, err = database.FetchComment(db, comment.ID); err != nil || comment == nil {
+			logger.WithError(err).Error("Failed to fetch updated comment")
+			http.Error(w, "Failed to fetch comment", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(newCommentResponse(*comment)); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// DeleteComment удаляет комментарий вместе с ответами на него. Удалить
+// комментарий может его автор, автор поста или модератор.
+func DeleteComment(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		comment := postComment(db, logger, w, r)
+		if comment == nil {
+			return
+		}
+
+		ownerID, err := database.GetPostOwner(db, comment.PostID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to retrieve post owner")
+			http.Error(w, "Failed to retrieve post owner", http.StatusInternalServerError)
+			return
+		}
+
+		if comment.AuthorID != userID && ownerID != userID && !authz.Can(r.Context(), authz.PostsDeleteAny) {
+			logger.WithFields(logrus.Fields{
+				"comment_id": comment.ID,
+				"author_id":  comment.AuthorID,
+				"user_id":    userID,
+			}).Warn("Unauthorized comment deletion attempt")
+			http.Error(w, "You are not authorized to delete this comment", http.StatusForbidden)
+			return
+		}
+
+		deleted, err := database.DeleteComment(db, comment.ID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to delete comment")
+			http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"comment_id": comment.ID,
+			"deleted":    len(deleted),
+			"user_id":    userID,
+		}).Info("Comment deleted")
+
+		retractCommentNotifications(logger, deleted)
+
+		response := map[string]string{"message": "Comment deleted successfully"}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
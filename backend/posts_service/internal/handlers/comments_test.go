@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestCommentContent(t *testing.T) {
+	if got, err := commentContent("  nice post \n"); err != nil || got != "nice post" {
+		t.Errorf("commentContent trims to %q, %v", got, err)
+	}
+	// Длина считается в символах, а не в байтах
+	if _, err := commentContent(strings.Repeat("ж", maxCommentLength)); err != nil {
+		t.Errorf("comment of exactly the limit rejected: %v", err)
+	}
+	for _, bad := range []string{"", " \t\n", strings.Repeat("a", maxCommentLength+1)} {
+		if _, err := commentContent(bad); err == nil {
+			t.Errorf("commentContent accepted %d characters", len(bad))
+		}
+	}
+}
+
+func TestNotifyNewComment(t *testing.T) {
+	sent := fakeNotifications(t, http.StatusCreated)
+
+	notifyNewComment(quietLogger(), "user-token", 4, &database.Comment{ID: 9, PostID: 2, AuthorID: 7})
+	n := <-sent
+	if n.method != http.MethodPost || n.path != "/notifications" || n.token != "Bearer user-token" {
+		t.Errorf("request = %s %s with %q", n.method, n.path, n.token)
+	}
+	if n.body["userId"] != 4.0 || n.body["likerId"] != 7.0 || n.body["commentId"] != 9.0 || n.body["type"] != "comment" {
+		t.Errorf("payload = %v", n.body)
+	}
+
+	// Свой комментарий автору поста не приходит
+	notifyNewComment(quietLogger(), "user-token", 7, &database.Comment{ID: 10, PostID: 2, AuthorID: 7})
+	select {
+	case n := <-sent:
+		t.Errorf("notification about an own comment: %v", n.body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRetractCommentNotificationsUsesServiceToken(t *testing.T) {
+	sent := fakeNotifications(t, http.StatusNoContent)
+	UseServiceTokens(staticTokens("svc"))
+	defer UseServiceTokens(nil)
+
+	retractCommentNotifications(quietLogger(), []database.DeletedComment{{ID: 3}, {ID: 5}})
+	n := <-sent
+	if n.method != http.MethodDelete || n.path != "/notifications/comments" || n.token != "Bearer svc" {
+		t.Errorf("request = %s %s with %q", n.method, n.path, n.token)
+	}
+	if ids, _ := n.body["commentIds"].([]interface{}); len(ids) != 2 {
+		t.Errorf("payload = %v", n.body)
+	}
+}
+
+// commentRequest собирает запрос к /posts/{id}/comments/{commentId}
+func commentRequest(method string, postID, commentID, userID int, body interface{}) *http.Request {
+	r := postRequest(method, "/", postID, userID, body)
+	vars := mux.Vars(r)
+	if commentID != 0 {
+		vars["commentId"] = strconv.Itoa(commentID)
+	}
+	return mux.SetURLVars(r, vars)
+}
+
+func TestCommentLifecycle(t *testing.T) {
+	db := openTestDB(t)
+	fakeNotifications(t, http.StatusCreated)
+	UseServiceTokens(staticTokens("svc"))
+	defer UseServiceTokens(nil)
+
+	owner := newUser(t, db, "owner")
+	commenter := newUser(t, db, "commenter")
+	stranger := newUser(t, db, "stranger")
+	post := newPost(t, db, owner, "post", "body")
+
+	create := func(userID int, body CreateCommentRequest) (*httptest.ResponseRecorder, int) {
+		rec := httptest.NewRecorder()
+		CreateComment(db)(rec, postRequest(http.MethodPost, "/", post.ID, userID, body))
+		var c database.Comment
+		json.NewDecoder(rec.Body).Decode(&c)
+		return rec, c.ID
+	}
+
+	rec, root := create(commenter, CreateCommentRequest{Content: "first"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+	}
+	_, reply := create(owner, CreateCommentRequest{Content: "reply", ParentID: &root})
+	// Ответ на ответ вкладывается в тот же комментарий верхнего уровня
+	_, nested := create(commenter, CreateCommentRequest{Content: "reply to reply", ParentID: &reply})
+	if c, _ := database.FetchComment(db, nested); c == nil || c.ParentID == nil || *c.ParentID != root {
+		t.Errorf("nested reply = %+v, want parent %d", c, root)
+	}
+	missing := root + 1000000
+	if rec, _ := create(commenter, CreateCommentRequest{Content: "x", ParentID: &missing}); rec.Code != http.StatusNotFound {
+		t.Errorf("reply to a missing comment: status %d, want 404", rec.Code)
+	}
+
+	// Править можно только своё и только в течение окна
+	rec = httptest.NewRecorder()
+	UpdateComment(db)(rec, commentRequest(http.MethodPatch, post.ID, root, owner, UpdateCommentRequest{Content: "edited"}))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("edit by the post owner: status %d, want 403", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	UpdateComment(db)(rec, commentRequest(http.MethodPatch, post.ID, root, commenter, UpdateCommentRequest{Content: "edited"}))
+	if rec.Code != http.StatusOK {
+		t.Errorf("edit within the window: status %d", rec.Code)
+	}
+	db.Exec("UPDATE comments SET created_at = now() - interval '1 hour' WHERE id = $1", root)
+	rec = httptest.NewRecorder()
+	UpdateComment(db)(rec, commentRequest(http.MethodPatch, post.ID, root, commenter, UpdateCommentRequest{Content: "too late"}))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("edit after the window: status %d, want 403", rec.Code)
+	}
+
+	if p, _ := database.FetchPostByID(db, post.ID, 0); p.CommentsCount != 3 {
+		t.Errorf("comments count = %d, want 3", p.CommentsCount)
+	}
+
+	rec = httptest.NewRecorder()
+	DeleteComment(db)(rec, commentRequest(http.MethodDelete, post.ID, root, stranger, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("delete by a stranger: status %d, want 403", rec.Code)
+	}
+	// Автор поста удаляет чужой комментарий вместе с ответами
+	rec = httptest.NewRecorder()
+	DeleteComment(db)(rec, commentRequest(http.MethodDelete, post.ID, root, owner, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete by the post owner: status %d", rec.Code)
+	}
+	if p, _ := database.FetchPostByID(db, post.ID, 0); p.CommentsCount != 0 {
+		t.Errorf("comments count after delete = %d, want 0", p.CommentsCount)
+	}
+}
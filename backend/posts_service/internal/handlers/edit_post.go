@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"posts_service/internal/database"
+	"posts_service/internal/feed"
+	"posts_service/internal/middlewares"
+	"posts_service/internal/textdiff"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdatePostRequest — изменяемые поля поста; отсутствующее поле не меняется.
+// Status переводит черновик или отложенный пост в другой статус.
+type UpdatePostRequest struct {
+	Title     *string    `json:"title"`
+	Content   *string    `json:"content"`
+	Status    *string    `json:"status"`
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+// UpdatePost изменяет заголовок, текст и статус поста. Править пост может
+// только автор; прежние версии остаются в истории ревизий. Опубликованный
+// этим запросом пост передаётся стратегии ленты. Пользователи, упомянутые в
+// опубликованном посте, получают уведомление один раз, даже если пост правят.
+func UpdatePost(db *sql.DB, strategy feed.Strategy) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		postID, err := atoiParam(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid post ID", http.StatusBadRequest)
+			return
+		}
+
+		var req UpdatePostRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Title == nil && req.Content == nil && req.Status == nil {
+			http.Error(w, "Nothing to update", http.StatusBadRequest)
+			return
+		}
+		if (req.Title != nil && strings.TrimSpace(*req.Title) == "") ||
+			(req.Content != nil && strings.TrimSpace(*req.Content) == "") {
+			http.Error(w, "Title and content cannot be empty", http.StatusBadRequest)
+			return
+		}
+		var status database.PostStatus
+		var publishAt *time.Time
+		if req.Status != nil {
+			if status, publishAt, err = postStatus(*req.Status, req.PublishAt); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		post, err := database.FetchPostByID(db, postID, userID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to fetch post")
+			http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
+			return
+		}
+		if post == nil {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		// В отличие от удаления, модераторы чужие посты не правят: текст остаётся авторским
+		if post.AuthorID != userID {
+			logger.WithFields(logrus.Fields{
+				"post_id":  postID,
+				"owner_id": post.AuthorID,
+				"user_id":  userID,
+			}).Warn("Unauthorized edit attempt")
+			http.Error(w, "You are not authorized to edit this post", http.StatusForbidden)
+			return
+		}
+
+		// Статус опубликованного поста не меняется. Это быстрая проверка до
+		// запроса к Users Service; окончательная — в транзакции UpdatePost
+		if status != "" && post.Status == database.StatusPublished {
+			http.Error(w, "Post is already published", http.StatusConflict)
+			return
+		}
+
+		title, content := post.Title, post.Content
+		if req.Title != nil {
+			title = *req.Title
+		}
+		// Упоминания разрешаются заново при каждом изменении текста
+		var mentioned map[string]int
+		if req.Content != nil {
+			content = *req.Content
+			mentioned = resolveMentionsOrDefer(logger, content)
+		}
+
+		// Текст и статус меняются одной транзакцией: при конфликте не
+		// сохраняется ни то, ни другое
+		updated, err := database.UpdatePost(db, postID, title, content, mentioned, status, publishAt)
+		if errors.Is(err, database.ErrPostNotFound) {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		} else if errors.Is(err, database.ErrAlreadyPublished) {
+			// Планировщик успел опубликовать пост между проверкой и обновлением
+			http.Error(w, "Post is already published", http.StatusConflict)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Failed to update post")
+			http.Error(w, "Failed to update post", http.StatusInternalServerError)
+			return
+		}
+		if updated {
+			logger.WithField("post_id", postID).Info("Post edited")
+		}
+		if status != "" {
+			logger.WithFields(logrus.Fields{
+				"post_id": postID,
+				"status":  status,
+			}).Info("Post status changed")
+		}
+
+		if updated || status != "" {
+			if post, err = database.FetchPostByID(db, postID, userID); err != nil || post == nil {
+				logger.WithError(err).Error("Failed to fetch updated post")
+				http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Пост уже сохранён: сбой обновления лент и уведомлений только логируется
+		if status == database.StatusPublished {
+			if err := strategy.OnPostCreated(post); err != nil {
+				logger.WithError(err).WithField("strategy", strategy.Name()).Error("Failed to add post to feeds")
+			}
+		}
+		// Уведомление получают только новые упомянутые пользователи
+		if post.Status == database.StatusPublished {
+			notifyMentions(db, logger, post)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(post); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// visiblePost проверяет, что пользователь видит пост: историю черновика
+// и отложенного поста видит только автор. Иначе отправляется 404.
+func visiblePost(db *sql.DB, logger *logrus.Logger, w http.ResponseWriter, r *http.Request, postID int) bool {
+	viewerID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+	post, err := database.FetchPostByID(db, postID, viewerID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to fetch post")
+		http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
+		return false
+	}
+	if post == nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// ListRevisions возвращает историю правок поста, последние ревизии сверху
+func ListRevisions(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		postID, err := atoiParam(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid post ID", http.StatusBadRequest)
+			return
+		}
+
+		if !visiblePost(db, logger, w, r, postID) {
+			return
+		}
+
+		revisions, err := database.FetchRevisions(db, postID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to fetch revisions")
+			http.Error(w, "Failed to fetch revisions", http.StatusInternalServerError)
+			return
+		}
+		if len(revisions) == 0 {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(revisions); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// revisionDiff — пословное сравнение двух ревизий
+type revisionDiff struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Title   []textdiff.Chunk `json:"title"`
+	Content []textdiff.Chunk `json:"content"`
+}
+
+// DiffRevisions сравнивает ревизии from и to. По умолчанию to — последняя
+// ревизия, from — предыдущая перед to.
+func DiffRevisions(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		postID, err := atoiParam(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid post ID", http.StatusBadRequest)
+			return
+		}
+
+		if !visiblePost(db, logger, w, r, postID) {
+			return
+		}
+
+		params := r.URL.Query()
+		to, from := 0, 0
+		if v := params.Get("to"); v != "" {
+			if to, err = strconv.Atoi(v); err != nil || to <= 0 {
+				http.Error(w, "Invalid to revision", http.StatusBadRequest)
+				return
+			}
+		} else {
+			if to, err = database.LatestRevision(db, postID); err != nil {
+				logger.WithError(err).Error("Failed to fetch latest revision")
+				http.Error(w, "Failed to fetch revisions", http.StatusInternalServerError)
+				return
+			}
+		}
+		if v := params.Get("from"); v != "" {
+			if from, err = strconv.Atoi(v); err != nil || from <= 0 {
+				http.Error(w, "Invalid from revision", http.StatusBadRequest)
+				return
+			}
+		} else {
+			from = to - 1
+		}
+		// Единственную ревизию сравнивать не с чем
+		if from <= 0 {
+			from = to
+		}
+
+		fromRev, err := database.FetchRevision(db, postID, from)
+		if err != nil {
+			logger.WithError(err).Error("Failed to fetch revision")
+			http.Error(w, "Failed to fetch revisions", http.StatusInternalServerError)
+			return
+		}
+		toRev, err := database.FetchRevision(db, postID, to)
+		if err != nil {
+			logger.WithError(err).Error("Failed to fetch revision")
+			http.Error(w, "Failed to fetch revisions", http.StatusInternalServerError)
+			return
+		}
+		if fromRev == nil || toRev == nil {
+			http.Error(w, "Revision not found", http.StatusNotFound)
+			return
+		}
+
+		diff := revisionDiff{
+			From:    from,
+			To:      to,
+			Title:   textdiff.Words(fromRev.Title, toRev.Title),
+			Content: textdiff.Words(fromRev.Content, toRev.Content),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(diff); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"posts_service/internal/database"
+	"posts_service/internal/textdiff"
+
+	"github.com/gorilla/mux"
+)
+
+// postRequest собирает запрос к маршруту /posts/{id} от имени userID;
+// userID 0 означает анонимный запрос
+func postRequest(method, target string, postID, userID int, body interface{}) *http.Request {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, target, bytes.NewReader(data))
+	r = mux.SetURLVars(r, map[string]string{"id": strconv.Itoa(postID)})
+	if userID != 0 {
+		r = asUser(r, userID)
+	}
+	return r
+}
+
+func patchPost(db *sql.DB, userID, postID int, body interface{}) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	UpdatePost(db, &fakeStrategy{})(rec, postRequest(http.MethodPatch, "/posts/"+strconv.Itoa(postID), postID, userID, body))
+	return rec
+}
+
+func TestUpdatePostValidatesRequest(t *testing.T) {
+	empty := ""
+	cases := []struct {
+		name   string
+		userID int
+		body   interface{}
+		want   int
+	}{
+		{"anonymous", 0, map[string]string{"title": "t"}, http.StatusUnauthorized},
+		{"nothing to update", 1, map[string]string{}, http.StatusBadRequest},
+		{"empty content", 1, UpdatePostRequest{Content: &empty}, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		// До базы запрос не доходит
+		if rec := patchPost(nil, c.userID, 1, c.body); rec.Code != c.want {
+			t.Errorf("%s: status %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+}
+
+func TestEditAndDiffRevisions(t *testing.T) {
+	db := openTestDB(t)
+	author := newUser(t, db, "author")
+	reader := newUser(t, db, "reader")
+	post := newPost(t, db, author, "Hello wrld", "body")
+
+	if rec := patchPost(db, reader, post.ID, map[string]string{"title": "Hijacked"}); rec.Code != http.StatusForbidden {
+		t.Errorf("edit by another user: status %d, want 403", rec.Code)
+	}
+
+	rec := patchPost(db, author, post.ID, map[string]string{"title": "Hello world"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("edit: status %d: %s", rec.Code, rec.Body)
+	}
+	var edited database.Post
+	json.NewDecoder(rec.Body).Decode(&edited)
+	if !edited.Edited || edited.UpdatedAt == nil || edited.Title != "Hello world" {
+		t.Errorf("edited post = %+v", edited)
+	}
+
+	// Читатель видит историю опубликованного поста
+	rec = httptest.NewRecorder()
+	ListRevisions(db)(rec, postRequest(http.MethodGet, "/", post.ID, reader, nil))
+	var revisions []database.Revision
+	json.NewDecoder(rec.Body).Decode(&revisions)
+	if len(revisions) != 2 || revisions[0].Revision != 2 || revisions[1].Title != "Hello wrld" {
+		t.Fatalf("revisions = %+v", revisions)
+	}
+
+	rec = httptest.NewRecorder()
+	DiffRevisions(db)(rec, postRequest(http.MethodGet, "/", post.ID, reader, nil))
+	var diff revisionDiff
+	json.NewDecoder(rec.Body).Decode(&diff)
+	want := []textdiff.Chunk{{Op: textdiff.Equal, Text: "Hello "}, {Op: textdiff.Delete, Text: "wrld"}, {Op: textdiff.Insert, Text: "world"}}
+	if diff.From != 1 || diff.To != 2 || !reflect.DeepEqual(diff.Title, want) {
+		t.Errorf("diff = %+v", diff)
+	}
+
+	rec = httptest.NewRecorder()
+	DiffRevisions(db)(rec, postRequest(http.MethodGet, "/?from=1&to=9", post.ID, reader, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("diff with a missing revision: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"posts_service/internal/database"
+	"posts_service/internal/feed"
+	"posts_service/internal/middlewares"
+	"shared/pagination"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// FetchFeed возвращает страницу персональной ленты: посты авторов, на которых
+// подписан пользователь, и его собственные, новые сверху
+func FetchFeed(strategy feed.Strategy) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "User not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+
+		posts, err := strategy.Page(userID, page)
+		if err != nil {
+			logger.WithError(err).WithField("strategy", strategy.Name()).Error("Failed to fetch feed")
+			http.Error(w, "Failed to fetch feed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pagination.NewPage(posts, page.Limit, postCursor)); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// postCursor возвращает позицию поста в списках, упорядоченных по времени создания
+func postCursor(p database.Post) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+// subscriptionTarget возвращает автора из пути и подписчика из контекста
+func subscriptionTarget(w http.ResponseWriter, r *http.Request) (authorID, userID int, ok bool) {
+	userID, ok = r.Context().Value(middlewares.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "User not authorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	authorID, err := atoiParam(mux.Vars(r)["authorId"])
+	if err != nil || authorID <= 0 {
+		http.Error(w, "Invalid author ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	if authorID == userID {
+		http.Error(w, "Your own posts are always in your feed", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return authorID, userID, true
+}
+
+// writeSubscription отвечает текущим состоянием подписки
+func writeSubscription(w http.ResponseWriter, status int, authorID int, subscribed bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"authorId":   authorID,
+		"subscribed": subscribed,
+	})
+}
+
+// GetSubscription сообщает, подписан ли пользователь на автора
+func GetSubscription(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorID, userID, ok := subscriptionTarget(w, r)
+		if !ok {
+			return
+		}
+
+		subscribed, err := database.IsSubscribed(db, userID, authorID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to check subscription")
+			http.Error(w, "Failed to check subscription", http.StatusInternalServerError)
+			return
+		}
+		writeSubscription(w, http.StatusOK, authorID, subscribed)
+	}
+}
+
+// Subscribe подписывает пользователя на посты автора. Повторная подписка ничего не меняет.
+func Subscribe(db *sql.DB, strategy feed.Strategy) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorID, userID, ok := subscriptionTarget(w, r)
+		if !ok {
+			return
+		}
+
+		exists, err := database.UserExists(db, authorID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to check author")
+			http.Error(w, "Failed to subscribe", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Author not found", http.StatusNotFound)
+			return
+		}
+
+		created, err := database.Subscribe(db, userID, authorID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to subscribe")
+			http.Error(w, "Failed to subscribe", http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+			if err := strategy.OnSubscribed(userID, authorID); err != nil {
+				// Подписка сохранена; лента дополнится следующими постами автора
+				logger.WithError(err).WithField("strategy", strategy.Name()).Error("Failed to update feed after subscribe")
+			}
+			logger.WithFields(logrus.Fields{"user_id": userID, "author_id": authorID}).Info("Subscribed to author")
+		}
+		writeSubscription(w, status, authorID, true)
+	}
+}
+
+// Unsubscribe отменяет подписку пользователя на автора
+func Unsubscribe(db *sql.DB, strategy feed.Strategy) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorID, userID, ok := subscriptionTarget(w, r)
+		if !ok {
+			return
+		}
+
+		removed, err := database.Unsubscribe(db, userID, authorID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to unsubscribe")
+			http.Error(w, "Failed to unsubscribe", http.StatusInternalServerError)
+			return
+		}
+		if removed {
+			if err := strategy.OnUnsubscribed(userID, authorID); err != nil {
+				logger.WithError(err).WithField("strategy", strategy.Name()).Error("Failed to update feed after unsubscribe")
+			}
+			logger.WithFields(logrus.Fields{"user_id": userID, "author_id": authorID}).Info("Unsubscribed from author")
+		}
+		writeSubscription(w, http.StatusOK, authorID, false)
+	}
+}
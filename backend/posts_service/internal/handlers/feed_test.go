@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+	"shared/pagination"
+
+	"github.com/gorilla/mux"
+)
+
+// fakeStrategy отдаёт заранее заданные посты и запоминает запросы ленты
+type fakeStrategy struct {
+	posts  []database.Post
+	err    error
+	userID int
+	page   pagination.Request
+}
+
+func (s *fakeStrategy) Name() string { return "fake" }
+
+func (s *fakeStrategy) Page(userID int, page pagination.Request) ([]database.Post, error) {
+	s.userID, s.page = userID, page
+	return s.posts, s.err
+}
+
+func (s *fakeStrategy) OnPostCreated(*database.Post) error { return nil }
+func (s *fakeStrategy) OnSubscribed(int, int) error        { return nil }
+func (s *fakeStrategy) OnUnsubscribed(int, int) error      { return nil }
+
+func TestFetchFeed(t *testing.T) {
+	now := time.Now()
+	strategy := &fakeStrategy{posts: []database.Post{
+		{ID: 3, CreatedAt: now},
+		{ID: 2, CreatedAt: now.Add(-time.Minute)},
+		{ID: 1, CreatedAt: now.Add(-2 * time.Minute)},
+	}}
+
+	rec := httptest.NewRecorder()
+	FetchFeed(strategy)(rec, asUser(httptest.NewRequest(http.MethodGet, "/feed?limit=2", nil), 7))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if strategy.userID != 7 || strategy.page.Limit != 2 {
+		t.Errorf("strategy asked for user %d limit %d", strategy.userID, strategy.page.Limit)
+	}
+
+	var page struct {
+		Items      []database.Post `json:"items"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	json.NewDecoder(rec.Body).Decode(&page)
+	if len(page.Items) != 2 || page.NextCursor == "" {
+		t.Fatalf("page = %d items, cursor %q", len(page.Items), page.NextCursor)
+	}
+	cursor, err := pagination.Decode(page.NextCursor)
+	if err != nil || cursor.ID != 2 {
+		t.Errorf("next cursor = %+v, %v; want post 2", cursor, err)
+	}
+}
+
+func TestFetchFeedErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	FetchFeed(&fakeStrategy{})(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	FetchFeed(&fakeStrategy{err: errors.New("db down")})(rec, asUser(httptest.NewRequest(http.MethodGet, "/feed", nil), 7))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("strategy error: status %d, want 500", rec.Code)
+	}
+}
+
+func TestSubscriptionTarget(t *testing.T) {
+	cases := []struct {
+		name     string
+		authorID string
+		userID   int
+		want     int
+	}{
+		{"anonymous", "5", 0, http.StatusUnauthorized},
+		{"own posts", "5", 5, http.StatusBadRequest},
+		{"not a number", "five", 7, http.StatusBadRequest},
+		{"zero", "0", 7, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/subscriptions/"+c.authorID, nil), map[string]string{"authorId": c.authorID})
+		if c.userID != 0 {
+			r = asUser(r, c.userID)
+		}
+		rec := httptest.NewRecorder()
+		// До базы и стратегии запрос не доходит
+		Subscribe(nil, nil)(rec, r)
+		if rec.Code != c.want {
+			t.Errorf("%s: status %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+}
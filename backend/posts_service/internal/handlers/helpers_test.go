@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+	"posts_service/internal/middlewares"
+
+	_ "github.com/lib/pq"
+)
+
+// asUser добавляет в запрос ID пользователя, как это делает AuthMiddleware
+func asUser(r *http.Request, userID int) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), middlewares.UserIDKey, userID))
+}
+
+// sentNotification — запрос, который получил поддельный Notifications Service
+type sentNotification struct {
+	method, path, token string
+	body                map[string]interface{}
+}
+
+// fakeNotifications поднимает Notifications Service, который отвечает status
+// и передаёт полученные запросы в канал; NOTIFICATIONS_SERVICE_URL указывает на него
+func fakeNotifications(t *testing.T, status int) <-chan sentNotification {
+	t.Helper()
+	sent := make(chan sentNotification, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := sentNotification{method: r.Method, path: r.URL.Path, token: r.Header.Get("Authorization")}
+		json.NewDecoder(r.Body).Decode(&n.body)
+		sent <- n
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("NOTIFICATIONS_SERVICE_URL", srv.URL)
+	return sent
+}
+
+// staticTokens выдаёт один и тот же сервисный токен
+type staticTokens string
+
+func (s staticTokens) Token() (string, error) { return string(s), nil }
+
+// openTestDB подключается к базе из TEST_POSTGRES_DSN, где уже есть базовые
+// таблицы users и posts, и применяет миграции; без переменной тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return db
+}
+
+// newUser создаёт пользователя и удаляет его с постами после теста
+func newUser(t *testing.T, db *sql.DB, prefix string) int {
+	t.Helper()
+	name := fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	var id int
+	err := db.QueryRow(`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x') RETURNING id`,
+		name, name+"@example.com").Scan(&id)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM posts WHERE author_id = $1", id)
+		db.Exec("DELETE FROM users WHERE id = $1", id)
+	})
+	return id
+}
+
+// newPost публикует пост без упоминаний
+func newPost(t *testing.T, db *sql.DB, authorID int, title, content string) *database.Post {
+	t.Helper()
+	post, err := database.CreatePost(db, title, content, authorID, database.StatusPublished, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	return post
+}
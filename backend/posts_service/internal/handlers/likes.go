@@ -9,6 +9,10 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+
+	"posts_service/internal/database"
+	"posts_service/internal/middlewares"
+	"shared/pagination"
 )
 
 func ToggleLike(db *sql.DB) http.HandlerFunc {
@@ -29,9 +33,16 @@ func ToggleLike(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Проверяем, существует ли пост и получаем его автора
+		// Лайк ставится только от своего имени
+		if userID, _ := r.Context().Value(middlewares.UserIDKey).(int); userID != likeRequest.UserID {
+			http.Error(w, "You can only like posts on your own behalf", http.StatusForbidden)
+			return
+		}
+		token := requestToken(r)
+
+		// Проверяем, что пост существует и опубликован, и получаем его автора
 		var postAuthorID int
-		err := db.QueryRow("SELECT author_id FROM posts WHERE id = $1", likeRequest.PostID).Scan(&postAuthorID)
+		err := db.QueryRow("SELECT author_id FROM posts WHERE id = $1 AND status = 'published'", likeRequest.PostID).Scan(&postAuthorID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				http.Error(w, "Post not found", http.StatusNotFound)
@@ -63,7 +74,6 @@ func ToggleLike(db *sql.DB) http.HandlerFunc {
 				"likerId": likeRequest.UserID,
 				"postId":  likeRequest.PostID,
 				"type":    "like",
-				"message": fmt.Sprintf("User %d liked your post %d", likeRequest.UserID, likeRequest.PostID),
 			}
 
 			notificationData, err := json.Marshal(notification)
@@ -77,6 +87,7 @@ func ToggleLike(db *sql.DB) http.HandlerFunc {
 					return
 				}
 				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
 				client := &http.Client{}
 				resp, err := client.Do(req)
 				if err != nil {
@@ -117,6 +128,7 @@ func ToggleLike(db *sql.DB) http.HandlerFunc {
 					return
 				}
 				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
 				client := &http.Client{}
 				resp, err := client.Do(req)
 				if err != nil {
@@ -136,26 +148,23 @@ func ToggleLike(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Получаем обновленный список пользователей, лайкнувших пост
-		userIDs, err := getLikes(db, likeRequest.PostID)
+		// Возвращаем новое число лайков; список лайкнувших отдаёт GetLikesForPost
+		count, err := database.CountLikes(db, likeRequest.PostID)
 		if err != nil {
-			log.Printf("Failed to fetch likes: %v", err)
+			log.Printf("Failed to count likes: %v", err)
 			http.Error(w, "Failed to fetch likes", http.StatusInternalServerError)
 			return
 		}
 
-		users, err := fetchUsersFromUsersService(userIDs)
-		if err != nil {
-			log.Printf("Failed to fetch user info: %v", err)
-			http.Error(w, "Failed to fetch user info", http.StatusInternalServerError)
-			return
-		}
-
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(users)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"liked":      r.Method == http.MethodPost,
+			"likesCount": count,
+		})
 	}
 }
 
+// GetLikesForPost возвращает страницу пользователей, лайкнувших пост
 func GetLikesForPost(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		postIDStr := r.URL.Query().Get("postId")
@@ -170,20 +179,22 @@ func GetLikesForPost(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		userIDs, err := getLikes(db, postID)
-		if err != nil {
-			http.Error(w, "Failed to fetch likes", http.StatusInternalServerError)
+		page, ok := pagination.Parse(w, r)
+		if !ok {
 			return
 		}
 
-		users, err := fetchUsersFromUsersService(userIDs)
+		likers, err := database.FetchLikes(db, postID, page)
 		if err != nil {
-			http.Error(w, "Failed to fetch user info", http.StatusInternalServerError)
+			log.Printf("Failed to fetch likes: %v", err)
+			http.Error(w, "Failed to fetch likes", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(users)
+		json.NewEncoder(w).Encode(pagination.NewPage(likers, page.Limit, func(l database.Liker) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: l.LikedAt, ID: l.ID}
+		}))
 	}
 }
 
@@ -202,26 +213,3 @@ func removeLike(db *sql.DB, postID, userID int) error {
 	`, postID, userID)
 	return err
 }
-
-// getLikes возвращает список user_id, лайкнувших пост
-func getLikes(db *sql.DB, postID int) ([]int, error) {
-	rows, err := db.Query(`
-        SELECT user_id
-        FROM likes
-        WHERE post_id = $1
-    `, postID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var userIDs []int
-	for rows.Next() {
-		var uid int
-		if err := rows.Scan(&uid); err != nil {
-			return nil, err
-		}
-		userIDs = append(userIDs, uid)
-	}
-	return userIDs, nil
-}
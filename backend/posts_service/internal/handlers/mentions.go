@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"posts_service/internal/database"
+	"posts_service/internal/mentions"
+
+	"github.com/sirupsen/logrus"
+)
+
+// resolveMentions находит пользователей, упомянутых в content, и возвращает
+// username → ID. Имена, которым не нашлось пользователя, пропускаются.
+func resolveMentions(content string) (map[string]int, error) {
+	names := mentions.Usernames(content)
+	if len(names) == 0 {
+		return map[string]int{}, nil
+	}
+	return lookupUserIDs(names)
+}
+
+// resolveMentionsOrDefer ищет упомянутых пользователей при записи поста.
+// Сбой не мешает сохранить пост: возвращается nil, пост встаёт в очередь
+// mention_lookups, и пользователей найдёт NotifyPendingMentions.
+func resolveMentionsOrDefer(logger *logrus.Logger, content string) map[string]int {
+	mentioned, err := resolveMentions(content)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to resolve mentions, deferring to the scheduler")
+		return nil
+	}
+	return mentioned
+}
+
+const (
+	// mentionMaxAttempts — сколько раз отправляется уведомление об упоминании
+	// или ищутся упомянутые пользователи, прежде чем от этого откажутся
+	mentionMaxAttempts = 8
+	// Повторы откладываются на mentionRetryBase * 2^(попытка-1), но не более mentionRetryMax
+	mentionRetryBase = time.Minute
+	mentionRetryMax  = time.Hour
+	// mentionLookupLease — на сколько откладывается пост, пока реплика ищет
+	// упомянутых в нём пользователей
+	mentionLookupLease = 5 * time.Minute
+)
+
+// mentionRetryDelay возвращает задержку перед повтором после attempts неудачных попыток
+func mentionRetryDelay(attempts int) time.Duration {
+	delay := mentionRetryBase
+	for i := 1; i < attempts && delay < mentionRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > mentionRetryMax {
+		delay = mentionRetryMax
+	}
+	return delay
+}
+
+// notifyMentions сообщает пользователям, упомянутым в опубликованном посте,
+// об упоминании — каждому один раз за всё время жизни поста, сколько бы его ни
+// правили
+func notifyMentions(db *sql.DB, logger *logrus.Logger, post *database.Post) {
+	claimed, err := database.ClaimMentionNotifications(db, post.ID)
+	if err != nil {
+		logger.WithError(err).WithField("post_id", post.ID).Error("Failed to claim mention notifications")
+		return
+	}
+	for _, n := range claimed {
+		sendMentionNotification(db, logger, n)
+	}
+}
+
+// sendMentionNotification отправляет одно отмеченное уведомление об
+// упоминании. Упоминания Notifications Service принимает только от
+// posts_service, поэтому запрос идёт с сервисным токеном. После временного
+// сбоя повтор откладывается с растущей задержкой (его выполнит
+// NotifyPendingMentions); после постоянной ошибки или mentionMaxAttempts
+// попыток уведомление больше не отправляется.
+func sendMentionNotification(db *sql.DB, logger *logrus.Logger, n database.MentionNotification) {
+	err := sendServiceNotification(http.MethodPost, "/notifications/mentions", map[string]interface{}{
+		"userId":  n.UserID,
+		"likerId": n.AuthorID,
+		"postId":  n.PostID,
+		"type":    notificationMention,
+	})
+	if err == nil {
+		return
+	}
+
+	fields := logrus.Fields{"post_id": n.PostID, "user_id": n.UserID, "attempt": n.Attempts}
+	if isPermanent(err) || n.Attempts >= mentionMaxAttempts {
+		logger.WithError(err).WithFields(fields).Error("Giving up on mention notification")
+		if err := database.FailMentionNotification(db, n.PostID, n.UserID); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Failed to mark mention notification as failed")
+		}
+		return
+	}
+
+	logger.WithError(err).WithFields(fields).Warn("Failed to send mention notification, will retry")
+	retryAt := time.Now().Add(mentionRetryDelay(n.Attempts))
+	if err := database.RetryMentionNotification(db, n.PostID, n.UserID, retryAt); err != nil {
+		logger.WithError(err).WithFields(fields).Error("Failed to schedule mention notification retry")
+	}
+}
+
+// resolveMentionLookup ищет пользователей, упомянутых в посте из очереди
+// mention_lookups. После временного сбоя повтор откладывается так же, как
+// для уведомлений; после постоянной ошибки или mentionMaxAttempts попыток
+// упоминания в посте остаются простым текстом.
+func resolveMentionLookup(db *sql.DB, logger *logrus.Logger, l database.MentionLookup) {
+	fields := logrus.Fields{"post_id": l.PostID, "attempt": l.Attempts}
+
+	mentioned, err := resolveMentions(l.Content)
+	if err == nil {
+		if _, err := database.ResolveMentionLookup(db, l.PostID, l.Content, mentioned); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Failed to save resolved mentions")
+		}
+		return
+	}
+
+	if isPermanent(err) || l.Attempts >= mentionMaxAttempts {
+		logger.WithError(err).WithFields(fields).Error("Giving up on resolving mentions")
+		if err := database.DropMentionLookup(db, l.PostID); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Failed to drop mention lookup")
+		}
+		return
+	}
+
+	logger.WithError(err).WithFields(fields).Warn("Failed to resolve mentions, will retry")
+	if err := database.RetryMentionLookup(db, l.PostID, time.Now().Add(mentionRetryDelay(l.Attempts))); err != nil {
+		logger.WithError(err).WithFields(fields).Error("Failed to schedule mention lookup retry")
+	}
+}
+
+// pendingMentionBatch — сколько постов и уведомлений каждого вида
+// обрабатывается за один вызов NotifyPendingMentions
+const pendingMentionBatch = 100
+
+// NotifyPendingMentions возвращает функцию, которая ищет упомянутых
+// пользователей в постах, где это не удалось при записи, рассылает
+// уведомления об упоминаниях, ещё не отправленные из опубликованных постов
+// (например, из постов, опубликованных планировщиком), и повторяет те, время
+// повтора которых наступило
+func NotifyPendingMentions(db *sql.DB) func() {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func() {
+		lookups, err := database.ClaimMentionLookups(db, pendingMentionBatch, time.Now().Add(mentionLookupLease))
+		if err != nil {
+			logger.WithError(err).Error("Failed to claim mention lookups")
+		}
+		for _, l := range lookups {
+			resolveMentionLookup(db, logger, l)
+		}
+
+		claimed, err := database.ClaimPendingMentionNotifications(db, pendingMentionBatch)
+		if err != nil {
+			logger.WithError(err).Error("Failed to claim pending mention notifications")
+		}
+		due, err := database.ClaimDueMentionNotifications(db, pendingMentionBatch)
+		if err != nil {
+			logger.WithError(err).Error("Failed to claim mention notification retries")
+		}
+		for _, n := range append(claimed, due...) {
+			sendMentionNotification(db, logger, n)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+)
+
+func TestMentionRetryDelay(t *testing.T) {
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour}
+	for i, d := range want {
+		if got := mentionRetryDelay(i + 1); got != d {
+			t.Errorf("mentionRetryDelay(%d) = %v, want %v", i+1, got, d)
+		}
+	}
+	if got := mentionRetryDelay(1000); got != mentionRetryMax {
+		t.Errorf("mentionRetryDelay(1000) = %v", got)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	for code, want := range map[int]bool{
+		http.StatusBadRequest:          true,
+		http.StatusForbidden:           true,
+		http.StatusNotFound:            true,
+		http.StatusUnauthorized:        false,
+		http.StatusRequestTimeout:      false,
+		http.StatusTooManyRequests:     false,
+		http.StatusInternalServerError: false,
+		http.StatusServiceUnavailable:  false,
+	} {
+		err := fmt.Errorf("wrapped: %w", &statusError{service: "users", code: code})
+		if got := isPermanent(err); got != want {
+			t.Errorf("isPermanent(%d) = %v, want %v", code, got, want)
+		}
+	}
+	if isPermanent(errors.New("connection refused")) {
+		t.Error("network error is permanent")
+	}
+}
+
+// fakeUsers поднимает Users Service, который находит пользователей из known
+func fakeUsers(t *testing.T, known map[string]int) *[]string {
+	t.Helper()
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		if r.URL.Path != "/users/by_usernames" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "[")
+		sep := ""
+		for _, name := range r.URL.Query()["username"] {
+			if id, ok := known[name]; ok {
+				fmt.Fprintf(w, `%s{"id":%d,"username":%q}`, sep, id, name)
+				sep = ","
+			}
+		}
+		fmt.Fprint(w, "]")
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("USERS_SERVICE_URL", srv.URL)
+	return &tokens
+}
+
+func TestResolveMentions(t *testing.T) {
+	tokens := fakeUsers(t, map[string]int{"ann": 1, "bob": 2})
+	UseServiceTokens(staticTokens("svc"))
+	defer UseServiceTokens(nil)
+
+	got, err := resolveMentions("@ann, @bob и @ghost")
+	if err != nil {
+		t.Fatalf("resolveMentions: %v", err)
+	}
+	if want := map[string]int{"ann": 1, "bob": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveMentions = %v, want %v", got, want)
+	}
+	if len(*tokens) != 1 || (*tokens)[0] != "Bearer svc" {
+		t.Errorf("users service saw tokens %v, want one service token", *tokens)
+	}
+
+	// Текст без упоминаний не требует запроса
+	if got, err := resolveMentions("plain"); err != nil || got == nil || len(got) != 0 || len(*tokens) != 1 {
+		t.Errorf("resolveMentions(plain) = %v, %v after %d requests", got, err, len(*tokens))
+	}
+}
+
+func TestResolveMentionsOrDefer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	t.Setenv("USERS_SERVICE_URL", srv.URL)
+	UseServiceTokens(staticTokens("svc"))
+	defer UseServiceTokens(nil)
+
+	if _, err := resolveMentions("@ann"); isPermanent(err) || err == nil {
+		t.Errorf("503 from users service: err = %v, want a temporary error", err)
+	}
+	// nil ставит пост в очередь поиска упоминаний
+	if got := resolveMentionsOrDefer(quietLogger(), "@ann"); got != nil {
+		t.Errorf("resolveMentionsOrDefer = %v, want nil", got)
+	}
+}
+
+func TestSendMentionNotificationOutcomes(t *testing.T) {
+	db := openTestDB(t)
+	author := newUser(t, db, "author")
+	readers := []int{newUser(t, db, "reader"), newUser(t, db, "reader"), newUser(t, db, "reader")}
+	UseServiceTokens(staticTokens("svc"))
+	defer UseServiceTokens(nil)
+
+	var names []string
+	mentioned := map[string]int{}
+	for _, id := range readers {
+		var name string
+		db.QueryRow("SELECT username FROM users WHERE id = $1", id).Scan(&name)
+		names = append(names, "@"+name)
+		mentioned[name] = id
+	}
+	post, err := database.CreatePost(db, "post", fmt.Sprint(names), author, database.StatusPublished, nil, mentioned)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	claimed, err := database.ClaimMentionNotifications(db, post.ID)
+	if err != nil || len(claimed) != 3 {
+		t.Fatalf("claimed %+v, %v", claimed, err)
+	}
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].UserID < claimed[j].UserID })
+
+	status := func(userID int) string {
+		var s string
+		db.QueryRow("SELECT status FROM mention_notifications WHERE post_id = $1 AND user_id = $2", post.ID, userID).Scan(&s)
+		return s
+	}
+	send := func(n database.MentionNotification, code int) {
+		sent := fakeNotifications(t, code)
+		sendMentionNotification(db, quietLogger(), n)
+		if req := <-sent; req.path != "/notifications/mentions" || req.token != "Bearer svc" || req.body["type"] != "mention" {
+			t.Errorf("request = %+v", req)
+		}
+	}
+
+	send(claimed[0], http.StatusCreated)
+	send(claimed[1], http.StatusServiceUnavailable)
+	send(claimed[2], http.StatusNotFound)
+	for i, want := range []string{"sent", "retry", "failed"} {
+		if got := status(claimed[i].UserID); got != want {
+			t.Errorf("notification %d: status %q, want %q", i, got, want)
+		}
+	}
+
+	// После последней попытки временная ошибка тоже окончательна
+	last := claimed[1]
+	last.Attempts = mentionMaxAttempts
+	send(last, http.StatusServiceUnavailable)
+	if got := status(last.UserID); got != "failed" {
+		t.Errorf("after the last attempt: status %q, want failed", got)
+	}
+}
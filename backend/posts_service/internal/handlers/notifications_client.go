@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"posts_service/internal/database"
+	"shared/jwtauth"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// notificationComment — тип уведомления о новом комментарии
+	notificationComment = "comment"
+	// notificationMention — тип уведомления об упоминании в посте
+	notificationMention = "mention"
+)
+
+// ServiceName — имя posts_service в сервисных токенах
+const ServiceName = "posts-service"
+
+var notificationsClient = &http.Client{Timeout: 5 * time.Second}
+
+// serviceTokens — сервисные токены, которые выдаёт auth_service, для
+// запросов не от имени пользователя; задаётся при старте через UseServiceTokens
+var serviceTokens jwtauth.TokenSource
+
+// UseServiceTokens задаёт источник сервисных токенов для запросов к
+// Notifications Service и Users Service
+func UseServiceTokens(src jwtauth.TokenSource) {
+	serviceTokens = src
+}
+
+// serviceToken возвращает действующий сервисный токен posts_service
+func serviceToken() (string, error) {
+	if serviceTokens == nil {
+		return "", fmt.Errorf("service tokens are not configured")
+	}
+	token, err := serviceTokens.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get service token: %w", err)
+	}
+	return token, nil
+}
+
+// sendNotification отправляет запрос в Notifications Service с токеном
+// пользователя, совершившего действие
+func sendNotification(method, token string, payload map[string]interface{}) error {
+	return callNotificationsService(method, "/notifications", token, payload)
+}
+
+// sendServiceNotification отправляет запрос на служебный маршрут path
+// с сервисным токеном posts_service
+func sendServiceNotification(method, path string, payload map[string]interface{}) error {
+	token, err := serviceToken()
+	if err != nil {
+		return err
+	}
+	return callNotificationsService(method, path, token, payload)
+}
+
+// callNotificationsService выполняет запрос к Notifications Service с JSON-телом
+func callNotificationsService(method, path, token string, payload map[string]interface{}) error {
+	notificationsURL := os.Getenv("NOTIFICATIONS_SERVICE_URL")
+	if notificationsURL == "" {
+		return fmt.Errorf("NOTIFICATIONS_SERVICE_URL not set")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequest(method, notificationsURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := notificationsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifications service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &statusError{service: "notifications", code: resp.StatusCode}
+	}
+	return nil
+}
+
+// notifyNewComment сообщает автору поста о комментарии. Свои комментарии
+// автору не приходят; сбой отправки только логируется.
+func notifyNewComment(logger *logrus.Logger, token string, postAuthorID int, comment *database.Comment) {
+	if comment.AuthorID == postAuthorID {
+		return
+	}
+	err := sendNotification(http.MethodPost, token, map[string]interface{}{
+		"userId":    postAuthorID,
+		"likerId":   comment.AuthorID,
+		"postId":    comment.PostID,
+		"commentId": comment.ID,
+		"type":      notificationComment,
+	})
+	if err != nil {
+		logger.WithError(err).WithField("comment_id", comment.ID).Error("Failed to send comment notification")
+	}
+}
+
+// retractCommentNotifications удаляет уведомления об удалённых комментариях.
+// Комментарий может удалить модератор, которому чужие уведомления недоступны,
+// поэтому запрос идёт с сервисным токеном, а не с токеном пользователя.
+func retractCommentNotifications(logger *logrus.Logger, deleted []database.DeletedComment) {
+	ids := make([]int, len(deleted))
+	for i, c := range deleted {
+		ids[i] = c.ID
+	}
+	err := sendServiceNotification(http.MethodDelete, "/notifications/comments", map[string]interface{}{
+		"commentIds": ids,
+	})
+	if err != nil {
+		logger.WithError(err).WithField("comment_ids", ids).Error("Failed to retract comment notifications")
+	}
+}
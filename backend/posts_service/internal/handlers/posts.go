@@ -3,22 +3,34 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"posts_service/internal/database"
+	"posts_service/internal/feed"
 	"posts_service/internal/middlewares"
+	"shared/authz"
+	"shared/pagination"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
+// FetchPosts возвращает страницу всех постов, новые сверху
 func FetchPosts(db *sql.DB) http.HandlerFunc {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+		userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+
 		// Получаем посты через функцию FetchPosts из database
-		posts, err := database.FetchPosts(db)
+		posts, err := database.FetchPosts(db, userID, page)
 		if err != nil {
 			logger.WithError(err).Error("Failed to fetch posts from database")
 			http.Error(w, "Failed to fetch posts", http.StatusInternalServerError)
@@ -32,21 +44,47 @@ func FetchPosts(db *sql.DB) http.HandlerFunc {
 
 		// Отправляем ответ
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(posts); err != nil {
+		if err := json.NewEncoder(w).Encode(pagination.NewPage(posts, page.Limit, postCursor)); err != nil {
 			logger.WithError(err).Error("Failed to encode response")
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		}
 	}
 }
 
-// CreatePostRequest представляет запрос на создание поста
+// CreatePostRequest представляет запрос на создание поста. Без статуса пост
+// публикуется сразу; для статуса "scheduled" нужен PublishAt.
 type CreatePostRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+// postStatus проверяет статус поста из запроса. Время публикации нужно только
+// отложенному посту и должно быть в будущем; для остальных статусов оно
+// отбрасывается.
+func postStatus(status string, publishAt *time.Time) (database.PostStatus, *time.Time, error) {
+	s := database.PostStatus(status)
+	if !s.Valid() {
+		return "", nil, errors.New("Invalid status")
+	}
+	if s != database.StatusScheduled {
+		return s, nil, nil
+	}
+	if publishAt == nil {
+		return "", nil, errors.New("publishAt is required for scheduled posts")
+	}
+	if !publishAt.After(time.Now()) {
+		return "", nil, errors.New("publishAt must be in the future")
+	}
+	return s, publishAt, nil
 }
 
-// CreatePost обрабатывает запрос на создание нового поста
-func CreatePost(db *sql.DB) http.HandlerFunc {
+// CreatePost обрабатывает запрос на создание нового поста. Опубликованный пост
+// передаётся стратегии ленты, которая при необходимости разносит его
+// подписчикам, а упомянутые в нём пользователи получают уведомления;
+// черновики и отложенные посты попадут в ленты при публикации.
+func CreatePost(db *sql.DB, strategy feed.Strategy) http.HandlerFunc {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
@@ -72,10 +110,22 @@ func CreatePost(db *sql.DB) http.HandlerFunc {
 		logger.WithFields(logrus.Fields{
 			"title":   req.Title,
 			"content": req.Content,
+			"status":  req.Status,
 		}).Info("Request body decoded")
 
+		if req.Status == "" {
+			req.Status = string(database.StatusPublished)
+		}
+		status, publishAt, err := postStatus(req.Status, req.PublishAt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mentioned := resolveMentionsOrDefer(logger, req.Content)
+
 		// Вставляем пост в базу данных
-		post, err := database.CreatePost(db, req.Title, req.Content, userID)
+		post, err := database.CreatePost(db, req.Title, req.Content, userID, status, publishAt, mentioned)
 		if err != nil {
 			logger.WithError(err).Error("Failed to create post in database")
 			http.Error(w, "Failed to create post", http.StatusInternalServerError)
@@ -89,6 +139,14 @@ func CreatePost(db *sql.DB) http.HandlerFunc {
 			"authorUsername": post.AuthorUsername,
 		}).Info("Post created successfully")
 
+		// Пост уже сохранён: сбой обновления лент и уведомлений только логируется
+		if post.Status == database.StatusPublished {
+			if err := strategy.OnPostCreated(post); err != nil {
+				logger.WithError(err).WithField("strategy", strategy.Name()).Error("Failed to add post to feeds")
+			}
+			notifyMentions(db, logger, post)
+		}
+
 		// Возвращаем новый пост
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(post); err != nil {
@@ -113,7 +171,8 @@ func FetchPostById(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Получаем пост из базы данных
-		post, err := database.FetchPostByID(db, postID)
+		userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+		post, err := database.FetchPostByID(db, postID, userID)
 		if err != nil {
 			logger.WithError(err).Error("Failed to fetch post")
 			http.Error(w, "Failed to fetch post", http.StatusInternalServerError)
@@ -171,7 +230,8 @@ func DeletePost(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		if ownerID != userID {
+		// Модераторы и администраторы могут удалять любые посты
+		if ownerID != userID && !authz.Can(r.Context(), authz.PostsDeleteAny) {
 			logger.WithFields(logrus.Fields{
 				"post_id":  postID,
 				"owner_id": ownerID,
@@ -188,6 +248,14 @@ func DeletePost(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		if ownerID != userID {
+			logger.WithFields(logrus.Fields{
+				"post_id":  postID,
+				"owner_id": ownerID,
+				"user_id":  userID,
+			}).Info("Post deleted by moderator")
+		}
+
 		// Формируем успешный ответ
 		response := map[string]string{"message": "Post deleted successfully"}
 		w.Header().Set("Content-Type", "application/json")
@@ -196,4 +264,4 @@ func DeletePost(db *sql.DB) http.HandlerFunc {
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListEndpointsRejectBadPageParameters(t *testing.T) {
+	// Некорректные cursor и limit отклоняются до обращения к базе
+	endpoints := map[string]http.HandlerFunc{
+		"/posts":                FetchPosts(nil),
+		"/posts/likes?postId=1": GetLikesForPost(nil),
+	}
+	for path, h := range endpoints {
+		for _, query := range []string{"limit=0", "limit=abc", "cursor=not-a-cursor"} {
+			sep := "?"
+			if path != "/posts" {
+				sep = "&"
+			}
+			rec := httptest.NewRecorder()
+			h(rec, httptest.NewRequest(http.MethodGet, path+sep+query, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s%s%s: status %d, want 400", path, sep, query, rec.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"posts_service/internal/database"
+	"posts_service/internal/middlewares"
+	"shared/pagination"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxSearchQueryLength — наибольшая длина поискового запроса в символах
+const maxSearchQueryLength = 200
+
+// SearchPosts ищет посты по тексту. Параметры: q — запрос (поддерживаются
+// "фразы", OR и -исключение), author — username автора, from и to — границы
+// даты создания в RFC 3339 или YYYY-MM-DD, cursor и limit. Результаты
+// упорядочены по релевантности.
+func SearchPosts(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		text := strings.TrimSpace(params.Get("q"))
+		if text == "" {
+			http.Error(w, "Search query is required", http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(text) > maxSearchQueryLength {
+			http.Error(w, "Search query is too long", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := pagination.LimitFromRequest(r)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		query := database.SearchQuery{
+			Text:   text,
+			Author: strings.TrimSpace(params.Get("author")),
+			Limit:  limit,
+		}
+
+		if v := params.Get("cursor"); v != "" {
+			c, err := pagination.DecodeRank(v)
+			if err != nil {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+				return
+			}
+			query.After = &c
+		}
+
+		if query.From, err = parseDateParam(params.Get("from"), false); err != nil {
+			http.Error(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+		if query.To, err = parseDateParam(params.Get("to"), true); err != nil {
+			http.Error(w, "Invalid to date", http.StatusBadRequest)
+			return
+		}
+		if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+			http.Error(w, "Invalid date range", http.StatusBadRequest)
+			return
+		}
+
+		viewerID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+		results, err := database.SearchPosts(db, viewerID, query)
+		if err != nil {
+			logger.WithError(err).Error("Failed to search posts")
+			http.Error(w, "Failed to search posts", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pagination.NewCustomPage(results, limit, searchCursor)); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// parseDateParam разбирает границу диапазона дат. Для даты без времени верхняя
+// граница сдвигается на конец дня, чтобы день to попадал в выдачу.
+func parseDateParam(v string, upper bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func searchCursor(res database.SearchResult) string {
+	return pagination.RankCursor{Rank: res.Rank, ID: res.ID}.Encode()
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDateParam(t *testing.T) {
+	day := time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC)
+
+	from, err := parseDateParam("2024-05-09", false)
+	if err != nil || !from.Equal(day) {
+		t.Errorf("from date = %v, %v", from, err)
+	}
+	// День to входит в диапазон целиком
+	to, err := parseDateParam("2024-05-09", true)
+	if err != nil || !to.Equal(day.AddDate(0, 0, 1)) {
+		t.Errorf("to date = %v, %v", to, err)
+	}
+	exact, err := parseDateParam("2024-05-09T13:45:00+03:00", true)
+	if err != nil || !exact.Equal(time.Date(2024, 5, 9, 10, 45, 0, 0, time.UTC)) {
+		t.Errorf("RFC 3339 time = %v, %v", exact, err)
+	}
+	if none, err := parseDateParam("", true); none != nil || err != nil {
+		t.Errorf("empty = %v, %v", none, err)
+	}
+	if _, err := parseDateParam("09.05.2024", false); err == nil {
+		t.Error("unsupported date format accepted")
+	}
+}
+
+func TestSearchPostsValidatesQuery(t *testing.T) {
+	bad := []url.Values{
+		{},
+		{"q": {"   "}},
+		{"q": {strings.Repeat("слово", maxSearchQueryLength)}},
+		{"q": {"go"}, "limit": {"0"}},
+		{"q": {"go"}, "cursor": {"oops"}},
+		{"q": {"go"}, "from": {"yesterday"}},
+		{"q": {"go"}, "from": {"2024-05-10"}, "to": {"2024-05-09"}},
+	}
+	for _, params := range bad {
+		rec := httptest.NewRecorder()
+		// До базы запрос не доходит
+		SearchPosts(nil)(rec, httptest.NewRequest(http.MethodGet, "/posts/search?"+params.Encode(), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", params.Encode(), rec.Code)
+		}
+	}
+}
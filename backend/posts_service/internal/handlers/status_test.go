@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+)
+
+func TestPostStatus(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	if s, at, err := postStatus("draft", &future); err != nil || s != database.StatusDraft || at != nil {
+		t.Errorf("draft = %q, %v, %v; publishAt must be dropped", s, at, err)
+	}
+	if s, at, err := postStatus("scheduled", &future); err != nil || s != database.StatusScheduled || at != &future {
+		t.Errorf("scheduled = %q, %v, %v", s, at, err)
+	}
+	for name, tc := range map[string]struct {
+		status string
+		at     *time.Time
+	}{
+		"unknown status":         {"archived", nil},
+		"scheduled without time": {"scheduled", nil},
+		"scheduled in the past":  {"scheduled", &past},
+	} {
+		if _, _, err := postStatus(tc.status, tc.at); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestPublishedPostStatusIsFinal(t *testing.T) {
+	db := openTestDB(t)
+	author := newUser(t, db, "author")
+	post := newPost(t, db, author, "title", "body")
+
+	rec := patchPost(db, author, post.ID, map[string]string{"status": "draft"})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("unpublish: status %d, want 409", rec.Code)
+	}
+
+	draft, err := database.CreatePost(db, "draft", "body", author, database.StatusDraft, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	rec = patchPost(db, author, draft.ID, map[string]interface{}{"status": "scheduled", "publishAt": time.Now().Add(time.Hour)})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("schedule draft: status %d: %s", rec.Code, rec.Body)
+	}
+	if p, _ := database.FetchPostByID(db, draft.ID, author); p.Status != database.StatusScheduled || p.PublishAt == nil {
+		t.Errorf("after scheduling: %+v", p)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"posts_service/internal/database"
+	"posts_service/internal/hashtags"
+	"posts_service/internal/middlewares"
+	"shared/pagination"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultTrendingWindow — окно популярных тегов, если параметр window не задан
+	defaultTrendingWindow = 72 * time.Hour
+	// maxTrendingWindow ограничивает окно: запрос агрегирует все посты за него
+	maxTrendingWindow = 30 * 24 * time.Hour
+	// trendingDecay — во сколько раз окно длиннее периода полураспада веса поста
+	trendingDecay = 4
+
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+// FetchTagPosts возвращает страницу опубликованных постов с тегом, новые сверху.
+// Тег в пути сравнивается без учёта регистра, ведущий # допускается.
+func FetchTagPosts(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := hashtags.Normalize(mux.Vars(r)["tag"])
+		if !ok {
+			http.Error(w, "Invalid tag", http.StatusBadRequest)
+			return
+		}
+
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+		viewerID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+
+		posts, err := database.FetchTagPosts(db, viewerID, tag, page)
+		if err != nil {
+			logger.WithError(err).WithField("tag", tag).Error("Failed to fetch tag posts")
+			http.Error(w, "Failed to fetch posts", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pagination.NewPage(posts, page.Limit, postCursor)); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
+
+// TrendingTags возвращает популярные теги за окно window (длительность вроде
+// "24h", по умолчанию 72h). Вес поста убывает вдвое за четверть окна.
+func TrendingTags(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		window := defaultTrendingWindow
+		if v := params.Get("window"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < time.Hour || d > maxTrendingWindow {
+				http.Error(w, "Invalid window", http.StatusBadRequest)
+				return
+			}
+			window = d
+		}
+
+		limit := defaultTrendingLimit
+		if v := params.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxTrendingLimit {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		tags, err := database.TrendingTags(db, window, window/trendingDecay, limit)
+		if err != nil {
+			logger.WithError(err).Error("Failed to fetch trending tags")
+			http.Error(w, "Failed to fetch trending tags", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(tags); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
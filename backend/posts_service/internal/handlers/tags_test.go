@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTagEndpointsValidateParameters(t *testing.T) {
+	// Проверки выполняются до обращения к базе
+	for _, target := range []string{
+		"/tags/trending?window=30m",
+		"/tags/trending?window=900h",
+		"/tags/trending?window=week",
+		"/tags/trending?limit=0",
+		"/tags/trending?limit=51",
+	} {
+		rec := httptest.NewRecorder()
+		TrendingTags(nil)(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want 400", target, rec.Code)
+		}
+	}
+
+	for _, tag := range []string{"123", "go-lang", "#"} {
+		rec := httptest.NewRecorder()
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tags/x/posts", nil), map[string]string{"tag": tag})
+		FetchTagPosts(nil)(rec, r)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("tag %q: status %d, want 400", tag, rec.Code)
+		}
+	}
+}
+
+func TestFetchTagPostsIgnoresCase(t *testing.T) {
+	db := openTestDB(t)
+	author := newUser(t, db, "author")
+	tag := fmt.Sprintf("Case%d", time.Now().UnixNano())
+	post := newPost(t, db, author, "title", "about #"+tag)
+
+	rec := httptest.NewRecorder()
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/tags/x/posts", nil), map[string]string{"tag": "#" + tag})
+	FetchTagPosts(db)(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var page struct {
+		Items []database.Post `json:"items"`
+	}
+	json.NewDecoder(rec.Body).Decode(&page)
+	if len(page.Items) != 1 || page.Items[0].ID != post.ID {
+		t.Errorf("items = %+v, want post %d", page.Items, post.ID)
+	}
+}
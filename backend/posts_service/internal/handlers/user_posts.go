@@ -3,19 +3,26 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
 	"posts_service/internal/database"
-	"strconv"
+	"posts_service/internal/middlewares"
+	"shared/pagination"
 
 	"github.com/gorilla/mux"
 )
 
+// FetchUserPosts возвращает страницу постов пользователя, новые сверху
 func FetchUserPosts(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		username := vars["username"]
 
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+
 		// Получаем userID по username через Users Service
 		userID, err := fetchUserIDByUsername(username)
 		if err != nil {
@@ -24,7 +31,8 @@ func FetchUserPosts(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Получаем посты пользователя
-		posts, err := database.FetchUserPosts(db, userID)
+		viewerID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+		posts, err := database.FetchUserPosts(db, userID, viewerID, page)
 		if err != nil {
 			http.Error(w, "Failed to fetch posts", http.StatusInternalServerError)
 			return
@@ -32,39 +40,22 @@ func FetchUserPosts(db *sql.DB) http.HandlerFunc {
 
 		// Отправляем ответ
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(posts)
+		json.NewEncoder(w).Encode(pagination.NewPage(posts, page.Limit, postCursor))
 	}
 }
 
 // fetchUserIDByUsername запрашивает userID из Users Service по username.
 func fetchUserIDByUsername(username string) (int, error) {
-	userServiceURL := os.Getenv("USERS_SERVICE_URL")
-	if userServiceURL == "" {
-		return 0, errNoUserService
-	}
-
-	url := userServiceURL + "/users/by_username?username=" + username
-	resp, err := http.Get(url)
+	ids, err := lookupUserIDs([]string{username})
 	if err != nil {
 		return 0, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
+	id, ok := ids[username]
+	if !ok {
 		return 0, errUserNotFound
 	}
-
-	var user struct {
-		ID int `json:"id"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return 0, err
-	}
-	return user.ID, nil
+	return id, nil
 }
 
-// Ошибки
-var (
-	errNoUserService = strconv.ErrSyntax
-	errUserNotFound  = strconv.ErrRange
-)
+// errUserNotFound — пользователя с таким username нет
+var errUserNotFound = errors.New("user not found")
@@ -2,55 +2,89 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+
+	"posts_service/internal/middlewares"
 )
 
-func fetchUsernameFromUsersService(userID int) (string, error) {
+// requestToken возвращает токен пользователя, выполняющего запрос. С ним
+// уведомления уходят в Notifications Service от имени пользователя.
+func requestToken(r *http.Request) string {
+	token, _ := r.Context().Value(middlewares.TokenKey).(string)
+	return token
+}
+
+// statusError — ответ другого сервиса с кодом ошибки
+type statusError struct {
+	service string
+	code    int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s service responded with status %d", e.service, e.code)
+}
+
+// isPermanent сообщает, что запрос отклонён окончательно и повтор не поможет:
+// ответ 4xx, кроме 401 (сервисный токен мог устареть), 408 и 429
+func isPermanent(err error) bool {
+	var se *statusError
+	if !errors.As(err, &se) || se.code < 400 || se.code >= 500 {
+		return false
+	}
+	return se.code != http.StatusUnauthorized &&
+		se.code != http.StatusRequestTimeout &&
+		se.code != http.StatusTooManyRequests
+}
+
+// lookupUserIDs находит пользователей по именам одним запросом к Users Service
+// и возвращает username → ID; ненайденные имена пропускаются. Запрос идёт с
+// сервисным токеном: токен пользователя или его API-ключ может не иметь
+// доступа к Users Service, а запрос без токена вовсе не аутентифицирован.
+func lookupUserIDs(usernames []string) (map[string]int, error) {
 	userServiceURL := os.Getenv("USERS_SERVICE_URL")
 	if userServiceURL == "" {
-		return "", fmt.Errorf("USERS_SERVICE_URL not set")
+		return nil, fmt.Errorf("USERS_SERVICE_URL not set")
+	}
+	token, err := serviceToken()
+	if err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/users/%d", userServiceURL, userID)
-	resp, err := http.Get(url)
+	query := url.Values{"username": usernames}
+	req, err := http.NewRequest(http.MethodGet, userServiceURL+"/users/by_usernames?"+query.Encode(), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch user: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("users service request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch user: status %d", resp.StatusCode)
+		return nil, &statusError{service: "users", code: resp.StatusCode}
 	}
 
-	var user struct {
+	var users []struct {
 		ID       int    `json:"id"`
 		Username string `json:"username"`
-		Email    string `json:"email"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return "", fmt.Errorf("failed to decode user data: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
 	}
 
-	return user.Username, nil
-}
-
-// fetchUsersFromUsersService принимает список userID и возвращает список мап с данными пользователей (id, username)
-func fetchUsersFromUsersService(userIDs []int) ([]map[string]interface{}, error) {
-	users := make([]map[string]interface{}, 0, len(userIDs))
-	for _, uid := range userIDs {
-		username, err := fetchUsernameFromUsersService(uid)
-		if err != nil {
-			return nil, err
-		}
-		users = append(users, map[string]interface{}{
-			"id":       uid,
-			"username": username,
-		})
-	}
-	return users, nil
+	ids := make(map[string]int, len(users))
+	for _, u := range users {
+		ids[u.Username] = u.ID
+	}
+	return ids, nil
 }
 
 // helper для конвертации string->int с обработкой ошибки
@@ -0,0 +1,83 @@
+// Package hashtags извлекает хэштеги из текста поста.
+//
+// Хэштег — символ # в начале текста или после символа, не входящего в слово,
+// и следующие за ним буквы, цифры и подчёркивания, среди которых есть хотя бы
+// одна буква: «#go_1» — тег, «#1» и «a#b» — нет. Теги сравниваются без учёта
+// регистра и хранятся в нижнем регистре.
+package hashtags
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength — наибольшая длина тега в символах; более длинные не считаются тегами
+	MaxLength = 50
+	// maxPerPost — сколько тегов одного поста сохраняется, остальные отбрасываются
+	maxPerPost = 30
+)
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Extract возвращает нормализованные теги текста без повторов в порядке
+// первого появления
+func Extract(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	prev := rune(-1) // -1 — начало текста
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if r != '#' || isWordRune(prev) {
+			prev = r
+			continue
+		}
+
+		start, hasLetter := i, false
+		prev = r
+		for i < len(text) {
+			c, n := utf8.DecodeRuneInString(text[i:])
+			if !isWordRune(c) {
+				break
+			}
+			hasLetter = hasLetter || unicode.IsLetter(c)
+			prev = c
+			i += n
+		}
+
+		if tag, ok := normalize(text[start:i], hasLetter); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+			if len(tags) == maxPerPost {
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// Normalize приводит тег из запроса к виду, в котором он хранится: без
+// ведущего # и в нижнем регистре. ok равен false, если это не тег.
+func Normalize(tag string) (string, bool) {
+	tag = strings.TrimPrefix(tag, "#")
+	hasLetter := false
+	for _, r := range tag {
+		if !isWordRune(r) {
+			return "", false
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	return normalize(tag, hasLetter)
+}
+
+func normalize(tag string, hasLetter bool) (string, bool) {
+	if !hasLetter || utf8.RuneCountInString(tag) > MaxLength {
+		return "", false
+	}
+	return strings.ToLower(tag), true
+}
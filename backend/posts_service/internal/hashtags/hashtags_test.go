@@ -0,0 +1,57 @@
+package hashtags
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleExtract() {
+	fmt.Println(Extract("Пишу на #Go и #go_1, а #2024 и a#b — не теги. (#Rust)"))
+	// Output: [go go_1 rust]
+}
+
+func TestExtractBoundaries(t *testing.T) {
+	for text, want := range map[string]string{
+		"#go is fun":   "go",
+		"##go":         "go",
+		"a#b c":        "",
+		"#1 #2024":     "",
+		"#Привет мир":  "привет",
+		"#GO #Go, #go": "go",
+		"#" + strings.Repeat("a", MaxLength+1) + " #ok": "ok",
+		"#" + strings.Repeat("я", MaxLength):            strings.Repeat("я", MaxLength),
+	} {
+		if got := strings.Join(Extract(text), " "); got != want {
+			t.Errorf("Extract(%.20q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestExtractKeepsFirstTags(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < maxPerPost*2; i++ {
+		fmt.Fprintf(&b, "#t%d ", i)
+	}
+	tags := Extract(b.String())
+	if len(tags) != maxPerPost || tags[0] != "t0" || tags[maxPerPost-1] != fmt.Sprintf("t%d", maxPerPost-1) {
+		t.Errorf("Extract kept %d tags: %v", len(tags), tags)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if tag, ok := Normalize("#GoLang"); !ok || tag != "golang" {
+		t.Errorf("Normalize(#GoLang) = %q, %v", tag, ok)
+	}
+	// Всё, что Extract находит в тексте, Normalize принимает без изменений
+	for _, tag := range Extract("#a_1 #Мир #x") {
+		if got, ok := Normalize(tag); !ok || got != tag {
+			t.Errorf("Normalize(%q) = %q, %v", tag, got, ok)
+		}
+	}
+	for _, bad := range []string{"", "#", "123", "go-lang", "#go lang", strings.Repeat("a", MaxLength+1)} {
+		if tag, ok := Normalize(bad); ok {
+			t.Errorf("Normalize(%q) accepted as %q", bad, tag)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package mentions находит упоминания пользователей вида @username в тексте поста.
+//
+// Упоминание — символ @ в начале текста или после символа, не входящего в
+// слово, и следующие за ним буквы, цифры и символы «_», «.», «-». Точки и
+// дефисы в конце имени не учитываются, чтобы «@alice.» в конце предложения
+// упоминало alice; «mail@example.com» — не упоминание. Имена сравниваются с
+// учётом регистра, как username в адресе профиля.
+package mentions
+
+import "unicode"
+
+// MaxPerPost — сколько разных пользователей разрешается за один пост;
+// остальные имена остаются простым текстом
+const MaxPerPost = 20
+
+// Mention — упоминание в тексте. Offset и Length считаются в символах Unicode,
+// а не в байтах, и включают символ @.
+type Mention struct {
+	Username string
+	Offset   int
+	Length   int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isNameRune(r rune) bool {
+	return isWordRune(r) || r == '.' || r == '-'
+}
+
+// Find возвращает все упоминания текста в порядке появления
+func Find(text string) []Mention {
+	var found []Mention
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isNameRune(runes[end]) {
+			end++
+		}
+		for end > i+1 && (runes[end-1] == '.' || runes[end-1] == '-') {
+			end--
+		}
+		if end == i+1 {
+			continue
+		}
+
+		found = append(found, Mention{Username: string(runes[i+1 : end]), Offset: i, Length: end - i})
+		i = end - 1
+	}
+	return found
+}
+
+// Usernames возвращает упомянутые имена без повторов в порядке первого
+// упоминания, не больше MaxPerPost
+func Usernames(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range Find(text) {
+		if seen[m.Username] {
+			continue
+		}
+		seen[m.Username] = true
+		names = append(names, m.Username)
+		if len(names) == MaxPerPost {
+			break
+		}
+	}
+	return names
+}
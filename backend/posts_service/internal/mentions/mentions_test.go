@@ -0,0 +1,64 @@
+package mentions
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// mark обрамляет найденные упоминания скобками, сдвигая их по Offset и Length
+// в символах, чтобы ошибки в подсчёте позиций были видны в тексте
+func mark(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	pos := 0
+	for _, m := range Find(text) {
+		b.WriteString(string(runes[pos:m.Offset]))
+		fmt.Fprintf(&b, "[%s]", string(runes[m.Offset:m.Offset+m.Length]))
+		pos = m.Offset + m.Length
+	}
+	b.WriteString(string(runes[pos:]))
+	return b.String()
+}
+
+func TestFind(t *testing.T) {
+	cases := [][2]string{
+		{"@alice hi", "[@alice] hi"},
+		{"(@bob), @carol.", "([@bob]), [@carol]."},
+		{"@alice.-. bye", "[@alice].-. bye"},
+		{"@j.doe-2 wrote", "[@j.doe-2] wrote"},
+		{"mail@example.com", "mail@example.com"},
+		{"@ @. a @", "@ @. a @"},
+		{"привет @вася!", "привет [@вася]!"},
+		{"@@alice", "@[@alice]"},
+	}
+	for _, c := range cases {
+		if got := mark(c[0]); got != c[1] {
+			t.Errorf("mark(%q) = %q, want %q", c[0], got, c[1])
+		}
+	}
+
+	// Смещения считаются в символах, а не в байтах
+	if m := Find("ёж @ёж"); len(m) != 1 || m[0].Offset != 3 || m[0].Length != 3 || m[0].Username != "ёж" {
+		t.Errorf("Find = %+v", m)
+	}
+}
+
+func TestUsernames(t *testing.T) {
+	if got := Usernames("no mentions"); got != nil {
+		t.Errorf("Usernames without mentions = %v", got)
+	}
+	// Повторы убираются, регистр имеет значение
+	if got := strings.Join(Usernames("@bob @alice @bob @Bob"), ","); got != "bob,alice,Bob" {
+		t.Errorf("Usernames = %s", got)
+	}
+
+	var text strings.Builder
+	for i := 0; i < MaxPerPost+5; i++ {
+		fmt.Fprintf(&text, "@user%d ", i)
+	}
+	names := Usernames(text.String())
+	if len(names) != MaxPerPost || names[MaxPerPost-1] != fmt.Sprintf("user%d", MaxPerPost-1) {
+		t.Errorf("Usernames kept %d names ending with %q", len(names), names[len(names)-1])
+	}
+}
@@ -5,52 +5,74 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"shared/apikeys"
+	"shared/authz"
+	"shared/jwtauth"
 )
 
 type ContextKey string
 
 const (
-	UserIDKey ContextKey = "user_id"
-	TokenKey  ContextKey = "token"
+	UserIDKey        ContextKey = "user_id"
+	TokenKey         ContextKey = "token"
+	EmailVerifiedKey ContextKey = "email_verified"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")
-		if tokenString == "" {
-			http.Error(w, "Authorization token missing", http.StatusUnauthorized)
-			return
-		}
-
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
-		}
-
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Секрет лучше вынести в переменные окружения
-			return []byte("akunamotata"), nil
+// AuthMiddleware проверяет access-токен или API-ключ и кладёт user_id, роль
+// и токен в контекст. Области доступа ключа проверяет apikeys.RequireScope на маршрутах.
+func AuthMiddleware(verifier *jwtauth.Verifier, keys *apikeys.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.BearerToken(r)
+			if tokenString == "" {
+				http.Error(w, "Authorization token missing", http.StatusUnauthorized)
+				return
+			}
+
+			if apikeys.IsKey(tokenString) {
+				principal, err := keys.Authenticate(tokenString)
+				if err != nil {
+					log.Printf("AuthMiddleware: %v", err)
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+				role, err := authz.ParseRole(principal.Role)
+				if err != nil {
+					log.Printf("AuthMiddleware: %v", err)
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+
+				ctx := authz.WithSubject(r.Context(), authz.Subject{UserID: principal.UserID, Role: role})
+				ctx = apikeys.WithPrincipal(ctx, principal)
+				ctx = context.WithValue(ctx, UserIDKey, principal.UserID)
+				ctx = context.WithValue(ctx, TokenKey, tokenString)
+				ctx = context.WithValue(ctx, EmailVerifiedKey, principal.EmailVerified)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			claims, err := verifier.Verify(tokenString)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			subject, err := authz.SubjectFromClaims(claims)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			// Добавляем user_id и токен в контекст
+			ctx := authz.WithSubject(r.Context(), subject)
+			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, TokenKey, tokenString)
+			ctx = context.WithValue(ctx, EmailVerifiedKey, claims.EmailVerified)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
-		if err != nil || !token.Valid {
-			log.Println("AuthMiddleware: Invalid token")
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
-
-		// Извлекаем user_id из claims
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			log.Println("AuthMiddleware: user_id not found in claims")
-			http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
-			return
-		}
-		userID := int(userIDFloat)
-
-		// Добавляем user_id и токен в контекст
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		ctx = context.WithValue(ctx, TokenKey, tokenString) // Добавляем токен
-
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	}
 }
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// UnverifiedPolicy определяет, что разрешено пользователям с неподтверждённым email
+type UnverifiedPolicy string
+
+const (
+	// UnverifiedAllow — никаких ограничений
+	UnverifiedAllow UnverifiedPolicy = "allow"
+	// UnverifiedReadOnly — разрешены только чтения (GET, HEAD)
+	UnverifiedReadOnly UnverifiedPolicy = "read_only"
+	// UnverifiedDeny — доступ запрещён полностью
+	UnverifiedDeny UnverifiedPolicy = "deny"
+)
+
+// UnverifiedPolicyFromEnv читает политику из UNVERIFIED_EMAIL_POLICY (по умолчанию read_only)
+func UnverifiedPolicyFromEnv() (UnverifiedPolicy, error) {
+	switch policy := UnverifiedPolicy(os.Getenv("UNVERIFIED_EMAIL_POLICY")); policy {
+	case "":
+		return UnverifiedReadOnly, nil
+	case UnverifiedAllow, UnverifiedReadOnly, UnverifiedDeny:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown UNVERIFIED_EMAIL_POLICY %q", policy)
+	}
+}
+
+// RequireVerifiedEmail применяет политику к пользователям с неподтверждённым email.
+// Должен подключаться после AuthMiddleware.
+func RequireVerifiedEmail(policy UnverifiedPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			verified, _ := r.Context().Value(EmailVerifiedKey).(bool)
+			if verified || policy == UnverifiedAllow {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+			if policy == UnverifiedReadOnly && readOnly {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log.Printf("RequireVerifiedEmail: %s %s rejected for unverified user", r.Method, r.URL.Path)
+			http.Error(w, "Email address is not verified", http.StatusForbidden)
+		})
+	}
+}
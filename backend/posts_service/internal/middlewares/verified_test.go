@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		policy   UnverifiedPolicy
+		verified bool
+		method   string
+		want     int
+	}{
+		{UnverifiedAllow, false, http.MethodPost, http.StatusNoContent},
+		{UnverifiedReadOnly, false, http.MethodGet, http.StatusNoContent},
+		{UnverifiedReadOnly, false, http.MethodHead, http.StatusNoContent},
+		{UnverifiedReadOnly, false, http.MethodPost, http.StatusForbidden},
+		{UnverifiedReadOnly, false, http.MethodDelete, http.StatusForbidden},
+		{UnverifiedReadOnly, true, http.MethodPost, http.StatusNoContent},
+		{UnverifiedDeny, false, http.MethodGet, http.StatusForbidden},
+		{UnverifiedDeny, true, http.MethodPut, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/posts", nil)
+		req = req.WithContext(context.WithValue(req.Context(), EmailVerifiedKey, tt.verified))
+		rec := httptest.NewRecorder()
+		RequireVerifiedEmail(tt.policy)(ok).ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s, verified=%v, %s: status %d, want %d", tt.policy, tt.verified, tt.method, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestRequireVerifiedEmailWithoutAuthContext(t *testing.T) {
+	// Без признака в контексте пользователь считается неподтверждённым
+	rec := httptest.NewRecorder()
+	RequireVerifiedEmail(UnverifiedReadOnly)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/posts", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status %d, want 403", rec.Code)
+	}
+}
+
+func TestUnverifiedPolicyFromEnv(t *testing.T) {
+	for env, want := range map[string]UnverifiedPolicy{
+		"":          UnverifiedReadOnly,
+		"allow":     UnverifiedAllow,
+		"read_only": UnverifiedReadOnly,
+		"deny":      UnverifiedDeny,
+	} {
+		t.Setenv("UNVERIFIED_EMAIL_POLICY", env)
+		got, err := UnverifiedPolicyFromEnv()
+		if err != nil || got != want {
+			t.Errorf("UNVERIFIED_EMAIL_POLICY=%q: %q, %v; want %q", env, got, err, want)
+		}
+	}
+
+	t.Setenv("UNVERIFIED_EMAIL_POLICY", "readonly")
+	if _, err := UnverifiedPolicyFromEnv(); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
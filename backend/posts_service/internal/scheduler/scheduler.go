@@ -0,0 +1,110 @@
+// Package scheduler публикует отложенные посты, когда наступает их время.
+// Планировщик запускается в каждой реплике posts_service; пост забирает ровно
+// одна из них (см. database.PublishDuePosts).
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"posts_service/internal/database"
+	"posts_service/internal/feed"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultInterval — период проверки, если PUBLISH_SCHEDULER_INTERVAL не задан
+	defaultInterval = 30 * time.Second
+	// batchSize — сколько постов публикуется одним запросом
+	batchSize = 100
+)
+
+// Scheduler периодически публикует отложенные посты и передаёт их стратегии ленты
+type Scheduler struct {
+	db       *sql.DB
+	strategy feed.Strategy
+	// notify рассылает неотправленные уведомления после каждой проверки
+	notify   func()
+	interval time.Duration
+	logger   *logrus.Logger
+}
+
+// NewFromEnv создаёт планировщик с периодом PUBLISH_SCHEDULER_INTERVAL
+// в формате time.ParseDuration, например "30s". notify вызывается после
+// каждой проверки: так уведомления об упоминаниях в только что
+// опубликованных постах уходят в тот же проход.
+func NewFromEnv(db *sql.DB, strategy feed.Strategy, notify func()) (*Scheduler, error) {
+	interval := defaultInterval
+	if v := os.Getenv("PUBLISH_SCHEDULER_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid PUBLISH_SCHEDULER_INTERVAL %q", v)
+		}
+		interval = d
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return &Scheduler{db: db, strategy: strategy, notify: notify, interval: interval, logger: logger}, nil
+}
+
+// Interval возвращает период проверки
+func (s *Scheduler) Interval() time.Duration { return s.interval }
+
+// Run публикует посты, время которых наступило, до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.publishDue()
+		if s.notify != nil {
+			s.notify()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDue публикует накопившиеся посты пачками, пока они не закончатся
+func (s *Scheduler) publishDue() {
+	for {
+		ids, err := database.PublishDuePosts(s.db, batchSize)
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to publish scheduled posts")
+			return
+		}
+		for _, id := range ids {
+			s.onPublished(id)
+		}
+		if len(ids) < batchSize {
+			return
+		}
+	}
+}
+
+// onPublished передаёт опубликованный пост стратегии ленты. Пост уже
+// опубликован: сбой только логируется, как и при создании поста.
+func (s *Scheduler) onPublished(postID int) {
+	post, err := database.FetchPostByID(s.db, postID, 0)
+	if err != nil || post == nil {
+		s.logger.WithError(err).WithField("post_id", postID).Error("Failed to fetch published post")
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"post_id":   post.ID,
+		"author_id": post.AuthorID,
+	}).Info("Scheduled post published")
+
+	if err := s.strategy.OnPostCreated(post); err != nil {
+		s.logger.WithError(err).WithField("strategy", s.strategy.Name()).Error("Failed to add post to feeds")
+	}
+}
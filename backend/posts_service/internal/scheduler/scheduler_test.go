@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"posts_service/internal/database"
+	"shared/pagination"
+
+	_ "github.com/lib/pq"
+)
+
+// recordingStrategy запоминает посты, переданные стратегии ленты
+type recordingStrategy struct {
+	mu      sync.Mutex
+	created []int
+}
+
+func (s *recordingStrategy) Name() string { return "recording" }
+
+func (s *recordingStrategy) Page(int, pagination.Request) ([]database.Post, error) { return nil, nil }
+
+func (s *recordingStrategy) OnPostCreated(post *database.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created = append(s.created, post.ID)
+	return nil
+}
+
+func (s *recordingStrategy) OnSubscribed(int, int) error   { return nil }
+func (s *recordingStrategy) OnUnsubscribed(int, int) error { return nil }
+
+func TestNewFromEnvInterval(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", defaultInterval, false},
+		{"5s", 5 * time.Second, false},
+		{"soon", 0, true},
+		{"0s", 0, true},
+		{"-1m", 0, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("PUBLISH_SCHEDULER_INTERVAL", tt.env)
+		s, err := NewFromEnv(nil, &recordingStrategy{}, nil)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: no error", tt.env)
+			}
+			continue
+		}
+		if err != nil || s.Interval() != tt.want {
+			t.Errorf("%q: interval %v, %v; want %v", tt.env, s.Interval(), err, tt.want)
+		}
+	}
+}
+
+func TestRunPublishesDuePosts(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	name := fmt.Sprintf("scheduler%d", time.Now().UnixNano())
+	var author int
+	if err := db.QueryRow(`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x') RETURNING id`,
+		name, name+"@example.com").Scan(&author); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	defer db.Exec("DELETE FROM users WHERE id = $1", author)
+	defer db.Exec("DELETE FROM posts WHERE author_id = $1", author)
+
+	publishAt := time.Now().Add(time.Hour)
+	post, err := database.CreatePost(db, "later", "scheduled", author, database.StatusScheduled, &publishAt, nil)
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	db.Exec("UPDATE posts SET publish_at = now() WHERE id = $1", post.ID)
+
+	strategy := &recordingStrategy{}
+	notified := make(chan struct{}, 1)
+	t.Setenv("PUBLISH_SCHEDULER_INTERVAL", "1h")
+	s, err := NewFromEnv(db, strategy, func() {
+		select {
+		case notified <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		t.Fatalf("NewFromEnv: %v", err)
+	}
+
+	// Первая проверка выполняется сразу, не дожидаясь интервала
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { s.Run(ctx); close(done) }()
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not finish its first pass")
+	}
+	cancel()
+	<-done
+
+	if p, _ := database.FetchPostByID(db, post.ID, 0); p == nil || p.Status != database.StatusPublished {
+		t.Errorf("post after the pass = %+v, want published", p)
+	}
+	strategy.mu.Lock()
+	defer strategy.mu.Unlock()
+	found := false
+	for _, id := range strategy.created {
+		found = found || id == post.ID
+	}
+	if !found {
+		t.Errorf("strategy got %v, want post %d", strategy.created, post.ID)
+	}
+}
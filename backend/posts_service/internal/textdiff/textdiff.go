@@ -0,0 +1,189 @@
+// Package textdiff строит пословное сравнение двух текстов алгоритмом Майерса
+package textdiff
+
+import "unicode"
+
+// Op — вид фрагмента сравнения
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Chunk — фрагмент сравнения. Фрагменты Equal и Delete вместе дают старый
+// текст, Equal и Insert — новый.
+type Chunk struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxEdits ограничивает число правок, которые ищет алгоритм: память растёт как
+// квадрат их числа. Если тексты различаются сильнее, старый текст целиком
+// заменяется новым.
+const maxEdits = 2000
+
+// Words сравнивает тексты по словам, пробелам и знакам препинания
+func Words(oldText, newText string) []Chunk {
+	a, b := tokenize(oldText), tokenize(newText)
+
+	// Общие начало и конец не влияют на результат, а правки обычно точечные
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var chunks []Chunk
+	for _, t := range a[:prefix] {
+		chunks = appendChunk(chunks, Equal, t)
+	}
+	middle, ok := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		middle = nil
+		for _, t := range a[prefix : len(a)-suffix] {
+			middle = append(middle, Chunk{Op: Delete, Text: t})
+		}
+		for _, t := range b[prefix : len(b)-suffix] {
+			middle = append(middle, Chunk{Op: Insert, Text: t})
+		}
+	}
+	for _, c := range middle {
+		chunks = appendChunk(chunks, c.Op, c.Text)
+	}
+	for _, t := range a[len(a)-suffix:] {
+		chunks = appendChunk(chunks, Equal, t)
+	}
+	return chunks
+}
+
+// appendChunk добавляет токен, склеивая его с предыдущим фрагментом того же вида
+func appendChunk(chunks []Chunk, op Op, text string) []Chunk {
+	if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+		chunks[n-1].Text += text
+		return chunks
+	}
+	return append(chunks, Chunk{Op: op, Text: text})
+}
+
+// tokenize разбивает текст на слова, последовательности пробелов и отдельные
+// прочие символы; склейка токенов даёт исходный текст
+func tokenize(s string) []string {
+	const (
+		other = iota
+		word
+		space
+	)
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return word
+		case unicode.IsSpace(r):
+			return space
+		}
+		return other
+	}
+
+	var tokens []string
+	start, prev := 0, -1
+	for i, r := range s {
+		c := class(r)
+		if i > start && (c != prev || c == other) {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// myers находит кратчайший сценарий правок. trace[d] хранит самые дальние
+// x по диагоналям k ∈ [-d, d] после шага d — по ним путь восстанавливается с конца.
+func myers(a, b []string) ([]Chunk, bool) {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil, true
+	}
+
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			switch {
+			case d == 0:
+				x = 0
+			case k == -d || (k != d && v[offset+k-1] < v[offset+k+1]):
+				x = v[offset+k+1] // шаг вниз: вставка
+			default:
+				x = v[offset+k-1] + 1 // шаг вправо: удаление
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b), true
+			}
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+	}
+	return nil, false
+}
+
+func backtrack(trace [][]int, a, b []string) []Chunk {
+	x, y := len(a), len(b)
+	var reversed []Chunk
+	for d := len(trace); d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Chunk{Op: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, Chunk{Op: Insert, Text: b[y-1]})
+		} else {
+			reversed = append(reversed, Chunk{Op: Delete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Chunk{Op: Equal, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	chunks := make([]Chunk, len(reversed))
+	for i, c := range reversed {
+		chunks[len(reversed)-1-i] = c
+	}
+	return chunks
+}
@@ -0,0 +1,101 @@
+package textdiff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// render записывает сравнение компактно: [-удалено-] и {+вставлено+}
+func render(chunks []Chunk) string {
+	var sb strings.Builder
+	for _, c := range chunks {
+		switch c.Op {
+		case Delete:
+			sb.WriteString("[-" + c.Text + "-]")
+		case Insert:
+			sb.WriteString("{+" + c.Text + "+}")
+		default:
+			sb.WriteString(c.Text)
+		}
+	}
+	return sb.String()
+}
+
+func TestWordsRender(t *testing.T) {
+	cases := []struct{ old, new, want string }{
+		{"same text", "same text", "same text"},
+		{"", "hello", "{+hello+}"},
+		{"hello", "", "[-hello-]"},
+		{"the quick fox", "the slow fox", "the [-quick-]{+slow+} fox"},
+		{"hello", "hello world", "hello{+ world+}"},
+		{"Hi, Bob", "Hi! Bob", "Hi[-,-]{+!+} Bob"},
+		{"Привет, мир", "Привет, дивный мир", "Привет, {+дивный +}мир"},
+		// Слово меняется целиком, а не по буквам
+		{"color", "colour", "[-color-]{+colour+}"},
+	}
+	for _, c := range cases {
+		if got := render(Words(c.old, c.new)); got != c.want {
+			t.Errorf("Words(%q, %q) = %s, want %s", c.old, c.new, got, c.want)
+		}
+	}
+	if chunks := Words("", ""); chunks != nil {
+		t.Errorf("Words of two empty texts = %+v, want nil", chunks)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := strings.Join(tokenize("a_b  c!?d"), "|")
+	if want := "a_b|  |c|!|?|d"; got != want {
+		t.Errorf("tokenize = %s, want %s", got, want)
+	}
+}
+
+// sides восстанавливает из сравнения старый и новый тексты
+func sides(chunks []Chunk) (string, string) {
+	var a, b strings.Builder
+	for _, c := range chunks {
+		if c.Op != Insert {
+			a.WriteString(c.Text)
+		}
+		if c.Op != Delete {
+			b.WriteString(c.Text)
+		}
+	}
+	return a.String(), b.String()
+}
+
+func TestWordsReconstructsRandomEdits(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	vocabulary := []string{"alpha", "beta", " ", "  ", ",", "\n", "гамма", "42"}
+	text := func() string {
+		var sb strings.Builder
+		for i := rnd.Intn(40); i > 0; i-- {
+			sb.WriteString(vocabulary[rnd.Intn(len(vocabulary))])
+		}
+		return sb.String()
+	}
+
+	for i := 0; i < 200; i++ {
+		old, new := text(), text()
+		chunks := Words(old, new)
+		if a, b := sides(chunks); a != old || b != new {
+			t.Fatalf("Words(%q, %q) reconstructs (%q, %q)", old, new, a, b)
+		}
+		// Соседние фрагменты одного вида склеены
+		for j := 1; j < len(chunks); j++ {
+			if chunks[j].Op == chunks[j-1].Op {
+				t.Fatalf("Words(%q, %q): adjacent %s chunks", old, new, chunks[j].Op)
+			}
+		}
+	}
+}
+
+func TestWordsFallsBackOnLargeRewrites(t *testing.T) {
+	old := strings.Repeat("x ", maxEdits+10)
+	new := strings.Repeat("y ", maxEdits+10)
+	chunks := Words(old, new)
+	if a, b := sides(chunks); a != old || b != new {
+		t.Fatal("fallback diff does not reconstruct the texts")
+	}
+}
@@ -0,0 +1,102 @@
+// Package apikeys выпускает и проверяет персональные API-ключи пользователей.
+// Ключи хранятся в таблице api_keys (её создаёт auth_service) в виде SHA-256
+// хэша; видимый префикс позволяет пользователю узнать ключ в списке.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefix отличает API-ключ от JWT в заголовке Authorization
+const Prefix = "yck_"
+
+// prefixLen — длина видимой части ключа после Prefix
+const prefixLen = 8
+
+// Scope ограничивает, что можно делать с ключом
+type Scope string
+
+const (
+	PostsRead          Scope = "posts:read"
+	PostsWrite         Scope = "posts:write"
+	NotificationsRead  Scope = "notifications:read"
+	NotificationsWrite Scope = "notifications:write"
+	UsersRead          Scope = "users:read"
+)
+
+// Scopes перечисляет все допустимые области доступа
+var Scopes = []Scope{PostsRead, PostsWrite, NotificationsRead, NotificationsWrite, UsersRead}
+
+// ParseScopes проверяет список областей доступа и убирает повторы
+func ParseScopes(names []string) ([]Scope, error) {
+	seen := make(map[Scope]bool, len(names))
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		scope := Scope(name)
+		if !isKnown(scope) {
+			return nil, fmt.Errorf("unknown scope %q", name)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func isKnown(scope Scope) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinScopes переводит список областей в строку через пробел для хранения
+func JoinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, " ")
+}
+
+// SplitScopes разбирает строку, сохранённую JoinScopes
+func SplitScopes(s string) []Scope {
+	fields := strings.Fields(s)
+	scopes := make([]Scope, len(fields))
+	for i, f := range fields {
+		scopes[i] = Scope(f)
+	}
+	return scopes
+}
+
+// Generate создаёт новый ключ. Возвращает сам ключ (показывается пользователю
+// один раз), его видимый префикс и хэш для хранения.
+func Generate() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	key = Prefix + secret
+	prefix = key[:len(Prefix)+prefixLen]
+	return key, prefix, Hash(key), nil
+}
+
+// Hash возвращает SHA-256 хэш ключа
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey сообщает, похожа ли строка из заголовка Authorization на API-ключ
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
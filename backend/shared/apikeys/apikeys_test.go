@@ -0,0 +1,80 @@
+package apikeys
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !IsKey(key) || !strings.HasPrefix(key, prefix) || len(prefix) != len(Prefix)+prefixLen {
+		t.Errorf("key %q, prefix %q", key, prefix)
+	}
+	if hash != Hash(key) || strings.Contains(hash, key[len(Prefix):]) {
+		t.Errorf("hash %q does not match the key", hash)
+	}
+
+	other, _, _, _ := Generate()
+	if other == key {
+		t.Error("two generated keys are equal")
+	}
+}
+
+func TestIsKey(t *testing.T) {
+	if IsKey("eyJhbGciOiJIUzI1NiJ9.e30.sig") {
+		t.Error("JWT recognized as an API key")
+	}
+	if !IsKey("yck_abc") {
+		t.Error("API key not recognized")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes([]string{"posts:read", "users:read", "posts:read"})
+	if err != nil {
+		t.Fatalf("ParseScopes: %v", err)
+	}
+	if got := JoinScopes(scopes); got != "posts:read users:read" {
+		t.Errorf("scopes = %q, duplicates must be removed", got)
+	}
+	if split := SplitScopes(JoinScopes(scopes)); len(split) != 2 || split[0] != PostsRead || split[1] != UsersRead {
+		t.Errorf("SplitScopes = %v", split)
+	}
+
+	for _, bad := range [][]string{{"posts:admin"}, {"posts:read", ""}, {"POSTS:READ"}} {
+		if _, err := ParseScopes(bad); err == nil {
+			t.Errorf("ParseScopes(%q) accepted", bad)
+		}
+	}
+	if got := SplitScopes("  "); len(got) != 0 {
+		t.Errorf("SplitScopes of blank = %v", got)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	h := RequireScope(PostsWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(p *Principal) int {
+		req := httptest.NewRequest(http.MethodPost, "/posts", nil)
+		if p != nil {
+			req = req.WithContext(WithPrincipal(req.Context(), p))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call(nil); code != http.StatusOK {
+		t.Errorf("JWT request: status %d", code)
+	}
+	if code := call(&Principal{Scopes: []Scope{PostsRead, PostsWrite}}); code != http.StatusOK {
+		t.Errorf("key with scope: status %d", code)
+	}
+	if code := call(&Principal{Scopes: []Scope{PostsRead}}); code != http.StatusForbidden {
+		t.Errorf("key without scope: status %d, want 403", code)
+	}
+}
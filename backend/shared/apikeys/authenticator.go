@@ -0,0 +1,68 @@
+package apikeys
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidKey возвращается для неизвестного или отозванного ключа
+var ErrInvalidKey = errors.New("invalid api key")
+
+// Principal — пользователь, аутентифицированный API-ключом
+type Principal struct {
+	KeyID         int
+	UserID        int
+	Role          string
+	EmailVerified bool
+	Scopes        []Scope
+}
+
+// HasScope сообщает, выдана ли ключу область доступа scope
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator проверяет API-ключи по общей базе данных
+type Authenticator struct {
+	db *sql.DB
+}
+
+// NewAuthenticator создаёт Authenticator
+func NewAuthenticator(db *sql.DB) *Authenticator {
+	return &Authenticator{db: db}
+}
+
+// Authenticate находит действующий ключ и его владельца и отмечает время использования
+func (a *Authenticator) Authenticate(key string) (*Principal, error) {
+	var (
+		p      Principal
+		scopes string
+	)
+	err := a.db.QueryRow(`
+		SELECT k.id, k.user_id, k.scopes, u.role, u.email_verified
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL
+	`, Hash(key)).Scan(&p.KeyID, &p.UserID, &scopes, &p.Role, &p.EmailVerified)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidKey
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	p.Scopes = SplitScopes(scopes)
+
+	// Время использования пишется не чаще раза в минуту, чтобы не нагружать базу
+	if _, err := a.db.Exec(`
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`, p.KeyID); err != nil {
+		return nil, fmt.Errorf("failed to record api key usage: %w", err)
+	}
+	return &p, nil
+}
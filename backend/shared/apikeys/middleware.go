@@ -0,0 +1,35 @@
+package apikeys
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+type contextKey struct{}
+
+// WithPrincipal отмечает в контексте, что запрос аутентифицирован API-ключом
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFromContext возвращает владельца ключа, если запрос пришёл с API-ключом
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok
+}
+
+// RequireScope требует область доступа scope у запросов с API-ключом.
+// Запросы с JWT проходят без изменений: пользователь действует от своего имени.
+func RequireScope(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p, ok := PrincipalFromContext(r.Context()); ok && !p.HasScope(scope) {
+				log.Printf("apikeys: key %d lacks scope %s for %s %s", p.KeyID, scope, r.Method, r.URL.Path)
+				http.Error(w, "API key does not have the required scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
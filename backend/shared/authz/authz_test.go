@@ -0,0 +1,129 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"shared/jwtauth"
+)
+
+func TestParseRole(t *testing.T) {
+	for in, want := range map[string]Role{"": RoleUser, "user": RoleUser, "moderator": RoleModerator, "admin": RoleAdmin} {
+		got, err := ParseRole(in)
+		if err != nil || got != want {
+			t.Errorf("ParseRole(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"root", "Admin", " admin"} {
+		if _, err := ParseRole(in); err == nil {
+			t.Errorf("ParseRole(%q) accepted", in)
+		}
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	perms := []Permission{PostsDeleteAny, UsersList, UsersUpdateAny, UsersDelete, UsersManageRoles, NotificationsManageAny}
+	granted := map[Role]map[Permission]bool{
+		RoleUser:      {},
+		RoleModerator: {PostsDeleteAny: true},
+		RoleAdmin:     {PostsDeleteAny: true, UsersList: true, UsersUpdateAny: true, UsersDelete: true, UsersManageRoles: true, NotificationsManageAny: true},
+	}
+	for role, want := range granted {
+		for _, p := range perms {
+			if got := role.Can(p); got != want[p] {
+				t.Errorf("%s.Can(%s) = %v, want %v", role, p, got, want[p])
+			}
+		}
+	}
+	if Role("ghost").Can(PostsDeleteAny) {
+		t.Error("unknown role has permissions")
+	}
+}
+
+func TestSubjectFromClaims(t *testing.T) {
+	s, err := SubjectFromClaims(&jwtauth.Claims{UserID: 4, Role: "moderator"})
+	if err != nil || s != (Subject{UserID: 4, Role: RoleModerator}) {
+		t.Errorf("SubjectFromClaims = %+v, %v", s, err)
+	}
+	// Токены, выданные до появления ролей
+	if s, err := SubjectFromClaims(&jwtauth.Claims{UserID: 4}); err != nil || s.Role != RoleUser {
+		t.Errorf("claims without role: %+v, %v", s, err)
+	}
+	if _, err := SubjectFromClaims(&jwtauth.Claims{UserID: 4, Role: "superuser"}); err == nil {
+		t.Error("unknown role claim accepted")
+	}
+}
+
+// serve выполняет запрос от имени subject; nil означает анонимный запрос
+func serve(h http.Handler, subject *Subject, path string) int {
+	req := httptest.NewRequest(http.MethodDelete, path, nil)
+	if subject != nil {
+		req = req.WithContext(WithSubject(req.Context(), *subject))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequire(t *testing.T) {
+	h := Require(UsersDelete)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	if code := serve(h, &Subject{UserID: 1, Role: RoleAdmin}, "/users/2"); code != http.StatusOK {
+		t.Errorf("admin: status %d", code)
+	}
+	if code := serve(h, &Subject{UserID: 2, Role: RoleModerator}, "/users/2"); code != http.StatusForbidden {
+		t.Errorf("moderator: status %d, want 403", code)
+	}
+	if code := serve(h, nil, "/users/2"); code != http.StatusForbidden {
+		t.Errorf("anonymous: status %d, want 403", code)
+	}
+
+	both := Require(PostsDeleteAny, UsersList)(http.NotFoundHandler())
+	if code := serve(both, &Subject{UserID: 1, Role: RoleModerator}, "/"); code != http.StatusForbidden {
+		t.Errorf("all permissions are required, moderator got %d", code)
+	}
+}
+
+func TestRequireSelfOr(t *testing.T) {
+	owner := func(r *http.Request) (int, bool) {
+		id, err := strconv.Atoi(r.URL.Path[len("/users/"):])
+		return id, err == nil
+	}
+	h := RequireSelfOr(owner, UsersUpdateAny)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		subject Subject
+		path    string
+		want    int
+	}{
+		{Subject{UserID: 7, Role: RoleUser}, "/users/7", http.StatusOK},
+		{Subject{UserID: 7, Role: RoleUser}, "/users/8", http.StatusForbidden},
+		{Subject{UserID: 7, Role: RoleModerator}, "/users/8", http.StatusForbidden},
+		{Subject{UserID: 1, Role: RoleAdmin}, "/users/8", http.StatusOK},
+		{Subject{UserID: 7, Role: RoleUser}, "/users/me", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		subject := tt.subject
+		if code := serve(h, &subject, tt.path); code != tt.want {
+			t.Errorf("%+v %s: status %d, want %d", tt.subject, tt.path, code, tt.want)
+		}
+	}
+}
+
+func TestContextHelpers(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := SubjectFromContext(ctx); ok {
+		t.Error("empty context has a subject")
+	}
+	if Can(ctx, PostsDeleteAny) || IsSelfOr(ctx, 0, PostsDeleteAny) {
+		t.Error("anonymous context is granted access")
+	}
+
+	ctx = WithSubject(ctx, Subject{UserID: 3, Role: RoleUser})
+	if !IsSelfOr(ctx, 3, UsersUpdateAny) || IsSelfOr(ctx, 4, UsersUpdateAny) {
+		t.Error("IsSelfOr does not match the owner")
+	}
+}
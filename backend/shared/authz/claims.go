@@ -0,0 +1,16 @@
+package authz
+
+import (
+	"fmt"
+
+	"shared/jwtauth"
+)
+
+// SubjectFromClaims строит Subject из проверенного access-токена
+func SubjectFromClaims(claims *jwtauth.Claims) (Subject, error) {
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return Subject{}, fmt.Errorf("invalid role claim: %w", err)
+	}
+	return Subject{UserID: claims.UserID, Role: role}, nil
+}
@@ -0,0 +1,80 @@
+package authz
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// Subject — аутентифицированный пользователь, выполняющий запрос
+type Subject struct {
+	UserID int
+	Role   Role
+}
+
+type contextKey struct{}
+
+// WithSubject кладёт Subject в контекст. Вызывается AuthMiddleware каждого сервиса.
+func WithSubject(ctx context.Context, s Subject) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// SubjectFromContext возвращает Subject запроса, если он аутентифицирован
+func SubjectFromContext(ctx context.Context) (Subject, bool) {
+	s, ok := ctx.Value(contextKey{}).(Subject)
+	return s, ok
+}
+
+// Can сообщает, есть ли у пользователя запроса право p
+func Can(ctx context.Context, p Permission) bool {
+	s, ok := SubjectFromContext(ctx)
+	return ok && s.Role.Can(p)
+}
+
+// IsSelfOr сообщает, совпадает ли пользователь запроса с userID или есть ли у него право p
+func IsSelfOr(ctx context.Context, userID int, p Permission) bool {
+	s, ok := SubjectFromContext(ctx)
+	return ok && (s.UserID == userID || s.Role.Can(p))
+}
+
+// Require пропускает запрос, только если у пользователя есть все перечисленные права.
+// Подключается к маршруту после AuthMiddleware.
+func Require(perms ...Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range perms {
+				if !Can(r.Context(), p) {
+					deny(w, r, p)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSelfOr пропускает запрос, если owner(r) совпадает с пользователем
+// запроса или у пользователя есть право p. owner возвращает false, если
+// владельца определить не удалось — такой запрос отклоняется с 400.
+func RequireSelfOr(owner func(*http.Request) (int, bool), p Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := owner(r)
+			if !ok {
+				http.Error(w, "Invalid user ID", http.StatusBadRequest)
+				return
+			}
+			if !IsSelfOr(r.Context(), userID, p) {
+				deny(w, r, p)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func deny(w http.ResponseWriter, r *http.Request, p Permission) {
+	s, _ := SubjectFromContext(r.Context())
+	log.Printf("authz: %s %s denied for user %d (role %q), requires %s", r.Method, r.URL.Path, s.UserID, s.Role, p)
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
@@ -0,0 +1,69 @@
+// Package authz описывает роли пользователей и проверку прав доступа к маршрутам.
+package authz
+
+import "fmt"
+
+// Role — роль пользователя, хранится в users_service и передаётся в claim "role"
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission — право на действие, которое не следует из владения ресурсом
+type Permission string
+
+const (
+	// PostsDeleteAny — удаление чужих постов
+	PostsDeleteAny Permission = "posts:delete_any"
+	// UsersList — просмотр списка всех пользователей
+	UsersList Permission = "users:list"
+	// UsersUpdateAny — изменение чужого профиля
+	UsersUpdateAny Permission = "users:update_any"
+	// UsersDelete — удаление пользователей
+	UsersDelete Permission = "users:delete"
+	// UsersManageRoles — назначение ролей
+	UsersManageRoles Permission = "users:manage_roles"
+	// NotificationsManageAny — доступ к чужим уведомлениям
+	NotificationsManageAny Permission = "notifications:manage_any"
+)
+
+// rolePermissions перечисляет права каждой роли. Обычному пользователю
+// дополнительные права не нужны: со своими ресурсами он работает как владелец.
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleModerator: {PostsDeleteAny},
+	RoleAdmin: {
+		PostsDeleteAny,
+		UsersList,
+		UsersUpdateAny,
+		UsersDelete,
+		UsersManageRoles,
+		NotificationsManageAny,
+	},
+}
+
+// ParseRole проверяет название роли. Пустая строка означает RoleUser:
+// так обрабатываются токены, выданные до появления ролей.
+func ParseRole(s string) (Role, error) {
+	if s == "" {
+		return RoleUser, nil
+	}
+	role := Role(s)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
+
+// Can сообщает, есть ли у роли право p
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+module shared
+
+go 1.21
+
+require (
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.30.0
+)
+
+require golang.org/x/sys v0.28.0 // indirect
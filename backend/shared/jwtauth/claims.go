@@ -0,0 +1,27 @@
+package jwtauth
+
+import "github.com/dgrijalva/jwt-go"
+
+// PurposePendingMFA помечает промежуточный токен, выдаваемый после проверки
+// пароля, пока пользователь не ввёл код второго фактора
+const PurposePendingMFA = "mfa_pending"
+
+// PurposeService помечает сервисный токен: его выпускает auth_service для
+// обращений к другим сервисам. Имя сервиса хранится в claim sub, user_id пуст.
+const PurposeService = "service"
+
+// Claims — типизированное содержимое токена
+type Claims struct {
+	UserID        int    `json:"user_id"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified"`
+	// Role — роль пользователя (см. пакет authz); пуста у токенов, выданных до появления ролей
+	Role string `json:"role,omitempty"`
+	// TokenVersion — users.token_version на момент выдачи; при смене пароля версия
+	// растёт, и ранее выданные токены отклоняются (см. пакет tokenversion)
+	TokenVersion int `json:"tv,omitempty"`
+	// Purpose пуст у access-токенов. Токены с непустым Purpose принимаются
+	// только VerifyPurpose и не открывают доступ к API.
+	Purpose string `json:"purpose,omitempty"`
+	jwt.StandardClaims
+}
@@ -0,0 +1,124 @@
+// Package jwtauth содержит общую для всех сервисов логику выпуска и проверки JWT.
+package jwtauth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIssuer   = "auth-service"
+	defaultAudience = "yandexcloud-api"
+	defaultLeeway   = 30 * time.Second
+	defaultKeyID    = "default"
+)
+
+// Config описывает ключи и ожидаемые значения стандартных claims
+type Config struct {
+	// Keys содержит все действующие ключи, индексированные по kid
+	Keys map[string]Key
+	// ActiveKeyID — kid ключа, которым подписываются новые токены
+	ActiveKeyID string
+	Issuer      string
+	Audience    string
+	// Leeway — допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration
+}
+
+// LoadConfig читает конфигурацию из переменных окружения:
+//
+//	JWT_PRIVATE_KEYS — список асимметричных ключей через запятую в формате kid:/path/key.pem
+//	JWT_KEYS         — список HMAC-ключей через запятую в формате kid:secret
+//	JWT_SECRET       — единственный HMAC-ключ с kid "default", если списки не заданы
+//	JWT_ACTIVE_KID   — kid ключа для подписи (по умолчанию первый из списка)
+//	JWT_ISSUER, JWT_AUDIENCE, JWT_LEEWAY
+//
+// Если задан JWT_PRIVATE_KEYS, HMAC-ключи не загружаются.
+func LoadConfig() (*Config, error) {
+	cfg, err := loadBaseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case os.Getenv("JWT_PRIVATE_KEYS") != "":
+		err = parseKeyList(cfg, "JWT_PRIVATE_KEYS", LoadPrivateKey)
+	case os.Getenv("JWT_KEYS") != "":
+		err = parseKeyList(cfg, "JWT_KEYS", func(kid, secret string) (Key, error) {
+			return NewHMACKey(kid, []byte(secret)), nil
+		})
+	case os.Getenv("JWT_SECRET") != "":
+		cfg.Keys[defaultKeyID] = NewHMACKey(defaultKeyID, []byte(os.Getenv("JWT_SECRET")))
+		cfg.ActiveKeyID = defaultKeyID
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Keys) == 0 {
+		return nil, fmt.Errorf("no signing keys configured: set JWT_PRIVATE_KEYS, JWT_KEYS or JWT_SECRET")
+	}
+
+	if kid := os.Getenv("JWT_ACTIVE_KID"); kid != "" {
+		if _, ok := cfg.Keys[kid]; !ok {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID %q is not among configured keys", kid)
+		}
+		cfg.ActiveKeyID = kid
+	}
+
+	return cfg, nil
+}
+
+// loadBaseConfig читает параметры проверки claims без ключей
+func loadBaseConfig() (*Config, error) {
+	cfg := &Config{
+		Keys:     make(map[string]Key),
+		Issuer:   envOrDefault("JWT_ISSUER", defaultIssuer),
+		Audience: envOrDefault("JWT_AUDIENCE", defaultAudience),
+		Leeway:   defaultLeeway,
+	}
+
+	if v := os.Getenv("JWT_LEEWAY"); v != "" {
+		leeway, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_LEEWAY: %w", err)
+		}
+		cfg.Leeway = leeway
+	}
+	return cfg, nil
+}
+
+// parseKeyList разбирает список вида kid:value,kid:value из переменной окружения
+func parseKeyList(cfg *Config, name string, load func(kid, value string) (Key, error)) error {
+	for _, entry := range strings.Split(os.Getenv(name), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, value, ok := strings.Cut(entry, ":")
+		if !ok || kid == "" || value == "" {
+			return fmt.Errorf("invalid %s entry: expected kid:value", name)
+		}
+		if _, exists := cfg.Keys[kid]; exists {
+			return fmt.Errorf("duplicate kid %q in %s", kid, name)
+		}
+		key, err := load(kid, value)
+		if err != nil {
+			return fmt.Errorf("failed to load key %q: %w", kid, err)
+		}
+		cfg.Keys[kid] = key
+		if cfg.ActiveKeyID == "" {
+			cfg.ActiveKeyID = kid
+		}
+	}
+	return nil
+}
+
+func envOrDefault(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,80 @@
+package jwtauth
+
+import (
+	"testing"
+	"time"
+)
+
+// clearJWTEnv сбрасывает переменные, которые читает LoadConfig
+func clearJWTEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"JWT_PRIVATE_KEYS", "JWT_KEYS", "JWT_SECRET", "JWT_ACTIVE_KID", "JWT_ISSUER", "JWT_AUDIENCE", "JWT_LEEWAY"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadConfigSingleSecret(t *testing.T) {
+	clearJWTEnv(t)
+	t.Setenv("JWT_SECRET", "s3cret")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.ActiveKeyID != defaultKeyID || len(cfg.Keys) != 1 {
+		t.Fatalf("keys = %v, active %q; want only %q", cfg.Keys, cfg.ActiveKeyID, defaultKeyID)
+	}
+	if cfg.Issuer != defaultIssuer || cfg.Audience != defaultAudience || cfg.Leeway != defaultLeeway {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+}
+
+func TestLoadConfigKeyList(t *testing.T) {
+	clearJWTEnv(t)
+	t.Setenv("JWT_KEYS", "2024:old, 2025:new")
+	t.Setenv("JWT_ISSUER", "issuer")
+	t.Setenv("JWT_AUDIENCE", "audience")
+	t.Setenv("JWT_LEEWAY", "5s")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(cfg.Keys))
+	}
+	// По умолчанию подписывает первый ключ списка
+	if cfg.ActiveKeyID != "2024" {
+		t.Errorf("active key = %q, want 2024", cfg.ActiveKeyID)
+	}
+	if cfg.Issuer != "issuer" || cfg.Audience != "audience" || cfg.Leeway != 5*time.Second {
+		t.Errorf("overrides not applied: %+v", cfg)
+	}
+
+	t.Setenv("JWT_ACTIVE_KID", "2025")
+	if cfg, err = LoadConfig(); err != nil || cfg.ActiveKeyID != "2025" {
+		t.Errorf("JWT_ACTIVE_KID: active %q, err %v", cfg.ActiveKeyID, err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	cases := map[string]map[string]string{
+		"no keys":           {},
+		"entry without kid": {"JWT_KEYS": "secret"},
+		"empty secret":      {"JWT_KEYS": "kid:"},
+		"duplicate kid":     {"JWT_KEYS": "a:one,a:two"},
+		"unknown active":    {"JWT_KEYS": "a:one", "JWT_ACTIVE_KID": "b"},
+		"bad leeway":        {"JWT_SECRET": "x", "JWT_LEEWAY": "soon"},
+	}
+	for name, env := range cases {
+		t.Run(name, func(t *testing.T) {
+			clearJWTEnv(t)
+			for k, v := range env {
+				t.Setenv(k, v)
+			}
+			if cfg, err := LoadConfig(); err == nil {
+				t.Errorf("LoadConfig succeeded with %+v", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS — набор открытых ключей, публикуемый по /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS собирает набор открытых ключей из конфигурации.
+// Симметричные ключи никогда не публикуются.
+func PublicJWKS(cfg *Config) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range cfg.Keys {
+		jwk, err := toJWK(key)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func toJWK(key Key) (JWK, error) {
+	switch pub := key.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	}
+	return JWK{}, fmt.Errorf("key %q is not asymmetric", key.ID)
+}
+
+// Key преобразует JWK в ключ проверки
+func (j JWK) Key() (Key, error) {
+	switch j.Kty {
+	case "RSA":
+		if j.Alg != "" && j.Alg != jwt.SigningMethodRS256.Alg() {
+			return Key{}, fmt.Errorf("unsupported algorithm %q", j.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid exponent: %w", err)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return Key{ID: j.Kid, Method: jwt.SigningMethodRS256, VerifyKey: pub}, nil
+	case "OKP":
+		if j.Alg != "" && j.Alg != SigningMethodEdDSA.Alg() {
+			return Key{}, fmt.Errorf("unsupported algorithm %q", j.Alg)
+		}
+		if j.Crv != "Ed25519" {
+			return Key{}, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return Key{}, fmt.Errorf("invalid Ed25519 public key")
+		}
+		return Key{ID: j.Kid, Method: SigningMethodEdDSA, VerifyKey: ed25519.PublicKey(x)}, nil
+	}
+	return Key{}, fmt.Errorf("unsupported key type %q", j.Kty)
+}
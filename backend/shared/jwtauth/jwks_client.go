@@ -0,0 +1,112 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSCacheTTL   = 10 * time.Minute
+	defaultJWKSMinRefresh = 10 * time.Second
+)
+
+// JWKSClient загружает открытые ключи auth_service и кэширует их.
+// Набор обновляется по истечении TTL, а также при встрече неизвестного kid,
+// но не чаще одного раза в minRefresh, чтобы токены со случайным kid
+// не превращались в поток запросов к auth_service.
+type JWKSClient struct {
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]Key
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewJWKSClient создаёт клиент для JWKS по указанному адресу
+func NewJWKSClient(url string, ttl time.Duration) *JWKSClient {
+	if ttl <= 0 {
+		ttl = defaultJWKSCacheTTL
+	}
+	return &JWKSClient{
+		url:        url,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		ttl:        ttl,
+		minRefresh: defaultJWKSMinRefresh,
+		keys:       make(map[string]Key),
+	}
+}
+
+// VerificationKey реализует KeyProvider
+func (c *JWKSClient) VerificationKey(kid string) (Key, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	fresh := time.Since(c.fetchedAt) < c.ttl
+	c.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := c.refresh(); err != nil {
+		log.Printf("JWKSClient: %v", err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// Если обновить набор не удалось, продолжаем доверять ранее загруженному ключу
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return Key{}, ErrUnknownKey
+}
+
+// refresh перезагружает набор ключей, соблюдая минимальный интервал между попытками
+func (c *JWKSClient) refresh() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.lastAttempt) < c.minRefresh {
+		return nil
+	}
+	c.lastAttempt = time.Now()
+
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			log.Printf("JWKSClient: skipping key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,166 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func loadKey(t *testing.T, kid, path string) Key {
+	t.Helper()
+	key, err := LoadPrivateKey(kid, path)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey: %v", err)
+	}
+	return key
+}
+
+func TestPublicJWKS(t *testing.T) {
+	cfg := &Config{Keys: map[string]Key{
+		"b-ed":  loadKey(t, "b-ed", writeEd25519Key(t)),
+		"a-rsa": {ID: "a-rsa", Method: jwt.SigningMethodRS256, SignKey: testRSAKey, VerifyKey: &testRSAKey.PublicKey},
+		"hmac":  NewHMACKey("hmac", []byte("secret")),
+	}}
+
+	set := PublicJWKS(cfg)
+	if len(set.Keys) != 2 {
+		t.Fatalf("published %d keys, want 2 (HMAC must stay private)", len(set.Keys))
+	}
+	rsaJWK, edJWK := set.Keys[0], set.Keys[1]
+	if rsaJWK.Kid != "a-rsa" || rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || rsaJWK.Use != "sig" {
+		t.Errorf("rsa jwk = %+v", rsaJWK)
+	}
+	if edJWK.Kid != "b-ed" || edJWK.Kty != "OKP" || edJWK.Crv != "Ed25519" || edJWK.Alg != "EdDSA" {
+		t.Errorf("ed25519 jwk = %+v", edJWK)
+	}
+
+	// Ключи из JWKS проверяют токены, подписанные закрытыми ключами
+	for _, jwk := range set.Keys {
+		pub, err := jwk.Key()
+		if err != nil {
+			t.Fatalf("%s: Key: %v", jwk.Kid, err)
+		}
+		signCfg := &Config{Keys: map[string]Key{jwk.Kid: cfg.Keys[jwk.Kid]}, ActiveKeyID: jwk.Kid, Issuer: "i", Audience: "a"}
+		token := mustSign(t, signCfg, Claims{UserID: 1}, time.Minute)
+		verifyCfg := &Config{Issuer: "i", Audience: "a"}
+		if _, err := NewVerifierWithKeys(verifyCfg, StaticKeys{jwk.Kid: pub}).Verify(token); err != nil {
+			t.Errorf("%s: token rejected by the published key: %v", jwk.Kid, err)
+		}
+	}
+}
+
+func TestJWKKeyRejectsUnsupported(t *testing.T) {
+	cases := map[string]JWK{
+		"unknown kty":  {Kty: "EC", Kid: "k"},
+		"rsa with hs":  {Kty: "RSA", Kid: "k", Alg: "HS256", N: "AQAB", E: "AQAB"},
+		"okp curve":    {Kty: "OKP", Kid: "k", Crv: "X25519", X: "AAAA"},
+		"okp short":    {Kty: "OKP", Kid: "k", Crv: "Ed25519", X: "AAAA"},
+		"okp with rsa": {Kty: "OKP", Kid: "k", Alg: "RS256", Crv: "Ed25519"},
+		"rsa bad n":    {Kty: "RSA", Kid: "k", N: "!!", E: "AQAB"},
+	}
+	for name, jwk := range cases {
+		if key, err := jwk.Key(); err == nil {
+			t.Errorf("%s: accepted as %+v", name, key)
+		}
+	}
+}
+
+// jwksServer публикует набор ключей, который тест может заменить, и считает запросы
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	set      JWKS
+	requests int
+}
+
+func newJWKSServer(t *testing.T, set JWKS) *jwksServer {
+	s := &jwksServer{set: set}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		json.NewEncoder(w).Encode(s.set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(set JWKS) {
+	s.mu.Lock()
+	s.set = set
+	s.mu.Unlock()
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func TestJWKSClientPicksUpRotatedKey(t *testing.T) {
+	first := loadKey(t, "2024", writeEd25519Key(t))
+	second := loadKey(t, "2025", writeEd25519Key(t))
+	server := newJWKSServer(t, PublicJWKS(&Config{Keys: map[string]Key{"2024": first}}))
+
+	client := NewJWKSClient(server.URL, time.Hour)
+	client.minRefresh = 0
+	if _, err := client.VerificationKey("2024"); err != nil {
+		t.Fatalf("first key: %v", err)
+	}
+	// Пока ключ в кэше, auth_service не опрашивается
+	client.VerificationKey("2024")
+	if n := server.count(); n != 1 {
+		t.Errorf("%d JWKS requests for a cached key, want 1", n)
+	}
+
+	// auth_service перешёл на новый ключ: неизвестный kid обновляет набор
+	server.publish(PublicJWKS(&Config{Keys: map[string]Key{"2024": first, "2025": second}}))
+	if _, err := client.VerificationKey("2025"); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if n := server.count(); n != 2 {
+		t.Errorf("%d JWKS requests after rotation, want 2", n)
+	}
+}
+
+func TestJWKSClientThrottlesUnknownKids(t *testing.T) {
+	key := loadKey(t, "k", writeEd25519Key(t))
+	server := newJWKSServer(t, PublicJWKS(&Config{Keys: map[string]Key{"k": key}}))
+	client := NewJWKSClient(server.URL, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		if _, err := client.VerificationKey("random"); err != ErrUnknownKey {
+			t.Fatalf("unknown kid: err = %v, want ErrUnknownKey", err)
+		}
+	}
+	// Поток токенов со случайным kid не превращается в поток запросов
+	if n := server.count(); n != 1 {
+		t.Errorf("%d JWKS requests, want 1", n)
+	}
+	// Загруженный при первой попытке ключ доступен без нового запроса
+	if _, err := client.VerificationKey("k"); err != nil {
+		t.Errorf("known kid: %v", err)
+	}
+}
+
+func TestJWKSClientKeepsKeysWhenUnavailable(t *testing.T) {
+	key := loadKey(t, "k", writeEd25519Key(t))
+	server := newJWKSServer(t, PublicJWKS(&Config{Keys: map[string]Key{"k": key}}))
+	client := NewJWKSClient(server.URL, time.Millisecond)
+	client.minRefresh = 0
+	if _, err := client.VerificationKey("k"); err != nil {
+		t.Fatalf("VerificationKey: %v", err)
+	}
+
+	// Кэш истёк, а auth_service недоступен: ранее загруженный ключ продолжает действовать
+	server.Close()
+	time.Sleep(5 * time.Millisecond)
+	if _, err := client.VerificationKey("k"); err != nil {
+		t.Errorf("cached key after a failed refresh: %v", err)
+	}
+}
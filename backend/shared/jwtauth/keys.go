@@ -0,0 +1,99 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrUnknownKey возвращается, если в токене указан неизвестный kid
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key описывает ключ подписи вместе с алгоритмом, которым он может использоваться
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// SignKey используется для подписи, VerifyKey — для проверки.
+	// Для HMAC это один и тот же секрет.
+	SignKey   interface{}
+	VerifyKey interface{}
+}
+
+// NewHMACKey создаёт симметричный ключ HS256
+func NewHMACKey(kid string, secret []byte) Key {
+	return Key{
+		ID:        kid,
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   secret,
+		VerifyKey: secret,
+	}
+}
+
+// LoadPrivateKey читает PKCS#8 PEM-файл с ключом RSA или Ed25519 и создаёт
+// асимметричный ключ: RSA подписывается RS256, Ed25519 — EdDSA.
+// Сгенерировать ключ можно так: openssl genpkey -algorithm ed25519 -out jwt.pem
+func LoadPrivateKey(kid, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch privateKey := parsed.(type) {
+	case *rsa.PrivateKey:
+		return Key{ID: kid, Method: jwt.SigningMethodRS256, SignKey: privateKey, VerifyKey: &privateKey.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return Key{ID: kid, Method: SigningMethodEdDSA, SignKey: privateKey, VerifyKey: privateKey.Public()}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+// IsAsymmetric сообщает, можно ли публиковать ключ проверки
+func (k Key) IsAsymmetric() bool {
+	switch k.VerifyKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return true
+	}
+	return false
+}
+
+// KeyProvider возвращает ключ проверки по kid
+type KeyProvider interface {
+	VerificationKey(kid string) (Key, error)
+}
+
+// StaticKeys — набор ключей, заданный конфигурацией
+type StaticKeys map[string]Key
+
+// VerificationKey реализует KeyProvider
+func (k StaticKeys) VerificationKey(kid string) (Key, error) {
+	key, ok := k[kid]
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+	return key, nil
+}
@@ -0,0 +1,142 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM сохраняет блок PEM во временный файл и возвращает путь
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return path
+}
+
+func writeEd25519Key(t *testing.T) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+// testRSAKey генерируется один раз: генерация RSA заметно медленнее остальных тестов
+var testRSAKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+func TestLoadPrivateKey(t *testing.T) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(testRSAKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		path string
+		alg  string
+	}{
+		{"ed25519", writeEd25519Key(t), "EdDSA"},
+		{"rsa pkcs8", writePEM(t, "PRIVATE KEY", pkcs8), "RS256"},
+		{"rsa pkcs1", writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(testRSAKey)), "RS256"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := LoadPrivateKey("k", tc.path)
+			if err != nil {
+				t.Fatalf("LoadPrivateKey: %v", err)
+			}
+			if key.ID != "k" || key.Method.Alg() != tc.alg || !key.IsAsymmetric() {
+				t.Errorf("key = %s/%s, asymmetric %v", key.ID, key.Method.Alg(), key.IsAsymmetric())
+			}
+
+			// Подписанный закрытым ключом токен проверяется открытым
+			cfg := &Config{Keys: map[string]Key{"k": key}, ActiveKeyID: "k", Issuer: "i", Audience: "a"}
+			token := mustSign(t, cfg, Claims{UserID: 2}, time.Minute)
+			if _, err := NewVerifier(cfg).Verify(token); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+		})
+	}
+
+	if NewHMACKey("h", []byte("s")).IsAsymmetric() {
+		t.Error("HMAC key reported as asymmetric")
+	}
+}
+
+func TestLoadPrivateKeyErrors(t *testing.T) {
+	ecKey := writePEM(t, "EC PRIVATE KEY", []byte{1, 2, 3})
+	notPEM := filepath.Join(t.TempDir(), "plain.txt")
+	os.WriteFile(notPEM, []byte("not a key"), 0o600)
+	broken := writePEM(t, "PRIVATE KEY", []byte("garbage"))
+
+	for name, path := range map[string]string{
+		"missing file":      filepath.Join(t.TempDir(), "missing.pem"),
+		"not pem":           notPEM,
+		"unsupported block": ecKey,
+		"broken der":        broken,
+	} {
+		if _, err := LoadPrivateKey("k", path); err == nil {
+			t.Errorf("%s: LoadPrivateKey succeeded", name)
+		}
+	}
+}
+
+func TestLoadConfigPrivateKeys(t *testing.T) {
+	clearJWTEnv(t)
+	t.Setenv("JWT_PRIVATE_KEYS", "ed:"+writeEd25519Key(t))
+	// HMAC-ключи при заданных закрытых ключах не загружаются
+	t.Setenv("JWT_SECRET", "ignored")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Keys) != 1 || cfg.ActiveKeyID != "ed" {
+		t.Errorf("keys %d, active %q; want only ed", len(cfg.Keys), cfg.ActiveKeyID)
+	}
+
+	t.Setenv("JWT_PRIVATE_KEYS", "ed:/nonexistent/key.pem")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig accepted a missing key file")
+	}
+}
+
+func TestEdDSARejectsWrongKeyTypes(t *testing.T) {
+	if _, err := SigningMethodEdDSA.Sign("payload", []byte("secret")); err == nil {
+		t.Error("Sign accepted an HMAC secret")
+	}
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	sig, err := SigningMethodEdDSA.Sign("payload", priv)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := SigningMethodEdDSA.Verify("payload", sig, pub); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if err := SigningMethodEdDSA.Verify("tampered", sig, pub); err == nil {
+		t.Error("Verify accepted a tampered payload")
+	}
+	if err := SigningMethodEdDSA.Verify("payload", sig, []byte("secret")); err == nil {
+		t.Error("Verify accepted an HMAC secret")
+	}
+}
@@ -0,0 +1,47 @@
+package jwtauth
+
+import (
+	"sync"
+	"time"
+)
+
+// SignService выпускает сервисный токен для сервиса name
+func (s *Signer) SignService(name string, ttl time.Duration) (string, time.Time, error) {
+	claims := Claims{Purpose: PurposeService}
+	claims.Subject = name
+	return s.Sign(claims, ttl)
+}
+
+// ServiceTokenSource выдаёт сервисный токен и перевыпускает его незадолго
+// до истечения, чтобы не подписывать токен на каждый запрос
+type ServiceTokenSource struct {
+	signer *Signer
+	name   string
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewServiceTokenSource создаёт источник сервисных токенов для сервиса name
+func NewServiceTokenSource(signer *Signer, name string, ttl time.Duration) *ServiceTokenSource {
+	return &ServiceTokenSource{signer: signer, name: name, ttl: ttl}
+}
+
+// Token возвращает действующий сервисный токен
+func (s *ServiceTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Перевыпускаем, когда осталось меньше трети срока
+	if s.token != "" && time.Until(s.expiresAt) > s.ttl/3 {
+		return s.token, nil
+	}
+	token, expiresAt, err := s.signer.SignService(s.name, s.ttl)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
@@ -0,0 +1,103 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource выдаёт действующий сервисный токен
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// ServiceTokenClient получает сервисные токены у auth_service по имени и
+// секрету сервиса (SERVICE_CLIENTS в auth_service) и кэширует их до
+// незадолго до истечения. Ключей подписи у сервиса при этом нет.
+type ServiceTokenClient struct {
+	url    string
+	name   string
+	secret string
+	client *http.Client
+
+	mu        sync.Mutex
+	token     string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// NewServiceTokenClient создаёт клиент для auth_service по адресу authURL
+func NewServiceTokenClient(authURL, name, secret string) *ServiceTokenClient {
+	return &ServiceTokenClient{
+		url:    strings.TrimRight(authURL, "/") + "/service-token",
+		name:   name,
+		secret: secret,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ErrServiceClientNotConfigured возвращается ServiceTokenClientFromEnv, если
+// не задана ни одна из переменных: сервис работает без сервисных токенов
+var ErrServiceClientNotConfigured = errors.New("service token client is not configured")
+
+// ServiceTokenClientFromEnv создаёт клиент для сервиса name по переменным
+// AUTH_SERVICE_URL и SERVICE_CLIENT_SECRET. Если не задана ни одна из них,
+// возвращает ErrServiceClientNotConfigured; если только одна — ошибку конфигурации.
+func ServiceTokenClientFromEnv(name string) (*ServiceTokenClient, error) {
+	authURL, secret := os.Getenv("AUTH_SERVICE_URL"), os.Getenv("SERVICE_CLIENT_SECRET")
+	if authURL == "" && secret == "" {
+		return nil, ErrServiceClientNotConfigured
+	}
+	if authURL == "" || secret == "" {
+		return nil, fmt.Errorf("AUTH_SERVICE_URL and SERVICE_CLIENT_SECRET must be set together")
+	}
+	return NewServiceTokenClient(authURL, name, secret), nil
+}
+
+// Token возвращает действующий сервисный токен, при необходимости запрашивая новый
+func (c *ServiceTokenClient) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Запрашиваем новый, когда осталось меньше трети срока
+	if c.token != "" && time.Until(c.expiresAt) > c.expiresAt.Sub(c.issuedAt)/3 {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(c.name, c.secret)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch service token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch service token: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token     string `json:"token"`
+		ExpiresIn int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode service token: %w", err)
+	}
+	if body.Token == "" || body.ExpiresIn <= 0 {
+		return "", fmt.Errorf("auth service returned an invalid service token")
+	}
+
+	c.token = body.Token
+	c.issuedAt = time.Now()
+	c.expiresAt = c.issuedAt.Add(time.Duration(body.ExpiresIn) * time.Second)
+	return c.token, nil
+}
@@ -0,0 +1,145 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServiceTokensAreSeparateFromUserTokens(t *testing.T) {
+	cfg := hmacConfig("a", "a")
+	signer, _ := NewSigner(cfg)
+	v := NewVerifier(cfg)
+
+	service, _, err := signer.SignService("posts-service", time.Minute)
+	if err != nil {
+		t.Fatalf("SignService: %v", err)
+	}
+	if name, err := v.VerifyService(service); err != nil || name != "posts-service" {
+		t.Errorf("VerifyService = %q, %v", name, err)
+	}
+	if _, err := v.Verify(service); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("service token accepted as a user token: %v", err)
+	}
+
+	user := mustSign(t, cfg, Claims{UserID: 1}, time.Minute)
+	if _, err := v.VerifyService(user); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("user token accepted as a service token: %v", err)
+	}
+
+	// Сервисный токен без имени сервиса
+	anonymous := mustSign(t, cfg, Claims{Purpose: PurposeService}, time.Minute)
+	if _, err := v.VerifyService(anonymous); err == nil {
+		t.Error("service token without a name accepted")
+	}
+}
+
+func TestServiceTokenChecksSkipUserChecks(t *testing.T) {
+	cfg := hmacConfig("a", "a")
+	v := NewVerifier(cfg)
+	v.AddCheck(func(*Claims) error { return errors.New("revoked") })
+
+	signer, _ := NewSigner(cfg)
+	token, _, _ := signer.SignService("users-service", time.Minute)
+	if _, err := v.VerifyService(token); err != nil {
+		t.Errorf("user token checks applied to a service token: %v", err)
+	}
+}
+
+func TestServiceTokenSourceReusesToken(t *testing.T) {
+	signer, _ := NewSigner(hmacConfig("a", "a"))
+	src := NewServiceTokenSource(signer, "auth-service", time.Hour)
+
+	first, err := src.Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	second, _ := src.Token()
+	if first != second {
+		t.Error("token was re-signed while it is still fresh")
+	}
+
+	// Осталось меньше трети срока: токен перевыпускается
+	src.expiresAt = time.Now().Add(10 * time.Minute)
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if time.Until(src.expiresAt) < 50*time.Minute {
+		t.Error("token close to expiry was not renewed")
+	}
+}
+
+// tokenEndpoint имитирует /service-token auth_service
+func tokenEndpoint(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, secret, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || r.URL.Path != "/service-token" || !ok || name != "posts-service" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&calls, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": "token-" + string(rune('0'+n)), "expires_in": expiresIn})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestServiceTokenClientCachesToken(t *testing.T) {
+	srv, calls := tokenEndpoint(t, 300)
+	c := NewServiceTokenClient(srv.URL+"/", "posts-service", "s3cret")
+
+	for i := 0; i < 3; i++ {
+		token, err := c.Token()
+		if err != nil || token != "token-1" {
+			t.Fatalf("Token = %q, %v", token, err)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("auth service called %d times, want 1", *calls)
+	}
+
+	// Из пяти минут срока осталась одна, меньше трети
+	c.issuedAt = time.Now().Add(-4 * time.Minute)
+	c.expiresAt = time.Now().Add(time.Minute)
+	if token, _ := c.Token(); token != "token-2" {
+		t.Errorf("token close to expiry was not renewed: %q", token)
+	}
+}
+
+func TestServiceTokenClientErrors(t *testing.T) {
+	srv, _ := tokenEndpoint(t, 300)
+	if _, err := NewServiceTokenClient(srv.URL, "posts-service", "wrong").Token(); err == nil {
+		t.Error("rejected credentials did not produce an error")
+	}
+
+	bad, _ := tokenEndpoint(t, 0)
+	if _, err := NewServiceTokenClient(bad.URL, "posts-service", "s3cret").Token(); err == nil {
+		t.Error("token without expiry accepted")
+	}
+}
+
+func TestServiceTokenClientFromEnv(t *testing.T) {
+	t.Setenv("AUTH_SERVICE_URL", "")
+	t.Setenv("SERVICE_CLIENT_SECRET", "")
+	if _, err := ServiceTokenClientFromEnv("posts-service"); !errors.Is(err, ErrServiceClientNotConfigured) {
+		t.Errorf("unset: err = %v, want ErrServiceClientNotConfigured", err)
+	}
+
+	t.Setenv("AUTH_SERVICE_URL", "http://auth-service:8080")
+	_, err := ServiceTokenClientFromEnv("posts-service")
+	if err == nil || errors.Is(err, ErrServiceClientNotConfigured) {
+		t.Errorf("only URL set: err = %v, want a configuration error", err)
+	}
+
+	t.Setenv("SERVICE_CLIENT_SECRET", "s3cret")
+	c, err := ServiceTokenClientFromEnv("posts-service")
+	if err != nil || c.url != "http://auth-service:8080/service-token" || c.name != "posts-service" {
+		t.Errorf("client = %+v, %v", c, err)
+	}
+}
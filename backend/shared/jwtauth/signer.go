@@ -0,0 +1,46 @@
+package jwtauth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Signer выпускает токены активным ключом из конфигурации
+type Signer struct {
+	key      Key
+	issuer   string
+	audience string
+}
+
+// NewSigner создаёт Signer для активного ключа конфигурации
+func NewSigner(cfg *Config) (*Signer, error) {
+	key, ok := cfg.Keys[cfg.ActiveKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q is not configured", cfg.ActiveKeyID)
+	}
+	return &Signer{key: key, issuer: cfg.Issuer, audience: cfg.Audience}, nil
+}
+
+// Sign подписывает claims, проставляя iss, aud, iat, nbf и exp.
+// Возвращает строку токена и момент истечения его срока действия.
+func (s *Signer) Sign(claims Claims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims.Issuer = s.issuer
+	claims.Audience = s.audience
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	token := jwt.NewWithClaims(s.key.Method, claims)
+	token.Header["kid"] = s.key.ID
+
+	tokenStr, err := token.SignedString(s.key.SignKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenStr, expiresAt, nil
+}
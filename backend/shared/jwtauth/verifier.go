@@ -0,0 +1,159 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrInvalidToken возвращается для любого токена, не прошедшего проверку
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier проверяет подпись и стандартные claims токенов
+type Verifier struct {
+	keys     KeyProvider
+	issuer   string
+	audience string
+	leeway   time.Duration
+	parser   *jwt.Parser
+	checks   []func(*Claims) error
+}
+
+// NewVerifier создаёт Verifier, использующий ключи из конфигурации
+func NewVerifier(cfg *Config) *Verifier {
+	return NewVerifierWithKeys(cfg, StaticKeys(cfg.Keys))
+}
+
+// NewVerifierWithKeys создаёт Verifier с произвольным источником ключей
+func NewVerifierWithKeys(cfg *Config, keys KeyProvider) *Verifier {
+	return &Verifier{
+		keys:     keys,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+		// Сроки проверяются вручную с учётом leeway
+		parser: &jwt.Parser{SkipClaimsValidation: true},
+	}
+}
+
+// NewVerifierFromEnv создаёт Verifier по переменным окружения. Если задан
+// JWKS_URL, открытые ключи загружаются из auth_service (кэш на JWKS_CACHE_TTL),
+// и сервису не нужен доступ к ключам подписи. Иначе используются ключи из LoadConfig.
+func NewVerifierFromEnv() (*Verifier, error) {
+	if url := os.Getenv("JWKS_URL"); url != "" {
+		cfg, err := loadBaseConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		var ttl time.Duration
+		if v := os.Getenv("JWKS_CACHE_TTL"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil {
+				return nil, fmt.Errorf("invalid JWKS_CACHE_TTL: %w", err)
+			}
+		}
+		return NewVerifierWithKeys(cfg, NewJWKSClient(url, ttl)), nil
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(cfg), nil
+}
+
+// Verify разбирает access-токен и проверяет alg, kid, подпись, exp, nbf, iss и aud
+func (v *Verifier) Verify(tokenString string) (*Claims, error) {
+	return v.VerifyPurpose(tokenString, "")
+}
+
+// VerifyPurpose проверяет токен так же, как Verify, и дополнительно требует,
+// чтобы claim purpose совпадал с ожидаемым
+func (v *Verifier) VerifyPurpose(tokenString, purpose string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("missing kid header")
+		}
+		key, err := v.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Алгоритм задаётся ключом, а не заголовком токена
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+		}
+		return key.VerifyKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("%w: unexpected token purpose %q", ErrInvalidToken, claims.Purpose)
+	}
+	if purpose != PurposeService && claims.UserID <= 0 {
+		return nil, fmt.Errorf("%w: missing user_id claim", ErrInvalidToken)
+	}
+	if purpose != PurposeService {
+		for _, check := range v.checks {
+			if err := check(claims); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+			}
+		}
+	}
+	return claims, nil
+}
+
+// AddCheck добавляет проверку пользовательских токенов, например отзыв по
+// версии токена. Вызывается при старте сервиса, до обработки запросов.
+func (v *Verifier) AddCheck(check func(*Claims) error) {
+	v.checks = append(v.checks, check)
+}
+
+// VerifyService проверяет сервисный токен и возвращает имя вызывающего сервиса
+func (v *Verifier) VerifyService(tokenString string) (string, error) {
+	claims, err := v.VerifyPurpose(tokenString, PurposeService)
+	if err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("%w: missing service name", ErrInvalidToken)
+	}
+	return claims.Subject, nil
+}
+
+func (v *Verifier) validate(claims *Claims) error {
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-v.leeway).Unix(), true) {
+		return errors.New("token is expired")
+	}
+	if !claims.VerifyNotBefore(now.Add(v.leeway).Unix(), false) {
+		return errors.New("token is not valid yet")
+	}
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if !claims.VerifyAudience(v.audience, true) {
+		return fmt.Errorf("unexpected audience %q", claims.Audience)
+	}
+	return nil
+}
+
+// BearerToken извлекает токен из заголовка Authorization
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return header
+}
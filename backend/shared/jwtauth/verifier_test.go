@@ -0,0 +1,170 @@
+package jwtauth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func hmacConfig(active string, kids ...string) *Config {
+	cfg := &Config{
+		Keys:        make(map[string]Key),
+		ActiveKeyID: active,
+		Issuer:      "auth-service",
+		Audience:    "api",
+		Leeway:      30 * time.Second,
+	}
+	for _, kid := range kids {
+		cfg.Keys[kid] = NewHMACKey(kid, []byte("secret-"+kid))
+	}
+	return cfg
+}
+
+func mustSign(t *testing.T, cfg *Config, claims Claims, ttl time.Duration) string {
+	t.Helper()
+	signer, err := NewSigner(cfg)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	token, _, err := signer.Sign(claims, ttl)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+// signRaw подписывает произвольные claims ключом kid в обход Signer
+func signRaw(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return s
+}
+
+func TestSignAndVerify(t *testing.T) {
+	cfg := hmacConfig("k1", "k1")
+	token := mustSign(t, cfg, Claims{UserID: 5, Email: "a@example.com", Role: "admin"}, time.Minute)
+
+	claims, err := NewVerifier(cfg).Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.UserID != 5 || claims.Email != "a@example.com" || claims.Role != "admin" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if claims.Issuer != "auth-service" || claims.Audience != "api" {
+		t.Errorf("iss/aud = %q/%q", claims.Issuer, claims.Audience)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	// Старым ключом подписан токен до ротации
+	before := hmacConfig("old", "old")
+	oldToken := mustSign(t, before, Claims{UserID: 1}, time.Hour)
+
+	// После ротации подписывает новый ключ, старый ещё принимается
+	during := hmacConfig("new", "old", "new")
+	newToken := mustSign(t, during, Claims{UserID: 1}, time.Hour)
+	v := NewVerifier(during)
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := v.Verify(token); err != nil {
+			t.Errorf("%s token rejected during rotation: %v", name, err)
+		}
+	}
+
+	// Когда старый ключ убран, его токены больше не принимаются
+	after := hmacConfig("new", "new")
+	if _, err := NewVerifier(after).Verify(oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token of a retired key: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := NewVerifier(after).Verify(newToken); err != nil {
+		t.Errorf("new token rejected after rotation: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	cfg := hmacConfig("k1", "k1")
+	secret := []byte("secret-k1")
+	now := time.Now()
+	valid := func() jwt.StandardClaims {
+		return jwt.StandardClaims{Issuer: "auth-service", Audience: "api", ExpiresAt: now.Add(time.Hour).Unix()}
+	}
+	with := func(edit func(*Claims)) Claims {
+		c := Claims{UserID: 1, StandardClaims: valid()}
+		edit(&c)
+		return c
+	}
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"missing kid", signRaw(t, jwt.SigningMethodHS256, "", secret, with(func(*Claims) {}))},
+		{"unknown kid", signRaw(t, jwt.SigningMethodHS256, "k2", secret, with(func(*Claims) {}))},
+		// Заголовок alg не может сменить алгоритм, заданный ключом
+		{"other algorithm", signRaw(t, jwt.SigningMethodHS512, "k1", secret, with(func(*Claims) {}))},
+		{"wrong secret", signRaw(t, jwt.SigningMethodHS256, "k1", []byte("guess"), with(func(*Claims) {}))},
+		{"expired", signRaw(t, jwt.SigningMethodHS256, "k1", secret, with(func(c *Claims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }))},
+		{"no exp", signRaw(t, jwt.SigningMethodHS256, "k1", secret, with(func(c *Claims) { c.ExpiresAt = 0 }))},
+		{"not yet valid", signRaw(t, jwt.SigningMethodHS256, "k1", secret, with(func(c *Claims) { c.NotBefore = now.Add(time.Minute).Unix() }))},
+		{"other issuer", signRaw(t, jwt.SigningMethodHS256, "k1", secret, with(func(c *Claims) { c.Issuer = "evil" }))},
+		{"other audience", signRaw(t, jwt.SigningMethodHS256, "k1", secret, with(func(c *Claims) { c.Audience = "other" }))},
+		{"no user", signRaw(t, jwt.SigningMethodHS256, "k1", secret, with(func(c *Claims) { c.UserID = 0 }))},
+		{"garbage", "not-a-token"},
+	}
+	v := NewVerifier(cfg)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if claims, err := v.Verify(tc.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify = %+v, %v; want ErrInvalidToken", claims, err)
+			}
+		})
+	}
+}
+
+func TestVerifyLeeway(t *testing.T) {
+	cfg := hmacConfig("k1", "k1")
+	secret := []byte("secret-k1")
+	claims := Claims{UserID: 1, StandardClaims: jwt.StandardClaims{
+		Issuer:    "auth-service",
+		Audience:  "api",
+		ExpiresAt: time.Now().Add(-10 * time.Second).Unix(),
+		NotBefore: time.Now().Add(10 * time.Second).Unix(),
+	}}
+	token := signRaw(t, jwt.SigningMethodHS256, "k1", secret, claims)
+
+	// Расхождение часов в пределах leeway допускается
+	if _, err := NewVerifier(cfg).Verify(token); err != nil {
+		t.Errorf("token within leeway rejected: %v", err)
+	}
+	cfg.Leeway = time.Second
+	if _, err := NewVerifier(cfg).Verify(token); err == nil {
+		t.Error("token outside leeway accepted")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	cases := map[string]string{
+		"Bearer abc.def": "abc.def",
+		"abc.def":        "abc.def",
+		"":               "",
+	}
+	for header, want := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		if got := BearerToken(r); got != want {
+			t.Errorf("BearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer не отправляет письма, а записывает их в файл или stdout.
+// Используется при локальной разработке и в тестах.
+type LogMailer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewLogMailer создаёт LogMailer, дописывающий письма в файл path.
+// Пустой path означает stdout.
+func NewLogMailer(path string) (*LogMailer, error) {
+	if path == "" {
+		return &LogMailer{out: os.Stdout}, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mail log: %w", err)
+	}
+	return &LogMailer{out: f}, nil
+}
+
+// NewWriterMailer создаёт LogMailer, пишущий в произвольный io.Writer
+func NewWriterMailer(w io.Writer) *LogMailer {
+	return &LogMailer{out: w}
+}
+
+// Send реализует Mailer
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.out, "=== %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,49 @@
+// Package mailer отправляет служебные письма: сброс пароля, подтверждение email и т.п.
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Message представляет одно текстовое письмо
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewFromEnv создаёт Mailer по переменной окружения MAILER:
+//
+//	smtp — SMTPMailer (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM)
+//	log  — LogMailer, пишет письма в MAIL_LOG_FILE или в stdout (по умолчанию)
+func NewFromEnv() (Mailer, error) {
+	switch kind := os.Getenv("MAILER"); kind {
+	case "", "log":
+		return NewLogMailer(os.Getenv("MAIL_LOG_FILE"))
+	case "smtp":
+		port := 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+			}
+			port = p
+		}
+		return NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", kind)
+	}
+}
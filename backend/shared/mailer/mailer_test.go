@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessage(t *testing.T) {
+	raw := string(buildMessage("noreply@example.com", Message{
+		To:      "ann@example.com",
+		Subject: "Сброс пароля",
+		Body:    "line one\nline two",
+	}))
+
+	head, body, ok := strings.Cut(raw, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("no blank line between headers and body:\n%q", raw)
+	}
+	for _, want := range []string{
+		"From: noreply@example.com",
+		"To: ann@example.com",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8",
+	} {
+		if !strings.Contains(head, want) {
+			t.Errorf("headers lack %q:\n%s", want, head)
+		}
+	}
+	if strings.Contains(head, "Сброс") {
+		t.Error("non-ASCII subject is not encoded")
+	}
+	if body != "line one\r\nline two" {
+		t.Errorf("body = %q, want CRLF line endings", body)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	m, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer: %v", err)
+	}
+	for _, msg := range []Message{
+		{To: "ann@example.com\r\nBcc: all@example.com", Subject: "hi"},
+		{To: "ann@example.com", Subject: "hi\nBcc: all@example.com"},
+	} {
+		err := m.Send(msg)
+		if err == nil || !strings.Contains(err.Error(), "invalid header") {
+			t.Errorf("Send(%q, %q) = %v, want invalid header error", msg.To, msg.Subject, err)
+		}
+	}
+}
+
+func TestNewSMTPMailerRequiresHostAndSender(t *testing.T) {
+	if _, err := NewSMTPMailer(SMTPConfig{From: "noreply@example.com"}); err == nil {
+		t.Error("mailer without host was created")
+	}
+	if _, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com"}); err == nil {
+		t.Error("mailer without sender was created")
+	}
+}
+
+func TestLogMailerAppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m, err := NewLogMailer(path)
+	if err != nil {
+		t.Fatalf("NewLogMailer: %v", err)
+	}
+	m.Send(Message{To: "a@example.com", Subject: "first", Body: "1"})
+	m.Send(Message{To: "b@example.com", Subject: "second", Body: "2"})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("To: a@example.com")) || !bytes.Contains(data, []byte("Subject: second")) {
+		t.Errorf("mail log = %q", data)
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("MAIL_LOG_FILE", filepath.Join(t.TempDir(), "mail.log"))
+
+	t.Setenv("MAILER", "")
+	if m, err := NewFromEnv(); err != nil {
+		t.Errorf("default mailer: %v", err)
+	} else if _, ok := m.(*LogMailer); !ok {
+		t.Errorf("default mailer is %T, want *LogMailer", m)
+	}
+
+	t.Setenv("MAILER", "smtp")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("MAIL_FROM", "noreply@example.com")
+	t.Setenv("SMTP_PORT", "not-a-port")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("invalid SMTP_PORT accepted")
+	}
+	t.Setenv("SMTP_PORT", "2525")
+	if m, err := NewFromEnv(); err != nil {
+		t.Errorf("smtp mailer: %v", err)
+	} else if s := m.(*SMTPMailer); s.cfg.Port != 2525 {
+		t.Errorf("port = %d, want 2525", s.cfg.Port)
+	}
+
+	t.Setenv("MAILER", "pigeon")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("unknown MAILER accepted")
+	}
+}
@@ -0,0 +1,192 @@
+// Package pagination реализует постраничную выдачу по ключу (keyset): курсор
+// указывает на последнюю отданную запись, а не на номер страницы, поэтому
+// страницы не сдвигаются при добавлении записей и не замедляются к концу списка.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLimit — размер страницы, если limit не указан
+	DefaultLimit = 20
+	// MaxLimit — наибольший допустимый размер страницы
+	MaxLimit = 100
+)
+
+// ErrInvalidCursor возвращается для повреждённого или чужого курсора
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidLimit возвращается для нечислового или неположительного limit
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// Cursor — позиция в списке, упорядоченном по (created_at, id) по убыванию
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// Encode возвращает непрозрачное представление курсора для клиента
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode разбирает курсор, полученный от клиента
+func Decode(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{CreatedAt: createdAt, ID: n}, nil
+}
+
+// RankCursor — позиция в списке, упорядоченном по (rank, id) по убыванию:
+// результаты поиска, где порядок задаёт релевантность
+type RankCursor struct {
+	Rank float32
+	ID   int
+}
+
+// Encode возвращает непрозрачное представление курсора. Ранг записывается
+// кратчайшим точным представлением float32 и при разборе восстанавливается без потерь.
+func (c RankCursor) Encode() string {
+	raw := strconv.FormatFloat(float64(c.Rank), 'g', -1, 32) + "," + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeRank разбирает курсор результатов поиска
+func DecodeRank(s string) (RankCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	rank, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	r, err := strconv.ParseFloat(rank, 32)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+	return RankCursor{Rank: float32(r), ID: n}, nil
+}
+
+// RankArgs возвращает ранг и id курсора для условия
+// ($1::real IS NULL OR (rank, id) < ($1, $2)); для первой страницы ранг равен nil.
+func RankArgs(c *RankCursor) (interface{}, int) {
+	if c == nil {
+		return nil, 0
+	}
+	return float64(c.Rank), c.ID
+}
+
+// Request — параметры страницы из запроса. After равен nil для первой страницы.
+type Request struct {
+	After *Cursor
+	Limit int
+}
+
+// LimitFromRequest читает параметр limit. limit больше MaxLimit
+// уменьшается до MaxLimit, а не считается ошибкой.
+func LimitFromRequest(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return DefaultLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, ErrInvalidLimit
+	}
+	if n > MaxLimit {
+		n = MaxLimit
+	}
+	return n, nil
+}
+
+// FromRequest читает параметры cursor и limit
+func FromRequest(r *http.Request) (Request, error) {
+	limit, err := LimitFromRequest(r)
+	if err != nil {
+		return Request{}, err
+	}
+	req := Request{Limit: limit}
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := Decode(v)
+		if err != nil {
+			return Request{}, err
+		}
+		req.After = &c
+	}
+	return req, nil
+}
+
+// Parse читает параметры страницы, а при ошибке отвечает клиенту 400 и возвращает false
+func Parse(w http.ResponseWriter, r *http.Request) (Request, bool) {
+	req, err := FromRequest(r)
+	switch {
+	case errors.Is(err, ErrInvalidCursor):
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return Request{}, false
+	case err != nil:
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return Request{}, false
+	}
+	return req, true
+}
+
+// Args возвращает время и id курсора для условия
+// ($1::timestamptz IS NULL OR (created_at, id) < ($1, $2)); для первой страницы время равно nil.
+func (r Request) Args() (interface{}, int) {
+	if r.After == nil {
+		return nil, 0
+	}
+	return r.After.CreatedAt, r.After.ID
+}
+
+// Page — общий формат ответа со страницей записей
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage строит страницу из выборки, запрошенной с лимитом Limit+1: лишняя
+// запись означает, что есть следующая страница, и в ответ не попадает
+func NewPage[T any](items []T, limit int, key func(T) Cursor) Page[T] {
+	return NewCustomPage(items, limit, func(item T) string { return key(item).Encode() })
+}
+
+// NewCustomPage — то же, что NewPage, для списков с другим порядком: курсор
+// следующей страницы строит encode по последней отданной записи
+func NewCustomPage[T any](items []T, limit int, encode func(T) string) Page[T] {
+	page := Page[T]{Items: items}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = encode(items[limit-1])
+	}
+	return page
+}
@@ -0,0 +1,166 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ExampleNewPage() {
+	// Выборка сделана с лимитом 2+1: третья запись говорит, что страница не последняя
+	ids := []int{30, 20, 10}
+	page := NewPage(ids, 2, func(id int) Cursor {
+		return Cursor{CreatedAt: time.Date(2024, 1, 1, 0, 0, id, 0, time.UTC), ID: id}
+	})
+	fmt.Println(page.Items, page.NextCursor != "")
+	// Output: [30 20] true
+}
+
+func TestCursorSurvivesEncoding(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	c := Cursor{CreatedAt: time.Date(2024, 3, 1, 15, 30, 0, 123456789, moscow), ID: 42}
+
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Наносекунды важны: по ним упорядочены записи, созданные в одну секунду
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != 42 {
+		t.Errorf("round trip: %v, want %v", got, c)
+	}
+}
+
+func TestDecodeRejectsForeignCursors(t *testing.T) {
+	encode := base64.RawURLEncoding.EncodeToString
+	bad := []string{
+		"",
+		"%%%",
+		base64.URLEncoding.EncodeToString([]byte("2024-03-01T12:30:00Z,4")), // с паддингом
+		encode([]byte("2024-03-01T12:30:00Z")),
+		encode([]byte("yesterday,42")),
+		encode([]byte("2024-03-01T12:30:00Z,")),
+		encode([]byte("2024-03-01T12:30:00Z,forty-two")),
+	}
+	for _, s := range bad {
+		if _, err := Decode(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	after := Cursor{CreatedAt: time.Unix(1700000000, 0).UTC(), ID: 9}
+
+	get := func(query string) (*httptest.ResponseRecorder, Request, bool) {
+		rec := httptest.NewRecorder()
+		req, ok := Parse(rec, httptest.NewRequest(http.MethodGet, "/items?"+query, nil))
+		return rec, req, ok
+	}
+
+	if _, req, ok := get(""); !ok || req.Limit != DefaultLimit || req.After != nil {
+		t.Errorf("no parameters: %+v, %v", req, ok)
+	}
+	if _, req, _ := get("limit=7"); req.Limit != 7 {
+		t.Errorf("limit=7: got %d", req.Limit)
+	}
+	if _, req, _ := get("limit=100000"); req.Limit != MaxLimit {
+		t.Errorf("huge limit: got %d, want MaxLimit", req.Limit)
+	}
+	if _, req, _ := get("cursor=" + after.Encode()); req.After == nil || req.After.ID != 9 {
+		t.Errorf("cursor: After = %+v", req.After)
+	}
+
+	for _, query := range []string{"limit=0", "limit=-3", "limit=many", "cursor=garbage!"} {
+		rec, _, ok := get(query)
+		if ok || rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: ok=%v status %d, want 400", query, ok, rec.Code)
+		}
+	}
+}
+
+func TestRequestArgs(t *testing.T) {
+	if at, id := (Request{}).Args(); at != nil || id != 0 {
+		t.Errorf("first page args = %v, %d", at, id)
+	}
+	c := Cursor{CreatedAt: time.Unix(5, 0), ID: 3}
+	at, id := Request{After: &c}.Args()
+	if at != c.CreatedAt || id != 3 {
+		t.Errorf("args = %v, %d", at, id)
+	}
+}
+
+func TestNewPageLastAndEmpty(t *testing.T) {
+	key := func(id int) Cursor { return Cursor{ID: id} }
+
+	if p := NewPage([]int{2, 1}, 2, key); len(p.Items) != 2 || p.NextCursor != "" {
+		t.Errorf("last page = %+v, want no cursor", p)
+	}
+	// Пустая страница кодируется как [], а не null
+	if p := NewPage[int](nil, 2, key); p.Items == nil || p.NextCursor != "" {
+		t.Errorf("empty page = %+v", p)
+	}
+	if p := NewPage([]int{5, 4, 3}, 2, key); p.NextCursor != key(4).Encode() {
+		t.Errorf("cursor points at %q, want the last returned item", p.NextCursor)
+	}
+}
+
+func TestLimitFromRequest(t *testing.T) {
+	cases := map[string]int{"": DefaultLimit, "limit=1": 1, "limit=100": MaxLimit, "limit=101": MaxLimit}
+	for query, want := range cases {
+		got, err := LimitFromRequest(httptest.NewRequest(http.MethodGet, "/search?"+query, nil))
+		if err != nil || got != want {
+			t.Errorf("%q: %d, %v; want %d", query, got, err, want)
+		}
+	}
+	if _, err := LimitFromRequest(httptest.NewRequest(http.MethodGet, "/search?limit=0", nil)); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("limit=0: err = %v, want ErrInvalidLimit", err)
+	}
+}
+
+func TestNewCustomPage(t *testing.T) {
+	// Порядок по релевантности: курсор строит вызывающий
+	encode := func(s string) string { return "after:" + s }
+	p := NewCustomPage([]string{"best", "good", "fair"}, 2, encode)
+	if len(p.Items) != 2 || p.NextCursor != "after:good" {
+		t.Errorf("page = %+v", p)
+	}
+	if p := NewCustomPage([]string{"only"}, 2, encode); p.NextCursor != "" {
+		t.Errorf("single-item page has a cursor: %q", p.NextCursor)
+	}
+}
+
+func TestRankCursorKeepsExactRank(t *testing.T) {
+	// Ранг сравнивается в запросе на равенство, поэтому потеря точности
+	// повторила бы или пропустила запись на границе страниц
+	for _, rank := range []float32{0.0607927, 1e-20, 3.1415927, 0} {
+		c := RankCursor{Rank: rank, ID: 11}
+		got, err := DecodeRank(c.Encode())
+		if err != nil || got != c {
+			t.Errorf("rank %v: round trip = %+v, %v", rank, got, err)
+		}
+	}
+
+	enc := base64.RawURLEncoding.EncodeToString
+	for _, s := range []string{"!!", enc([]byte("0.5")), enc([]byte("high,1")), enc([]byte("0.5,x"))} {
+		if _, err := DecodeRank(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeRank(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+	// Курсор по времени не принимается как курсор поиска
+	if _, err := DecodeRank(Cursor{CreatedAt: time.Now(), ID: 1}.Encode()); err == nil {
+		t.Error("time cursor decoded as a rank cursor")
+	}
+}
+
+func TestRankArgs(t *testing.T) {
+	if rank, id := RankArgs(nil); rank != nil || id != 0 {
+		t.Errorf("RankArgs(nil) = %v, %d", rank, id)
+	}
+	if rank, id := RankArgs(&RankCursor{Rank: 2.5, ID: 4}); rank != 2.5 || id != 4 {
+		t.Errorf("RankArgs = %v, %d", rank, id)
+	}
+}
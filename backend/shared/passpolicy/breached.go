@@ -0,0 +1,76 @@
+package passpolicy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed breached_sha1.txt
+var bundledBreached []byte
+
+// prefixLen — длина префикса хэша, по которому идёт поиск (как в k-anonymity API)
+const prefixLen = 5
+
+// BreachedList — набор SHA-1 утекших паролей, сгруппированный по префиксу хэша.
+// Поиск идёт по диапазону префикса, поэтому источник можно заменить на
+// внешний сервис, которому передаётся только префикс.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBreachedList читает список из файла path, а если путь пустой — встроенный
+func LoadBreachedList(path string) (*BreachedList, error) {
+	if path == "" {
+		return parseBreachedList(bytes.NewReader(bundledBreached))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+	return parseBreachedList(f)
+}
+
+// parseBreachedList разбирает строки вида SHA1 или SHA1:COUNT; строки с # пропускаются
+func parseBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{ranges: make(map[string]map[string]struct{})}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached password list: invalid hash on line %d", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("breached password list: invalid hash on line %d", line)
+		}
+		prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+		if list.ranges[prefix] == nil {
+			list.ranges[prefix] = make(map[string]struct{})
+		}
+		list.ranges[prefix][suffix] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return list, nil
+}
+
+// Contains сообщает, встречается ли пароль в списке утечек
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := l.ranges[hash[:prefixLen]][hash[prefixLen:]]
+	return found
+}
@@ -0,0 +1,87 @@
+package passpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestBundledList(t *testing.T) {
+	list, err := LoadBreachedList("")
+	if err != nil {
+		t.Fatalf("LoadBreachedList: %v", err)
+	}
+	for _, leaked := range []string{"password", "123456", "password123", "Password1", "1q2w3e4r5t"} {
+		if !list.Contains(leaked) {
+			t.Errorf("bundled list does not contain %q", leaked)
+		}
+	}
+	// Сравнение точное: регистр и пробелы меняют хэш
+	for _, fresh := range []string{"PASSWORD123", " password", "Tr0ub4dor&3-horse", ""} {
+		if list.Contains(fresh) {
+			t.Errorf("bundled list unexpectedly contains %q", fresh)
+		}
+	}
+}
+
+func TestLoadBreachedListFile(t *testing.T) {
+	// SHA-1 от "hunter2" и "letmein!" в разных допустимых записях
+	path := writeList(t, strings.Join([]string{
+		"# comment",
+		"",
+		"f3bbbd66a63d4bf1747940578ec3d0103530e21d",
+		"  403E35A2B0243D40400AF6BB358B5C546CDDD981:1532  ",
+	}, "\n"))
+
+	list, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList: %v", err)
+	}
+	if !list.Contains("hunter2") {
+		t.Error("lowercase hash was not loaded")
+	}
+	if !list.Contains("letmein!") {
+		t.Error("hash with a count suffix was not loaded")
+	}
+	if list.Contains("password") {
+		t.Error("custom list must replace the bundled one")
+	}
+}
+
+func TestLoadBreachedListMalformed(t *testing.T) {
+	valid := "F3BBBD66A63D4BF1747940578EC3D0103530E21D"
+	cases := map[string]struct {
+		content string
+		line    string
+	}{
+		"short hash":     {content: valid + "\nABCDEF", line: "line 2"},
+		"long hash":      {content: valid + "00", line: "line 1"},
+		"not hex":        {content: "# header\n" + strings.Repeat("Z", 40), line: "line 2"},
+		"bad count part": {content: "\n\n" + valid[:39] + ":1", line: "line 3"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadBreachedList(writeList(t, c.content))
+			if err == nil {
+				t.Fatal("LoadBreachedList accepted a malformed list")
+			}
+			if !strings.Contains(err.Error(), c.line) {
+				t.Errorf("error %q does not point at %s", err, c.line)
+			}
+		})
+	}
+
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadBreachedList accepted a missing file")
+	}
+}
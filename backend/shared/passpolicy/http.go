@@ -0,0 +1,41 @@
+package passpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errorResponse — тело ответа 400 при нарушении политики
+type errorResponse struct {
+	Message string      `json:"message"`
+	Errors  []Violation `json:"errors"`
+}
+
+// WriteError отвечает 400 со списком нарушенных правил
+func WriteError(w http.ResponseWriter, err *ValidationError) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	return json.NewEncoder(w).Encode(errorResponse{
+		Message: "Password does not meet the policy",
+		Errors:  err.Violations,
+	})
+}
+
+// ReadError разбирает ответ другого сервиса, записанный WriteError.
+// Возвращает nil, если в теле нет списка нарушений.
+func ReadError(body io.Reader) *ValidationError {
+	var resp errorResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil || len(resp.Errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: resp.Errors}
+}
+
+// AsValidationError извлекает *ValidationError из цепочки ошибок
+func AsValidationError(err error) (*ValidationError, bool) {
+	var verr *ValidationError
+	ok := errors.As(err, &verr)
+	return verr, ok
+}
@@ -0,0 +1,45 @@
+package passpolicy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteErrorReadErrorRoundTrip(t *testing.T) {
+	violations := []Violation{
+		{Rule: RuleMinLength, Message: "Password must be at least 10 characters long"},
+		{Rule: RuleNotBreached, Message: "Password appears in a list of leaked passwords"},
+	}
+
+	rec := httptest.NewRecorder()
+	if err := WriteError(rec, &ValidationError{Violations: violations}); err != nil {
+		t.Fatalf("WriteError: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	got := ReadError(rec.Body)
+	if got == nil || !reflect.DeepEqual(got.Violations, violations) {
+		t.Fatalf("ReadError() = %+v, want %+v", got, violations)
+	}
+}
+
+func TestReadErrorIgnoresOtherBodies(t *testing.T) {
+	for _, body := range []string{
+		"",
+		"Invalid request payload\n",
+		`{"message":"Password does not meet the policy","errors":[]}`,
+		`{"errors":"min_length"}`,
+	} {
+		if got := ReadError(strings.NewReader(body)); got != nil {
+			t.Errorf("ReadError(%q) = %+v, want nil", body, got)
+		}
+	}
+}
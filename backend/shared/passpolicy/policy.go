@@ -0,0 +1,191 @@
+// Package passpolicy проверяет новые пароли: длину, наборы символов, совпадение
+// с именем пользователя или email и наличие в списке утекших паролей.
+package passpolicy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Правила, которые может нарушить пароль
+const (
+	RuleMinLength   = "min_length"
+	RuleMaxLength   = "max_length"
+	RuleCharClasses = "character_classes"
+	RuleNotUsername = "not_username"
+	RuleNotEmail    = "not_email"
+	RuleNotBreached = "not_breached"
+)
+
+const (
+	defaultMinLength  = 10
+	defaultMaxLength  = 72
+	defaultMinClasses = 2
+	totalCharClasses  = 4
+)
+
+// Config задаёт требования к паролю
+type Config struct {
+	// MinLength — минимальная длина в символах
+	MinLength int
+	// MaxLength — максимальная длина в байтах: bcrypt учитывает только первые 72
+	MaxLength int
+	// MinClasses — сколько наборов символов из четырёх (строчные, заглавные,
+	// цифры, прочие) должно встречаться в пароле
+	MinClasses int
+	// CheckBreached включает проверку по списку утекших паролей
+	CheckBreached bool
+}
+
+// DefaultConfig возвращает требования по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		MinLength:     defaultMinLength,
+		MaxLength:     defaultMaxLength,
+		MinClasses:    defaultMinClasses,
+		CheckBreached: true,
+	}
+}
+
+// Violation — одно нарушенное правило
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError перечисляет все правила, которые нарушает пароль
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// Policy проверяет пароли по Config и списку утечек
+type Policy struct {
+	cfg      Config
+	breached *BreachedList
+}
+
+// New создаёт Policy. breached может быть nil, если проверка утечек выключена.
+func New(cfg Config, breached *BreachedList) *Policy {
+	return &Policy{cfg: cfg, breached: breached}
+}
+
+// NewFromEnv создаёт Policy по переменным окружения PASSWORD_MIN_LENGTH,
+// PASSWORD_MAX_LENGTH, PASSWORD_MIN_CLASSES и PASSWORD_BREACH_CHECK (true/false).
+// Список утечек читается из PASSWORD_BREACHED_FILE, по умолчанию — встроенный.
+func NewFromEnv() (*Policy, error) {
+	cfg := DefaultConfig()
+	var err error
+	if cfg.MinLength, err = intFromEnv("PASSWORD_MIN_LENGTH", cfg.MinLength, 1); err != nil {
+		return nil, err
+	}
+	if cfg.MaxLength, err = intFromEnv("PASSWORD_MAX_LENGTH", cfg.MaxLength, cfg.MinLength); err != nil {
+		return nil, err
+	}
+	if cfg.MinClasses, err = intFromEnv("PASSWORD_MIN_CLASSES", cfg.MinClasses, 0); err != nil {
+		return nil, err
+	}
+	if cfg.MinClasses > totalCharClasses {
+		return nil, fmt.Errorf("invalid PASSWORD_MIN_CLASSES: at most %d", totalCharClasses)
+	}
+	if v := os.Getenv("PASSWORD_BREACH_CHECK"); v != "" {
+		if cfg.CheckBreached, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid PASSWORD_BREACH_CHECK: %q", v)
+		}
+	}
+
+	var breached *BreachedList
+	if cfg.CheckBreached {
+		if breached, err = LoadBreachedList(os.Getenv("PASSWORD_BREACHED_FILE")); err != nil {
+			return nil, err
+		}
+	}
+	return New(cfg, breached), nil
+}
+
+// Check проверяет пароль пользователя username с адресом email. Пароль
+// проверяется как есть, без обрезки пробелов. Возвращает *ValidationError со
+// всеми нарушенными правилами или nil.
+func (p *Policy) Check(password, username, email string) error {
+	var violations []Violation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.cfg.MinLength {
+		add(RuleMinLength, "Password must be at least %d characters long", p.cfg.MinLength)
+	}
+	if len(password) > p.cfg.MaxLength {
+		add(RuleMaxLength, "Password must be at most %d bytes long", p.cfg.MaxLength)
+	}
+	if charClasses(password) < p.cfg.MinClasses {
+		add(RuleCharClasses, "Password must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.cfg.MinClasses)
+	}
+
+	lower := strings.ToLower(password)
+	if username != "" && lower == strings.ToLower(username) {
+		add(RuleNotUsername, "Password must not match the username")
+	}
+	if email != "" {
+		local, _, _ := strings.Cut(strings.ToLower(email), "@")
+		if lower == strings.ToLower(email) || lower == local {
+			add(RuleNotEmail, "Password must not match the email address")
+		}
+	}
+
+	if p.cfg.CheckBreached && p.breached != nil && p.breached.Contains(password) {
+		add(RuleNotBreached, "Password appears in a list of leaked passwords")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// charClasses считает, сколько наборов символов встречается в пароле
+func charClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			n++
+		}
+	}
+	return n
+}
+
+func intFromEnv(name string, fallback, min int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return n, nil
+}
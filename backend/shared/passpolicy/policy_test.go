@@ -0,0 +1,162 @@
+package passpolicy
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestPolicy(t *testing.T, cfg Config) *Policy {
+	t.Helper()
+	breached, err := LoadBreachedList("")
+	if err != nil {
+		t.Fatalf("LoadBreachedList: %v", err)
+	}
+	return New(cfg, breached)
+}
+
+// expectRules проверяет, что Check нарушает ровно правила want в этом порядке
+func expectRules(t *testing.T, p *Policy, password, username, email string, want ...string) {
+	t.Helper()
+	err := p.Check(password, username, email)
+	var got []string
+	if err != nil {
+		verr, ok := AsValidationError(err)
+		if !ok {
+			t.Fatalf("Check(%q) returned %T, want *ValidationError", password, err)
+		}
+		for _, v := range verr.Violations {
+			if v.Message == "" {
+				t.Errorf("Check(%q): rule %s has no message", password, v.Rule)
+			}
+			got = append(got, v.Rule)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check(%q, %q, %q) violates %v, want %v", password, username, email, got, want)
+	}
+}
+
+func TestCheckAcceptsStrongPassword(t *testing.T) {
+	p := newTestPolicy(t, DefaultConfig())
+	if err := p.Check("Tr0ub4dor&3-horse", "alice", "alice@example.com"); err != nil {
+		t.Fatalf("Check rejected a strong password: %v", err)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	p := newTestPolicy(t, DefaultConfig())
+
+	t.Run("min length counts characters", func(t *testing.T) {
+		expectRules(t, p, "Short1pw", "", "", RuleMinLength)
+		// 10 кириллических букв — 20 байт, но 10 символов
+		expectRules(t, p, "ПарольДлин", "", "")
+	})
+	t.Run("max length counts bytes", func(t *testing.T) {
+		expectRules(t, p, strings.Repeat("aB", 36), "", "")
+		expectRules(t, p, strings.Repeat("aB", 36)+"c", "", "", RuleMaxLength)
+		expectRules(t, p, strings.Repeat("Жж", 19), "", "", RuleMaxLength)
+	})
+	t.Run("character classes", func(t *testing.T) {
+		expectRules(t, p, "onlylowercaseletters", "", "", RuleCharClasses)
+		expectRules(t, p, "lowercase and spaces", "", "")
+		expectRules(t, p, "1234567890123", "", "", RuleCharClasses)
+	})
+	t.Run("not username", func(t *testing.T) {
+		expectRules(t, p, "Alice_Smith1", "alice_smith1", "", RuleNotUsername)
+		expectRules(t, p, "Alice_Smith1", "", "")
+	})
+	t.Run("not email", func(t *testing.T) {
+		expectRules(t, p, "Bob.Jones42@example.com", "", "bob.jones42@example.com", RuleNotEmail)
+		expectRules(t, p, "Bob.Jones42", "", "bob.jones42@example.com", RuleNotEmail)
+		expectRules(t, p, "Bob.Jones42", "", "")
+	})
+	t.Run("not breached", func(t *testing.T) {
+		expectRules(t, p, "password123", "", "", RuleNotBreached)
+		expectRules(t, p, "qwerty123456", "", "", RuleNotBreached)
+	})
+}
+
+func TestCheckReportsEveryViolation(t *testing.T) {
+	p := newTestPolicy(t, DefaultConfig())
+	expectRules(t, p, "password", "password", "password@example.com",
+		RuleMinLength, RuleCharClasses, RuleNotUsername, RuleNotEmail, RuleNotBreached)
+}
+
+func TestCheckWithoutBreachList(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CheckBreached = false
+	expectRules(t, New(cfg, nil), "password123", "", "")
+
+	// Включённая проверка без списка тоже ничего не находит
+	expectRules(t, New(DefaultConfig(), nil), "password123", "", "")
+}
+
+func TestCheckCustomConfig(t *testing.T) {
+	p := New(Config{MinLength: 4, MaxLength: 8, MinClasses: 4}, nil)
+	expectRules(t, p, "aB3$", "", "")
+	expectRules(t, p, "aB3$aB3$x", "", "", RuleMaxLength)
+	expectRules(t, p, "aB3d", "", "", RuleCharClasses)
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := newTestPolicy(t, DefaultConfig()).Check("abc", "", "")
+	if err == nil {
+		t.Fatal("Check accepted a three-character password")
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "password does not meet the policy: ") || !strings.Contains(msg, "; ") {
+		t.Errorf("Error() = %q, want every violation listed", msg)
+	}
+
+	wrapped := errors.Join(errors.New("register"), err)
+	if verr, ok := AsValidationError(wrapped); !ok || len(verr.Violations) != 2 {
+		t.Errorf("AsValidationError(wrapped) = %v, %v", verr, ok)
+	}
+	if _, ok := AsValidationError(errors.New("other")); ok {
+		t.Error("AsValidationError matched an unrelated error")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		p, err := NewFromEnv()
+		if err != nil {
+			t.Fatalf("NewFromEnv: %v", err)
+		}
+		if p.cfg != DefaultConfig() || p.breached == nil {
+			t.Errorf("NewFromEnv() = %+v, want defaults with the bundled list", p.cfg)
+		}
+	})
+	t.Run("overrides", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_LENGTH", "12")
+		t.Setenv("PASSWORD_MAX_LENGTH", "64")
+		t.Setenv("PASSWORD_MIN_CLASSES", "3")
+		t.Setenv("PASSWORD_BREACH_CHECK", "false")
+		p, err := NewFromEnv()
+		if err != nil {
+			t.Fatalf("NewFromEnv: %v", err)
+		}
+		want := Config{MinLength: 12, MaxLength: 64, MinClasses: 3}
+		if p.cfg != want || p.breached != nil {
+			t.Errorf("NewFromEnv() = %+v, want %+v without a list", p.cfg, want)
+		}
+	})
+
+	for name, env := range map[string][2]string{
+		"zero min length":      {"PASSWORD_MIN_LENGTH", "0"},
+		"max below min":        {"PASSWORD_MAX_LENGTH", "5"},
+		"too many classes":     {"PASSWORD_MIN_CLASSES", "5"},
+		"bad breach flag":      {"PASSWORD_BREACH_CHECK", "sometimes"},
+		"missing breach file":  {"PASSWORD_BREACHED_FILE", "/nonexistent/breached.txt"},
+		"non-numeric min size": {"PASSWORD_MIN_LENGTH", "ten"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := NewFromEnv(); err == nil {
+				t.Errorf("NewFromEnv accepted %s=%q", env[0], env[1])
+			}
+		})
+	}
+}
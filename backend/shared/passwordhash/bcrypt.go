@@ -0,0 +1,47 @@
+package passwordhash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultBcryptCost = 12
+
+// Хэши bcrypt хранятся в их собственном формате $2a$<cost>$..., как и до
+// появления Argon2id, поэтому существующие записи проверяются без миграции
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func validateBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("invalid bcrypt cost %d", cost)
+	}
+	return nil
+}
+
+func hashBcrypt(password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// verifyBcrypt сравнивает пароль с хэшем и возвращает стоимость хэша
+func verifyBcrypt(password, encoded string) (int, bool, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid bcrypt hash: %w", err)
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return cost, false, nil
+	} else if err != nil {
+		return cost, false, fmt.Errorf("failed to compare bcrypt hash: %w", err)
+	}
+	return cost, true, nil
+}
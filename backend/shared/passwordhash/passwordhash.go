@@ -0,0 +1,136 @@
+// Package passwordhash хэширует и проверяет пароли. Новые хэши создаются
+// настроенным алгоритмом (Argon2id в формате PHC или bcrypt), а проверка
+// понимает оба формата и сообщает, когда хэш пора пересчитать с текущими параметрами.
+package passwordhash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Алгоритмы хэширования
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// ErrUnknownFormat возвращается для хэша неизвестного формата
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+// Config задаёт алгоритм новых хэшей и его параметры
+type Config struct {
+	Algorithm string
+	Argon2    Argon2Params
+	// BcryptCost — стоимость bcrypt для новых хэшей
+	BcryptCost int
+}
+
+// DefaultConfig возвращает параметры по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		Algorithm:  Argon2id,
+		Argon2:     DefaultArgon2Params(),
+		BcryptCost: defaultBcryptCost,
+	}
+}
+
+// Hasher создаёт и проверяет хэши паролей
+type Hasher struct {
+	cfg Config
+}
+
+// New создаёт Hasher
+func New(cfg Config) (*Hasher, error) {
+	switch cfg.Algorithm {
+	case Argon2id:
+		if err := cfg.Argon2.validate(); err != nil {
+			return nil, err
+		}
+	case Bcrypt:
+		if err := validateBcryptCost(cfg.BcryptCost); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+	return &Hasher{cfg: cfg}, nil
+}
+
+// NewFromEnv создаёт Hasher по переменным окружения:
+//
+//	PASSWORD_HASH_ALGORITHM — argon2id (по умолчанию) или bcrypt
+//	ARGON2_MEMORY_KIB, ARGON2_ITERATIONS, ARGON2_PARALLELISM
+//	BCRYPT_COST
+func NewFromEnv() (*Hasher, error) {
+	cfg := DefaultConfig()
+	if v := os.Getenv("PASSWORD_HASH_ALGORITHM"); v != "" {
+		cfg.Algorithm = v
+	}
+	var err error
+	if cfg.Argon2.Memory, err = uintFromEnv("ARGON2_MEMORY_KIB", cfg.Argon2.Memory); err != nil {
+		return nil, err
+	}
+	if cfg.Argon2.Iterations, err = uintFromEnv("ARGON2_ITERATIONS", cfg.Argon2.Iterations); err != nil {
+		return nil, err
+	}
+	parallelism, err := uintFromEnv("ARGON2_PARALLELISM", uint32(cfg.Argon2.Parallelism))
+	if err != nil {
+		return nil, err
+	}
+	if parallelism > 255 {
+		return nil, fmt.Errorf("invalid ARGON2_PARALLELISM: %d", parallelism)
+	}
+	cfg.Argon2.Parallelism = uint8(parallelism)
+	cost, err := uintFromEnv("BCRYPT_COST", uint32(cfg.BcryptCost))
+	if err != nil {
+		return nil, err
+	}
+	cfg.BcryptCost = int(cost)
+	return New(cfg)
+}
+
+// Hash хэширует пароль текущим алгоритмом
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == Bcrypt {
+		return hashBcrypt(password, h.cfg.BcryptCost)
+	}
+	return hashArgon2id(password, h.cfg.Argon2)
+}
+
+// Verify сравнивает пароль с хэшем. needsRehash равен true, если пароль верный,
+// но хэш создан другим алгоритмом или с устаревшими параметрами. Пустой хэш
+// (пользователь без пароля) не совпадает ни с одним паролем.
+func (h *Hasher) Verify(password, encoded string) (ok, needsRehash bool, err error) {
+	switch {
+	case encoded == "":
+		return false, false, nil
+	case strings.HasPrefix(encoded, "$"+Argon2id+"$"):
+		params, ok, err := verifyArgon2id(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, h.cfg.Algorithm != Argon2id || params != h.cfg.Argon2, nil
+	case isBcrypt(encoded):
+		cost, ok, err := verifyBcrypt(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, h.cfg.Algorithm != Bcrypt || cost != h.cfg.BcryptCost, nil
+	}
+	return false, false, ErrUnknownFormat
+}
+
+func uintFromEnv(name string, fallback uint32) (uint32, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+	return uint32(n), nil
+}
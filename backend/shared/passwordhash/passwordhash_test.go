@@ -0,0 +1,152 @@
+package passwordhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cheapArgon2 — минимальные параметры, чтобы тесты не тратили по 64 МиБ на хэш
+var cheapArgon2 = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newHasher(t *testing.T, cfg Config) *Hasher {
+	t.Helper()
+	h, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New(%+v): %v", cfg, err)
+	}
+	return h
+}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	h := newHasher(t, Config{Algorithm: Argon2id, Argon2: cheapArgon2})
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("hash %q is not in PHC format", encoded)
+	}
+	if again, _ := h.Hash("correct horse"); again == encoded {
+		t.Error("two hashes of the same password are equal: salt is not random")
+	}
+
+	if ok, rehash, err := h.Verify("correct horse", encoded); !ok || rehash || err != nil {
+		t.Errorf("Verify(correct) = %v, %v, %v", ok, rehash, err)
+	}
+	if ok, _, err := h.Verify("battery staple", encoded); ok || err != nil {
+		t.Errorf("Verify(wrong) = %v, %v", ok, err)
+	}
+}
+
+func TestBcryptRoundTrip(t *testing.T) {
+	h := newHasher(t, Config{Algorithm: Bcrypt, BcryptCost: 4})
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash, err := h.Verify("correct horse", encoded); !ok || rehash || err != nil {
+		t.Errorf("Verify(correct) = %v, %v, %v", ok, rehash, err)
+	}
+	if ok, _, _ := h.Verify("battery staple", encoded); ok {
+		t.Error("wrong password accepted")
+	}
+}
+
+func TestVerifyAsksForRehash(t *testing.T) {
+	argon := newHasher(t, Config{Algorithm: Argon2id, Argon2: cheapArgon2})
+	bcryptHasher := newHasher(t, Config{Algorithm: Bcrypt, BcryptCost: 4})
+	argonHash, _ := argon.Hash("pw")
+	bcryptHash, _ := bcryptHasher.Hash("pw")
+
+	// Хэш с прежними параметрами или другим алгоритмом принимается, но его
+	// нужно пересчитать
+	upgrades := []struct {
+		from    string
+		to      *Hasher
+		encoded string
+	}{
+		{"argon2id, more memory", newHasher(t, Config{Algorithm: Argon2id, Argon2: Argon2Params{Memory: 128, Iterations: 1, Parallelism: 1}}), argonHash},
+		{"argon2id, more iterations", newHasher(t, Config{Algorithm: Argon2id, Argon2: Argon2Params{Memory: 64, Iterations: 2, Parallelism: 1}}), argonHash},
+		{"bcrypt, higher cost", newHasher(t, Config{Algorithm: Bcrypt, BcryptCost: 5}), bcryptHash},
+		{"bcrypt to argon2id", argon, bcryptHash},
+		{"argon2id to bcrypt", bcryptHasher, argonHash},
+	}
+	for _, u := range upgrades {
+		ok, rehash, err := u.to.Verify("pw", u.encoded)
+		if !ok || !rehash || err != nil {
+			t.Errorf("%s: Verify = %v, %v, %v; want true, true, nil", u.from, ok, rehash, err)
+		}
+		// Неверный пароль не повод пересчитывать хэш
+		if _, rehash, _ := u.to.Verify("other", u.encoded); rehash {
+			t.Errorf("%s: rehash requested for a wrong password", u.from)
+		}
+	}
+}
+
+func TestVerifyEmptyHash(t *testing.T) {
+	h := newHasher(t, DefaultConfig())
+	if ok, rehash, err := h.Verify("", ""); ok || rehash || err != nil {
+		t.Errorf("Verify of an account without password = %v, %v, %v", ok, rehash, err)
+	}
+}
+
+func TestVerifyMalformedHashes(t *testing.T) {
+	h := newHasher(t, Config{Algorithm: Argon2id, Argon2: cheapArgon2})
+
+	for _, encoded := range []string{"plaintext", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA", "$md5$abc"} {
+		if _, _, err := h.Verify("pw", encoded); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("Verify(%q): err = %v, want ErrUnknownFormat", encoded, err)
+		}
+	}
+	for _, encoded := range []string{
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$aGFzaA", // неподдерживаемая версия
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$aGFzaA", // нулевое число итераций
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$aGFzaA",    // соль не в base64
+		"$2a$broken",
+	} {
+		ok, _, err := h.Verify("pw", encoded)
+		if ok || err == nil {
+			t.Errorf("Verify(%q) = %v, %v; want an error", encoded, ok, err)
+		}
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(DefaultConfig()); err != nil {
+		t.Errorf("default config rejected: %v", err)
+	}
+	bad := map[string]Config{
+		"unknown algorithm":    {Algorithm: "md5"},
+		"bcrypt cost too low":  {Algorithm: Bcrypt, BcryptCost: 3},
+		"bcrypt cost too high": {Algorithm: Bcrypt, BcryptCost: 32},
+		"zero iterations":      {Algorithm: Argon2id, Argon2: Argon2Params{Memory: 64, Parallelism: 1}},
+		"memory below 8*p KiB": {Algorithm: Argon2id, Argon2: Argon2Params{Memory: 8, Iterations: 1, Parallelism: 2}},
+	}
+	for name, cfg := range bad {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%s: config accepted", name)
+		}
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	t.Setenv("BCRYPT_COST", "6")
+	h, err := NewFromEnv()
+	if err != nil {
+		t.Fatalf("NewFromEnv: %v", err)
+	}
+	if h.cfg.Algorithm != Bcrypt || h.cfg.BcryptCost != 6 {
+		t.Errorf("config = %+v", h.cfg)
+	}
+
+	for name, value := range map[string]string{"ARGON2_ITERATIONS": "0", "ARGON2_MEMORY_KIB": "lots", "ARGON2_PARALLELISM": "256"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := NewFromEnv(); err == nil {
+				t.Errorf("%s=%s accepted", name, value)
+			}
+		})
+	}
+}
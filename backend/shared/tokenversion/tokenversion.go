@@ -0,0 +1,99 @@
+// Package tokenversion отзывает ранее выданные токены пользователя. При смене
+// пароля users_service увеличивает users.token_version, и токены со старым
+// claim tv перестают приниматься всеми сервисами.
+package tokenversion
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"shared/jwtauth"
+)
+
+// ErrRevoked возвращается для токена, выданного до смены пароля
+var ErrRevoked = errors.New("token has been revoked")
+
+const (
+	defaultCacheTTL = 5 * time.Second
+	// maxEntries ограничивает размер кэша; при переполнении он очищается
+	maxEntries = 10000
+)
+
+type entry struct {
+	version   int
+	fetchedAt time.Time
+}
+
+// Checker сверяет claim tv с текущей версией пользователя. Версии кэшируются
+// на ttl, поэтому отзыв вступает в силу с задержкой не больше ttl.
+type Checker struct {
+	db  *sql.DB
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[int]entry
+}
+
+// New создаёт Checker поверх общей базы данных
+func New(db *sql.DB, ttl time.Duration) *Checker {
+	return &Checker{db: db, ttl: ttl, cache: make(map[int]entry)}
+}
+
+// NewFromEnv создаёт Checker со сроком кэша из TOKEN_VERSION_CACHE_TTL
+// (по умолчанию 5s, 0 — без кэша)
+func NewFromEnv(db *sql.DB) (*Checker, error) {
+	ttl := defaultCacheTTL
+	if v := os.Getenv("TOKEN_VERSION_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid TOKEN_VERSION_CACHE_TTL: %q", v)
+		}
+		ttl = d
+	}
+	return New(db, ttl), nil
+}
+
+// Check отклоняет токен удалённого пользователя или выданный до смены пароля.
+// Подходит для jwtauth.Verifier.AddCheck.
+func (c *Checker) Check(claims *jwtauth.Claims) error {
+	current, err := c.current(claims.UserID)
+	if err != nil {
+		return err
+	}
+	if claims.TokenVersion < current {
+		return ErrRevoked
+	}
+	return nil
+}
+
+func (c *Checker) current(userID int) (int, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.cache[userID]
+	c.mu.Unlock()
+	if ok && now.Sub(e.fetchedAt) < c.ttl {
+		return e.version, nil
+	}
+
+	var version int
+	err := c.db.QueryRow("SELECT token_version FROM users WHERE id = $1", userID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, ErrRevoked
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to fetch token version: %w", err)
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		if len(c.cache) >= maxEntries {
+			c.cache = make(map[int]entry)
+		}
+		c.cache[userID] = entry{version: version, fetchedAt: now}
+		c.mu.Unlock()
+	}
+	return version, nil
+}
@@ -0,0 +1,116 @@
+package tokenversion
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"shared/jwtauth"
+
+	_ "github.com/lib/pq"
+)
+
+func TestCheckComparesWithCachedVersion(t *testing.T) {
+	// База не нужна, пока версия пользователя лежит в кэше
+	c := New(nil, time.Hour)
+	c.cache[1] = entry{version: 3, fetchedAt: time.Now()}
+
+	check := func(version int) error {
+		return c.Check(&jwtauth.Claims{UserID: 1, TokenVersion: version})
+	}
+	if err := check(3); err != nil {
+		t.Errorf("current version rejected: %v", err)
+	}
+	if err := check(4); err != nil {
+		t.Errorf("newer version rejected: %v", err)
+	}
+	if err := check(2); !errors.Is(err, ErrRevoked) {
+		t.Errorf("token issued before the password change: err = %v, want ErrRevoked", err)
+	}
+	if err := check(0); !errors.Is(err, ErrRevoked) {
+		t.Errorf("token without tv claim: err = %v, want ErrRevoked", err)
+	}
+}
+
+func TestCheckerPlugsIntoVerifier(t *testing.T) {
+	cfg := &jwtauth.Config{
+		Keys:        map[string]jwtauth.Key{"k": jwtauth.NewHMACKey("k", []byte("secret"))},
+		ActiveKeyID: "k",
+		Issuer:      "auth-service",
+		Audience:    "api",
+	}
+	signer, _ := jwtauth.NewSigner(cfg)
+	old, _, _ := signer.Sign(jwtauth.Claims{UserID: 9, TokenVersion: 1}, time.Minute)
+	fresh, _, _ := signer.Sign(jwtauth.Claims{UserID: 9, TokenVersion: 2}, time.Minute)
+
+	c := New(nil, time.Hour)
+	c.cache[9] = entry{version: 2, fetchedAt: time.Now()}
+	v := jwtauth.NewVerifier(cfg)
+	v.AddCheck(c.Check)
+
+	if _, err := v.Verify(old); !errors.Is(err, jwtauth.ErrInvalidToken) {
+		t.Errorf("revoked token: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := v.Verify(fresh); err != nil {
+		t.Errorf("fresh token: %v", err)
+	}
+}
+
+func TestNewFromEnvCacheTTL(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": defaultCacheTTL, "30s": 30 * time.Second, "0": 0} {
+		t.Setenv("TOKEN_VERSION_CACHE_TTL", value)
+		c, err := NewFromEnv(nil)
+		if err != nil || c.ttl != want {
+			t.Errorf("TOKEN_VERSION_CACHE_TTL=%q: ttl %v, err %v; want %v", value, c.ttl, err, want)
+		}
+	}
+	for _, value := range []string{"-1s", "soon"} {
+		t.Setenv("TOKEN_VERSION_CACHE_TTL", value)
+		if _, err := NewFromEnv(nil); err == nil {
+			t.Errorf("TOKEN_VERSION_CACHE_TTL=%q accepted", value)
+		}
+	}
+}
+
+// TestCheckReadsDatabase нужна база с таблицей users после миграций users_service
+func TestCheckReadsDatabase(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	name := fmt.Sprintf("tv%d", time.Now().UnixNano())
+	var id int
+	if err := db.QueryRow(`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x') RETURNING id`,
+		name, name+"@example.com").Scan(&id); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	defer db.Exec("DELETE FROM users WHERE id = $1", id)
+
+	c := New(db, time.Hour)
+	if err := c.Check(&jwtauth.Claims{UserID: id, TokenVersion: 0}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	// Смена пароля увеличивает версию; кэш её пока не видит, Checker без кэша — сразу
+	db.Exec("UPDATE users SET token_version = token_version + 1 WHERE id = $1", id)
+	if err := c.Check(&jwtauth.Claims{UserID: id, TokenVersion: 0}); err != nil {
+		t.Errorf("cached version: %v", err)
+	}
+	if err := New(db, 0).Check(&jwtauth.Claims{UserID: id, TokenVersion: 0}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("uncached check: err = %v, want ErrRevoked", err)
+	}
+
+	db.Exec("DELETE FROM users WHERE id = $1", id)
+	if err := New(db, 0).Check(&jwtauth.Claims{UserID: id, TokenVersion: 1}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("deleted user: err = %v, want ErrRevoked", err)
+	}
+}
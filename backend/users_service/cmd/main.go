@@ -1,12 +1,23 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
+	"shared/apikeys"
+	"shared/authz"
+	"shared/jwtauth"
+	"shared/mailer"
+	"shared/passpolicy"
+	"shared/passwordhash"
+	"shared/tokenversion"
+	"users_service/internal/blobstore"
 	"users_service/internal/database"
 	"users_service/internal/handlers"
+	"users_service/internal/middlewares"
 
 	"github.com/gorilla/mux"
 )
@@ -24,6 +35,17 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
+// splitList разбирает список значений через запятую, пропуская пустые
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
 	db, err := database.Connect()
 	if err != nil {
@@ -31,20 +53,127 @@ func main() {
 	}
 	defer db.Close()
 
-	r := mux.NewRouter()
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// ADMIN_EMAILS — список email через запятую, которым при старте выдаётся роль admin
+	if emails := splitList(os.Getenv("ADMIN_EMAILS")); len(emails) > 0 {
+		if err := database.PromoteAdmins(db, emails); err != nil {
+			log.Fatalf("Failed to promote admins: %v", err)
+		}
+	}
+
+	m, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	policy, err := passpolicy.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure password policy: %v", err)
+	}
+
+	hasher, err := passwordhash.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure password hashing: %v", err)
+	}
 
-	r.HandleFunc("/users/register", handlers.RegisterUser(db)).Methods("POST")
+	store, err := blobstore.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure blob store: %v", err)
+	}
+	// BLOB_PUBLIC_URL — внешний адрес, по которому доступны ключи хранилища (маршрут /avatars/...)
+	blobURL := os.Getenv("BLOB_PUBLIC_URL")
+	if blobURL == "" {
+		blobURL = "/api/users/"
+	}
 
-	r.HandleFunc("/users/by_email", handlers.GetUserByEmail(db)).Methods("GET")
+	// Уведомления о подписках принимаются только от users_service с сервисным
+	// токеном, который выдаёт auth_service (AUTH_SERVICE_URL,
+	// SERVICE_CLIENT_SECRET и запись users-service в SERVICE_CLIENTS).
+	// Без них сервис работает, но уведомления о подписчиках не отправляются.
+	serviceTokens, err := jwtauth.ServiceTokenClientFromEnv(handlers.ServiceName)
+	switch {
+	case errors.Is(err, jwtauth.ErrServiceClientNotConfigured):
+		log.Printf("Warning: AUTH_SERVICE_URL and SERVICE_CLIENT_SECRET are not set; follower notifications are disabled")
+	case err != nil:
+		log.Fatalf("Invalid configuration: %v", err)
+	default:
+		handlers.UseServiceTokens(serviceTokens)
+	}
+
+	verifier, err := jwtauth.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load JWT configuration: %v", err)
+	}
+	// Токены, выданные до смены пароля, отклоняются
+	versions, err := tokenversion.NewFromEnv(db)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	verifier.AddCheck(versions.Check)
+	// TRUSTED_SERVICES — сервисы, которым разрешены служебные маршруты (по умолчанию auth-service)
+	trustedServices := splitList(os.Getenv("TRUSTED_SERVICES"))
+	if len(trustedServices) == 0 {
+		trustedServices = []string{"auth-service"}
+	}
+
+	// LOOKUP_SERVICES — сервисы, которые ищут пользователей по именам со своим
+	// сервисным токеном (по умолчанию posts-service). Служебные маршруты
+	// TRUSTED_SERVICES им не доступны.
+	lookupServices := splitList(os.Getenv("LOOKUP_SERVICES"))
+	if len(lookupServices) == 0 {
+		lookupServices = []string{"posts-service"}
+	}
+
+	keys := apikeys.NewAuthenticator(db)
+	requireAuth := middlewares.AuthMiddleware(verifier, keys)
+	optionalAuth := middlewares.OptionalAuthMiddleware(verifier, keys)
+	requireService := middlewares.ServiceMiddleware(verifier, trustedServices)
+	requireUserOrService := middlewares.UserOrServiceMiddleware(verifier, keys, trustedServices)
+	requireUserOrLookup := middlewares.UserOrServiceMiddleware(verifier, keys, lookupServices)
+	selfOr := func(p authz.Permission) func(http.Handler) http.Handler {
+		return authz.RequireSelfOr(middlewares.UserIDFromPath, p)
+	}
+
+	r := mux.NewRouter()
 
-	r.HandleFunc("/users/by_username", handlers.GetUserByUsername(db)).Methods("GET")
+	// Публичные маршруты
+	r.HandleFunc("/verify-email", handlers.VerifyEmail(db)).Methods("GET")
+	r.Handle("/profile/{username}", optionalAuth(handlers.GetPublicProfile(db, blobURL))).Methods("GET")
+	r.HandleFunc("/users/{id:[0-9]+}/followers", handlers.ListFollowers(db, blobURL)).Methods("GET")
+	r.HandleFunc("/users/{id:[0-9]+}/following", handlers.ListFollowing(db, blobURL)).Methods("GET")
+	r.HandleFunc("/avatars/{key:.+}", handlers.ServeAvatar(store)).Methods("GET")
 
-	r.HandleFunc("/users/{id:[0-9]+}", handlers.GetUserByID(db)).Methods("GET")
-	r.HandleFunc("/users/{id:[0-9]+}", handlers.UpdateUser(db)).Methods("PATCH")
-	r.HandleFunc("/users/{id:[0-9]+}/password", handlers.UpdateUserPassword(db)).Methods("PATCH")
-	r.HandleFunc("/users/{id:[0-9]+}", handlers.DeleteUser(db)).Methods("DELETE")
+	// Служебные маршруты: учётные данные и MFA доступны только auth_service
+	r.Handle("/users/register", requireService(handlers.RegisterUser(db, m, policy, hasher))).Methods("POST")
+	r.Handle("/users/verify-credentials", requireService(handlers.VerifyCredentials(db, hasher))).Methods("POST")
+	r.Handle("/users/by_email", requireService(handlers.GetUserByEmail(db))).Methods("GET")
+	r.Handle("/users/by_identity", requireService(handlers.GetUserByIdentity(db))).Methods("GET")
+	r.Handle("/users/oidc", requireService(handlers.CreateOIDCUser(db))).Methods("POST")
+	r.Handle("/users/{id:[0-9]+}/identities", requireService(handlers.LinkIdentity(db))).Methods("POST")
+	r.Handle("/users/{id:[0-9]+}/password", requireService(handlers.UpdateUserPassword(db, policy, hasher))).Methods("PATCH")
+	r.Handle("/users/{id:[0-9]+}/mfa", requireService(handlers.GetMFA(db))).Methods("GET")
+	r.Handle("/users/{id:[0-9]+}/mfa", requireService(handlers.UpdateMFA(db))).Methods("PUT")
+	r.Handle("/users/{id:[0-9]+}/mfa/step", requireService(handlers.AdvanceTOTPStep(db))).Methods("POST")
+	r.Handle("/users/{id:[0-9]+}/recovery-codes", requireService(handlers.ReplaceRecoveryCodes(db))).Methods("PUT")
+	r.Handle("/users/{id:[0-9]+}/recovery-codes/consume", requireService(handlers.ConsumeRecoveryCode(db))).Methods("POST")
 
-	r.HandleFunc("/users", handlers.ListUsers(db)).Methods("GET")
+	// Пользовательские маршруты
+	r.Handle("/users/by_username", requireAuth(handlers.GetUserByUsername(db, blobURL))).Methods("GET")
+	r.Handle("/users/by_usernames", requireUserOrLookup(handlers.GetUsersByUsernames(db))).Methods("GET")
+	r.Handle("/users/search", requireAuth(handlers.SearchUsers(db, blobURL))).Methods("GET")
+	r.Handle("/users/{id:[0-9]+}", requireUserOrService(handlers.GetUserByID(db, blobURL))).Methods("GET")
+	r.Handle("/users/{id:[0-9]+}", requireAuth(selfOr(authz.UsersUpdateAny)(handlers.UpdateUser(db, m)))).Methods("PATCH")
+	// Удалять учётные записи могут только администраторы, в том числе свою
+	r.Handle("/users/{id:[0-9]+}", requireAuth(authz.Require(authz.UsersDelete)(handlers.DeleteUser(db)))).Methods("DELETE")
+	r.Handle("/users/{id:[0-9]+}/avatar", requireAuth(selfOr(authz.UsersUpdateAny)(handlers.UploadAvatar(db, store, blobURL)))).Methods("PUT")
+	r.Handle("/users/{id:[0-9]+}/avatar", requireAuth(selfOr(authz.UsersUpdateAny)(handlers.DeleteAvatar(db, store)))).Methods("DELETE")
+	r.Handle("/users/{id:[0-9]+}/follow", requireAuth(handlers.FollowUser(db))).Methods("POST")
+	r.Handle("/users/{id:[0-9]+}/follow", requireAuth(handlers.UnfollowUser(db))).Methods("DELETE")
+	r.Handle("/users/{id:[0-9]+}/role", requireAuth(authz.Require(authz.UsersManageRoles)(handlers.UpdateUserRole(db)))).Methods("PUT")
+	r.Handle("/users", requireAuth(authz.Require(authz.UsersList)(handlers.ListUsers(db)))).Methods("GET")
 
 	corsHandler := enableCORS(r)
 
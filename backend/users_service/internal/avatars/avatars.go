@@ -0,0 +1,90 @@
+// Package avatars превращает загруженное изображение в набор квадратных
+// миниатюр фиксированных размеров
+package avatars
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// MaxUploadSize — максимальный размер загружаемого файла
+	MaxUploadSize = 5 << 20
+	// maxDimension защищает от изображений, которые занимают гигабайты после распаковки
+	maxDimension = 6000
+	jpegQuality  = 85
+)
+
+// Sizes — стороны миниатюр в пикселях
+var Sizes = []int{256, 64}
+
+// ErrUnsupportedImage возвращается для файла, который не является JPEG, PNG или GIF
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// ErrImageTooLarge возвращается для изображения со слишком большой стороной
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// Thumbnails декодирует изображение, обрезает его до квадрата по центру и
+// возвращает JPEG-миниатюры для каждого размера из Sizes
+func Thumbnails(r io.Reader) (map[int][]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxUploadSize {
+		return nil, ErrImageTooLarge
+	}
+
+	// Размеры проверяются до декодирования всего изображения
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, ErrImageTooLarge
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return nil, ErrUnsupportedImage
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	square := centerSquare(src.Bounds())
+	thumbs := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		// Прозрачные области PNG и GIF заливаются белым: в JPEG нет альфа-канала
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, square, draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		thumbs[size] = buf.Bytes()
+	}
+	return thumbs, nil
+}
+
+// centerSquare возвращает наибольший квадрат в центре прямоугольника
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
@@ -0,0 +1,83 @@
+package avatars
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// encodePNG рисует изображение w×h: левая половина красная, правая синяя
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		c := color.RGBA{R: 255, A: 255}
+		if x >= w/2 {
+			c = color.RGBA{B: 255, A: 255}
+		}
+		for y := 0; y < h; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnails(t *testing.T) {
+	thumbs, err := Thumbnails(bytes.NewReader(encodePNG(t, 600, 300)))
+	if err != nil {
+		t.Fatalf("Thumbnails: %v", err)
+	}
+	if len(thumbs) != len(Sizes) {
+		t.Fatalf("got %d thumbnails, want %d", len(thumbs), len(Sizes))
+	}
+	for _, size := range Sizes {
+		img, err := jpeg.Decode(bytes.NewReader(thumbs[size]))
+		if err != nil {
+			t.Fatalf("thumbnail %d is not a JPEG: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("thumbnail %d is %dx%d", size, b.Dx(), b.Dy())
+		}
+		// Квадрат вырезан по центру, поэтому обе половины остаются в кадре
+		left, _, _, _ := img.At(size/8, size/2).RGBA()
+		_, _, right, _ := img.At(size-size/8, size/2).RGBA()
+		if left < 0xc000 || right < 0xc000 {
+			t.Errorf("thumbnail %d is not centred: left red %x, right blue %x", size, left, right)
+		}
+	}
+}
+
+func TestThumbnailsRejectsBadInput(t *testing.T) {
+	if _, err := Thumbnails(strings.NewReader("definitely not an image")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("text file: err = %v, want ErrUnsupportedImage", err)
+	}
+	if _, err := Thumbnails(bytes.NewReader(encodePNG(t, maxDimension+1, 1))); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("wide image: err = %v, want ErrImageTooLarge", err)
+	}
+	if _, err := Thumbnails(bytes.NewReader(make([]byte, MaxUploadSize+1))); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("oversized file: err = %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestCenterSquare(t *testing.T) {
+	cases := map[image.Rectangle]image.Rectangle{
+		image.Rect(0, 0, 100, 100): image.Rect(0, 0, 100, 100),
+		image.Rect(0, 0, 300, 100): image.Rect(100, 0, 200, 100),
+		image.Rect(0, 0, 100, 301): image.Rect(0, 100, 100, 200),
+		image.Rect(10, 20, 50, 40): image.Rect(20, 20, 40, 40),
+	}
+	for in, want := range cases {
+		if got := centerSquare(in); got != want {
+			t.Errorf("centerSquare(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
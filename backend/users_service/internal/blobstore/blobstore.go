@@ -0,0 +1,50 @@
+// Package blobstore хранит загруженные пользователями файлы, например аватары.
+// Хранилище выбирается переменной BLOB_STORE; сейчас реализовано только
+// local — каталог на диске.
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound возвращается для отсутствующего файла
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey возвращается для ключа с недопустимыми символами
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store — хранилище файлов по ключу вида "avatars/42/abc-256.jpg"
+type Store interface {
+	Put(key string, data []byte) error
+	// Open возвращает содержимое файла; закрыть его должен вызывающий
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// NewFromEnv создаёт хранилище по BLOB_STORE (local по умолчанию).
+// Для local каталог задаётся BLOB_STORE_DIR, по умолчанию ./data/blobs.
+func NewFromEnv() (Store, error) {
+	switch kind := os.Getenv("BLOB_STORE"); kind {
+	case "", "local":
+		dir := os.Getenv("BLOB_STORE_DIR")
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+		return NewLocalStore(dir)
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", kind)
+	}
+}
+
+var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)*(\.[a-z0-9]+)?$`)
+
+// ValidKey сообщает, допустим ли ключ: сегменты из букв, цифр, _ и -,
+// разделённые /, и необязательное расширение. Ключ не может выйти за пределы хранилища.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key) && !strings.Contains(key, "..")
+}
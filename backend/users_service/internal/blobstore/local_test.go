@@ -0,0 +1,80 @@
+package blobstore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+
+	if err := s.Put("avatars/7/abc-64.jpg", []byte("first")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// Повторная запись заменяет содержимое
+	if err := s.Put("avatars/7/abc-64.jpg", []byte("second")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	f, err := s.Open("avatars/7/abc-64.jpg")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "second" {
+		t.Errorf("content = %q, want %q", data, "second")
+	}
+
+	// Временные файлы не остаются в каталоге
+	entries, _ := os.ReadDir(filepath.Join(dir, "blobs", "avatars", "7"))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+
+	if err := s.Delete("avatars/7/abc-64.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Open("avatars/7/abc-64.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete("avatars/7/abc-64.jpg"); err != nil {
+		t.Errorf("Delete of a missing blob: %v", err)
+	}
+}
+
+func TestLocalStoreRejectsInvalidKeys(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"../escape.jpg", "avatars/../../etc/passwd", "/abs.jpg", "a//b", "", "avatars/x.JPG", "name with spaces"} {
+		if err := s.Put(key, []byte("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+		if _, err := s.Open(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Open(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("BLOB_STORE_DIR", t.TempDir())
+	for _, kind := range []string{"", "local"} {
+		t.Setenv("BLOB_STORE", kind)
+		if _, err := NewFromEnv(); err != nil {
+			t.Errorf("BLOB_STORE=%q: %v", kind, err)
+		}
+	}
+	t.Setenv("BLOB_STORE", "s3")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("unknown BLOB_STORE accepted")
+	}
+}
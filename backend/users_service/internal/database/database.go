@@ -26,19 +26,106 @@ func Connect() (*sql.DB, error) {
 	return db, nil
 }
 
+// migrations дополняет таблицу users и создаёт таблицы, которыми владеет users_service.
+// Выражения идемпотентны и выполняются при каждом старте сервиса.
+var migrations = []string{
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS recovery_codes (
+		id         SERIAL PRIMARY KEY,
+		user_id    INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		code_hash  TEXT NOT NULL,
+		used_at    TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS recovery_codes_user_id_idx ON recovery_codes (user_id)`,
+	// Пользователи, зарегистрированные до появления подтверждения, считаются подтверждёнными
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT true`,
+	`ALTER TABLE users ALTER COLUMN email_verified SET DEFAULT false`,
+	`CREATE TABLE IF NOT EXISTS email_verifications (
+		id          SERIAL PRIMARY KEY,
+		user_id     INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		email       TEXT NOT NULL,
+		token_hash  TEXT NOT NULL UNIQUE,
+		expires_at  TIMESTAMPTZ NOT NULL,
+		used_at     TIMESTAMPTZ,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'
+		CHECK (role IN ('user', 'moderator', 'admin'))`,
+	`CREATE TABLE IF NOT EXISTS user_identities (
+		id          SERIAL PRIMARY KEY,
+		user_id     INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		provider    TEXT NOT NULL,
+		subject     TEXT NOT NULL,
+		email       TEXT NOT NULL,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+		UNIQUE (provider, subject)
+	)`,
+	`CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id)`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS location TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS website TEXT NOT NULL DEFAULT ''`,
+	// avatar_key — общий префикс ключей миниатюр в хранилище, пустой без аватара
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS follows (
+		follower_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		followee_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (follower_id, followee_id),
+		CHECK (follower_id <> followee_id)
+	)`,
+	// Индексы под постраничные списки подписчиков и подписок, новые сверху
+	`CREATE INDEX IF NOT EXISTS follows_followee_created_idx ON follows (followee_id, created_at DESC, follower_id DESC)`,
+	`CREATE INDEX IF NOT EXISTS follows_follower_created_idx ON follows (follower_id, created_at DESC, followee_id DESC)`,
+	// Время регистрации нужно для постраничного списка пользователей; у старых
+	// пользователей оно совпадает со временем миграции
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
+	`CREATE INDEX IF NOT EXISTS users_created_idx ON users (created_at DESC, id DESC)`,
+	// Поиск пользователей: триграммы для нечёткого совпадения и подстрок,
+	// text_pattern_ops — для коротких префиксов, которые триграммам не по силам
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`CREATE INDEX IF NOT EXISTS users_username_trgm_idx ON users USING GIN (lower(username) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS users_display_name_trgm_idx ON users USING GIN (lower(display_name) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS users_username_prefix_idx ON users (lower(username) text_pattern_ops)`,
+}
+
+// Migrate применяет недостающие изменения схемы
+func Migrate(db *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply migration: %w", err)
+		}
+	}
+	return nil
+}
+
 // User представляет данные пользователя
 type User struct {
-	ID           int    `json:"id"`
-	Username     string `json:"username"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"password_hash"`
+	ID            int    `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	PasswordHash  string `json:"-"`
+	TOTPEnabled   bool   `json:"totp_enabled"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	TokenVersion  int    `json:"token_version"`
+}
+
+// userColumns перечисляет столбцы users в порядке полей, которые читает scanUser
+const userColumns = "u.id, u.username, u.email, u.password_hash, u.totp_enabled, u.email_verified, u.role, u.token_version"
+
+func scanUser(row *sql.Row, user *User) error {
+	return row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.TOTPEnabled, &user.EmailVerified, &user.Role, &user.TokenVersion)
 }
 
 // GetUserByEmail выполняет запрос к базе данных для получения пользователя по email
 func GetUserByEmail(db *sql.DB, email string) (*User, error) {
 	var user User
-	err := db.QueryRow("SELECT id, username, email, password_hash FROM users WHERE email = $1", email).
-		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+	err := scanUser(db.QueryRow("SELECT "+userColumns+" FROM users u WHERE u.email = $1", email), &user)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // Пользователь не найден
@@ -48,11 +135,13 @@ func GetUserByEmail(db *sql.DB, email string) (*User, error) {
 	return &user, nil // Пользователь найден
 }
 
-// SaveUser сохраняет нового пользователя в базе данных
-func SaveUser(db *sql.DB, username, email, passwordHash string) error {
-	_, err := db.Exec(`
+// SaveUser сохраняет нового пользователя в базе данных и возвращает его ID
+func SaveUser(db *sql.DB, username, email, passwordHash string) (int, error) {
+	var id int
+	err := db.QueryRow(`
 		INSERT INTO users (username, email, password_hash)
 		VALUES ($1, $2, $3)
-	`, username, email, passwordHash)
-	return err
+		RETURNING id
+	`, username, email, passwordHash).Scan(&id)
+	return id, err
 }
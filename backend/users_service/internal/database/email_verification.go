@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVerificationTokenInvalid возвращается для неизвестного, истёкшего, уже
+// использованного токена или токена, выданного для прежнего адреса
+var ErrVerificationTokenInvalid = errors.New("invalid or expired verification token")
+
+// CreateEmailVerification сохраняет хэш токена подтверждения для указанного адреса
+func CreateEmailVerification(db *sql.DB, userID int, email, tokenHash string, expiresAt time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO email_verifications (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, userID, email, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create email verification: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail погашает токен и отмечает адрес пользователя подтверждённым.
+// Токен действителен, только если адрес пользователя не менялся после его выдачи.
+func VerifyEmail(db *sql.DB, tokenHash string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id, userID int
+	err = tx.QueryRow(`
+		SELECT ev.id, ev.user_id
+		FROM email_verifications ev
+		JOIN users u ON u.id = ev.user_id AND u.email = ev.email
+		WHERE ev.token_hash = $1 AND ev.used_at IS NULL AND ev.expires_at > now()
+		FOR UPDATE OF ev
+	`, tokenHash).Scan(&id, &userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrVerificationTokenInvalid
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to fetch email verification: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE email_verifications SET used_at = now() WHERE id = $1", id); err != nil {
+		return 0, fmt.Errorf("failed to mark verification as used: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE users SET email_verified = true WHERE id = $1", userID); err != nil {
+		return 0, fmt.Errorf("failed to mark email as verified: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, nil
+}
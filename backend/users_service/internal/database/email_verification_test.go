@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// userEmail возвращает адрес пользователя и признак его подтверждения
+func userEmail(t *testing.T, db *sql.DB, id int) (string, bool) {
+	t.Helper()
+	var email string
+	var verified bool
+	if err := db.QueryRow("SELECT email, email_verified FROM users WHERE id = $1", id).Scan(&email, &verified); err != nil {
+		t.Fatalf("fetch user: %v", err)
+	}
+	return email, verified
+}
+
+func TestVerifyEmail(t *testing.T) {
+	db := openTestDB(t)
+	id := newUser(t, db, "verify")
+	email, verified := userEmail(t, db, id)
+	if verified {
+		t.Fatal("new user is already verified")
+	}
+
+	hash := fmt.Sprintf("verify-%d", time.Now().UnixNano())
+	if err := CreateEmailVerification(db, id, email, hash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("CreateEmailVerification: %v", err)
+	}
+
+	got, err := VerifyEmail(db, hash)
+	if err != nil || got != id {
+		t.Fatalf("VerifyEmail = %d, %v; want %d", got, err, id)
+	}
+	if _, verified := userEmail(t, db, id); !verified {
+		t.Error("email is not marked as verified")
+	}
+	if _, err := VerifyEmail(db, hash); !errors.Is(err, ErrVerificationTokenInvalid) {
+		t.Errorf("second use: err = %v, want ErrVerificationTokenInvalid", err)
+	}
+}
+
+func TestVerifyEmailRejectsStaleTokens(t *testing.T) {
+	db := openTestDB(t)
+	id := newUser(t, db, "stale")
+	email, _ := userEmail(t, db, id)
+
+	expired := fmt.Sprintf("expired-%d", time.Now().UnixNano())
+	CreateEmailVerification(db, id, email, expired, time.Now().Add(-time.Minute))
+	if _, err := VerifyEmail(db, expired); !errors.Is(err, ErrVerificationTokenInvalid) {
+		t.Errorf("expired token: err = %v", err)
+	}
+
+	// Ссылка, отправленная на прежний адрес, не подтверждает новый
+	old := fmt.Sprintf("old-%d", time.Now().UnixNano())
+	CreateEmailVerification(db, id, email, old, time.Now().Add(time.Hour))
+	if _, err := db.Exec("UPDATE users SET email = $1 WHERE id = $2", "new-"+email, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyEmail(db, old); !errors.Is(err, ErrVerificationTokenInvalid) {
+		t.Errorf("token for the previous address: err = %v", err)
+	}
+	if _, verified := userEmail(t, db, id); verified {
+		t.Error("new address was verified by a link sent to the old one")
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"shared/pagination"
+)
+
+// Relationship — связь между просматривающим пользователем и владельцем профиля
+type Relationship struct {
+	Following  bool `json:"following"`
+	FollowedBy bool `json:"followed_by"`
+	Mutual     bool `json:"mutual"`
+}
+
+// FollowEntry — пользователь в списке подписчиков или подписок
+type FollowEntry struct {
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarKey   string    `json:"-"`
+	FollowedAt  time.Time `json:"followed_at"`
+	// Mutual — владелец списка и этот пользователь подписаны друг на друга
+	Mutual bool `json:"mutual"`
+}
+
+// UserExists сообщает, есть ли пользователь с таким ID
+func UserExists(db *sql.DB, userID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", userID).Scan(&exists)
+	return exists, err
+}
+
+// Follow подписывает followerID на followeeID. created равен false, если
+// подписка уже была; повторная подписка ошибкой не считается.
+func Follow(db *sql.DB, followerID, followeeID int) (created bool, err error) {
+	res, err := db.Exec(`
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, followerID, followeeID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Unfollow отменяет подписку. removed равен false, если подписки не было.
+func Unfollow(db *sql.DB, followerID, followeeID int) (removed bool, err error) {
+	res, err := db.Exec("DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetRelationship возвращает связь viewerID с userID
+func GetRelationship(db *sql.DB, viewerID, userID int) (Relationship, error) {
+	var rel Relationship
+	err := db.QueryRow(`
+		SELECT
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2),
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND followee_id = $1)
+	`, viewerID, userID).Scan(&rel.Following, &rel.FollowedBy)
+	rel.Mutual = rel.Following && rel.FollowedBy
+	return rel, err
+}
+
+// ListFollowers возвращает подписчиков userID, новые сверху. Выбирается
+// page.Limit+1 записей, чтобы понять, есть ли следующая страница.
+func ListFollowers(db *sql.DB, userID int, page pagination.Request) ([]FollowEntry, error) {
+	after, afterID := page.Args()
+	return queryFollowEntries(db, `
+		SELECT u.id, u.username, u.display_name, u.avatar_key, f.created_at,
+			EXISTS(SELECT 1 FROM follows b WHERE b.follower_id = $1 AND b.followee_id = u.id)
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		  AND ($2::timestamptz IS NULL OR (f.created_at, f.follower_id) < ($2, $3))
+		ORDER BY f.created_at DESC, f.follower_id DESC
+		LIMIT $4
+	`, userID, after, afterID, page.Limit+1)
+}
+
+// ListFollowing возвращает пользователей, на которых подписан userID, новые сверху
+func ListFollowing(db *sql.DB, userID int, page pagination.Request) ([]FollowEntry, error) {
+	after, afterID := page.Args()
+	return queryFollowEntries(db, `
+		SELECT u.id, u.username, u.display_name, u.avatar_key, f.created_at,
+			EXISTS(SELECT 1 FROM follows b WHERE b.follower_id = u.id AND b.followee_id = $1)
+		FROM follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		  AND ($2::timestamptz IS NULL OR (f.created_at, f.followee_id) < ($2, $3))
+		ORDER BY f.created_at DESC, f.followee_id DESC
+		LIMIT $4
+	`, userID, after, afterID, page.Limit+1)
+}
+
+func queryFollowEntries(db *sql.DB, query string, args ...interface{}) ([]FollowEntry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []FollowEntry
+	for rows.Next() {
+		var e FollowEntry
+		if err := rows.Scan(&e.ID, &e.Username, &e.DisplayName, &e.AvatarKey, &e.FollowedAt, &e.Mutual); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
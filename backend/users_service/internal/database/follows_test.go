@@ -0,0 +1,95 @@
+package database
+
+import (
+	"testing"
+
+	"shared/pagination"
+)
+
+func TestFollowAndRelationship(t *testing.T) {
+	db := openTestDB(t)
+	alice, bob := newUser(t, db, "alice"), newUser(t, db, "bob")
+
+	if created, err := Follow(db, alice, bob); err != nil || !created {
+		t.Fatalf("Follow = %v, %v", created, err)
+	}
+	// Повторная подписка не ошибка, но и не новая запись
+	if created, err := Follow(db, alice, bob); err != nil || created {
+		t.Fatalf("repeated Follow = %v, %v", created, err)
+	}
+
+	rel, err := GetRelationship(db, alice, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel != (Relationship{Following: true}) {
+		t.Errorf("alice→bob = %+v", rel)
+	}
+	if rel, _ := GetRelationship(db, bob, alice); rel != (Relationship{FollowedBy: true}) {
+		t.Errorf("bob→alice = %+v", rel)
+	}
+
+	Follow(db, bob, alice)
+	if rel, _ := GetRelationship(db, alice, bob); !rel.Mutual {
+		t.Errorf("mutual follow not detected: %+v", rel)
+	}
+
+	profile, _ := GetProfileByID(db, bob)
+	if profile.Followers != 1 || profile.Following != 1 {
+		t.Errorf("bob counts = %d followers, %d following", profile.Followers, profile.Following)
+	}
+
+	if removed, err := Unfollow(db, alice, bob); err != nil || !removed {
+		t.Errorf("Unfollow = %v, %v", removed, err)
+	}
+	if removed, _ := Unfollow(db, alice, bob); removed {
+		t.Error("second Unfollow removed something")
+	}
+}
+
+func TestListFollowersPages(t *testing.T) {
+	db := openTestDB(t)
+	star := newUser(t, db, "star")
+	fans := []int{newUser(t, db, "fan"), newUser(t, db, "fan"), newUser(t, db, "fan")}
+	for _, fan := range fans {
+		if _, err := Follow(db, fan, star); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Звезда подписана в ответ только на последнего
+	Follow(db, star, fans[2])
+
+	seen := map[int]bool{}
+	page := pagination.Request{Limit: 2}
+	for i := 0; i < 3; i++ {
+		entries, err := ListFollowers(db, star, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) > page.Limit {
+			entries = entries[:page.Limit]
+		}
+		for _, e := range entries {
+			if seen[e.ID] {
+				t.Errorf("follower %d returned twice", e.ID)
+			}
+			seen[e.ID] = true
+			if e.Mutual != (e.ID == fans[2]) {
+				t.Errorf("follower %d: mutual = %v", e.ID, e.Mutual)
+			}
+		}
+		if len(entries) < page.Limit {
+			break
+		}
+		last := entries[len(entries)-1]
+		page.After = &pagination.Cursor{CreatedAt: last.FollowedAt, ID: last.ID}
+	}
+	if len(seen) != len(fans) {
+		t.Errorf("listed %d followers, want %d", len(seen), len(fans))
+	}
+
+	following, err := ListFollowing(db, fans[0], pagination.Request{Limit: 10})
+	if err != nil || len(following) != 1 || following[0].ID != star {
+		t.Errorf("ListFollowing = %+v, %v", following, err)
+	}
+}
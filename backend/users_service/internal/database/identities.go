@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrIdentityLinked возвращается, если внешний аккаунт уже привязан к другому пользователю
+var ErrIdentityLinked = errors.New("identity is linked to another user")
+
+// GetUserByIdentity ищет пользователя по привязанному внешнему аккаунту.
+// Возвращает nil без ошибки, если аккаунт не привязан.
+func GetUserByIdentity(db *sql.DB, provider, subject string) (*User, error) {
+	var user User
+	err := scanUser(db.QueryRow(`
+		SELECT `+userColumns+`
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2
+	`, provider, subject), &user)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch user by identity: %w", err)
+	}
+	return &user, nil
+}
+
+// LinkIdentity привязывает внешний аккаунт к пользователю. Повторная привязка
+// к тому же пользователю не считается ошибкой.
+func LinkIdentity(db *sql.DB, userID int, provider, subject, email string) error {
+	return linkIdentity(db, userID, provider, subject, email)
+}
+
+// rowQuerier — общее для *sql.DB и *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func linkIdentity(q rowQuerier, userID int, provider, subject, email string) error {
+	var linkedTo int
+	err := q.QueryRow(`
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, subject) DO UPDATE SET email = user_identities.email
+		RETURNING user_id
+	`, userID, provider, subject, email).Scan(&linkedTo)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	if linkedTo != userID {
+		return ErrIdentityLinked
+	}
+	return nil
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// CreateOIDCUser создаёт пользователя без пароля для входа через внешнего
+// провайдера и сразу привязывает к нему внешний аккаунт. Email считается
+// подтверждённым провайдером. Если имя занято, к нему добавляется номер.
+func CreateOIDCUser(db *sql.DB, usernameHint, email, provider, subject string) (*User, error) {
+	base := usernameUnsafe.ReplaceAllString(usernameHint, "")
+	if base == "" {
+		base = usernameUnsafe.ReplaceAllString(strings.SplitN(email, "@", 2)[0], "")
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	username := base
+	for i := 2; ; i++ {
+		var taken bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)", username).Scan(&taken); err != nil {
+			return nil, fmt.Errorf("failed to check username: %w", err)
+		}
+		if !taken {
+			break
+		}
+		if i > 100 {
+			return nil, fmt.Errorf("failed to find a free username for %q", base)
+		}
+		username = fmt.Sprintf("%s%d", base, i)
+	}
+
+	// Пустой password_hash не совпадёт ни с одним паролем: войти можно только через провайдера
+	user := User{Username: username, Email: email, EmailVerified: true, Role: "user"}
+	if err := tx.QueryRow(`
+		INSERT INTO users (username, email, password_hash, email_verified)
+		VALUES ($1, $2, '', true)
+		RETURNING id
+	`, username, email).Scan(&user.ID); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if err := linkIdentity(tx, user.ID, provider, subject, email); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinkIdentity(t *testing.T) {
+	db := openTestDB(t)
+	owner, other := newUser(t, db, "owner"), newUser(t, db, "other")
+	subject := fmt.Sprintf("sub-%d", time.Now().UnixNano())
+
+	if err := LinkIdentity(db, owner, "mock", subject, "a@example.com"); err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+	// Повторная привязка к тому же пользователю допустима
+	if err := LinkIdentity(db, owner, "mock", subject, "a@example.com"); err != nil {
+		t.Errorf("repeated link: %v", err)
+	}
+	if err := LinkIdentity(db, other, "mock", subject, "a@example.com"); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("link to another user: err = %v, want ErrIdentityLinked", err)
+	}
+
+	user, err := GetUserByIdentity(db, "mock", subject)
+	if err != nil || user == nil || user.ID != owner {
+		t.Errorf("GetUserByIdentity = %+v, %v; want user %d", user, err, owner)
+	}
+	if user, err := GetUserByIdentity(db, "google", subject); err != nil || user != nil {
+		t.Errorf("same subject of another provider = %+v, %v", user, err)
+	}
+}
+
+func TestCreateOIDCUserPicksFreeUsername(t *testing.T) {
+	db := openTestDB(t)
+	suffix := time.Now().UnixNano()
+	base := fmt.Sprintf("oidc%d", suffix)
+	taken, err := SaveUser(db, base, base+"-taken@example.com", "hash")
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", taken) })
+
+	// Недопустимые символы из имени у провайдера выбрасываются
+	user, err := CreateOIDCUser(db, base+" !", base+"@example.com", "mock", fmt.Sprintf("s-%d", suffix))
+	if err != nil {
+		t.Fatalf("CreateOIDCUser: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", user.ID) })
+
+	if user.Username != base+"2" {
+		t.Errorf("username = %q, want %q", user.Username, base+"2")
+	}
+	if !user.EmailVerified || strings.Contains(user.Username, " ") {
+		t.Errorf("user = %+v", user)
+	}
+	if linked, _ := GetUserByIdentity(db, "mock", fmt.Sprintf("s-%d", suffix)); linked == nil || linked.ID != user.ID {
+		t.Error("identity is not linked to the new user")
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// MFAState представляет настройки двухфакторной аутентификации пользователя
+type MFAState struct {
+	Secret       string `json:"secret"`
+	Enabled      bool   `json:"enabled"`
+	LastStep     int64  `json:"last_step"`
+	RecoveryLeft int    `json:"recovery_codes_left"`
+}
+
+// GetMFAState возвращает настройки TOTP пользователя или nil, если пользователь не найден
+func GetMFAState(db *sql.DB, userID int) (*MFAState, error) {
+	var state MFAState
+	err := db.QueryRow(`
+		SELECT u.totp_secret, u.totp_enabled, u.totp_last_step,
+		       (SELECT COUNT(*) FROM recovery_codes rc WHERE rc.user_id = u.id AND rc.used_at IS NULL)
+		FROM users u
+		WHERE u.id = $1
+	`, userID).Scan(&state.Secret, &state.Enabled, &state.LastStep, &state.RecoveryLeft)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch mfa state: %w", err)
+	}
+	return &state, nil
+}
+
+// SetMFAState сохраняет секрет и признак включения TOTP. При выключении
+// секрет и все коды восстановления удаляются.
+func SetMFAState(db *sql.DB, userID int, secret string, enabled bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users SET totp_secret = $1, totp_enabled = $2, totp_last_step = 0
+		WHERE id = $3
+	`, secret, enabled, userID); err != nil {
+		return fmt.Errorf("failed to update mfa state: %w", err)
+	}
+
+	if !enabled {
+		if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AdvanceTOTPStep запоминает последний принятый временной шаг TOTP.
+// Возвращает false, если шаг уже был использован: так один код нельзя предъявить дважды.
+func AdvanceTOTPStep(db *sql.DB, userID int, step int64) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND totp_last_step < $1
+	`, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp step: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// hashRecoveryCode возвращает SHA-256 хэш кода восстановления. Коды случайные
+// и длинные, поэтому медленный хэш здесь не нужен, а поиск остаётся дешёвым.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func ReplaceRecoveryCodes(db *sql.DB, userID int, codes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, code := range codes {
+		if _, err := tx.Exec(`
+			INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hashRecoveryCode(code)); err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode помечает код восстановления использованным.
+// Возвращает false, если такого неиспользованного кода нет.
+func ConsumeRecoveryCode(db *sql.DB, userID int, code string) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import "testing"
+
+func TestAdvanceTOTPStepRejectsReplay(t *testing.T) {
+	db := openTestDB(t)
+	id := newUser(t, db, "totp")
+	if err := SetMFAState(db, id, "SECRET", true); err != nil {
+		t.Fatalf("SetMFAState: %v", err)
+	}
+
+	steps := []struct {
+		step int64
+		want bool
+	}{
+		{100, true},
+		{100, false}, // тот же код ещё раз
+		{99, false},  // код предыдущего шага после более нового
+		{101, true},
+	}
+	for _, s := range steps {
+		ok, err := AdvanceTOTPStep(db, id, s.step)
+		if err != nil {
+			t.Fatalf("AdvanceTOTPStep(%d): %v", s.step, err)
+		}
+		if ok != s.want {
+			t.Errorf("AdvanceTOTPStep(%d) = %v, want %v", s.step, ok, s.want)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	db := openTestDB(t)
+	id := newUser(t, db, "recovery")
+	if err := SetMFAState(db, id, "SECRET", true); err != nil {
+		t.Fatalf("SetMFAState: %v", err)
+	}
+	if err := ReplaceRecoveryCodes(db, id, []string{"aaaaa-11111", "bbbbb-22222"}); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes: %v", err)
+	}
+
+	// Коды хранятся только в виде хэшей
+	var stored int
+	db.QueryRow("SELECT count(*) FROM recovery_codes WHERE user_id = $1 AND code_hash = 'aaaaa-11111'", id).Scan(&stored)
+	if stored != 0 {
+		t.Error("recovery code is stored in plain text")
+	}
+
+	if ok, _ := ConsumeRecoveryCode(db, id, "aaaaa-11111"); !ok {
+		t.Fatal("valid recovery code rejected")
+	}
+	if ok, _ := ConsumeRecoveryCode(db, id, "aaaaa-11111"); ok {
+		t.Error("recovery code accepted twice")
+	}
+	if ok, _ := ConsumeRecoveryCode(db, newUser(t, db, "other"), "bbbbb-22222"); ok {
+		t.Error("recovery code accepted for another user")
+	}
+
+	state, err := GetMFAState(db, id)
+	if err != nil || state.RecoveryLeft != 1 {
+		t.Fatalf("state = %+v, %v; want 1 recovery code left", state, err)
+	}
+
+	// Выключение TOTP удаляет секрет и все коды
+	if err := SetMFAState(db, id, "", false); err != nil {
+		t.Fatalf("SetMFAState: %v", err)
+	}
+	if state, _ := GetMFAState(db, id); state.Secret != "" || state.RecoveryLeft != 0 {
+		t.Errorf("state after disabling = %+v", state)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrResetTokenInvalid возвращается для неизвестного, использованного или просроченного токена сброса
+var ErrResetTokenInvalid = errors.New("reset token is invalid or expired")
+
+// ResetTokenOwner возвращает владельца действующего токена сброса пароля.
+// Токены выпускает auth_service и хранит их хэши в таблице password_resets.
+func ResetTokenOwner(db *sql.DB, tokenHash string) (int, error) {
+	var userID int
+	err := db.QueryRow(`
+		SELECT user_id FROM password_resets
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+	`, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrResetTokenInvalid
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to fetch reset token: %w", err)
+	}
+	return userID, nil
+}
+
+// UpdatePassword сохраняет новый хэш пароля и увеличивает token_version,
+// отзывая все ранее выданные токены пользователя. Возвращает новую версию.
+func UpdatePassword(db *sql.DB, userID int, passwordHash string) (int, error) {
+	var version int
+	err := db.QueryRow(`
+		UPDATE users SET password_hash = $1, token_version = token_version + 1
+		WHERE id = $2
+		RETURNING token_version
+	`, passwordHash, userID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+	return version, nil
+}
+
+// RehashPassword заменяет хэш пароля на пересчитанный с новыми параметрами.
+// Замена выполняется, только если хэш не изменился с момента проверки, и не
+// трогает token_version: пароль остался прежним.
+func RehashPassword(db *sql.DB, userID int, oldHash, newHash string) error {
+	_, err := db.Exec(
+		"UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3",
+		newHash, userID, oldHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+	return nil
+}
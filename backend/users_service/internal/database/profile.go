@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// Profile — публичные поля пользователя
+type Profile struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	Location    string `json:"location"`
+	Website     string `json:"website"`
+	AvatarKey   string `json:"-"`
+	Followers   int    `json:"followers_count"`
+	Following   int    `json:"following_count"`
+}
+
+const profileColumns = `id, username, display_name, bio, location, website, avatar_key,
+	(SELECT count(*) FROM follows WHERE followee_id = users.id),
+	(SELECT count(*) FROM follows WHERE follower_id = users.id)`
+
+func scanProfile(row *sql.Row) (*Profile, error) {
+	var p Profile
+	err := row.Scan(&p.ID, &p.Username, &p.DisplayName, &p.Bio, &p.Location, &p.Website, &p.AvatarKey, &p.Followers, &p.Following)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetProfileByUsername возвращает публичный профиль или nil, если пользователя нет
+func GetProfileByUsername(db *sql.DB, username string) (*Profile, error) {
+	return scanProfile(db.QueryRow("SELECT "+profileColumns+" FROM users WHERE username = $1", username))
+}
+
+// GetProfileByID возвращает публичный профиль или nil, если пользователя нет
+func GetProfileByID(db *sql.DB, userID int) (*Profile, error) {
+	return scanProfile(db.QueryRow("SELECT "+profileColumns+" FROM users WHERE id = $1", userID))
+}
+
+// ReplaceAvatarKey сохраняет новый ключ аватара и возвращает прежний, чтобы
+// вызывающий удалил старые файлы. found равен false, если пользователя нет.
+func ReplaceAvatarKey(db *sql.DB, userID int, key string) (previous string, found bool, err error) {
+	err = db.QueryRow(`
+		UPDATE users u SET avatar_key = $1
+		FROM (SELECT id, avatar_key FROM users WHERE id = $2 FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING old.avatar_key
+	`, key, userID).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return previous, true, nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetUserRole назначает пользователю роль. Возвращает false, если пользователь не найден.
+func SetUserRole(db *sql.DB, userID int, role string) (bool, error) {
+	res, err := db.Exec("UPDATE users SET role = $1 WHERE id = $2", role, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update role: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update role: %w", err)
+	}
+	return n > 0, nil
+}
+
+// PromoteAdmins назначает роль admin пользователям с перечисленными email.
+// Используется при старте, чтобы в системе появился первый администратор.
+func PromoteAdmins(db *sql.DB, emails []string) error {
+	for _, email := range emails {
+		if _, err := db.Exec("UPDATE users SET role = 'admin' WHERE email = $1", email); err != nil {
+			return fmt.Errorf("failed to promote %s: %w", email, err)
+		}
+	}
+	return nil
+}
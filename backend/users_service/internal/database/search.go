@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"shared/pagination"
+
+	"github.com/lib/pq"
+)
+
+// UserMatch — найденный пользователь; только публичные поля
+type UserMatch struct {
+	ID          int     `json:"id"`
+	Username    string  `json:"username"`
+	DisplayName string  `json:"display_name"`
+	AvatarKey   string  `json:"-"`
+	Rank        float32 `json:"-"`
+}
+
+// likeEscaper экранирует спецсимволы LIKE в пользовательском вводе
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers ищет пользователей по username и отображаемому имени. Выше всего
+// ранжируется точное совпадение username, затем префикс username, затем префикс
+// имени или одного из его слов; внутри групп — по триграммному сходству, оно же
+// находит опечатки. Выбирается limit+1 результатов.
+func SearchUsers(db *sql.DB, query string, after *pagination.RankCursor, limit int) ([]UserMatch, error) {
+	q := strings.ToLower(query)
+	prefix := likeEscaper.Replace(q) + "%"
+	wordPrefix := "% " + prefix
+	afterRank, afterID := pagination.RankArgs(after)
+
+	rows, err := db.Query(`
+		SELECT id, username, display_name, avatar_key, rank
+		FROM (
+			SELECT id, username, display_name, avatar_key,
+				(CASE
+					WHEN lower(username) = $1 THEN 3
+					WHEN lower(username) LIKE $2 THEN 2
+					WHEN lower(display_name) LIKE $2 OR lower(display_name) LIKE $3 THEN 1
+					ELSE 0
+				END + GREATEST(similarity(lower(username), $1), similarity(lower(display_name), $1)))::real AS rank
+			FROM users
+			WHERE lower(username) LIKE $2
+			   OR lower(display_name) LIKE $2
+			   OR lower(display_name) LIKE $3
+			   OR lower(username) % $1
+			   OR lower(display_name) % $1
+		) AS matches
+		WHERE ($4::real IS NULL OR (rank, id) < ($4, $5))
+		ORDER BY rank DESC, id DESC
+		LIMIT $6
+	`, q, prefix, wordPrefix, afterRank, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []UserMatch
+	for rows.Next() {
+		var u UserMatch
+		if err := rows.Scan(&u.ID, &u.Username, &u.DisplayName, &u.AvatarKey, &u.Rank); err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return users, nil
+}
+
+// FindUsersByUsernames возвращает пользователей с перечисленными username.
+// Имена сравниваются точно, как в профиле; несуществующие пропускаются.
+func FindUsersByUsernames(db *sql.DB, usernames []string) ([]UserMatch, error) {
+	rows, err := db.Query(`
+		SELECT id, username, display_name, avatar_key
+		FROM users
+		WHERE username = ANY($1)
+		ORDER BY id
+	`, pq.Array(usernames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []UserMatch{}
+	for rows.Next() {
+		var u UserMatch
+		if err := rows.Scan(&u.ID, &u.Username, &u.DisplayName, &u.AvatarKey); err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating over rows: %w", err)
+	}
+	return users, nil
+}
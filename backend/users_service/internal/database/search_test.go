@@ -0,0 +1,33 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindUsersByUsernames(t *testing.T) {
+	db := openTestDB(t)
+	var names []string
+	var ids []int
+	for _, prefix := range []string{"ann", "bob"} {
+		id := newUser(t, db, prefix)
+		var name string
+		if err := db.QueryRow("SELECT username FROM users WHERE id = $1", id).Scan(&name); err != nil {
+			t.Fatalf("select username: %v", err)
+		}
+		names, ids = append(names, name), append(ids, id)
+	}
+
+	// Имена сравниваются точно: другой регистр и несуществующие пропускаются
+	users, err := FindUsersByUsernames(db, []string{names[1], names[0], strings.ToUpper(names[0]), "ghost-" + names[0]})
+	if err != nil {
+		t.Fatalf("FindUsersByUsernames: %v", err)
+	}
+	if len(users) != 2 || users[0].ID != ids[0] || users[1].ID != ids[1] || users[0].Username != names[0] {
+		t.Errorf("users = %+v, want %v ordered by id", users, ids)
+	}
+
+	if users, err := FindUsersByUsernames(db, nil); err != nil || users == nil || len(users) != 0 {
+		t.Errorf("FindUsersByUsernames(nil) = %#v, %v; want an empty slice", users, err)
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"users_service/internal/avatars"
+	"users_service/internal/blobstore"
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// avatarFileKey возвращает ключ миниатюры заданного размера
+func avatarFileKey(key string, size int) string {
+	return fmt.Sprintf("%s-%d.jpg", key, size)
+}
+
+// newAvatarKey возвращает новый префикс ключей. Случайная часть меняет адрес при
+// каждой загрузке, поэтому миниатюры можно кэшировать без срока.
+func newAvatarKey(userID int) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("avatars/%d/%s", userID, hex.EncodeToString(b)), nil
+}
+
+// deleteAvatarFiles удаляет миниатюры; ошибки только логируются — файлы без
+// ссылки из базы лишь занимают место
+func deleteAvatarFiles(store blobstore.Store, logger *logrus.Logger, key string) {
+	if key == "" {
+		return
+	}
+	for _, size := range avatars.Sizes {
+		if err := store.Delete(avatarFileKey(key, size)); err != nil {
+			logger.WithError(err).WithField("key", key).Warn("Users-Service: Failed to delete avatar file")
+		}
+	}
+}
+
+// UploadAvatar принимает изображение в поле avatar формы multipart/form-data,
+// сохраняет его миниатюры и заменяет ими прежний аватар
+func UploadAvatar(db *sql.DB, store blobstore.Store, blobURL string) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		// Запас на заголовки multipart сверх размера самого файла
+		r.Body = http.MaxBytesReader(w, r.Body, avatars.MaxUploadSize+64<<10)
+		file, _, err := r.FormFile("avatar")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Avatar file is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Avatar file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		thumbs, err := avatars.Thumbnails(file)
+		switch {
+		case errors.Is(err, avatars.ErrUnsupportedImage):
+			http.Error(w, "Avatar must be a JPEG, PNG or GIF image", http.StatusBadRequest)
+			return
+		case errors.Is(err, avatars.ErrImageTooLarge):
+			http.Error(w, "Avatar image is too large", http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			logger.WithError(err).Error("Users-Service: Failed to process avatar")
+			http.Error(w, "Failed to process avatar", http.StatusInternalServerError)
+			return
+		}
+
+		key, err := newAvatarKey(userID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to generate avatar key")
+			http.Error(w, "Failed to store avatar", http.StatusInternalServerError)
+			return
+		}
+		for size, data := range thumbs {
+			if err := store.Put(avatarFileKey(key, size), data); err != nil {
+				logger.WithError(err).Error("Users-Service: Failed to store avatar")
+				deleteAvatarFiles(store, logger, key)
+				http.Error(w, "Failed to store avatar", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		previous, found, err := database.ReplaceAvatarKey(db, userID, key)
+		if err != nil || !found {
+			deleteAvatarFiles(store, logger, key)
+			if err != nil {
+				logger.WithError(err).Error("Users-Service: Failed to save avatar")
+				http.Error(w, "Failed to save avatar", http.StatusInternalServerError)
+			} else {
+				http.Error(w, "User not found", http.StatusNotFound)
+			}
+			return
+		}
+		deleteAvatarFiles(store, logger, previous)
+
+		logger.WithField("user_id", userID).Info("Users-Service: Avatar updated")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":     "Avatar updated successfully",
+			"avatar_urls": avatarURLs(blobURL, key),
+		})
+	}
+}
+
+// DeleteAvatar удаляет аватар пользователя
+func DeleteAvatar(db *sql.DB, store blobstore.Store) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		previous, found, err := database.ReplaceAvatarKey(db, userID, "")
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to remove avatar")
+			http.Error(w, "Failed to remove avatar", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		deleteAvatarFiles(store, logger, previous)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Avatar removed successfully"})
+	}
+}
+
+// ServeAvatar отдаёт файл миниатюры из хранилища
+func ServeAvatar(store blobstore.Store) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := "avatars/" + mux.Vars(r)["key"]
+		f, err := store.Open(key)
+		if errors.Is(err, blobstore.ErrNotFound) || errors.Is(err, blobstore.ErrInvalidKey) {
+			http.NotFound(w, r)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to open avatar")
+			http.Error(w, "Failed to load avatar", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		// Миниатюры всегда JPEG, а их ключи не переиспользуются
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		io.Copy(w, f)
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"shared/authz"
+	"shared/jwtauth"
+	"shared/pagination"
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// followEntry — пользователь в списке подписчиков с адресами аватара
+type followEntry struct {
+	database.FollowEntry
+	AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+}
+
+// followTarget возвращает ID пользователя из пути и ID вызывающего
+func followTarget(w http.ResponseWriter, r *http.Request) (targetID, callerID int, ok bool) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	subject, ok := authz.SubjectFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	if subject.UserID == targetID {
+		http.Error(w, "You cannot follow yourself", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return targetID, subject.UserID, true
+}
+
+// writeRelationship отвечает связью вызывающего с пользователем и его числом подписчиков
+func writeRelationship(w http.ResponseWriter, status int, rel database.Relationship, profile *database.Profile) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"relationship":    rel,
+		"followers_count": profile.Followers,
+	})
+}
+
+// FollowUser подписывает вызывающего на пользователя из пути. Новому подписанному
+// отправляется уведомление; повторная подписка ничего не меняет.
+func FollowUser(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetID, callerID, ok := followTarget(w, r)
+		if !ok {
+			return
+		}
+
+		exists, err := database.UserExists(db, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to check user existence")
+			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		created, err := database.Follow(db, callerID, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to follow user")
+			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+			return
+		}
+
+		rel, err := database.GetRelationship(db, callerID, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch relationship")
+			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+			return
+		}
+		target, err := database.GetProfileByID(db, targetID)
+		if err != nil || target == nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch profile")
+			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+			logger.WithFields(logrus.Fields{"follower_id": callerID, "followee_id": targetID}).Info("Users-Service: User followed")
+			go notifyNewFollower(logger, targetID, callerID)
+		}
+		writeRelationship(w, status, rel, target)
+	}
+}
+
+// UnfollowUser отменяет подписку вызывающего на пользователя из пути
+func UnfollowUser(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetID, callerID, ok := followTarget(w, r)
+		if !ok {
+			return
+		}
+
+		target, err := database.GetProfileByID(db, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch profile")
+			http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+			return
+		}
+		if target == nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		removed, err := database.Unfollow(db, callerID, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to unfollow user")
+			http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+			return
+		}
+		if removed {
+			target.Followers--
+			logger.WithFields(logrus.Fields{"follower_id": callerID, "followee_id": targetID}).Info("Users-Service: User unfollowed")
+			go retractFollowNotification(logger, jwtauth.BearerToken(r), targetID, callerID)
+		}
+
+		rel, err := database.GetRelationship(db, callerID, targetID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch relationship")
+			http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+			return
+		}
+		writeRelationship(w, http.StatusOK, rel, target)
+	}
+}
+
+// ListFollowers возвращает страницу подписчиков пользователя
+func ListFollowers(db *sql.DB, blobURL string) http.HandlerFunc {
+	return listFollows(db, blobURL, database.ListFollowers)
+}
+
+// ListFollowing возвращает страницу пользователей, на которых подписан пользователь
+func ListFollowing(db *sql.DB, blobURL string) http.HandlerFunc {
+	return listFollows(db, blobURL, database.ListFollowing)
+}
+
+func listFollows(db *sql.DB, blobURL string, list func(*sql.DB, int, pagination.Request) ([]database.FollowEntry, error)) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+
+		exists, err := database.UserExists(db, userID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to check user existence")
+			http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		entries, err := list(db, userID, page)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch follows")
+			http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+			return
+		}
+
+		items := make([]followEntry, len(entries))
+		for i, e := range entries {
+			items[i] = followEntry{FollowEntry: e, AvatarURLs: avatarURLs(blobURL, e.AvatarKey)}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pagination.NewPage(items, page.Limit, func(e followEntry) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: e.FollowedAt, ID: e.ID}
+		}))
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"shared/authz"
+)
+
+func TestFollowUserRequiresAnotherUser(t *testing.T) {
+	vars := map[string]string{"id": "5"}
+	if rec := serve(FollowUser(nil), request(http.MethodPost, "/users/5/follow", nil, vars, nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", rec.Code)
+	}
+	self := &authz.Subject{UserID: 5, Role: authz.RoleUser}
+	if rec := serve(FollowUser(nil), request(http.MethodPost, "/users/5/follow", nil, vars, self)); rec.Code != http.StatusBadRequest {
+		t.Errorf("self follow: status %d, want 400", rec.Code)
+	}
+	if rec := serve(UnfollowUser(nil), request(http.MethodDelete, "/users/5/follow", nil, vars, self)); rec.Code != http.StatusBadRequest {
+		t.Errorf("self unfollow: status %d, want 400", rec.Code)
+	}
+}
+
+func TestFollowUserFlow(t *testing.T) {
+	db := openTestDB(t)
+	follower, _ := newUser(t, db, "follower")
+	followee, _ := newUser(t, db, "followee")
+	vars := map[string]string{"id": strconv.Itoa(followee)}
+	caller := &authz.Subject{UserID: follower, Role: authz.RoleUser}
+
+	var resp struct {
+		Relationship struct {
+			Following bool `json:"following"`
+		} `json:"relationship"`
+		Followers int `json:"followers_count"`
+	}
+
+	rec := serve(FollowUser(db), request(http.MethodPost, "/", nil, vars, caller))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("first follow: status %d: %s", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if !resp.Relationship.Following || resp.Followers != 1 {
+		t.Errorf("follow response = %+v", resp)
+	}
+
+	if rec := serve(FollowUser(db), request(http.MethodPost, "/", nil, vars, caller)); rec.Code != http.StatusOK {
+		t.Errorf("repeated follow: status %d, want 200", rec.Code)
+	}
+
+	rec = serve(UnfollowUser(db), request(http.MethodDelete, "/", nil, vars, caller))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unfollow: status %d: %s", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Relationship.Following || resp.Followers != 0 {
+		t.Errorf("unfollow response = %+v", resp)
+	}
+
+	missing := map[string]string{"id": strconv.Itoa(followee + 1000000)}
+	if rec := serve(FollowUser(db), request(http.MethodPost, "/", nil, missing, caller)); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status %d, want 404", rec.Code)
+	}
+}
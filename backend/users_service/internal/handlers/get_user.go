@@ -6,10 +6,61 @@ import (
 	"net/http"
 	"strconv"
 
+	"shared/authz"
+	"users_service/internal/middlewares"
+
 	"github.com/gorilla/mux"
 )
 
-func GetUserByID(db *sql.DB) http.HandlerFunc {
+// privateUserFields — поля, которые видят только сам пользователь,
+// администратор и доверенные сервисы
+type privateUserFields struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	TokenVersion  int    `json:"token_version"`
+}
+
+// userResponse — пользователь в ответе; приватные поля опускаются, если
+// вызывающему они не положены
+type userResponse struct {
+	ID         int               `json:"id"`
+	Username   string            `json:"username"`
+	AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+	*privateUserFields
+}
+
+// userResponseColumns — поля, которые читает scanUserResponse
+const userResponseColumns = "id, username, avatar_key, email, email_verified, role, token_version"
+
+// scanUserResponse читает пользователя и оставляет приватные поля, только если
+// их можно показать вызывающему
+func scanUserResponse(r *http.Request, row *sql.Row, blobURL string) (*userResponse, error) {
+	var user userResponse
+	var avatarKey string
+	var private privateUserFields
+	if err := row.Scan(&user.ID, &user.Username, &avatarKey, &private.Email, &private.EmailVerified, &private.Role, &private.TokenVersion); err != nil {
+		return nil, err
+	}
+	user.AvatarURLs = avatarURLs(blobURL, avatarKey)
+	if canSeePrivate(r, user.ID) {
+		user.privateUserFields = &private
+	}
+	return &user, nil
+}
+
+// canSeePrivate сообщает, можно ли показать вызывающему приватные поля
+// пользователя userID: ему самому, администратору или доверенному сервису
+func canSeePrivate(r *http.Request, userID int) bool {
+	if service, _ := r.Context().Value(middlewares.ServiceKey).(string); service != "" {
+		return true
+	}
+	return authz.IsSelfOr(r.Context(), userID, authz.UsersUpdateAny)
+}
+
+// GetUserByID возвращает пользователя по ID. Email, роль и версию токенов
+// получают только сам пользователь, администратор и доверенные сервисы.
+func GetUserByID(db *sql.DB, blobURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		userIDStr := vars["id"]
@@ -19,15 +70,7 @@ func GetUserByID(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var user struct {
-			ID           int    `json:"id"`
-			Username     string `json:"username"`
-			Email        string `json:"email"`
-			PasswordHash string `json:"-"`
-		}
-
-		err = db.QueryRow("SELECT id, username, email, password_hash FROM users WHERE id = $1", userID).
-			Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+		user, err := scanUserResponse(r, db.QueryRow("SELECT "+userResponseColumns+" FROM users WHERE id = $1", userID), blobURL)
 		if err == sql.ErrNoRows {
 			http.Error(w, "User not found", http.StatusNotFound)
 			return
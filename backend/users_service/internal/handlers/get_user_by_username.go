@@ -4,9 +4,18 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+
+	"users_service/internal/database"
+
+	"github.com/sirupsen/logrus"
 )
 
-func GetUserByUsername(db *sql.DB) http.HandlerFunc {
+// maxLookupUsernames — сколько имён можно запросить за один раз
+const maxLookupUsernames = 50
+
+// GetUserByUsername возвращает пользователя по username. Приватные поля
+// отдаются по тем же правилам, что и в GetUserByID.
+func GetUserByUsername(db *sql.DB, blobURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		username := r.URL.Query().Get("username")
 		if username == "" {
@@ -14,15 +23,7 @@ func GetUserByUsername(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var user struct {
-			ID           int    `json:"id"`
-			Username     string `json:"username"`
-			Email        string `json:"email"`
-			PasswordHash string `json:"-"`
-		}
-
-		err := db.QueryRow("SELECT id, username, email, password_hash FROM users WHERE username = $1", username).
-			Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+		user, err := scanUserResponse(r, db.QueryRow("SELECT "+userResponseColumns+" FROM users WHERE username = $1", username), blobURL)
 		if err == sql.ErrNoRows {
 			http.Error(w, "User not found", http.StatusNotFound)
 			return
@@ -35,3 +36,35 @@ func GetUserByUsername(db *sql.DB) http.HandlerFunc {
 		json.NewEncoder(w).Encode(user)
 	}
 }
+
+// GetUsersByUsernames находит пользователей по списку имён (параметр username
+// повторяется) и отдаёт их публичные поля. Ненайденные имена пропускаются,
+// поэтому posts_service разрешает все @-упоминания поста одним запросом.
+func GetUsersByUsernames(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		usernames := r.URL.Query()["username"]
+		if len(usernames) == 0 {
+			http.Error(w, "Username is required", http.StatusBadRequest)
+			return
+		}
+		if len(usernames) > maxLookupUsernames {
+			http.Error(w, "Too many usernames", http.StatusBadRequest)
+			return
+		}
+
+		users, err := database.FindUsersByUsernames(db, usernames)
+		if err != nil {
+			logger.WithError(err).Error("Failed to find users by usernames")
+			http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(users); err != nil {
+			logger.WithError(err).Error("Failed to encode response")
+		}
+	}
+}
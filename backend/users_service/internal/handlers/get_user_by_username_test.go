@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGetUsersByUsernamesValidatesQuery(t *testing.T) {
+	many := url.Values{}
+	for i := 0; i <= maxLookupUsernames; i++ {
+		many.Add("username", "u"+strings.Repeat("x", i))
+	}
+	for _, query := range []string{"", "name=ann", many.Encode()} {
+		rec := serve(GetUsersByUsernames(nil), request(http.MethodGet, "/users/by_usernames?"+query, nil, nil, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("query %.30q: status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestGetUsersByUsernamesReturnsPublicFields(t *testing.T) {
+	db := openTestDB(t)
+	id, name := newUser(t, db, "mentioned")
+
+	query := url.Values{"username": {name, "nobody-" + name}}
+	rec := serve(GetUsersByUsernames(db), request(http.MethodGet, "/users/by_usernames?"+query.Encode(), nil, nil, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var users []map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&users)
+	if len(users) != 1 || users[0]["id"] != float64(id) || users[0]["username"] != name {
+		t.Fatalf("users = %v, want only %s", users, name)
+	}
+	if _, ok := users[0]["email"]; ok {
+		t.Error("lookup exposes email")
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"shared/authz"
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// openTestDB подключается к базе из TEST_POSTGRES_DSN, где уже есть базовая
+// таблица users, и применяет миграции; без переменной тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return db
+}
+
+// newUser создаёт пользователя с уникальным именем и удаляет его после теста
+func newUser(t *testing.T, db *sql.DB, prefix string) (int, string) {
+	t.Helper()
+	name := fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	id, err := database.SaveUser(db, name, name+"@example.com", "hash")
+	if err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", id) })
+	return id, name
+}
+
+// request собирает запрос с JSON-телом, переменными маршрута и пользователем
+// subject в контексте; nil subject означает анонимный запрос
+func request(method, path string, body interface{}, vars map[string]string, subject *authz.Subject) *http.Request {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	if subject != nil {
+		r = r.WithContext(authz.WithSubject(r.Context(), *subject))
+	}
+	return r
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// IdentityRequest описывает внешний аккаунт, подтверждённый провайдером OIDC
+type IdentityRequest struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+	// Username — желаемое имя для нового пользователя; используется только в CreateOIDCUser
+	Username string `json:"username,omitempty"`
+}
+
+func (req IdentityRequest) valid() bool {
+	return req.Provider != "" && req.Subject != "" && req.Email != ""
+}
+
+// GetUserByIdentity возвращает пользователя, к которому привязан внешний аккаунт
+func GetUserByIdentity(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := r.URL.Query().Get("provider")
+		subject := r.URL.Query().Get("subject")
+		if provider == "" || subject == "" {
+			http.Error(w, "Provider and subject are required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := database.GetUserByIdentity(db, provider, subject)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch user by identity")
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user)
+	}
+}
+
+// LinkIdentity привязывает внешний аккаунт к существующему пользователю
+func LinkIdentity(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		var req IdentityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.valid() {
+			http.Error(w, "Provider, subject and email are required", http.StatusBadRequest)
+			return
+		}
+
+		err = database.LinkIdentity(db, userID, req.Provider, req.Subject, req.Email)
+		if errors.Is(err, database.ErrIdentityLinked) {
+			http.Error(w, "Identity is linked to another user", http.StatusConflict)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to link identity")
+			http.Error(w, "Failed to link identity", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":  userID,
+			"provider": req.Provider,
+		}).Info("Users-Service: Identity linked")
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CreateOIDCUser создаёт пользователя без пароля и привязывает к нему внешний аккаунт
+func CreateOIDCUser(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req IdentityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.valid() {
+			http.Error(w, "Provider, subject and email are required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := database.CreateOIDCUser(db, req.Username, req.Email, req.Provider, req.Subject)
+		if errors.Is(err, database.ErrIdentityLinked) {
+			http.Error(w, "Identity is linked to another user", http.StatusConflict)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to create oidc user")
+			http.Error(w, "Failed to create user", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":  user.ID,
+			"username": user.Username,
+			"provider": req.Provider,
+		}).Info("Users-Service: User registered via external provider")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+	}
+}
@@ -5,11 +5,36 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
+
+	"shared/pagination"
 )
 
+// listedUser — пользователь в административном списке
+type listedUser struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListUsers возвращает страницу пользователей, новые сверху
 func ListUsers(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rows, err := db.Query("SELECT id, username, email FROM users ORDER BY id ASC")
+		page, ok := pagination.Parse(w, r)
+		if !ok {
+			return
+		}
+		after, afterID := page.Args()
+
+		rows, err := db.Query(`
+			SELECT id, username, email, role, created_at
+			FROM users
+			WHERE ($1::timestamptz IS NULL OR (created_at, id) < ($1, $2))
+			ORDER BY created_at DESC, id DESC
+			LIMIT $3
+		`, after, afterID, page.Limit+1)
 		if err != nil {
 			log.Println("ListUsers: Query error:", err)
 			http.Error(w, "Failed to list users", http.StatusInternalServerError)
@@ -17,26 +42,25 @@ func ListUsers(db *sql.DB) http.HandlerFunc {
 		}
 		defer rows.Close()
 
-		var users []map[string]interface{}
+		var users []listedUser
 		for rows.Next() {
-			var (
-				id       int
-				username string
-				email    string
-			)
-			if err := rows.Scan(&id, &username, &email); err != nil {
+			var u listedUser
+			if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.CreatedAt); err != nil {
 				log.Println("ListUsers: Scan error:", err)
 				http.Error(w, "Failed to parse users", http.StatusInternalServerError)
 				return
 			}
-			users = append(users, map[string]interface{}{
-				"id":       id,
-				"username": username,
-				"email":    email,
-			})
+			users = append(users, u)
+		}
+		if err := rows.Err(); err != nil {
+			log.Println("ListUsers: Rows error:", err)
+			http.Error(w, "Failed to list users", http.StatusInternalServerError)
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(users)
+		json.NewEncoder(w).Encode(pagination.NewPage(users, page.Limit, func(u listedUser) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+		}))
 	}
 }
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListUsersPages(t *testing.T) {
+	db := openTestDB(t)
+	newUser(t, db, "listed")
+	newUser(t, db, "listed")
+	newest, _ := newUser(t, db, "listed")
+
+	type page struct {
+		Items []struct {
+			ID int `json:"id"`
+		} `json:"items"`
+		NextCursor string `json:"next_cursor"`
+	}
+	fetch := func(query string) page {
+		rec := serve(ListUsers(db), request(http.MethodGet, "/users?"+query, nil, nil, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", query, rec.Code)
+		}
+		var p page
+		json.NewDecoder(rec.Body).Decode(&p)
+		return p
+	}
+
+	first := fetch("limit=1")
+	if len(first.Items) != 1 || first.Items[0].ID != newest || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	second := fetch("limit=1&cursor=" + url.QueryEscape(first.NextCursor))
+	if len(second.Items) != 1 || second.Items[0].ID >= newest {
+		t.Errorf("second page = %+v", second)
+	}
+
+	if rec := serve(ListUsers(db), request(http.MethodGet, "/users?cursor=bogus", nil, nil, nil)); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad cursor: status %d, want 400", rec.Code)
+	}
+}
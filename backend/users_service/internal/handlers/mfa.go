@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateMFARequest представляет новые настройки TOTP пользователя
+type UpdateMFARequest struct {
+	Secret  string `json:"secret"`
+	Enabled bool   `json:"enabled"`
+}
+
+// TOTPStepRequest представляет использованный временной шаг TOTP
+type TOTPStepRequest struct {
+	Step int64 `json:"step"`
+}
+
+// RecoveryCodesRequest представляет набор кодов восстановления
+type RecoveryCodesRequest struct {
+	Codes []string `json:"codes"`
+}
+
+// RecoveryCodeRequest представляет один код восстановления
+type RecoveryCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// GetMFA возвращает настройки TOTP пользователя
+func GetMFA(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		state, err := database.GetMFAState(db, userID)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch mfa state")
+			http.Error(w, "Failed to fetch mfa state", http.StatusInternalServerError)
+			return
+		}
+		if state == nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+	}
+}
+
+// UpdateMFA сохраняет секрет TOTP и признак его включения
+func UpdateMFA(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		var req UpdateMFARequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if req.Enabled && req.Secret == "" {
+			http.Error(w, "Secret is required to enable TOTP", http.StatusBadRequest)
+			return
+		}
+
+		if err := database.SetMFAState(db, userID, req.Secret, req.Enabled); err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to update mfa state")
+			http.Error(w, "Failed to update mfa state", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"enabled": req.Enabled,
+		}).Info("Users-Service: MFA state updated")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "MFA state updated successfully"})
+	}
+}
+
+// AdvanceTOTPStep фиксирует использованный шаг TOTP; повторный шаг отклоняется с 409
+func AdvanceTOTPStep(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		var req TOTPStepRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Step <= 0 {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		advanced, err := database.AdvanceTOTPStep(db, userID, req.Step)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to update totp step")
+			http.Error(w, "Failed to update totp step", http.StatusInternalServerError)
+			return
+		}
+		if !advanced {
+			logger.WithField("user_id", userID).Warn("Users-Service: TOTP code replay rejected")
+			http.Error(w, "TOTP code already used", http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ReplaceRecoveryCodes сохраняет хэши нового набора кодов восстановления
+func ReplaceRecoveryCodes(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		var req RecoveryCodesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Codes) == 0 {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		if err := database.ReplaceRecoveryCodes(db, userID, req.Codes); err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to store recovery codes")
+			http.Error(w, "Failed to store recovery codes", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ConsumeRecoveryCode погашает код восстановления; неизвестный или использованный код — 404
+func ConsumeRecoveryCode(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		var req RecoveryCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		consumed, err := database.ConsumeRecoveryCode(db, userID, req.Code)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to consume recovery code")
+			http.Error(w, "Failed to consume recovery code", http.StatusInternalServerError)
+			return
+		}
+		if !consumed {
+			logger.WithField("user_id", userID).Warn("Users-Service: Invalid recovery code")
+			http.Error(w, "Recovery code not found", http.StatusNotFound)
+			return
+		}
+
+		logger.WithField("user_id", userID).Info("Users-Service: Recovery code consumed")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"shared/jwtauth"
+
+	"github.com/sirupsen/logrus"
+)
+
+// notificationFollow — тип уведомления о новом подписчике
+const notificationFollow = "follow"
+
+// ServiceName — имя users_service в сервисных токенах
+const ServiceName = "users-service"
+
+var notificationsClient = &http.Client{Timeout: 5 * time.Second}
+
+// serviceTokens — сервисные токены, которые выдаёт auth_service; задаётся
+// при старте через UseServiceTokens
+var serviceTokens jwtauth.TokenSource
+
+// UseServiceTokens задаёт источник сервисных токенов для запросов к Notifications Service
+func UseServiceTokens(src jwtauth.TokenSource) {
+	serviceTokens = src
+}
+
+// sendNotification отправляет запрос в Notifications Service с токеном
+// пользователя, совершившего действие: сервис проверяет, что likerId — это он
+func sendNotification(method, token string, payload map[string]interface{}) error {
+	return callNotificationsService(method, "/notifications", token, payload)
+}
+
+// sendServiceNotification отправляет запрос на служебный маршрут path
+// с сервисным токеном users_service
+func sendServiceNotification(method, path string, payload map[string]interface{}) error {
+	if serviceTokens == nil {
+		return fmt.Errorf("service tokens are not configured")
+	}
+	token, err := serviceTokens.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get service token: %w", err)
+	}
+	return callNotificationsService(method, path, token, payload)
+}
+
+// callNotificationsService выполняет запрос к Notifications Service с JSON-телом
+func callNotificationsService(method, path, token string, payload map[string]interface{}) error {
+	notificationsURL := os.Getenv("NOTIFICATIONS_SERVICE_URL")
+	if notificationsURL == "" {
+		return fmt.Errorf("NOTIFICATIONS_SERVICE_URL not set")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequest(method, notificationsURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := notificationsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifications service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notifications service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyNewFollower сообщает followeeID о новом подписчике. Уведомления о
+// подписках принимаются только от users_service, поэтому запрос идёт с
+// сервисным токеном. Сбой отправки только логируется: подписка к этому
+// моменту уже создана.
+func notifyNewFollower(logger *logrus.Logger, followeeID, followerID int) {
+	err := sendServiceNotification(http.MethodPost, "/notifications/follows", map[string]interface{}{
+		"userId":  followeeID,
+		"likerId": followerID,
+		"type":    notificationFollow,
+	})
+	if err != nil {
+		logger.WithError(err).WithField("user_id", followeeID).Error("Users-Service: Failed to send follower notification")
+	}
+}
+
+// retractFollowNotification удаляет уведомление о подписке после отписки,
+// чтобы серия подписок и отписок не засыпала пользователя уведомлениями
+func retractFollowNotification(logger *logrus.Logger, token string, followeeID, followerID int) {
+	err := sendNotification(http.MethodDelete, token, map[string]interface{}{
+		"userId":  followeeID,
+		"likerId": followerID,
+		"type":    notificationFollow,
+	})
+	if err != nil {
+		logger.WithError(err).WithField("user_id", followeeID).Error("Users-Service: Failed to retract follower notification")
+	}
+}
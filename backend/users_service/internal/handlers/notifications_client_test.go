@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// staticTokens выдаёт один и тот же сервисный токен
+type staticTokens string
+
+func (s staticTokens) Token() (string, error) { return string(s), nil }
+
+func TestNotifyNewFollowerUsesServiceRoute(t *testing.T) {
+	type call struct {
+		method, path, auth string
+		body               map[string]interface{}
+	}
+	calls := make(chan call, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c call
+		c.method, c.path, c.auth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&c.body)
+		calls <- c
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	t.Setenv("NOTIFICATIONS_SERVICE_URL", srv.URL)
+
+	UseServiceTokens(staticTokens("svc-token"))
+	defer UseServiceTokens(nil)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	notifyNewFollower(logger, 5, 8)
+
+	c := <-calls
+	if c.method != http.MethodPost || c.path != "/notifications/follows" {
+		t.Errorf("request = %s %s, want POST /notifications/follows", c.method, c.path)
+	}
+	if c.auth != "Bearer svc-token" {
+		t.Errorf("Authorization = %q, want the service token", c.auth)
+	}
+	// Текст уведомления строит notification_service, клиент его не передаёт
+	if _, ok := c.body["message"]; ok {
+		t.Errorf("payload carries a message: %v", c.body)
+	}
+	if c.body["userId"] != 5.0 || c.body["likerId"] != 8.0 || c.body["type"] != "follow" {
+		t.Errorf("payload = %v", c.body)
+	}
+}
+
+func TestSendServiceNotificationWithoutTokens(t *testing.T) {
+	t.Setenv("NOTIFICATIONS_SERVICE_URL", "http://127.0.0.1:1")
+	UseServiceTokens(nil)
+	if err := sendServiceNotification(http.MethodPost, "/notifications/follows", nil); err == nil {
+		t.Error("notification sent without a service token")
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"shared/authz"
+	"users_service/internal/avatars"
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// publicProfile — профиль в ответе: публичные поля и адреса миниатюр аватара
+type publicProfile struct {
+	*database.Profile
+	AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+	// Relationship заполняется, когда профиль смотрит другой вошедший пользователь
+	Relationship *database.Relationship `json:"relationship,omitempty"`
+}
+
+func newPublicProfile(p *database.Profile, blobURL string) publicProfile {
+	return publicProfile{Profile: p, AvatarURLs: avatarURLs(blobURL, p.AvatarKey)}
+}
+
+// avatarURLs возвращает адреса миниатюр по их размеру; nil, если аватара нет
+func avatarURLs(baseURL, key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+	urls := make(map[string]string, len(avatars.Sizes))
+	for _, size := range avatars.Sizes {
+		urls[strconv.Itoa(size)] = baseURL + avatarFileKey(key, size)
+	}
+	return urls
+}
+
+// GetPublicProfile возвращает публичные поля пользователя по имени.
+// Email, роль и прочие служебные поля в ответ не попадают. Если запрос
+// с токеном, в ответ добавляется связь вызывающего с пользователем.
+func GetPublicProfile(db *sql.DB, blobURL string) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		profile, err := database.GetProfileByUsername(db, mux.Vars(r)["username"])
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to fetch profile")
+			http.Error(w, "Failed to fetch profile", http.StatusInternalServerError)
+			return
+		}
+		if profile == nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		resp := newPublicProfile(profile, blobURL)
+		if subject, ok := authz.SubjectFromContext(r.Context()); ok && subject.UserID != profile.ID {
+			rel, err := database.GetRelationship(db, subject.UserID, profile.ID)
+			if err != nil {
+				logger.WithError(err).Error("Users-Service: Failed to fetch relationship")
+				http.Error(w, "Failed to fetch profile", http.StatusInternalServerError)
+				return
+			}
+			resp.Relationship = &rel
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"users_service/internal/blobstore"
+
+	"github.com/gorilla/mux"
+)
+
+func TestUpdateUserValidatesProfileFields(t *testing.T) {
+	cases := []struct {
+		name string
+		body map[string]string
+	}{
+		{"display name too long", map[string]string{"display_name": strings.Repeat("я", maxDisplayNameLength+1)}},
+		{"bio too long", map[string]string{"bio": strings.Repeat("b", maxBioLength+1)}},
+		{"javascript website", map[string]string{"website": "javascript:alert(1)"}},
+		{"relative website", map[string]string{"website": "example.com/me"}},
+		{"nothing to update", map[string]string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// До базы запрос не доходит
+			rec := serve(UpdateUser(nil, nil), request(http.MethodPatch, "/users/1", c.body, map[string]string{"id": "1"}, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want 400", rec.Code)
+			}
+		})
+	}
+}
+
+// avatarUpload собирает multipart-запрос с файлом в поле avatar
+func avatarUpload(t *testing.T, userID int, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("avatar", "avatar.png")
+	part.Write(data)
+	mw.Close()
+
+	id := strconv.Itoa(userID)
+	r := httptest.NewRequest(http.MethodPut, "/users/"+id+"/avatar", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return mux.SetURLVars(r, map[string]string{"id": id})
+}
+
+func TestUploadAvatarRejectsNonImages(t *testing.T) {
+	store, _ := blobstore.NewLocalStore(t.TempDir())
+	rec := serve(UploadAvatar(nil, store, "/"), avatarUpload(t, 1, []byte("not an image at all")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", rec.Code)
+	}
+}
+
+func TestServeAvatar(t *testing.T) {
+	store, _ := blobstore.NewLocalStore(t.TempDir())
+	store.Put("avatars/3/k-64.jpg", []byte("jpeg bytes"))
+
+	rec := httptest.NewRecorder()
+	ServeAvatar(store)(rec, request(http.MethodGet, "/avatars/3/k-64.jpg", nil, map[string]string{"key": "3/k-64.jpg"}, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "jpeg bytes" {
+		t.Fatalf("status %d body %q", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	for _, key := range []string{"3/missing-64.jpg", "../../etc/passwd"} {
+		rec := httptest.NewRecorder()
+		ServeAvatar(store)(rec, request(http.MethodGet, "/avatars/"+key, nil, map[string]string{"key": key}, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("key %q: status %d, want 404", key, rec.Code)
+		}
+	}
+}
+
+func TestProfileAndAvatarRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	id, name := newUser(t, db, "profile")
+	vars := map[string]string{"id": strconv.Itoa(id)}
+
+	update := map[string]string{"display_name": "  Ada  ", "bio": "Engines", "website": "https://example.com"}
+	if rec := serve(UpdateUser(db, nil), request(http.MethodPatch, "/users/"+vars["id"], update, vars, nil)); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateUser: status %d: %s", rec.Code, rec.Body)
+	}
+
+	store, _ := blobstore.NewLocalStore(t.TempDir())
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 40, 30)))
+	if rec := serve(UploadAvatar(db, store, "/"), avatarUpload(t, id, img.Bytes())); rec.Code != http.StatusOK {
+		t.Fatalf("UploadAvatar: status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(GetPublicProfile(db, "https://cdn.example/"), request(http.MethodGet, "/profile/"+name, nil, map[string]string{"username": name}, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetPublicProfile: status %d", rec.Code)
+	}
+	var profile map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&profile)
+	if profile["display_name"] != "Ada" || profile["website"] != "https://example.com" {
+		t.Errorf("profile = %v", profile)
+	}
+	for _, private := range []string{"email", "password_hash", "role", "avatar_key"} {
+		if _, ok := profile[private]; ok {
+			t.Errorf("public profile exposes %q", private)
+		}
+	}
+	urls, _ := profile["avatar_urls"].(map[string]interface{})
+	thumb, _ := urls["64"].(string)
+	key := strings.TrimPrefix(thumb, "https://cdn.example/")
+	if f, err := store.Open(key); err != nil {
+		t.Errorf("thumbnail %q is not in the store: %v", thumb, err)
+	} else {
+		f.Close()
+	}
+
+	// Удаление аватара убирает и файлы
+	if rec := serve(DeleteAvatar(db, store), request(http.MethodDelete, "/users/"+vars["id"]+"/avatar", nil, vars, nil)); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteAvatar: status %d", rec.Code)
+	}
+	if _, err := store.Open(key); err == nil {
+		t.Error("thumbnail is still stored after the avatar was removed")
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"strings"
 
+	"shared/mailer"
+	"shared/passpolicy"
+	"shared/passwordhash"
 	"users_service/internal/database"
 
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // RegisterRequest представляет данные для запроса регистрации
@@ -20,7 +22,7 @@ type RegisterRequest struct {
 }
 
 // RegisterUser обрабатывает регистрацию нового пользователя
-func RegisterUser(db *sql.DB) http.HandlerFunc {
+func RegisterUser(db *sql.DB, m mailer.Mailer, policy *passpolicy.Policy, hasher *passwordhash.Hasher) http.HandlerFunc {
 	// Инициализация логгера
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
@@ -35,10 +37,9 @@ func RegisterUser(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Очистка данных
+		// Очистка данных. Пароль не меняется: пробелы в нём значимы
 		req.Username = strings.TrimSpace(req.Username)
 		req.Email = strings.TrimSpace(req.Email)
-		req.Password = strings.TrimSpace(req.Password)
 
 		// Валидация данных
 		if req.Username == "" || req.Email == "" || req.Password == "" {
@@ -47,8 +48,14 @@ func RegisterUser(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		if verr, ok := passpolicy.AsValidationError(policy.Check(req.Password, req.Username, req.Email)); ok {
+			logger.WithField("username", req.Username).Warn("Users-Service: Password does not meet the policy")
+			passpolicy.WriteError(w, verr)
+			return
+		}
+
 		// Хэширование пароля
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashedPassword, err := hasher.Hash(req.Password)
 		if err != nil {
 			logger.WithError(err).Error("Users-Service: Failed to hash password")
 			http.Error(w, "Failed to hash password", http.StatusInternalServerError)
@@ -56,7 +63,7 @@ func RegisterUser(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Сохранение пользователя в базе данных
-		err = database.SaveUser(db, req.Username, req.Email, string(hashedPassword))
+		userID, err := database.SaveUser(db, req.Username, req.Email, hashedPassword)
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"username": req.Username,
@@ -73,6 +80,13 @@ func RegisterUser(db *sql.DB) http.HandlerFunc {
 			"email":    req.Email,
 		}).Info("Users-Service: User successfully registered")
 
+		// Письмо отправляется в фоне: сбой почты не должен ломать регистрацию
+		go func() {
+			if err := sendVerificationEmail(db, m, userID, req.Username, req.Email); err != nil {
+				logger.WithError(err).WithField("user_id", userID).Error("Users-Service: Failed to send verification email")
+			}
+		}()
+
 		w.WriteHeader(http.StatusCreated)
 		if err := json.NewEncoder(w).Encode(map[string]string{"message": "Registration successful"}); err != nil {
 			logger.WithError(err).Error("Users-Service: Failed to send response to client")
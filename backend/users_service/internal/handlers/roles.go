@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"shared/authz"
+	"users_service/internal/database"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// UpdateUserRole назначает пользователю роль. Маршрут доступен только с правом users:manage_roles.
+func UpdateUserRole(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		var req UpdateRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		// Пустая роль не принимается: ParseRole трактует её как user только для старых токенов
+		role, err := authz.ParseRole(req.Role)
+		if err != nil || req.Role == "" {
+			http.Error(w, "Unknown role", http.StatusBadRequest)
+			return
+		}
+
+		found, err := database.SetUserRole(db, userID, string(role))
+		if err != nil {
+			logger.WithError(err).Error("Failed to update role")
+			http.Error(w, "Failed to update role", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		subject, _ := authz.SubjectFromContext(r.Context())
+		logger.WithFields(logrus.Fields{
+			"user_id":    userID,
+			"role":       role,
+			"changed_by": subject.UserID,
+		}).Info("User role updated")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Role updated successfully"})
+	}
+}
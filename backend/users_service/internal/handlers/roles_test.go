@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"shared/authz"
+	"users_service/internal/middlewares"
+)
+
+func TestCanSeePrivate(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject *authz.Subject
+		service string
+		want    bool
+	}{
+		{"anonymous", nil, "", false},
+		{"self", &authz.Subject{UserID: 10, Role: authz.RoleUser}, "", true},
+		{"other user", &authz.Subject{UserID: 11, Role: authz.RoleUser}, "", false},
+		{"moderator", &authz.Subject{UserID: 11, Role: authz.RoleModerator}, "", false},
+		{"admin", &authz.Subject{UserID: 1, Role: authz.RoleAdmin}, "", true},
+		{"trusted service", nil, "auth-service", true},
+	}
+	for _, tt := range tests {
+		r := request(http.MethodGet, "/users/10", nil, nil, tt.subject)
+		if tt.service != "" {
+			r = r.WithContext(context.WithValue(r.Context(), middlewares.ServiceKey, tt.service))
+		}
+		if got := canSeePrivate(r, 10); got != tt.want {
+			t.Errorf("%s: canSeePrivate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateUserRoleValidatesRole(t *testing.T) {
+	admin := &authz.Subject{UserID: 1, Role: authz.RoleAdmin}
+	h := UpdateUserRole(nil)
+
+	for _, role := range []string{"", "root", "ADMIN"} {
+		r := request(http.MethodPut, "/users/5/role", UpdateRoleRequest{Role: role}, map[string]string{"id": "5"}, admin)
+		if rec := serve(h, r); rec.Code != http.StatusBadRequest {
+			t.Errorf("role %q: status %d, want 400", role, rec.Code)
+		}
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	db := openTestDB(t)
+	id, _ := newUser(t, db, "role")
+	admin := &authz.Subject{UserID: 1, Role: authz.RoleAdmin}
+	vars := map[string]string{"id": strconv.Itoa(id)}
+
+	rec := serve(UpdateUserRole(db), request(http.MethodPut, "/users/role", UpdateRoleRequest{Role: "moderator"}, vars, admin))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	// Новая роль видна в приватных полях профиля
+	rec = serve(GetUserByID(db, ""), request(http.MethodGet, "/users", nil, vars, admin))
+	var user struct {
+		Role string `json:"role"`
+	}
+	json.NewDecoder(rec.Body).Decode(&user)
+	if user.Role != "moderator" {
+		t.Errorf("role = %q, want moderator", user.Role)
+	}
+
+	rec = serve(UpdateUserRole(db), request(http.MethodPut, "/users/role", UpdateRoleRequest{Role: "admin"}, map[string]string{"id": "0"}, admin))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status %d, want 404", rec.Code)
+	}
+}
+
+func TestGetUserByIDHidesPrivateFields(t *testing.T) {
+	db := openTestDB(t)
+	id, _ := newUser(t, db, "private")
+	vars := map[string]string{"id": strconv.Itoa(id)}
+
+	fields := func(subject *authz.Subject) map[string]interface{} {
+		rec := serve(GetUserByID(db, ""), request(http.MethodGet, "/users", nil, vars, subject))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d", rec.Code)
+		}
+		var m map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&m)
+		return m
+	}
+
+	if m := fields(&authz.Subject{UserID: id + 1, Role: authz.RoleUser}); m["email"] != nil || m["role"] != nil || m["token_version"] != nil {
+		t.Errorf("another user sees private fields: %v", m)
+	}
+	if m := fields(&authz.Subject{UserID: id, Role: authz.RoleUser}); m["email"] == nil || m["role"] != "user" {
+		t.Errorf("owner does not see private fields: %v", m)
+	}
+}
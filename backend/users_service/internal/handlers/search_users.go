@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"shared/pagination"
+	"users_service/internal/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxUserQueryLength — наибольшая длина запроса в символах
+const maxUserQueryLength = 50
+
+// userMatch — найденный пользователь с адресами аватара
+type userMatch struct {
+	database.UserMatch
+	AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+}
+
+// SearchUsers ищет пользователей по началу или части username и отображаемого
+// имени (параметр q) и отдаёт страницу с публичными полями, самые релевантные
+// сверху. Подходит для автодополнения @-упоминаний.
+func SearchUsers(db *sql.DB, blobURL string) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(os.Stdout)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		// @ допускается, чтобы клиент мог передать упоминание как есть
+		q := strings.TrimPrefix(strings.TrimSpace(params.Get("q")), "@")
+		if q == "" {
+			http.Error(w, "Search query is required", http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(q) > maxUserQueryLength {
+			http.Error(w, "Search query is too long", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := pagination.LimitFromRequest(r)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		var after *pagination.RankCursor
+		if v := params.Get("cursor"); v != "" {
+			c, err := pagination.DecodeRank(v)
+			if err != nil {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+				return
+			}
+			after = &c
+		}
+
+		matches, err := database.SearchUsers(db, q, after, limit)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to search users")
+			http.Error(w, "Failed to search users", http.StatusInternalServerError)
+			return
+		}
+
+		items := make([]userMatch, len(matches))
+		for i, m := range matches {
+			items[i] = userMatch{UserMatch: m, AvatarURLs: avatarURLs(blobURL, m.AvatarKey)}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pagination.NewCustomPage(items, limit, func(m userMatch) string {
+			return pagination.RankCursor{Rank: m.Rank, ID: m.ID}.Encode()
+		}))
+	}
+}
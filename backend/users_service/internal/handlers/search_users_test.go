@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"users_service/internal/database"
+)
+
+func TestSearchUsersValidatesQuery(t *testing.T) {
+	for _, query := range []string{"", "q=", "q=@", "q=" + strings.Repeat("a", maxUserQueryLength+1), "q=ann&limit=0", "q=ann&cursor=zzz"} {
+		rec := serve(SearchUsers(nil, "/"), request(http.MethodGet, "/users/search?"+query, nil, nil, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestSearchUsersRanking(t *testing.T) {
+	db := openTestDB(t)
+	// Уникальная основа, чтобы в выдачу не попали пользователи других прогонов
+	base := fmt.Sprintf("zq%d", time.Now().UnixNano()%1000000000)
+
+	create := func(username, displayName string) int {
+		id, err := database.SaveUser(db, username, username+"@example.com", "hash")
+		if err != nil {
+			t.Fatalf("SaveUser: %v", err)
+		}
+		t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", id) })
+		db.Exec("UPDATE users SET display_name = $1 WHERE id = $2", displayName, id)
+		return id
+	}
+	byName := create("x"+base, "Anna "+base)
+	prefix := create(base+"_second", "")
+	exact := create(base, "")
+	create("unrelated"+base[:3], "")
+
+	search := func(q string) []map[string]interface{} {
+		rec := serve(SearchUsers(db, "/"), request(http.MethodGet, "/users/search?q="+url.QueryEscape(q), nil, nil, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("q=%s: status %d", q, rec.Code)
+		}
+		var page struct {
+			Items []map[string]interface{} `json:"items"`
+		}
+		json.NewDecoder(rec.Body).Decode(&page)
+		return page.Items
+	}
+
+	items := search("@" + strings.ToUpper(base))
+	var ids []int
+	for _, item := range items {
+		ids = append(ids, int(item["id"].(float64)))
+		for _, private := range []string{"email", "role", "password_hash"} {
+			if _, ok := item[private]; ok {
+				t.Errorf("search result exposes %q", private)
+			}
+		}
+	}
+	// Точное имя, затем префикс имени, затем слово отображаемого имени
+	want := fmt.Sprint([]int{exact, prefix, byName})
+	if len(ids) < 3 || fmt.Sprint(ids[:3]) != want {
+		t.Errorf("ranking = %v, want %s first", ids, want)
+	}
+
+	// % и _ в запросе — обычные символы, а не шаблон LIKE
+	for _, item := range search(base[:2] + "%") {
+		if name := item["username"].(string); !strings.Contains(name, "%") && strings.HasPrefix(name, base) {
+			t.Errorf("%% matched %q as a wildcard", name)
+		}
+	}
+}
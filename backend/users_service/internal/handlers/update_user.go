@@ -3,19 +3,51 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"shared/mailer"
 
 	"github.com/gorilla/mux"
 )
 
 type UpdateUserRequest struct {
-	Username *string `json:"username,omitempty"`
-	Email    *string `json:"email,omitempty"`
+	Username    *string `json:"username,omitempty"`
+	Email       *string `json:"email,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	Location    *string `json:"location,omitempty"`
+	Website     *string `json:"website,omitempty"`
+}
+
+// Ограничения полей профиля в символах
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 500
+	maxLocationLength    = 100
+	maxWebsiteLength     = 200
+)
+
+// profileField — поле профиля, которое можно очистить пустой строкой
+type profileField struct {
+	column string
+	name   string
+	value  *string
+	limit  int
+}
+
+// validateWebsite допускает только абсолютные http(s)-адреса: иначе в профиле
+// оказалась бы ссылка вида javascript:
+func validateWebsite(v string) bool {
+	u, err := url.Parse(v)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
 
-func UpdateUser(db *sql.DB) http.HandlerFunc {
+func UpdateUser(db *sql.DB, m mailer.Mailer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		userIDStr := vars["id"]
@@ -43,25 +75,63 @@ func UpdateUser(db *sql.DB) http.HandlerFunc {
 		}
 
 		if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
-			setParts = append(setParts, "email = $"+strconv.Itoa(argIndex))
+			// Новый адрес требует повторного подтверждения
+			setParts = append(setParts, "email = $"+strconv.Itoa(argIndex), "email_verified = false")
 			args = append(args, strings.TrimSpace(*req.Email))
 			argIndex++
 		}
 
+		fields := []profileField{
+			{"display_name", "Display name", req.DisplayName, maxDisplayNameLength},
+			{"bio", "Bio", req.Bio, maxBioLength},
+			{"location", "Location", req.Location, maxLocationLength},
+			{"website", "Website", req.Website, maxWebsiteLength},
+		}
+		for _, f := range fields {
+			if f.value == nil {
+				continue
+			}
+			value := strings.TrimSpace(*f.value)
+			if utf8.RuneCountInString(value) > f.limit {
+				http.Error(w, f.name+" must be at most "+strconv.Itoa(f.limit)+" characters", http.StatusBadRequest)
+				return
+			}
+			if f.column == "website" && value != "" && !validateWebsite(value) {
+				http.Error(w, "Website must be an http or https URL", http.StatusBadRequest)
+				return
+			}
+			setParts = append(setParts, f.column+" = $"+strconv.Itoa(argIndex))
+			args = append(args, value)
+			argIndex++
+		}
+
 		if len(setParts) == 0 {
 			http.Error(w, "No fields to update", http.StatusBadRequest)
 			return
 		}
 
-		query := "UPDATE users SET " + strings.Join(setParts, ", ") + " WHERE id = $" + strconv.Itoa(argIndex)
+		query := "UPDATE users SET " + strings.Join(setParts, ", ") + " WHERE id = $" + strconv.Itoa(argIndex) +
+			" RETURNING username, email"
 		args = append(args, userID)
 
-		_, err = db.Exec(query, args...)
-		if err != nil {
+		var username, email string
+		err = db.QueryRow(query, args...).Scan(&username, &email)
+		if err == sql.ErrNoRows {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		} else if err != nil {
 			http.Error(w, "Failed to update user", http.StatusInternalServerError)
 			return
 		}
 
+		if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
+			go func() {
+				if err := sendVerificationEmail(db, m, userID, username, email); err != nil {
+					log.Println("UpdateUser: Failed to send verification email:", err)
+				}
+			}()
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"message": "User updated successfully"})
 	}
@@ -1,21 +1,37 @@
 package handlers
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
+
+	"shared/passpolicy"
+	"shared/passwordhash"
+	"users_service/internal/database"
 
 	"github.com/gorilla/mux"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/sirupsen/logrus"
 )
 
+// UpdatePasswordRequest — новый пароль и подтверждение права его установить:
+// текущий пароль или токен сброса пароля
 type UpdatePasswordRequest struct {
-	NewPassword string `json:"new_password"`
+	NewPassword     string `json:"new_password"`
+	CurrentPassword string `json:"current_password,omitempty"`
+	ResetToken      string `json:"reset_token,omitempty"`
 }
 
-func UpdateUserPassword(db *sql.DB) http.HandlerFunc {
+// UpdateUserPassword меняет пароль пользователя. Требуется текущий пароль или
+// действующий токен сброса; после смены все выданные токены отзываются
+// увеличением token_version.
+func UpdateUserPassword(db *sql.DB, policy *passpolicy.Policy, hasher *passwordhash.Hasher) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		userIDStr := vars["id"]
@@ -31,25 +47,70 @@ func UpdateUserPassword(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		req.NewPassword = strings.TrimSpace(req.NewPassword)
 		if req.NewPassword == "" {
 			http.Error(w, "New password is required", http.StatusBadRequest)
 			return
 		}
+		if (req.CurrentPassword == "") == (req.ResetToken == "") {
+			http.Error(w, "Either current password or reset token is required", http.StatusBadRequest)
+			return
+		}
+
+		var username, email, passwordHash string
+		err = db.QueryRow("SELECT username, email, password_hash FROM users WHERE id = $1", userID).Scan(&username, &email, &passwordHash)
+		if err == sql.ErrNoRows {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+			return
+		}
 
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if req.ResetToken != "" {
+			sum := sha256.Sum256([]byte(req.ResetToken))
+			owner, err := database.ResetTokenOwner(db, hex.EncodeToString(sum[:]))
+			if errors.Is(err, database.ErrResetTokenInvalid) || (err == nil && owner != userID) {
+				logger.WithField("user_id", userID).Warn("Users-Service: Invalid reset token for password change")
+				http.Error(w, "Invalid or expired reset token", http.StatusForbidden)
+				return
+			} else if err != nil {
+				logger.WithError(err).Error("Users-Service: Failed to check reset token")
+				http.Error(w, "Failed to update password", http.StatusInternalServerError)
+				return
+			}
+		} else if ok, _, err := hasher.Verify(req.CurrentPassword, passwordHash); err != nil || !ok {
+			if err != nil {
+				logger.WithError(err).WithField("user_id", userID).Error("Users-Service: Failed to verify password hash")
+			}
+			logger.WithField("user_id", userID).Warn("Users-Service: Wrong current password for password change")
+			http.Error(w, "Current password is incorrect", http.StatusForbidden)
+			return
+		}
+
+		if verr, ok := passpolicy.AsValidationError(policy.Check(req.NewPassword, username, email)); ok {
+			passpolicy.WriteError(w, verr)
+			return
+		}
+
+		hashedPassword, err := hasher.Hash(req.NewPassword)
 		if err != nil {
 			http.Error(w, "Failed to hash password", http.StatusInternalServerError)
 			return
 		}
 
-		_, err = db.Exec("UPDATE users SET password_hash = $1 WHERE id = $2", string(hashedPassword), userID)
+		version, err := database.UpdatePassword(db, userID, hashedPassword)
 		if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to update password")
 			http.Error(w, "Failed to update password", http.StatusInternalServerError)
 			return
 		}
 
+		logger.WithField("user_id", userID).Info("Users-Service: Password changed, issued tokens revoked")
+
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"message": "Password updated successfully"})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":       "Password updated successfully",
+			"token_version": version,
+		})
 	}
 }
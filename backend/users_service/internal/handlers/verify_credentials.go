@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"shared/passwordhash"
+	"users_service/internal/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+// VerifyCredentialsRequest представляет email и пароль для проверки
+type VerifyCredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// VerifyCredentials проверяет email и пароль и возвращает данные пользователя.
+// Хэш пароля не покидает users_service. Для неизвестного email и неверного
+// пароля ответ одинаковый — 401, и время ответа тоже: хэш сравнивается всегда.
+// Хэш с устаревшим алгоритмом или параметрами пересчитывается после успешной проверки.
+func VerifyCredentials(db *sql.DB, hasher *passwordhash.Hasher) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	// Хэш-заглушка для неизвестных email, чтобы не выдавать их по времени ответа
+	dummyHash, err := hasher.Hash("dummy password")
+	if err != nil {
+		logger.WithError(err).Fatal("Users-Service: Failed to prepare dummy hash")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyCredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		if req.Email == "" || req.Password == "" {
+			http.Error(w, "Email and password are required", http.StatusBadRequest)
+			return
+		}
+
+		user, err := database.GetUserByEmail(db, req.Email)
+		if err != nil {
+			logger.WithError(err).Error("Users-Service: Database query failed")
+			http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+			return
+		}
+
+		hash := dummyHash
+		if user != nil && user.PasswordHash != "" {
+			hash = user.PasswordHash
+		}
+		ok, needsRehash, err := hasher.Verify(req.Password, hash)
+		if err != nil {
+			logger.WithError(err).WithField("email", req.Email).Error("Users-Service: Failed to verify password hash")
+		}
+		if !ok || user == nil || user.PasswordHash == "" {
+			http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+			return
+		}
+
+		if needsRehash {
+			rehashPassword(db, hasher, logger, user.ID, req.Password, user.PasswordHash)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(user); err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to encode response")
+		}
+	}
+}
+
+// rehashPassword сохраняет хэш пароля с текущими параметрами. Ошибка только
+// логируется: пользователь уже вошёл, пересчёт повторится при следующем входе.
+func rehashPassword(db *sql.DB, hasher *passwordhash.Hasher, logger *logrus.Logger, userID int, password, oldHash string) {
+	newHash, err := hasher.Hash(password)
+	if err != nil {
+		logger.WithError(err).WithField("user_id", userID).Error("Users-Service: Failed to rehash password")
+		return
+	}
+	if err := database.RehashPassword(db, userID, oldHash, newHash); err != nil {
+		logger.WithError(err).WithField("user_id", userID).Error("Users-Service: Failed to store rehashed password")
+		return
+	}
+	logger.WithField("user_id", userID).Info("Users-Service: Password hash upgraded")
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"shared/passwordhash"
+)
+
+func TestVerifyCredentialsUpgradesOutdatedHash(t *testing.T) {
+	db := openTestDB(t)
+	id, name := newUser(t, db, "rehash")
+
+	legacy, _ := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: 4})
+	oldHash, _ := legacy.Hash("correct horse")
+	if _, err := db.Exec("UPDATE users SET password_hash = $1 WHERE id = $2", oldHash, id); err != nil {
+		t.Fatal(err)
+	}
+
+	current, _ := passwordhash.New(passwordhash.Config{
+		Algorithm: passwordhash.Argon2id,
+		Argon2:    passwordhash.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1},
+	})
+	h := VerifyCredentials(db, current)
+	body := VerifyCredentialsRequest{Email: name + "@example.com", Password: "correct horse"}
+
+	if rec := serve(h, request(http.MethodPost, "/users/verify-credentials", body, nil, nil)); rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var stored string
+	db.QueryRow("SELECT password_hash FROM users WHERE id = $1", id).Scan(&stored)
+	if stored == oldHash {
+		t.Fatal("bcrypt hash was not upgraded after login")
+	}
+	if ok, rehash, err := current.Verify("correct horse", stored); !ok || rehash || err != nil {
+		t.Errorf("upgraded hash: Verify = %v, %v, %v", ok, rehash, err)
+	}
+
+	// Неверный пароль хэш не трогает
+	body.Password = "wrong"
+	if rec := serve(h, request(http.MethodPost, "/users/verify-credentials", body, nil, nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"shared/mailer"
+	"users_service/internal/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultVerificationTTL = 48 * time.Hour
+
+func verificationTTL() time.Duration {
+	if v := os.Getenv("EMAIL_VERIFICATION_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultVerificationTTL
+}
+
+// verificationLink формирует ссылку подтверждения адреса
+func verificationLink(token string) string {
+	base := os.Getenv("EMAIL_VERIFICATION_URL")
+	if base == "" {
+		base = "http://localhost:3000/api/users/verify-email"
+	}
+	return base + "?token=" + url.QueryEscape(token)
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendVerificationEmail выпускает токен подтверждения и отправляет письмо на адрес email
+func sendVerificationEmail(db *sql.DB, m mailer.Mailer, userID int, username, email string) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	ttl := verificationTTL()
+	if err := database.CreateEmailVerification(db, userID, email, hashVerificationToken(token), time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	return m.Send(mailer.Message{
+		To:      email,
+		Subject: "Подтвердите адрес электронной почты",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес электронной почты, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			username, verificationLink(token), ttl),
+	})
+}
+
+// VerifyEmail подтверждает адрес по токену из письма
+func VerifyEmail(db *sql.DB) http.HandlerFunc {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "Token is required", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := database.VerifyEmail(db, hashVerificationToken(token))
+		if errors.Is(err, database.ErrVerificationTokenInvalid) {
+			logger.Warn("Users-Service: Invalid or expired verification token")
+			http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			logger.WithError(err).Error("Users-Service: Failed to verify email")
+			http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithField("user_id", userID).Info("Users-Service: Email verified")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Email verified successfully"})
+	}
+}
@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"shared/apikeys"
+	"shared/authz"
+	"shared/jwtauth"
+
+	"github.com/gorilla/mux"
+)
+
+type ContextKey string
+
+// ServiceKey — имя сервиса, вызвавшего маршрут с сервисным токеном
+const ServiceKey ContextKey = "service"
+
+// AuthMiddleware проверяет access-токен и кладёт пользователя и его роль в контекст.
+// API-ключи принимаются только для чтения и только с областью доступа users:read.
+func AuthMiddleware(verifier *jwtauth.Verifier, keys *apikeys.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.BearerToken(r)
+			if tokenString == "" {
+				http.Error(w, "Authorization token missing", http.StatusUnauthorized)
+				return
+			}
+
+			if apikeys.IsKey(tokenString) {
+				if r.Method != http.MethodGet && r.Method != http.MethodHead {
+					http.Error(w, "API keys cannot modify accounts", http.StatusForbidden)
+					return
+				}
+				principal, err := keys.Authenticate(tokenString)
+				if err != nil {
+					log.Printf("AuthMiddleware: %v", err)
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+				if !principal.HasScope(apikeys.UsersRead) {
+					http.Error(w, "API key does not have the required scope", http.StatusForbidden)
+					return
+				}
+				role, err := authz.ParseRole(principal.Role)
+				if err != nil {
+					log.Printf("AuthMiddleware: %v", err)
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+
+				ctx := authz.WithSubject(r.Context(), authz.Subject{UserID: principal.UserID, Role: role})
+				next.ServeHTTP(w, r.WithContext(apikeys.WithPrincipal(ctx, principal)))
+				return
+			}
+
+			claims, err := verifier.Verify(tokenString)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			subject, err := authz.SubjectFromClaims(claims)
+			if err != nil {
+				log.Printf("AuthMiddleware: %v", err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(authz.WithSubject(r.Context(), subject)))
+		})
+	}
+}
+
+// ServiceMiddleware пропускает только сервисные токены сервисов из allowed.
+// Такими маршрутами пользуется auth_service: проверка пароля, смена пароля, MFA.
+func ServiceMiddleware(verifier *jwtauth.Verifier, allowed []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, ok := serviceContext(w, r, verifier, allowed)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UserOrServiceMiddleware принимает как пользовательский токен, так и сервисный
+// токен сервиса из allowed
+func UserOrServiceMiddleware(verifier *jwtauth.Verifier, keys *apikeys.Authenticator, allowed []string) func(http.Handler) http.Handler {
+	userAuth := AuthMiddleware(verifier, keys)
+	return func(next http.Handler) http.Handler {
+		asUser := userAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := verifier.VerifyService(jwtauth.BearerToken(r)); err != nil {
+				asUser.ServeHTTP(w, r)
+				return
+			}
+			ctx, ok := serviceContext(w, r, verifier, allowed)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// serviceContext проверяет сервисный токен и при ошибке сам отвечает клиенту
+func serviceContext(w http.ResponseWriter, r *http.Request, verifier *jwtauth.Verifier, allowed []string) (context.Context, bool) {
+	tokenString := jwtauth.BearerToken(r)
+	if tokenString == "" {
+		http.Error(w, "Authorization token missing", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	service, err := verifier.VerifyService(tokenString)
+	if err != nil {
+		log.Printf("ServiceMiddleware: %v", err)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	for _, name := range allowed {
+		if name == service {
+			return context.WithValue(r.Context(), ServiceKey, service), true
+		}
+	}
+	log.Printf("ServiceMiddleware: service %q is not allowed to call %s %s", service, r.Method, r.URL.Path)
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	return nil, false
+}
+
+// UserIDFromPath извлекает владельца ресурса из переменной маршрута {id}
+func UserIDFromPath(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	return id, err == nil
+}
+
+// OptionalAuthMiddleware проверяет токен так же, как AuthMiddleware, если он
+// передан, а запрос без токена пропускает анонимным
+func OptionalAuthMiddleware(verifier *jwtauth.Verifier, keys *apikeys.Authenticator) func(http.Handler) http.Handler {
+	auth := AuthMiddleware(verifier, keys)
+	return func(next http.Handler) http.Handler {
+		authenticated := auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if jwtauth.BearerToken(r) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
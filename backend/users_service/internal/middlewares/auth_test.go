@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shared/authz"
+	"shared/jwtauth"
+)
+
+func testConfig() *jwtauth.Config {
+	return &jwtauth.Config{
+		Keys:        map[string]jwtauth.Key{"k": jwtauth.NewHMACKey("k", []byte("middleware-secret"))},
+		ActiveKeyID: "k",
+		Issuer:      "auth-service",
+		Audience:    "yandexcloud-api",
+	}
+}
+
+func sign(t *testing.T, cfg *jwtauth.Config, claims jwtauth.Claims) string {
+	t.Helper()
+	signer, err := jwtauth.NewSigner(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := signer.Sign(claims, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthMiddlewarePutsRoleIntoContext(t *testing.T) {
+	cfg := testConfig()
+	var got authz.Subject
+	h := AuthMiddleware(jwtauth.NewVerifier(cfg), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = authz.SubjectFromContext(r.Context())
+	}))
+
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call(sign(t, cfg, jwtauth.Claims{UserID: 2, Role: "admin"})); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if got != (authz.Subject{UserID: 2, Role: authz.RoleAdmin}) {
+		t.Errorf("subject = %+v", got)
+	}
+
+	// Роль, которой нет в системе, не превращается в обычного пользователя
+	if code := call(sign(t, cfg, jwtauth.Claims{UserID: 2, Role: "root"})); code != http.StatusUnauthorized {
+		t.Errorf("unknown role: status %d, want 401", code)
+	}
+}
+
+func TestAuthMiddlewareAPIKeysAreReadOnly(t *testing.T) {
+	// Метод проверяется до поиска ключа в базе
+	h := AuthMiddleware(jwtauth.NewVerifier(testConfig()), nil)(http.NotFoundHandler())
+	for _, method := range []string{http.MethodPatch, http.MethodPut, http.MethodDelete, http.MethodPost} {
+		req := httptest.NewRequest(method, "/users/1", nil)
+		req.Header.Set("Authorization", "Bearer yck_whatever")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s with an API key: status %d, want 403", method, rec.Code)
+		}
+	}
+}
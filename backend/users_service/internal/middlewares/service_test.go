@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shared/authz"
+	"shared/jwtauth"
+)
+
+func serviceToken(t *testing.T, cfg *jwtauth.Config, name string) string {
+	t.Helper()
+	signer, err := jwtauth.NewSigner(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := signer.SignService(name, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// caller записывает, от чьего имени обработчик получил запрос
+type caller struct {
+	service string
+	subject authz.Subject
+}
+
+func (c *caller) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.service, _ = r.Context().Value(ServiceKey).(string)
+		c.subject, _ = authz.SubjectFromContext(r.Context())
+	})
+}
+
+func callWith(h http.Handler, token string) int {
+	req := httptest.NewRequest(http.MethodPost, "/users/verify-credentials", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestServiceMiddleware(t *testing.T) {
+	cfg := testConfig()
+	var c caller
+	h := ServiceMiddleware(jwtauth.NewVerifier(cfg), []string{"auth-service"})(c.handler())
+
+	if code := callWith(h, serviceToken(t, cfg, "auth-service")); code != http.StatusOK || c.service != "auth-service" {
+		t.Errorf("auth-service: status %d, service %q", code, c.service)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"service not allowed", serviceToken(t, cfg, "posts-service"), http.StatusForbidden},
+		{"user token", sign(t, cfg, jwtauth.Claims{UserID: 1, Role: "admin"}), http.StatusUnauthorized},
+		{"foreign key", serviceToken(t, &jwtauth.Config{
+			Keys:        map[string]jwtauth.Key{"k": jwtauth.NewHMACKey("k", []byte("other"))},
+			ActiveKeyID: "k", Issuer: cfg.Issuer, Audience: cfg.Audience,
+		}, "auth-service"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if code := callWith(h, tt.token); code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.want)
+		}
+	}
+}
+
+func TestUserOrServiceMiddleware(t *testing.T) {
+	cfg := testConfig()
+	verifier := jwtauth.NewVerifier(cfg)
+
+	var c caller
+	h := UserOrServiceMiddleware(verifier, nil, []string{"posts-service"})(c.handler())
+	if code := callWith(h, serviceToken(t, cfg, "posts-service")); code != http.StatusOK || c.service != "posts-service" {
+		t.Errorf("service: status %d, service %q", code, c.service)
+	}
+
+	c = caller{}
+	if code := callWith(h, sign(t, cfg, jwtauth.Claims{UserID: 4})); code != http.StatusOK || c.subject.UserID != 4 || c.service != "" {
+		t.Errorf("user: status %d, caller %+v", code, c)
+	}
+
+	if code := callWith(h, serviceToken(t, cfg, "auth-service")); code != http.StatusForbidden {
+		t.Errorf("service outside the list: status %d, want 403", code)
+	}
+}
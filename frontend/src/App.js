@@ -6,11 +6,14 @@ import PrivateRoute from './PrivateRoute/PrivateRoute';
 import Login from './components/Auth/Login';
 import Logout from './components/Auth/Logout';
 import Register from './components/Auth/Register';
+import OAuthCallback from './components/Auth/OAuthCallback';
 
 import Header from './components/Header/Header';
 import MainPage from './components/MainPage/MainPage';
 import Profile from './components/Profile/Profile';
 import PostPage from './components/PostPage/PostPage';
+import SearchPage from './components/Search/SearchPage';
+import TagPage from './components/Tags/TagPage';
 
 function App() {
   return (
@@ -19,6 +22,7 @@ function App() {
         <Routes>
           <Route path="/login" element={<Login />} />
           <Route path="/register" element={<Register />} />
+          <Route path="/oauth/callback" element={<OAuthCallback />} />
 
           <Route
             path="/"
@@ -59,6 +63,26 @@ function App() {
             }
           />
 
+          <Route
+            path="/search"
+            element={
+              <PrivateRoute>
+                <Header />
+                <SearchPage />
+              </PrivateRoute>
+            }
+          />
+
+          <Route
+            path="/tags/:tag"
+            element={
+              <PrivateRoute>
+                <Header />
+                <TagPage />
+              </PrivateRoute>
+            }
+          />
+
           <Route
             path="/post/:postID"
             element={
@@ -16,24 +16,194 @@ function getAuthHeaders() {
   return { Authorization: `Bearer ${token}` };
 }
 
+// Обновляет пару токенов по refresh-токену; старый refresh-токен становится недействительным
+export const refreshSession = async () => {
+  const refreshToken = localStorage.getItem('refreshToken');
+
+  if (!refreshToken) {
+    throw new Error('Refresh token not found');
+  }
+
+  const response = await axios.post(`${AUTH_API_URL}/refresh`, { refresh_token: refreshToken });
+  localStorage.setItem('token', response.data.token);
+  localStorage.setItem('refreshToken', response.data.refresh_token);
+  return response.data;
+};
+
+// При 401 пытаемся один раз обновить токен и повторить запрос
+let refreshPromise = null;
+
+axios.interceptors.response.use(
+  (response) => response,
+  async (error) => {
+    const original = error.config;
+
+    if (
+      !error.response ||
+      error.response.status !== 401 ||
+      !original ||
+      original._retried ||
+      original.url.startsWith(AUTH_API_URL)
+    ) {
+      return Promise.reject(error);
+    }
+
+    original._retried = true;
+
+    try {
+      refreshPromise = refreshPromise || refreshSession();
+      const data = await refreshPromise;
+      original.headers = { ...original.headers, Authorization: `Bearer ${data.token}` };
+      return axios(original);
+    } catch (refreshError) {
+      return Promise.reject(error);
+    } finally {
+      refreshPromise = null;
+    }
+  }
+);
+
 export const login = async (email, password) => {
   return axios.post(`${AUTH_API_URL}/login`, { email, password });
 };
 
+// Коды восстановления имеют вид xxxxx-xxxxx, коды TOTP состоят из цифр
+export const loginMfa = async (mfaToken, code) => {
+  const payload = code.includes('-')
+    ? { mfa_token: mfaToken, recovery_code: code }
+    : { mfa_token: mfaToken, code };
+
+  return axios.post(`${AUTH_API_URL}/login/mfa`, payload);
+};
+
+// После смены пароля прежние токены отозваны: сохраняем выданную новую пару
+export const changePassword = async (currentPassword, newPassword) => {
+  const headers = getAuthHeaders();
+  const response = await axios.post(
+    `${AUTH_API_URL}/password/change`,
+    { current_password: currentPassword, new_password: newPassword },
+    { headers }
+  );
+  localStorage.setItem('token', response.data.token);
+  localStorage.setItem('refreshToken', response.data.refresh_token);
+  return response.data;
+};
+
+// Вход через внешнего провайдера — переход браузера, а не XHR-запрос
+export const oidcLoginUrl = (provider) => `${AUTH_API_URL}/oidc/${provider}/authorize`;
+
+export const logout = async (refreshToken) => {
+  return axios.post(`${AUTH_API_URL}/logout`, { refresh_token: refreshToken });
+};
+
 export const register = async (username, email, password) => {
   return axios.post(`${AUTH_API_URL}/register`, { username, email, password });
 };
 
-export const fetchPosts = async () => {
+// Ключ целиком возвращается только при создании, в списке виден лишь префикс
+export const createApiKey = async (name, scopes) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.post(`${AUTH_API_URL}/api-keys`, { name, scopes }, { headers });
+  return response.data;
+};
+
+export const fetchApiKeys = async () => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.get(`${AUTH_API_URL}/api-keys`, { headers });
+  return response.data;
+};
+
+export const renameApiKey = async (id, name) => {
+  const headers = getAuthHeaders();
+
+  return axios.patch(`${AUTH_API_URL}/api-keys/${id}`, { name }, { headers });
+};
+
+export const revokeApiKey = async (id) => {
+  const headers = getAuthHeaders();
+
+  return axios.delete(`${AUTH_API_URL}/api-keys/${id}`, { headers });
+};
+
+// Списки возвращаются страницами { items, next_cursor }; cursor — значение
+// next_cursor предыдущей страницы, без него возвращается первая
+export const fetchPosts = async (cursor) => {
+  const headers = getAuthHeaders();
+  const params = cursor ? { cursor } : {};
+
+  const response = await axios.get(`${POSTS_API_URL}/posts`, { headers, params });
+  return response.data;
+};
+
+// Персональная лента: посты авторов из подписок и свои; ответ — { items, next_cursor }
+export const fetchFeed = async (cursor) => {
+  const headers = getAuthHeaders();
+  const params = cursor ? { cursor } : {};
+
+  const response = await axios.get(`${POSTS_API_URL}/feed`, { headers, params });
+  return response.data;
+};
+
+// filters: { q, author, from, to }; пустые фильтры не передаются
+export const searchPosts = async (filters, cursor) => {
+  const headers = getAuthHeaders();
+  const params = {};
+  Object.entries(filters).forEach(([key, value]) => {
+    if (value) params[key] = value;
+  });
+  if (cursor) params.cursor = cursor;
+
+  const response = await axios.get(`${POSTS_API_URL}/posts/search`, { headers, params });
+  return response.data;
+};
+
+// tag — без # и в любом регистре
+export const fetchTagPosts = async (tag, cursor) => {
   const headers = getAuthHeaders();
+  const params = cursor ? { cursor } : {};
 
-  return axios.get(`${POSTS_API_URL}/posts`, { headers });
+  const response = await axios.get(`${POSTS_API_URL}/tags/${encodeURIComponent(tag)}/posts`, { headers, params });
+  return response.data;
+};
+
+// window — длительность вроде '24h'; [{ name, score, posts }]
+export const fetchTrendingTags = async (window, limit = 10) => {
+  const headers = getAuthHeaders();
+  const params = window ? { window, limit } : { limit };
+
+  const response = await axios.get(`${POSTS_API_URL}/tags/trending`, { headers, params });
+  return response.data;
 };
 
-export const createPost = async (title, content) => {
+export const fetchSubscription = async (authorId) => {
   const headers = getAuthHeaders();
 
-  return axios.post(`${POSTS_API_URL}/posts`, { title, content }, { headers });
+  const response = await axios.get(`${POSTS_API_URL}/subscriptions/${authorId}`, { headers });
+  return response.data;
+};
+
+export const subscribeToAuthor = async (authorId) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.post(`${POSTS_API_URL}/subscriptions/${authorId}`, null, { headers });
+  return response.data;
+};
+
+export const unsubscribeFromAuthor = async (authorId) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.delete(`${POSTS_API_URL}/subscriptions/${authorId}`, { headers });
+  return response.data;
+};
+
+// options: { status: 'published' | 'draft' | 'scheduled', publishAt } —
+// без status пост публикуется сразу
+export const createPost = async (title, content, options = {}) => {
+  const headers = getAuthHeaders();
+
+  return axios.post(`${POSTS_API_URL}/posts`, { title, content, ...options }, { headers });
 };
 
 export const deletePost = async (postId) => {
@@ -42,16 +212,78 @@ export const deletePost = async (postId) => {
   return axios.delete(`${POSTS_API_URL}/posts/${postId}`, { headers });
 };
 
+// fields: { title, content, status, publishAt } — передаются только изменяемые поля
+export const updatePost = async (postId, fields) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.patch(`${POSTS_API_URL}/posts/${postId}`, fields, { headers });
+  return response.data;
+};
+
+export const fetchRevisions = async (postId) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.get(`${POSTS_API_URL}/posts/${postId}/revisions`, { headers });
+  return response.data;
+};
+
+export const fetchRevisionDiff = async (postId, from, to) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.get(`${POSTS_API_URL}/posts/${postId}/revisions/diff`, {
+    headers,
+    params: { from, to },
+  });
+  return response.data;
+};
+
+// Без parentId — комментарии верхнего уровня, с parentId — ответы на комментарий
+export const fetchComments = async (postId, { parentId, cursor } = {}) => {
+  const headers = getAuthHeaders();
+  const params = {};
+  if (parentId) params.parentId = parentId;
+  if (cursor) params.cursor = cursor;
+
+  const response = await axios.get(`${POSTS_API_URL}/posts/${postId}/comments`, { headers, params });
+  return response.data;
+};
+
+export const createComment = async (postId, content, parentId) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.post(
+    `${POSTS_API_URL}/posts/${postId}/comments`,
+    { content, parentId: parentId || null },
+    { headers }
+  );
+  return response.data;
+};
+
+export const updateComment = async (postId, commentId, content) => {
+  const headers = getAuthHeaders();
+
+  const response = await axios.patch(`${POSTS_API_URL}/posts/${postId}/comments/${commentId}`, { content }, { headers });
+  return response.data;
+};
+
+export const deleteComment = async (postId, commentId) => {
+  const headers = getAuthHeaders();
+
+  return axios.delete(`${POSTS_API_URL}/posts/${postId}/comments/${commentId}`, { headers });
+};
+
 export const fetchPostById = async (postId) => {
   const headers = getAuthHeaders();
 
   return axios.get(`${POSTS_API_URL}/posts/${postId}`, { headers });
 };
 
-export const fetchUserPosts = async (username) => {
+export const fetchUserPosts = async (username, cursor) => {
   const headers = getAuthHeaders();
+  const params = cursor ? { cursor } : {};
 
-  return axios.get(`${POSTS_API_URL}/profile/${username}/posts`, { headers });
+  const response = await axios.get(`${POSTS_API_URL}/profile/${username}/posts`, { headers, params });
+  return response.data;
 };
 
 export const toggleLike = async (postId, userId, liked) => {
@@ -64,39 +296,83 @@ export const toggleLike = async (postId, userId, liked) => {
     data: { postId, userId },
   });
 
-  if (response.data === null || response.data === undefined) {
-    return [];
-  }
+  // { liked, likesCount }
+  return response.data;
+};
 
-  if (!Array.isArray(response.data)) {
-    throw new Error('Server returned invalid response');
-  }
+export const fetchLikes = async (postId, cursor) => {
+  const headers = getAuthHeaders();
+  const params = cursor ? { postId, cursor } : { postId };
 
+  const response = await axios.get(`${POSTS_API_URL}/likes`, { headers, params });
   return response.data;
 };
 
-export const fetchLikes = async (postId) => {
+// Публичный профиль: email и служебные поля в ответ не входят.
+// С токеном в ответе есть и связь с текущим пользователем (relationship).
+export const fetchUserProfile = async (username) => {
+  const token = localStorage.getItem('token');
+  const headers = token ? { Authorization: `Bearer ${token}` } : {};
+
+  return axios.get(`${USERS_API_URL}/profile/${encodeURIComponent(username)}`, { headers });
+};
+
+export const followUser = async (userId) => {
   const headers = getAuthHeaders();
 
-  const response = await axios.get(`${POSTS_API_URL}/likes?postId=${postId}`, { headers });
+  const response = await axios.post(`${USERS_API_URL}/users/${userId}/follow`, null, { headers });
+  return response.data;
+};
 
-  if (!response.data) {
-    return { users: [] };
-  }
+export const unfollowUser = async (userId) => {
+  const headers = getAuthHeaders();
 
+  const response = await axios.delete(`${USERS_API_URL}/users/${userId}/follow`, { headers });
   return response.data;
 };
 
-export const fetchUserProfile = async (username) => {
+// kind — followers или following; ответ — { items, next_cursor }
+// Поиск пользователей по началу или части username и имени, для @-упоминаний
+export const searchUsers = async (q, limit = 5) => {
   const headers = getAuthHeaders();
-  
-  return axios.get(`${USERS_API_URL}/users/by_username?username=${username}`, { headers });
+
+  const response = await axios.get(`${USERS_API_URL}/users/search`, { headers, params: { q, limit } });
+  return response.data;
+};
+
+export const fetchFollows = async (userId, kind, cursor) => {
+  const params = cursor ? { cursor } : {};
+
+  const response = await axios.get(`${USERS_API_URL}/users/${userId}/${kind}`, { params });
+  return response.data;
+};
+
+export const updateProfile = async (userId, fields) => {
+  const headers = getAuthHeaders();
+
+  return axios.patch(`${USERS_API_URL}/users/${userId}`, fields, { headers });
+};
+
+export const uploadAvatar = async (userId, file) => {
+  const headers = getAuthHeaders();
+  const form = new FormData();
+  form.append('avatar', file);
+
+  const response = await axios.put(`${USERS_API_URL}/users/${userId}/avatar`, form, { headers });
+  return response.data;
+};
+
+export const deleteAvatar = async (userId) => {
+  const headers = getAuthHeaders();
+
+  return axios.delete(`${USERS_API_URL}/users/${userId}/avatar`, { headers });
 };
 
-export const fetchNotifications = async (userId) => {
+export const fetchNotifications = async (userId, cursor) => {
   const headers = getAuthHeaders();
+  const params = cursor ? { userId, cursor } : { userId };
 
-  const response = await axios.get(`${NOTIS_API_URL}/notifications?userId=${userId}`, { headers });
+  const response = await axios.get(`${NOTIS_API_URL}/notifications`, { headers, params });
   return response.data;
 };
 
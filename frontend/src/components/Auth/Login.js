@@ -1,13 +1,17 @@
 import React, { useState, useEffect } from 'react';
-import { Link, useNavigate } from 'react-router-dom';
+import { Link, useLocation, useNavigate } from 'react-router-dom';
 import { useAuth } from '../../context/AuthContext';
-import { login } from '../../api/api';
+import { login, loginMfa, oidcLoginUrl } from '../../api/api';
 
 import '../../styles/Auth/Login.css';
 
 const Login = () => {
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
+  const location = useLocation();
+  // После входа через провайдера второй фактор запрашивается здесь же
+  const [mfaToken, setMfaToken] = useState(location.state?.mfaToken || null);
+  const [code, setCode] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
   const { login: loginUser } = useAuth();
@@ -25,11 +29,25 @@ const Login = () => {
     setError('');
     setLoading(true);
     try {
-      const response = await login(email, password);
+      // Второй шаг: код из приложения-аутентификатора
+      const response = mfaToken
+        ? await loginMfa(mfaToken, code)
+        : await login(email, password);
+
+      if (response.data.mfa_required) {
+        setMfaToken(response.data.mfa_token);
+        return;
+      }
+
       await loginUser(response.data); // Дождаться обновления контекста
       navigate('/');
     } catch (error) {
-      setError('Login failed. Please check your credentials.');
+      if (mfaToken && error.response && error.response.status === 401) {
+        // Промежуточный токен истёк — начинаем вход заново
+        setMfaToken(null);
+        setCode('');
+      }
+      setError(mfaToken ? 'Invalid code. Please try again.' : 'Login failed. Please check your credentials.');
       console.error('Login failed:', error);
     } finally {
       setLoading(false);
@@ -41,25 +59,44 @@ const Login = () => {
       <form className="login-form" onSubmit={handleSubmit}>
         <h2>Login</h2>
         {error && <p className="error-message">{error}</p>}
-        <input
-          type="email"
-          placeholder="Email"
-          value={email}
-          onChange={(e) => setEmail(e.target.value)}
-          autoComplete="email"
-          className="form-input"
-        />
-        <input
-          type="password"
-          placeholder="Password"
-          value={password}
-          onChange={(e) => setPassword(e.target.value)}
-          autoComplete="current-password"
-          className="form-input"
-        />
+        {mfaToken ? (
+          <input
+            type="text"
+            inputMode="numeric"
+            placeholder="Authentication or recovery code"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+            autoComplete="one-time-code"
+            className="form-input"
+          />
+        ) : (
+          <>
+            <input
+              type="email"
+              placeholder="Email"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+              autoComplete="email"
+              className="form-input"
+            />
+            <input
+              type="password"
+              placeholder="Password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              autoComplete="current-password"
+              className="form-input"
+            />
+          </>
+        )}
         <button type="submit" className="login-button" disabled={loading}>
           {loading ? 'Logging in...' : 'Login'}
         </button>
+        {!mfaToken && (
+          <a href={oidcLoginUrl('mock')} className="login-button oidc-button">
+            Sign in with Mock ID
+          </a>
+        )}
         <p className="register-link">
           Don't have an account? <Link to="/register">Register here</Link>
         </p>
@@ -0,0 +1,65 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Link, useNavigate } from 'react-router-dom';
+import { useAuth } from '../../context/AuthContext';
+
+import '../../styles/Auth/Login.css';
+
+const errorMessages = {
+  email_not_verified: 'The provider has not verified your email address.',
+  account_email_unverified: 'An account with this email exists but is not verified. Log in with your password and verify the email first.',
+  identity_linked_elsewhere: 'This external account is already linked to another user.',
+};
+
+// Auth Service возвращает результат входа через провайдера во фрагменте URL
+const OAuthCallback = () => {
+  const [error, setError] = useState('');
+  const { login: loginUser } = useAuth();
+  const navigate = useNavigate();
+  const handled = useRef(false);
+
+  useEffect(() => {
+    if (handled.current) return;
+    handled.current = true;
+
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    // Убираем токены из адресной строки и истории браузера
+    window.history.replaceState(null, '', window.location.pathname);
+
+    if (params.get('mfa_token')) {
+      navigate('/login', { replace: true, state: { mfaToken: params.get('mfa_token') } });
+      return;
+    }
+
+    const token = params.get('token');
+    if (!token) {
+      setError(errorMessages[params.get('error')] || 'Sign in failed. Please try again.');
+      return;
+    }
+
+    loginUser({
+      user: JSON.parse(params.get('user')),
+      token,
+      refresh_token: params.get('refresh_token'),
+    });
+    navigate('/', { replace: true });
+  }, [loginUser, navigate]);
+
+  return (
+    <div className="login-container">
+      <div className="login-form">
+        {error ? (
+          <>
+            <p className="error-message">{error}</p>
+            <p className="register-link">
+              <Link to="/login">Back to login</Link>
+            </p>
+          </>
+        ) : (
+          <p>Signing in...</p>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default OAuthCallback;
@@ -11,6 +11,7 @@ const Register = () => {
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
   const [error, setError] = useState('');
+  const [passwordErrors, setPasswordErrors] = useState([]);
   const [loading, setLoading] = useState(false);
   const [captchaToken, setCaptchaToken] = useState('');
 
@@ -27,6 +28,7 @@ const Register = () => {
   const handleSubmit = async (e) => {
     e.preventDefault();
     setError('');
+    setPasswordErrors([]);
     setLoading(true);
 
     if (!captchaToken) {
@@ -43,14 +45,21 @@ const Register = () => {
       const loginResponse = await apiLogin(email, password);
 
       // Сохраняем токен и данные пользователя
-      const { token, user } = loginResponse.data;
+      const { token, refresh_token: refreshToken, user } = loginResponse.data;
       localStorage.setItem('token', token);
+      localStorage.setItem('refreshToken', refreshToken);
       setAuthToken(token);
       setAuthUser(user);
 
       // Перенаправляем на главную страницу
       navigate('/');
     } catch (error) {
+      // Сервер перечисляет все нарушенные правила политики паролей
+      if (error.response?.data?.errors) {
+        setPasswordErrors(error.response.data.errors);
+        setError('Пароль не соответствует требованиям:');
+        return;
+      }
       setError('Не удалось зарегистрироваться или войти. Пожалуйста, попробуйте снова.');
       console.error('Ошибка при регистрации или входе:', error);
     } finally {
@@ -63,6 +72,13 @@ const Register = () => {
       <form className="register-form" onSubmit={handleSubmit}>
         <h2>Регистрация</h2>
         {error && <p className="error-message">{error}</p>}
+        {passwordErrors.length > 0 && (
+          <ul className="error-message">
+            {passwordErrors.map((item) => (
+              <li key={item.rule}>{item.message}</li>
+            ))}
+          </ul>
+        )}
         <input
           type="text"
           placeholder="Имя пользователя"
@@ -3,29 +3,26 @@ import { Link } from 'react-router-dom';
 import '../../styles/Blog/LikeButton.css';
 import { toggleLike, fetchLikes } from '../../api/api';
 
-const LikeButton = ({ postId, initialLikes = [], currentUserId, onLikeChange }) => {
-  const [liked, setLiked] = useState(false);
-  const [likes, setLikes] = useState(initialLikes);
+const LikeButton = ({ postId, initialCount = 0, initialLiked = false, currentUserId, onLikeChange }) => {
+  const [liked, setLiked] = useState(initialLiked);
+  const [likesCount, setLikesCount] = useState(initialCount);
   const [showTooltip, setShowTooltip] = useState(false);
   const [tooltipData, setTooltipData] = useState([]);
+  const [tooltipHasMore, setTooltipHasMore] = useState(false);
   const timerRef = useRef(null);
 
   useEffect(() => {
-    const validLikes = Array.isArray(likes) ? likes : [];
-    const userHasLiked = validLikes.some((like) => like.id === currentUserId);
-    setLiked(userHasLiked);
-    setTooltipData(validLikes);
-  }, [likes, currentUserId]);
+    setLiked(initialLiked);
+    setLikesCount(initialCount);
+  }, [initialLiked, initialCount]);
 
   const handleLike = async () => {
     try {
-      const updatedLikes = await toggleLike(postId, currentUserId, liked);
-      setLikes(updatedLikes);
+      const result = await toggleLike(postId, currentUserId, liked);
+      setLiked(result.liked);
+      setLikesCount(result.likesCount);
 
-      const userHasLiked = updatedLikes.some((like) => like.id === currentUserId);
-      setLiked(userHasLiked);
-
-      onLikeChange(updatedLikes);
+      onLikeChange(result);
     } catch (error) {
       console.error('Ошибка при изменении лайка:', error);
     }
@@ -36,8 +33,10 @@ const LikeButton = ({ postId, initialLikes = [], currentUserId, onLikeChange })
 
     timerRef.current = setTimeout(async () => {
       try {
-        const data = await fetchLikes(postId);
-        setTooltipData(data || []);
+        // В подсказке только первая страница лайкнувших
+        const page = await fetchLikes(postId);
+        setTooltipData(page.items);
+        setTooltipHasMore(Boolean(page.next_cursor));
         setShowTooltip(true);
       } catch (error) {
         console.error('Ошибка при загрузке лайков:', error);
@@ -59,7 +58,7 @@ const LikeButton = ({ postId, initialLikes = [], currentUserId, onLikeChange })
       style={{ position: 'relative', cursor: 'pointer' }}
     >
     <span className="heart">{liked ? '❤️' : '🤍'}</span>
-    <span className="like-count">{likes.length}</span>
+    <span className="like-count">{likesCount}</span>
 
       {showTooltip && (
         <div
@@ -83,6 +82,7 @@ const LikeButton = ({ postId, initialLikes = [], currentUserId, onLikeChange })
                   </li>
                 ))}
               </ul>
+              {tooltipHasMore && <p>и другие</p>}
             </>
           ) : (
             <p>Никто не лайкнул этот пост</p>
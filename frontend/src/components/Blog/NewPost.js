@@ -1,14 +1,51 @@
-import React, { useState } from 'react';
+import React, { useState, useEffect, useRef } from 'react';
 import '../../styles/Blog/NewPost.css';
-import { createPost } from '../../api/api';
+import { createPost, searchUsers } from '../../api/api';
 import MicrophoneButton from './MicrophoneButton';
 
+// Упоминание, которое пользователь набирает перед курсором: @ в начале слова
+const MENTION_BEFORE_CURSOR = /(^|\s)@([\p{L}\p{N}_.-]{1,50})$/u;
+
+// Задержка перед запросом подсказок, чтобы не искать на каждое нажатие
+const MENTION_DEBOUNCE_MS = 200;
+
 const NewPost = ({ onPostCreated }) => {
   const [title, setTitle] = useState('');
   const [content, setContent] = useState('');
+  const [status, setStatus] = useState('published');
+  const [publishAt, setPublishAt] = useState(''); // значение datetime-local в местном времени
   const [errorMessage, setErrorMessage] = useState('');
   const [successMessage, setSuccessMessage] = useState('');
   const [isWaiting, setIsWaiting] = useState(false); // Флаг ожидания результата распознавания
+  const [mention, setMention] = useState(null); // { query, start } — набираемое упоминание
+  const [suggestions, setSuggestions] = useState([]);
+  const [activeSuggestion, setActiveSuggestion] = useState(0);
+  const textareaRef = useRef(null);
+
+  useEffect(() => {
+    if (!mention) {
+      setSuggestions([]);
+      return undefined;
+    }
+
+    let cancelled = false;
+    const timer = setTimeout(async () => {
+      try {
+        const page = await searchUsers(mention.query);
+        if (!cancelled) {
+          setSuggestions(page.items);
+          setActiveSuggestion(0);
+        }
+      } catch (error) {
+        console.error('Failed to search users:', error);
+      }
+    }, MENTION_DEBOUNCE_MS);
+
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [mention]);
 
   const handleSubmit = async (e) => {
     e.preventDefault();
@@ -17,13 +54,26 @@ const NewPost = ({ onPostCreated }) => {
       return;
     }
 
+    if (status === 'scheduled' && !publishAt) {
+      setErrorMessage('Choose when to publish the post.');
+      return;
+    }
+
     try {
-      const response = await createPost(title, content);
+      const options = status === 'scheduled' ? { status, publishAt: new Date(publishAt).toISOString() } : { status };
+      const response = await createPost(title, content, options);
       setErrorMessage('');
-      setSuccessMessage('Post created successfully!');
-      onPostCreated(response.data);
+      if (status === 'published') {
+        setSuccessMessage('Post created successfully!');
+        onPostCreated(response.data);
+      } else {
+        // Черновики и отложенные посты видны только в профиле автора
+        setSuccessMessage(status === 'draft' ? 'Draft saved.' : 'Post scheduled.');
+      }
       setTitle('');
       setContent('');
+      setPublishAt('');
+      setMention(null);
     } catch (error) {
       console.error('Failed to create post:', error);
       setErrorMessage('Failed to create post. Please try again later.');
@@ -42,6 +92,52 @@ const NewPost = ({ onPostCreated }) => {
   const handleContentChange = (e) => {
     if (!isWaiting) {
       setContent(e.target.value);
+      updateMention(e.target.value, e.target.selectionStart);
+    }
+  };
+
+  const updateMention = (text, cursor) => {
+    const match = text.slice(0, cursor).match(MENTION_BEFORE_CURSOR);
+    if (!match) {
+      setMention(null);
+      return;
+    }
+    const query = match[2];
+    setMention((prev) =>
+      prev && prev.query === query ? prev : { query, start: cursor - query.length - 1 }
+    );
+  };
+
+  // Заменяет набираемое упоминание выбранным username
+  const applySuggestion = (username) => {
+    const end = mention.start + mention.query.length + 1;
+    const inserted = `@${username} `;
+    setContent(content.slice(0, mention.start) + inserted + content.slice(end));
+    setMention(null);
+
+    const cursor = mention.start + inserted.length;
+    requestAnimationFrame(() => {
+      if (textareaRef.current) {
+        textareaRef.current.focus();
+        textareaRef.current.setSelectionRange(cursor, cursor);
+      }
+    });
+  };
+
+  const handleContentKeyDown = (e) => {
+    if (!mention || suggestions.length === 0) return;
+
+    if (e.key === 'ArrowDown') {
+      e.preventDefault();
+      setActiveSuggestion((i) => (i + 1) % suggestions.length);
+    } else if (e.key === 'ArrowUp') {
+      e.preventDefault();
+      setActiveSuggestion((i) => (i - 1 + suggestions.length) % suggestions.length);
+    } else if (e.key === 'Enter' || e.key === 'Tab') {
+      e.preventDefault();
+      applySuggestion(suggestions[activeSuggestion].username);
+    } else if (e.key === 'Escape') {
+      setMention(null);
     }
   };
 
@@ -57,18 +153,64 @@ const NewPost = ({ onPostCreated }) => {
             value={title}
             onChange={(e) => setTitle(e.target.value)}
           />
-          <textarea
-            className="new-post-textarea"
-            placeholder="Post Content"
-            value={isWaiting ? 'Подождите...' : content}
-            onChange={handleContentChange}
-            readOnly={isWaiting}
-          />
+          <div className="new-post-content">
+            <textarea
+              ref={textareaRef}
+              className="new-post-textarea"
+              placeholder="Post Content"
+              value={isWaiting ? 'Подождите...' : content}
+              onChange={handleContentChange}
+              onKeyDown={handleContentKeyDown}
+              onBlur={() => setMention(null)}
+              readOnly={isWaiting}
+            />
+            {mention && suggestions.length > 0 && (
+              <ul className="mention-suggestions">
+                {suggestions.map((user, i) => (
+                  <li
+                    key={user.id}
+                    className={i === activeSuggestion ? 'active' : ''}
+                    // mousedown, а не click: иначе textarea потеряет фокус и закроет список раньше
+                    onMouseDown={(e) => {
+                      e.preventDefault();
+                      applySuggestion(user.username);
+                    }}
+                  >
+                    {user.avatar_urls ? (
+                      <img className="mention-avatar" src={user.avatar_urls['64']} alt="" />
+                    ) : (
+                      <span className="mention-avatar placeholder">{user.username.charAt(0).toUpperCase()}</span>
+                    )}
+                    <span className="mention-username">@{user.username}</span>
+                    {user.display_name && <span className="mention-name">{user.display_name}</span>}
+                  </li>
+                ))}
+              </ul>
+            )}
+          </div>
           <div className="button-container">
             <div className="button-left">
               <button type="submit" className="action-button submit-button">
-                Submit
+                {status === 'published' ? 'Submit' : status === 'draft' ? 'Save draft' : 'Schedule'}
               </button>
+              <select
+                className="publish-mode"
+                value={status}
+                onChange={(e) => setStatus(e.target.value)}
+                aria-label="Publication"
+              >
+                <option value="published">Опубликовать сейчас</option>
+                <option value="draft">Черновик</option>
+                <option value="scheduled">Запланировать</option>
+              </select>
+              {status === 'scheduled' && (
+                <input
+                  type="datetime-local"
+                  className="publish-at"
+                  value={publishAt}
+                  onChange={(e) => setPublishAt(e.target.value)}
+                />
+              )}
             </div>
             <div className="button-right">
               <MicrophoneButton
@@ -1,10 +1,18 @@
 import React, { useState } from 'react';
 import { Link } from 'react-router-dom';
 import '../../styles/Blog/Post.css';
+import { updatePost } from '../../api/api';
 import LikeButton from './LikeButton';
+import PostHistory from './PostHistory';
+import PostContent from './PostContent';
 
 const Post = ({ post, currentUserId, canDelete, onDelete }) => {
   const [isModalOpen, setIsModalOpen] = useState(false);
+  const [current, setCurrent] = useState(post); // пост с учётом правок
+  const [isEditing, setIsEditing] = useState(false);
+  const [draft, setDraft] = useState({ title: post.title, content: post.content });
+  const [editError, setEditError] = useState('');
+  const [isHistoryOpen, setIsHistoryOpen] = useState(false);
   const isOwner = post.authorId === currentUserId;
 
   const openModal = () => setIsModalOpen(true);
@@ -21,31 +29,124 @@ const Post = ({ post, currentUserId, canDelete, onDelete }) => {
     }
   };
 
+  const handlePublish = async () => {
+    try {
+      const updated = await updatePost(current.id, { status: 'published' });
+      setCurrent((prev) => ({ ...prev, ...updated }));
+    } catch (error) {
+      console.error('Failed to publish post:', error);
+      alert('Failed to publish the post. Please try again later.');
+    }
+  };
+
+  const startEditing = () => {
+    setDraft({ title: current.title, content: current.content });
+    setEditError('');
+    setIsEditing(true);
+  };
+
+  const handleSave = async (e) => {
+    e.preventDefault();
+    if (!draft.title.trim() || !draft.content.trim()) {
+      setEditError('Both fields are required!');
+      return;
+    }
+    try {
+      const updated = await updatePost(current.id, draft);
+      setCurrent((prev) => ({ ...prev, ...updated }));
+      setIsEditing(false);
+    } catch (error) {
+      console.error('Failed to update post:', error);
+      setEditError('Failed to save changes. Please try again later.');
+    }
+  };
+
   return (
     <div className="post-card">
-      <h3>
-        <Link to={`/post/${post.id}`} className="post-title-link">
-          {post.title}
-        </Link>
-      </h3>
-      <p>{post.content}</p>
+      {isEditing ? (
+        <form className="post-edit-form" onSubmit={handleSave}>
+          <input
+            type="text"
+            value={draft.title}
+            onChange={(e) => setDraft({ ...draft, title: e.target.value })}
+          />
+          <textarea
+            value={draft.content}
+            onChange={(e) => setDraft({ ...draft, content: e.target.value })}
+          />
+          {editError && <div className="error-message">{editError}</div>}
+          <div className="post-edit-actions">
+            <button type="submit" className="save-button">
+              Сохранить
+            </button>
+            <button type="button" className="cancel-button" onClick={() => setIsEditing(false)}>
+              Отмена
+            </button>
+          </div>
+        </form>
+      ) : (
+        <>
+          <h3>
+            <Link to={`/post/${post.id}`} className="post-title-link">
+              {current.title}
+            </Link>
+          </h3>
+          <p>
+            <PostContent content={current.content} tags={current.tags} mentions={current.mentions} />
+          </p>
+        </>
+      )}
       <div className="post-footer">
         <div className="post-footer-left">
           <LikeButton
             postId={post.id}
-            initialLikes={post.likes}
+            initialCount={post.likesCount}
+            initialLiked={post.likedByMe}
             currentUserId={currentUserId}
-            onLikeChange={(updatedLikes) => {
-              post.likes = updatedLikes;
+            onLikeChange={({ liked, likesCount }) => {
+              post.likedByMe = liked;
+              post.likesCount = likesCount;
             }}
           />
+          <Link to={`/post/${post.id}`} className="post-comments-link" title="Комментарии">
+            💬 {post.commentsCount ?? 0}
+          </Link>
         </div>
 
         <div className="post-footer-right">
+          {current.status === 'draft' && <span className="post-status">Черновик</span>}
+          {current.status === 'scheduled' && (
+            <span className="post-status">
+              Публикация {new Date(current.publishAt).toLocaleString()}
+            </span>
+          )}
+          {isOwner && current.status && current.status !== 'published' && (
+            <button type="button" className="publish-button" onClick={handlePublish}>
+              Опубликовать
+            </button>
+          )}
+
+          {current.edited && (
+            <button
+              type="button"
+              className="post-edited"
+              title={new Date(current.updatedAt).toLocaleString()}
+              onClick={() => setIsHistoryOpen(true)}
+            >
+              изменено
+            </button>
+          )}
+
           <Link to={`/profile/${post.authorUsername}`} className="post-author">
             {isOwner ? 'By You' : `By ${post.authorUsername || 'Unknown'}`}
           </Link>
 
+          {isOwner && !isEditing && (
+            <button type="button" className="edit-button" onClick={startEditing} title="Редактировать">
+              ✎
+            </button>
+          )}
+
           {canDelete && isOwner && (
             <button className="delete-button" onClick={openModal}>
               ❌
@@ -54,6 +155,8 @@ const Post = ({ post, currentUserId, canDelete, onDelete }) => {
         </div>
       </div>
 
+      {isHistoryOpen && <PostHistory postId={post.id} onClose={() => setIsHistoryOpen(false)} />}
+
       {isModalOpen && (
         <div className="modal-overlay" onClick={handleOverlayClick}>
           <div className="modal-container">
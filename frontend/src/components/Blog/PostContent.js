@@ -0,0 +1,60 @@
+import React from 'react';
+import { Link } from 'react-router-dom';
+
+// Хэштег по тем же правилам, что и на сервере: # в начале текста или после
+// символа не из слова, затем буквы, цифры и подчёркивания
+const HASHTAG = /(^|[^\p{L}\p{N}_])#([\p{L}\p{N}_]+)/gu;
+
+// Текст поста, в котором теги из tags ведут на страницы тегов, а упоминания
+// из mentions — на профили. И то и другое распознал сервер.
+const PostContent = ({ content, tags, mentions }) => {
+  const known = new Set(tags || []);
+  const links = [];
+
+  for (const match of content.matchAll(HASHTAG)) {
+    const [, before, word] = match;
+    const tag = word.toLowerCase();
+    if (!known.has(tag)) continue;
+
+    const start = match.index + before.length;
+    links.push({
+      start,
+      end: start + 1 + word.length,
+      node: (
+        <Link key={`tag-${start}`} to={`/tags/${encodeURIComponent(tag)}`} className="post-hashtag">
+          #{word}
+        </Link>
+      ),
+    });
+  }
+
+  // Сервер считает смещения упоминаний в символах Unicode, а индексы строк JS — в UTF-16
+  const chars = Array.from(content);
+  for (const mention of mentions || []) {
+    const start = chars.slice(0, mention.offset).join('').length;
+    const text = chars.slice(mention.offset, mention.offset + mention.length).join('');
+    links.push({
+      start,
+      end: start + text.length,
+      node: (
+        <Link key={`mention-${start}`} to={`/profile/${encodeURIComponent(mention.username)}`} className="post-mention">
+          {text}
+        </Link>
+      ),
+    });
+  }
+  if (links.length === 0) return content;
+
+  links.sort((a, b) => a.start - b.start);
+  const parts = [];
+  let last = 0;
+  for (const link of links) {
+    if (link.start < last) continue;
+    parts.push(content.slice(last, link.start), link.node);
+    last = link.end;
+  }
+  parts.push(content.slice(last));
+  return <>{parts}</>;
+};
+
+export default PostContent;
@@ -0,0 +1,105 @@
+import React, { useState, useEffect } from 'react';
+import { fetchRevisions, fetchRevisionDiff } from '../../api/api';
+
+// Фрагменты сравнения: вставки и удаления выделяются, общий текст выводится как есть
+const Diff = ({ chunks }) => (
+  <>
+    {chunks.map((chunk, i) => {
+      if (chunk.op === 'insert') return <ins key={i}>{chunk.text}</ins>;
+      if (chunk.op === 'delete') return <del key={i}>{chunk.text}</del>;
+      return <span key={i}>{chunk.text}</span>;
+    })}
+  </>
+);
+
+// История правок поста: список ревизий и изменения выбранной ревизии
+// относительно предыдущей
+const PostHistory = ({ postId, onClose }) => {
+  const [revisions, setRevisions] = useState([]);
+  const [selected, setSelected] = useState(null);
+  const [diff, setDiff] = useState(null);
+  const [error, setError] = useState(null);
+
+  useEffect(() => {
+    fetchRevisions(postId)
+      .then((items) => {
+        setRevisions(items);
+        if (items.length > 0) setSelected(items[0].revision);
+      })
+      .catch((err) => {
+        console.error('Failed to fetch revisions:', err);
+        setError('Failed to load history.');
+      });
+  }, [postId]);
+
+  useEffect(() => {
+    if (!selected || selected === 1) {
+      setDiff(null);
+      return;
+    }
+    fetchRevisionDiff(postId, selected - 1, selected)
+      .then(setDiff)
+      .catch((err) => {
+        console.error('Failed to fetch diff:', err);
+        setError('Failed to load changes.');
+      });
+  }, [postId, selected]);
+
+  const handleOverlayClick = (e) => {
+    if (e.target.classList.contains('modal-overlay')) {
+      onClose();
+    }
+  };
+
+  const original = revisions.find((rev) => rev.revision === 1);
+
+  return (
+    <div className="modal-overlay" onClick={handleOverlayClick}>
+      <div className="modal-container post-history">
+        <div className="post-history-header">
+          <h4>История правок</h4>
+          <button type="button" className="cancel-button" onClick={onClose}>
+            Закрыть
+          </button>
+        </div>
+        {error && <p>{error}</p>}
+        <div className="post-history-body">
+          <ul className="post-history-revisions">
+            {revisions.map((rev) => (
+              <li key={rev.revision}>
+                <button
+                  type="button"
+                  className={rev.revision === selected ? 'active' : ''}
+                  onClick={() => setSelected(rev.revision)}
+                >
+                  {rev.revision === 1 ? 'Original' : `Revision ${rev.revision}`}
+                  <span>{new Date(rev.createdAt).toLocaleString()}</span>
+                </button>
+              </li>
+            ))}
+          </ul>
+          <div className="post-history-diff">
+            {selected === 1 && original && (
+              <>
+                <h3>{original.title}</h3>
+                <p>{original.content}</p>
+              </>
+            )}
+            {selected > 1 && diff && (
+              <>
+                <h3>
+                  <Diff chunks={diff.title} />
+                </h3>
+                <p>
+                  <Diff chunks={diff.content} />
+                </p>
+              </>
+            )}
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default PostHistory;
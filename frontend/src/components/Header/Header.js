@@ -1,5 +1,5 @@
-import React, { useEffect } from 'react';
-import { Link } from 'react-router-dom';
+import React, { useEffect, useState } from 'react';
+import { Link, useNavigate } from 'react-router-dom';
 import { useAuth } from '../../context/AuthContext';
 import Notifications from './Notifications';
 import Menu from './Menu';
@@ -8,6 +8,8 @@ import '../../styles/Header/Header.css';
 
 const Header = () => {
   const { isAuthenticated, user } = useAuth();
+  const navigate = useNavigate();
+  const [query, setQuery] = useState('');
 
   useEffect(() => {
     document.body.classList.add('with-header');
@@ -16,6 +18,14 @@ const Header = () => {
     };
   }, []);
 
+  const handleSearch = (e) => {
+    e.preventDefault();
+    const q = query.trim();
+    if (q) {
+      navigate(`/search?q=${encodeURIComponent(q)}`);
+    }
+  };
+
   return (
     <header className="header">
       <nav className="header-nav">
@@ -27,6 +37,14 @@ const Header = () => {
         <div className="header-right">
           {isAuthenticated && user && (
             <>
+              <form className="header-search" onSubmit={handleSearch}>
+                <input
+                  type="search"
+                  placeholder="Search posts"
+                  value={query}
+                  onChange={(e) => setQuery(e.target.value)}
+                />
+              </form>
               <Notifications userId={user.id} />
               <Menu user={user} />
             </>
@@ -7,16 +7,18 @@ const Notifications = ({ userId }) => {
   const [notifications, setNotifications] = useState([]);
   const [showDropdown, setShowDropdown] = useState(false);
   const [unreadCount, setUnreadCount] = useState(0); // Отдельное состояние для непрочитанных уведомлений
+  const [nextCursor, setNextCursor] = useState('');
   const dropdownRef = useRef(null);
 
   // Загружаем уведомления
   useEffect(() => {
     if (userId) {
       fetchNotifications(userId)
-        .then((data) => {
-          setNotifications(data || []);
-          const count = (data || []).filter((n) => !n.isRead).length;
-          setUnreadCount(count); // Устанавливаем количество непрочитанных уведомлений
+        .then((page) => {
+          setNotifications(page.items);
+          setNextCursor(page.next_cursor || '');
+          const count = page.items.filter((n) => !n.isRead).length;
+          setUnreadCount(count); // Непрочитанные среди загруженных уведомлений
         })
         .catch((error) => console.error('Failed to fetch notifications:', error));
     }
@@ -51,10 +53,23 @@ const Notifications = ({ userId }) => {
     };
   }, [showDropdown, markAllAsReadOnServer]);
 
+  // Догружаем следующую страницу уведомлений
+  const handleLoadMore = () => {
+    fetchNotifications(userId, nextCursor)
+      .then((page) => {
+        setNotifications((prev) => [...prev, ...page.items]);
+        setNextCursor(page.next_cursor || '');
+      })
+      .catch((error) => console.error('Failed to fetch notifications:', error));
+  };
+
   // Обработчик очистки уведомлений
   const handleClearNotifications = () => {
     clearNotifications(userId)
-      .then(() => setNotifications([]))
+      .then(() => {
+        setNotifications([]);
+        setNextCursor('');
+      })
       .catch((error) => console.error('Failed to clear notifications:', error));
   };
 
@@ -107,6 +122,68 @@ const Notifications = ({ userId }) => {
               </a>
               .
             </>
+          ) : notification.type === 'follow' ? (
+            <>
+              Пользователь{' '}
+              <a
+                href={`/profile/${notification.likerUsername}`}
+                className="link"
+                target="_blank"
+                rel="noopener noreferrer"
+                onClick={() => markNotificationAsRead(notification.id)}
+              >
+                {notification.likerUsername}
+              </a>{' '}
+              подписался на вас.
+            </>
+          ) : notification.type === 'comment' ? (
+            <>
+              Пользователь{' '}
+              <a
+                href={`/profile/${notification.likerUsername}`}
+                className="link"
+                target="_blank"
+                rel="noopener noreferrer"
+                onClick={() => markNotificationAsRead(notification.id)}
+              >
+                {notification.likerUsername}
+              </a>{' '}
+              прокомментировал ваш{' '}
+              <a
+                href={`/post/${notification.postId}`}
+                className="link"
+                target="_blank"
+                rel="noopener noreferrer"
+                onClick={() => markNotificationAsRead(notification.id)}
+              >
+                пост
+              </a>
+              .
+            </>
+          ) : notification.type === 'mention' ? (
+            <>
+              Пользователь{' '}
+              <a
+                href={`/profile/${notification.likerUsername}`}
+                className="link"
+                target="_blank"
+                rel="noopener noreferrer"
+                onClick={() => markNotificationAsRead(notification.id)}
+              >
+                {notification.likerUsername}
+              </a>{' '}
+              упомянул вас в{' '}
+              <a
+                href={`/post/${notification.postId}`}
+                className="link"
+                target="_blank"
+                rel="noopener noreferrer"
+                onClick={() => markNotificationAsRead(notification.id)}
+              >
+                посте
+              </a>
+              .
+            </>
           ) : (
             notification.message
           )}
@@ -135,6 +212,11 @@ const Notifications = ({ userId }) => {
           ) : (
             notifications.map(renderNotification)
           )}
+          {nextCursor && (
+            <button onClick={handleLoadMore} className="load-more-notifications-button">
+              Показать ещё
+            </button>
+          )}
         </div>
       )}
     </div>
@@ -1,7 +1,8 @@
 import React, { useState, useEffect } from 'react';
 import PostList from '../Blog/PostList';
 import NewPost from '../Blog/NewPost';
-import { fetchPosts, fetchPostById } from '../../api/api';
+import TrendingTags from '../Tags/TrendingTags';
+import { fetchPosts, fetchPostById, fetchFeed } from '../../api/api';
 import { useAuth } from '../../context/AuthContext';
 import '../../styles/MainPage/MainPage.css';
 
@@ -11,6 +12,9 @@ const MainPage = () => {
   const [isLoadingUser, setIsLoadingUser] = useState(true); // Индикатор загрузки пользователя
   const [isLoadingPosts, setIsLoadingPosts] = useState(true); // Индикатор загрузки постов
   const [error, setError] = useState(null); // Ошибки загрузки
+  const [view, setView] = useState('feed'); // feed — подписки, all — все посты
+  const [nextCursor, setNextCursor] = useState('');
+  const [isLoadingMore, setIsLoadingMore] = useState(false);
 
   // Эффект для проверки готовности пользователя
   useEffect(() => {
@@ -29,8 +33,10 @@ const MainPage = () => {
     const loadPosts = async () => {
       try {
         setIsLoadingPosts(true); // Устанавливаем состояние загрузки постов
-        const response = await fetchPosts();
-        setPosts(response.data || []);
+        setError(null);
+        const page = view === 'feed' ? await fetchFeed() : await fetchPosts();
+        setPosts(page.items);
+        setNextCursor(page.next_cursor || '');
       } catch (error) {
         console.error('Failed to fetch posts:', error);
         setError('Failed to fetch posts.');
@@ -40,7 +46,7 @@ const MainPage = () => {
     };
 
     loadPosts();
-  }, [user]);
+  }, [user, view]);
 
   // Показываем лоадер, если данные пользователя или постов загружаются
   if (isLoadingUser || isLoadingPosts) {
@@ -56,12 +62,35 @@ const MainPage = () => {
     setPosts((prevPosts) => [newPost, ...prevPosts]);
   };
 
+  const handleLoadMore = async () => {
+    setIsLoadingMore(true);
+    try {
+      const page = view === 'feed' ? await fetchFeed(nextCursor) : await fetchPosts(nextCursor);
+      setPosts((prevPosts) => [...prevPosts, ...page.items]);
+      setNextCursor(page.next_cursor || '');
+    } catch (error) {
+      console.error('Failed to fetch posts:', error);
+      alert('Failed to load more posts. Please try again.');
+    } finally {
+      setIsLoadingMore(false);
+    }
+  };
+
   return (
     <div>
       <div className="main-page-container">
         <div className="main-page-new-post">
           <NewPost onPostCreated={handlePostCreated} />
         </div>
+        <TrendingTags />
+        <div className="main-page-tabs">
+          <button type="button" className={view === 'feed' ? 'active' : ''} onClick={() => setView('feed')}>
+            My feed
+          </button>
+          <button type="button" className={view === 'all' ? 'active' : ''} onClick={() => setView('all')}>
+            All posts
+          </button>
+        </div>
         <div className="main-page-posts">
           <PostList 
             posts={posts}
@@ -69,6 +98,11 @@ const MainPage = () => {
             canDelete={false}
             isOwnProfile={false}
           />
+          {nextCursor && (
+            <button type="button" className="load-more-button" onClick={handleLoadMore} disabled={isLoadingMore}>
+              {isLoadingMore ? 'Loading...' : 'Load more'}
+            </button>
+          )}
         </div>
       </div>
     </div>
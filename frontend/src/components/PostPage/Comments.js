@@ -0,0 +1,291 @@
+import React, { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import { fetchComments, createComment, updateComment, deleteComment } from '../../api/api';
+import '../../styles/PostPage/Comments.css';
+
+// Форма нового комментария или ответа
+const CommentForm = ({ onSubmit, placeholder, autoFocus, onCancel }) => {
+  const [content, setContent] = useState('');
+  const [error, setError] = useState('');
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    if (!content.trim()) return;
+    try {
+      await onSubmit(content);
+      setContent('');
+      setError('');
+    } catch (err) {
+      console.error('Failed to post comment:', err);
+      setError('Не удалось отправить комментарий.');
+    }
+  };
+
+  return (
+    <form className="comment-form" onSubmit={handleSubmit}>
+      <textarea
+        value={content}
+        placeholder={placeholder}
+        autoFocus={autoFocus}
+        maxLength={2000}
+        onChange={(e) => setContent(e.target.value)}
+      />
+      {error && <div className="error-message">{error}</div>}
+      <div className="comment-form-actions">
+        <button type="submit" className="save-button" disabled={!content.trim()}>
+          Отправить
+        </button>
+        {onCancel && (
+          <button type="button" className="cancel-button" onClick={onCancel}>
+            Отмена
+          </button>
+        )}
+      </div>
+    </form>
+  );
+};
+
+// Отдельный комментарий; onReply передаётся только комментариям верхнего уровня
+const Comment = ({ comment, postId, postAuthorId, currentUserId, onChange, onDelete, onReply }) => {
+  const [isEditing, setIsEditing] = useState(false);
+  const [draft, setDraft] = useState(comment.content);
+  const [error, setError] = useState('');
+  const isAuthor = comment.authorId === currentUserId;
+  // Окно правки проверяет сервер; здесь кнопка лишь скрывается после его окончания
+  const canEdit = isAuthor && new Date(comment.editableUntil) > new Date();
+  const canDelete = isAuthor || postAuthorId === currentUserId;
+
+  const handleSave = async (e) => {
+    e.preventDefault();
+    if (!draft.trim()) return;
+    try {
+      const updated = await updateComment(postId, comment.id, draft);
+      onChange(updated);
+      setIsEditing(false);
+      setError('');
+    } catch (err) {
+      console.error('Failed to update comment:', err);
+      setError(err.response?.status === 403 ? 'Время на правку истекло.' : 'Не удалось сохранить изменения.');
+    }
+  };
+
+  const handleDelete = async () => {
+    if (!window.confirm('Удалить комментарий?')) return;
+    try {
+      await deleteComment(postId, comment.id);
+      onDelete(comment);
+    } catch (err) {
+      console.error('Failed to delete comment:', err);
+    }
+  };
+
+  return (
+    <div className="comment">
+      <div className="comment-header">
+        <Link to={`/profile/${comment.authorUsername}`} className="comment-author">
+          {comment.authorUsername}
+        </Link>
+        <span className="comment-time">{new Date(comment.createdAt).toLocaleString()}</span>
+        {comment.edited && <span className="comment-edited">изменено</span>}
+      </div>
+      {isEditing ? (
+        <form className="comment-form" onSubmit={handleSave}>
+          <textarea value={draft} maxLength={2000} onChange={(e) => setDraft(e.target.value)} />
+          {error && <div className="error-message">{error}</div>}
+          <div className="comment-form-actions">
+            <button type="submit" className="save-button">
+              Сохранить
+            </button>
+            <button type="button" className="cancel-button" onClick={() => setIsEditing(false)}>
+              Отмена
+            </button>
+          </div>
+        </form>
+      ) : (
+        <p className="comment-content">{comment.content}</p>
+      )}
+      <div className="comment-actions">
+        {onReply && currentUserId && (
+          <button type="button" onClick={onReply}>
+            Ответить
+          </button>
+        )}
+        {canEdit && !isEditing && (
+          <button
+            type="button"
+            onClick={() => {
+              setDraft(comment.content);
+              setIsEditing(true);
+            }}
+          >
+            Изменить
+          </button>
+        )}
+        {canDelete && (
+          <button type="button" onClick={handleDelete}>
+            Удалить
+          </button>
+        )}
+      </div>
+    </div>
+  );
+};
+
+// Ветка: комментарий верхнего уровня и ответы на него в порядке написания
+const Thread = ({ comment, postId, postAuthorId, currentUserId, onChange, onDelete }) => {
+  const [replies, setReplies] = useState([]);
+  const [nextCursor, setNextCursor] = useState(null);
+  const [isExpanded, setIsExpanded] = useState(false);
+  const [isReplying, setIsReplying] = useState(false);
+
+  const loadReplies = async (cursor) => {
+    try {
+      const page = await fetchComments(postId, { parentId: comment.id, cursor });
+      setReplies((prev) => (cursor ? [...prev, ...page.items] : page.items));
+      setNextCursor(page.next_cursor || null);
+      setIsExpanded(true);
+    } catch (err) {
+      console.error('Failed to fetch replies:', err);
+    }
+  };
+
+  const handleReply = async (content) => {
+    const reply = await createComment(postId, content, comment.id);
+    onChange({ ...comment, repliesCount: comment.repliesCount + 1 });
+    // Свёрнутая ветка раскрывается вместе с новым ответом; если ответы загружены
+    // не полностью, он появится в конце списка при догрузке
+    if (isExpanded && !nextCursor) {
+      setReplies((prev) => [...prev, reply]);
+    } else if (!isExpanded) {
+      await loadReplies();
+    }
+    setIsReplying(false);
+  };
+
+  const handleReplyChange = (updated) => {
+    setReplies((prev) => prev.map((r) => (r.id === updated.id ? updated : r)));
+  };
+
+  const handleReplyDelete = (deleted) => {
+    setReplies((prev) => prev.filter((r) => r.id !== deleted.id));
+    onChange({ ...comment, repliesCount: comment.repliesCount - 1 });
+  };
+
+  return (
+    <div className="comment-thread">
+      <Comment
+        comment={comment}
+        postId={postId}
+        postAuthorId={postAuthorId}
+        currentUserId={currentUserId}
+        onChange={onChange}
+        onDelete={onDelete}
+        onReply={() => setIsReplying(true)}
+      />
+      <div className="comment-replies">
+        {!isExpanded && comment.repliesCount > 0 && (
+          <button type="button" className="comment-replies-toggle" onClick={() => loadReplies()}>
+            Показать ответы ({comment.repliesCount})
+          </button>
+        )}
+        {isExpanded &&
+          replies.map((reply) => (
+            <Comment
+              key={reply.id}
+              comment={reply}
+              postId={postId}
+              postAuthorId={postAuthorId}
+              currentUserId={currentUserId}
+              onChange={handleReplyChange}
+              onDelete={handleReplyDelete}
+            />
+          ))}
+        {isExpanded && nextCursor && (
+          <button type="button" className="comment-replies-toggle" onClick={() => loadReplies(nextCursor)}>
+            Ещё ответы
+          </button>
+        )}
+        {isReplying && (
+          <CommentForm
+            placeholder={`Ответ для ${comment.authorUsername}`}
+            autoFocus
+            onSubmit={handleReply}
+            onCancel={() => setIsReplying(false)}
+          />
+        )}
+      </div>
+    </div>
+  );
+};
+
+// Комментарии к посту: новые сверху, ответы вложены на один уровень
+const Comments = ({ postId, postAuthorId, currentUserId, onCountChange }) => {
+  const [comments, setComments] = useState([]);
+  const [nextCursor, setNextCursor] = useState(null);
+  const [error, setError] = useState(null);
+
+  const loadComments = async (cursor) => {
+    try {
+      const page = await fetchComments(postId, { cursor });
+      setComments((prev) => (cursor ? [...prev, ...page.items] : page.items));
+      setNextCursor(page.next_cursor || null);
+    } catch (err) {
+      console.error('Failed to fetch comments:', err);
+      setError('Не удалось загрузить комментарии.');
+    }
+  };
+
+  useEffect(() => {
+    loadComments();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [postId]);
+
+  const handleCreate = async (content) => {
+    const comment = await createComment(postId, content);
+    setComments((prev) => [comment, ...prev]);
+    onCountChange?.(1);
+  };
+
+  const handleChange = (updated) => {
+    setComments((prev) => prev.map((c) => (c.id === updated.id ? updated : c)));
+  };
+
+  const handleReplyCountChange = (updated) => {
+    const before = comments.find((c) => c.id === updated.id);
+    if (before && before.repliesCount !== updated.repliesCount) {
+      onCountChange?.(updated.repliesCount - before.repliesCount);
+    }
+    handleChange(updated);
+  };
+
+  const handleDelete = (deleted) => {
+    setComments((prev) => prev.filter((c) => c.id !== deleted.id));
+    // Вместе с комментарием удаляются и ответы на него
+    onCountChange?.(-1 - deleted.repliesCount);
+  };
+
+  return (
+    <div className="comments">
+      {currentUserId && <CommentForm placeholder="Написать комментарий…" onSubmit={handleCreate} />}
+      {error && <p>{error}</p>}
+      {comments.map((comment) => (
+        <Thread
+          key={comment.id}
+          comment={comment}
+          postId={postId}
+          postAuthorId={postAuthorId}
+          currentUserId={currentUserId}
+          onChange={handleReplyCountChange}
+          onDelete={handleDelete}
+        />
+      ))}
+      {nextCursor && (
+        <button type="button" className="comments-load-more" onClick={() => loadComments(nextCursor)}>
+          Показать ещё
+        </button>
+      )}
+    </div>
+  );
+};
+
+export default Comments;
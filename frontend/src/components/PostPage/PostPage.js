@@ -4,6 +4,7 @@ import { useParams } from 'react-router-dom'; // Импортируем useParam
 import { fetchPostById } from '../../api/api'; // Функция для загрузки поста
 
 import Post from '../Blog/Post'; // Импортируем компонент Post
+import Comments from './Comments';
 import '../../styles/PostPage/PostPage.css';
 
 const PostPage = () => { // Получаем пропсы, если нужно
@@ -45,6 +46,14 @@ const PostPage = () => { // Получаем пропсы, если нужно
           currentUserId={user?.id} 
           canDelete={false}
         />
+        <Comments
+          postId={post.id}
+          postAuthorId={post.authorId}
+          currentUserId={user?.id}
+          onCountChange={(delta) =>
+            setPost((prev) => ({ ...prev, commentsCount: (prev.commentsCount ?? 0) + delta }))
+          }
+        />
       </div>
     </div>
   );
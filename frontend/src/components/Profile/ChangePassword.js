@@ -0,0 +1,69 @@
+import React, { useState } from 'react';
+import { changePassword } from '../../api/api';
+
+const ChangePassword = () => {
+  const [currentPassword, setCurrentPassword] = useState('');
+  const [newPassword, setNewPassword] = useState('');
+  const [errors, setErrors] = useState([]);
+  const [message, setMessage] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setErrors([]);
+    setMessage('');
+    setLoading(true);
+    try {
+      await changePassword(currentPassword, newPassword);
+      setCurrentPassword('');
+      setNewPassword('');
+      setMessage('Password changed. Other sessions have been signed out.');
+    } catch (err) {
+      const data = err.response?.data;
+      if (data?.errors) {
+        setErrors(data.errors.map((item) => item.message));
+      } else if (err.response?.status === 403) {
+        setErrors(['Current password is incorrect.']);
+      } else if (err.response?.status === 429) {
+        setErrors(['Too many attempts. Please try again later.']);
+      } else {
+        setErrors(['Failed to change password. Please try again.']);
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <form className="change-password" onSubmit={handleSubmit}>
+      <h2>Change password</h2>
+      {errors.length > 0 && (
+        <ul className="error-message">
+          {errors.map((text) => (
+            <li key={text}>{text}</li>
+          ))}
+        </ul>
+      )}
+      {message && <p className="success-message">{message}</p>}
+      <input
+        type="password"
+        placeholder="Current password"
+        value={currentPassword}
+        onChange={(e) => setCurrentPassword(e.target.value)}
+        autoComplete="current-password"
+      />
+      <input
+        type="password"
+        placeholder="New password"
+        value={newPassword}
+        onChange={(e) => setNewPassword(e.target.value)}
+        autoComplete="new-password"
+      />
+      <button type="submit" disabled={loading || !currentPassword || !newPassword}>
+        {loading ? 'Saving...' : 'Change password'}
+      </button>
+    </form>
+  );
+};
+
+export default ChangePassword;
@@ -0,0 +1,64 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { Link } from 'react-router-dom';
+import { fetchFollows } from '../../api/api';
+
+const titles = { followers: 'Followers', following: 'Following' };
+
+const FollowList = ({ userId, kind, onClose }) => {
+  const [items, setItems] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState('');
+
+  const load = useCallback(async (cursor) => {
+    setLoading(true);
+    setError('');
+    try {
+      const page = await fetchFollows(userId, kind, cursor);
+      setItems((prev) => (cursor ? [...prev, ...page.items] : page.items));
+      setNextCursor(page.next_cursor || '');
+    } catch (err) {
+      console.error('Failed to load follows:', err);
+      setError('Failed to load users.');
+    } finally {
+      setLoading(false);
+    }
+  }, [userId, kind]);
+
+  useEffect(() => {
+    load();
+  }, [load]);
+
+  return (
+    <div className="follow-list">
+      <div className="follow-list-header">
+        <h3>{titles[kind]}</h3>
+        <button type="button" onClick={onClose}>Close</button>
+      </div>
+      {error && <p className="error-message">{error}</p>}
+      {!loading && !error && items.length === 0 && <p className="no-blogs">Nobody here yet.</p>}
+      <ul>
+        {items.map((item) => (
+          <li key={item.id}>
+            <Link to={`/profile/${item.username}`} onClick={onClose}>
+              {item.avatar_urls ? (
+                <img className="follow-avatar" src={item.avatar_urls['64']} alt="" />
+              ) : (
+                <span className="follow-avatar placeholder">{item.username.charAt(0).toUpperCase()}</span>
+              )}
+              <span>{item.display_name || item.username}</span>
+            </Link>
+            {item.mutual && <span className="mutual-badge">Mutual</span>}
+          </li>
+        ))}
+      </ul>
+      {nextCursor && (
+        <button type="button" disabled={loading} onClick={() => load(nextCursor)}>
+          {loading ? 'Loading...' : 'Show more'}
+        </button>
+      )}
+    </div>
+  );
+};
+
+export default FollowList;
@@ -1,8 +1,20 @@
 import React, { useState, useEffect } from 'react';
 import { useParams } from 'react-router-dom';
 import { useAuth } from '../../context/AuthContext';
-import { fetchUserProfile, fetchUserPosts, deletePost } from '../../api/api';
+import {
+  fetchUserProfile,
+  fetchUserPosts,
+  deletePost,
+  followUser,
+  unfollowUser,
+  fetchSubscription,
+  subscribeToAuthor,
+  unsubscribeFromAuthor,
+} from '../../api/api';
 import PostList from '../Blog/PostList';
+import ChangePassword from './ChangePassword';
+import ProfileEditor from './ProfileEditor';
+import FollowList from './FollowList';
 import '../../styles/Profile/Profile.css';
 
 const Profile = () => {
@@ -12,6 +24,11 @@ const Profile = () => {
   const [posts, setPosts] = useState([]);
   const [isLoading, setIsLoading] = useState(true); // Состояние загрузки
   const [error, setError] = useState(null);
+  const [followBusy, setFollowBusy] = useState(false);
+  const [openList, setOpenList] = useState(null); // followers, following или null
+  const [subscribed, setSubscribed] = useState(false); // посты автора попадают в ленту
+  const [nextCursor, setNextCursor] = useState('');
+  const [isLoadingMore, setIsLoadingMore] = useState(false);
 
   const isOwnProfile = username === user?.username;
 
@@ -20,6 +37,11 @@ const Profile = () => {
       try {
         const profileResponse = await fetchUserProfile(username);
         setProfile(profileResponse.data);
+        if (user && profileResponse.data.id !== user.id) {
+          fetchSubscription(profileResponse.data.id)
+            .then((subscription) => setSubscribed(subscription.subscribed))
+            .catch((err) => console.error('Failed to load subscription:', err));
+        }
       } catch (err) {
         console.error('Failed to load profile:', err);
         setError('Failed to load profile.');
@@ -31,8 +53,9 @@ const Profile = () => {
 
     const loadPosts = async () => {
       try {
-        const postsResponse = await fetchUserPosts(username);
-        setPosts(postsResponse.data);
+        const page = await fetchUserPosts(username);
+        setPosts(page.items);
+        setNextCursor(page.next_cursor || '');
       } catch (err) {
         console.error('Failed to load posts:', err);
         setError('Failed to load posts.');
@@ -44,7 +67,54 @@ const Profile = () => {
     
     loadProfile()
     loadPosts();
-  }, [username]);
+  }, [username, user]);
+
+  const handleToggleFollow = async () => {
+    setFollowBusy(true);
+    try {
+      const following = profile.relationship?.following;
+      const data = following ? await unfollowUser(profile.id) : await followUser(profile.id);
+      setProfile((prev) => ({
+        ...prev,
+        relationship: data.relationship,
+        followers_count: data.followers_count,
+      }));
+    } catch (err) {
+      console.error('Failed to update follow:', err);
+      alert('Failed to update subscription. Please try again.');
+    } finally {
+      setFollowBusy(false);
+    }
+  };
+
+  const handleToggleSubscription = async () => {
+    setFollowBusy(true);
+    try {
+      const data = subscribed
+        ? await unsubscribeFromAuthor(profile.id)
+        : await subscribeToAuthor(profile.id);
+      setSubscribed(data.subscribed);
+    } catch (err) {
+      console.error('Failed to update subscription:', err);
+      alert('Failed to update subscription. Please try again.');
+    } finally {
+      setFollowBusy(false);
+    }
+  };
+
+  const handleLoadMore = async () => {
+    setIsLoadingMore(true);
+    try {
+      const page = await fetchUserPosts(username, nextCursor);
+      setPosts((prev) => [...prev, ...page.items]);
+      setNextCursor(page.next_cursor || '');
+    } catch (err) {
+      console.error('Failed to load posts:', err);
+      alert('Failed to load more posts. Please try again.');
+    } finally {
+      setIsLoadingMore(false);
+    }
+  };
 
   const handleDeletePost = async (postId) => {
     try {
@@ -71,9 +141,48 @@ const Profile = () => {
           <h1>{isOwnProfile ? 'Your Profile' : `${profile.username}'s Profile`}</h1>
         </div>
         <div className="profile-info">
-          <h2>Username: {profile.username}</h2>
-          <h3>Email: {profile.email}</h3>
+          {profile.avatar_urls ? (
+            <img className="profile-avatar" src={profile.avatar_urls['256']} alt={profile.username} />
+          ) : (
+            <div className="profile-avatar placeholder">{profile.username.charAt(0).toUpperCase()}</div>
+          )}
+          <div className="profile-details">
+            <h2>{profile.display_name || profile.username}</h2>
+            {profile.display_name && <h3>@{profile.username}</h3>}
+            {isOwnProfile && user?.email && <p className="profile-meta">{user.email}</p>}
+            {profile.bio && <p className="profile-bio">{profile.bio}</p>}
+            {profile.location && <p className="profile-meta">{profile.location}</p>}
+            {profile.website && (
+              <a className="profile-meta" href={profile.website} target="_blank" rel="noopener noreferrer nofollow">
+                {profile.website}
+              </a>
+            )}
+            <div className="profile-follows">
+              <button type="button" className="link-button" onClick={() => setOpenList('followers')}>
+                <strong>{profile.followers_count}</strong> followers
+              </button>
+              <button type="button" className="link-button" onClick={() => setOpenList('following')}>
+                <strong>{profile.following_count}</strong> following
+              </button>
+              {profile.relationship?.followed_by && (
+                <span className="mutual-badge">{profile.relationship.mutual ? 'Mutual' : 'Follows you'}</span>
+              )}
+            </div>
+            {user && !isOwnProfile && (
+              <div className="profile-actions">
+                <button type="button" className="follow-button" onClick={handleToggleFollow} disabled={followBusy}>
+                  {profile.relationship?.following ? 'Unfollow' : 'Follow'}
+                </button>
+                <button type="button" className="follow-button" onClick={handleToggleSubscription} disabled={followBusy}>
+                  {subscribed ? 'Remove posts from feed' : 'Add posts to feed'}
+                </button>
+              </div>
+            )}
+          </div>
         </div>
+        {openList && <FollowList userId={profile.id} kind={openList} onClose={() => setOpenList(null)} />}
+        {isOwnProfile && <ProfileEditor profile={profile} userId={user.id} onUpdated={setProfile} />}
+        {isOwnProfile && <ChangePassword />}
         <div className="profile-posts">
           <h2>{isOwnProfile ? 'My Blogs' : `${profile.username}'s Blogs`}</h2>
           <PostList
@@ -83,6 +192,11 @@ const Profile = () => {
             canDelete={isOwnProfile}
             isOwnProfile={isOwnProfile}
           />
+          {nextCursor && (
+            <button type="button" className="load-more-button" onClick={handleLoadMore} disabled={isLoadingMore}>
+              {isLoadingMore ? 'Loading...' : 'Load more'}
+            </button>
+          )}
         </div>
       </div>
     </div>
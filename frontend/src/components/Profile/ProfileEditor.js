@@ -0,0 +1,112 @@
+import React, { useState } from 'react';
+import { updateProfile, uploadAvatar, deleteAvatar } from '../../api/api';
+
+const fields = [
+  { name: 'display_name', label: 'Display name', maxLength: 50 },
+  { name: 'location', label: 'Location', maxLength: 100 },
+  { name: 'website', label: 'Website', maxLength: 200, type: 'url' },
+];
+
+const ProfileEditor = ({ profile, userId, onUpdated }) => {
+  const [form, setForm] = useState({
+    display_name: profile.display_name || '',
+    bio: profile.bio || '',
+    location: profile.location || '',
+    website: profile.website || '',
+  });
+  const [error, setError] = useState('');
+  const [message, setMessage] = useState('');
+  const [saving, setSaving] = useState(false);
+
+  const handleChange = (e) => {
+    setForm((prev) => ({ ...prev, [e.target.name]: e.target.value }));
+  };
+
+  // Сервер отвечает текстом ошибки валидации, его и показываем
+  const showError = (err, fallback) => {
+    const data = err.response?.data;
+    setError(typeof data === 'string' && data.trim() ? data.trim() : fallback);
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError('');
+    setMessage('');
+    setSaving(true);
+    try {
+      await updateProfile(userId, form);
+      onUpdated({ ...profile, ...form });
+      setMessage('Profile saved.');
+    } catch (err) {
+      showError(err, 'Failed to save profile. Please try again.');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleAvatarChange = async (e) => {
+    const file = e.target.files[0];
+    e.target.value = '';
+    if (!file) {
+      return;
+    }
+    setError('');
+    setMessage('');
+    setSaving(true);
+    try {
+      const data = await uploadAvatar(userId, file);
+      onUpdated({ ...profile, avatar_urls: data.avatar_urls });
+    } catch (err) {
+      showError(err, 'Failed to upload avatar. Please try again.');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleAvatarDelete = async () => {
+    setError('');
+    setSaving(true);
+    try {
+      await deleteAvatar(userId);
+      onUpdated({ ...profile, avatar_urls: undefined });
+    } catch (err) {
+      showError(err, 'Failed to remove avatar. Please try again.');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  return (
+    <form className="profile-editor" onSubmit={handleSubmit}>
+      <h2>Edit profile</h2>
+      {error && <p className="error-message">{error}</p>}
+      {message && <p className="success-message">{message}</p>}
+      <label className="avatar-upload">
+        Avatar (JPEG, PNG or GIF, up to 5 MB)
+        <input type="file" accept="image/jpeg,image/png,image/gif" onChange={handleAvatarChange} disabled={saving} />
+      </label>
+      {profile.avatar_urls && (
+        <button type="button" className="secondary" onClick={handleAvatarDelete} disabled={saving}>
+          Remove avatar
+        </button>
+      )}
+      {fields.map(({ name, label, maxLength, type }) => (
+        <input
+          key={name}
+          name={name}
+          type={type || 'text'}
+          placeholder={label}
+          maxLength={maxLength}
+          value={form[name]}
+          onChange={handleChange}
+        />
+      ))}
+      <textarea name="bio" placeholder="Bio" maxLength={500} rows={4} value={form.bio} onChange={handleChange} />
+      <button type="submit" disabled={saving}>
+        {saving ? 'Saving...' : 'Save profile'}
+      </button>
+    </form>
+  );
+};
+
+export default ProfileEditor;
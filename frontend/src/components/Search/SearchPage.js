@@ -0,0 +1,140 @@
+import React, { useState, useEffect } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { searchPosts } from '../../api/api';
+import '../../styles/Search/SearchPage.css';
+
+// Сервер отмечает совпадения тегами <mark>; остальной текст выводится как
+// обычный текст, без разбора HTML
+const Highlighted = ({ text }) => {
+  const parts = text.split(/<mark>|<\/mark>/);
+  return (
+    <>
+      {parts.map((part, i) => (i % 2 === 1 ? <mark key={i}>{part}</mark> : part))}
+    </>
+  );
+};
+
+const SearchPage = () => {
+  const [searchParams, setSearchParams] = useSearchParams();
+  const [form, setForm] = useState({ q: '', author: '', from: '', to: '' });
+  const [results, setResults] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+  const [isLoadingMore, setIsLoadingMore] = useState(false);
+  const [error, setError] = useState(null);
+
+  const filters = {
+    q: searchParams.get('q') || '',
+    author: searchParams.get('author') || '',
+    from: searchParams.get('from') || '',
+    to: searchParams.get('to') || '',
+  };
+  const filtersKey = searchParams.toString();
+
+  useEffect(() => {
+    setForm(filters);
+    if (!filters.q) {
+      setResults([]);
+      setNextCursor('');
+      return;
+    }
+
+    const load = async () => {
+      setIsLoading(true);
+      setError(null);
+      try {
+        const page = await searchPosts(filters);
+        setResults(page.items);
+        setNextCursor(page.next_cursor || '');
+      } catch (err) {
+        console.error('Failed to search posts:', err);
+        setError('Search failed. Please try again.');
+      } finally {
+        setIsLoading(false);
+      }
+    };
+
+    load();
+    // filters пересоздаётся при каждом рендере, его содержимое задаёт filtersKey
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [filtersKey]);
+
+  const handleChange = (e) => {
+    setForm((prev) => ({ ...prev, [e.target.name]: e.target.value }));
+  };
+
+  const handleSubmit = (e) => {
+    e.preventDefault();
+    const params = {};
+    Object.entries(form).forEach(([key, value]) => {
+      if (value.trim()) params[key] = value.trim();
+    });
+    setSearchParams(params);
+  };
+
+  const handleLoadMore = async () => {
+    setIsLoadingMore(true);
+    try {
+      const page = await searchPosts(filters, nextCursor);
+      setResults((prev) => [...prev, ...page.items]);
+      setNextCursor(page.next_cursor || '');
+    } catch (err) {
+      console.error('Failed to search posts:', err);
+      alert('Failed to load more results. Please try again.');
+    } finally {
+      setIsLoadingMore(false);
+    }
+  };
+
+  return (
+    <div className="search-page">
+      <form className="search-form" onSubmit={handleSubmit}>
+        <input type="search" name="q" placeholder="Search posts" value={form.q} onChange={handleChange} />
+        <div className="search-filters">
+          <input type="text" name="author" placeholder="Author username" value={form.author} onChange={handleChange} />
+          <label>
+            From <input type="date" name="from" value={form.from} onChange={handleChange} />
+          </label>
+          <label>
+            To <input type="date" name="to" value={form.to} onChange={handleChange} />
+          </label>
+          <button type="submit">Search</button>
+        </div>
+      </form>
+
+      {isLoading && <div className="search-status">Loading...</div>}
+      {error && <p className="search-status">{error}</p>}
+      {!isLoading && !error && filters.q && results.length === 0 && (
+        <div className="search-status">Nothing found.</div>
+      )}
+
+      <div className="search-results">
+        {results.map((result) => (
+          <div key={result.id} className="search-result">
+            <Link to={`/post/${result.id}`} className="search-result-title">
+              <Highlighted text={result.titleHighlight} />
+            </Link>
+            <p className="search-result-snippet">
+              <Highlighted text={result.snippet} />
+            </p>
+            <div className="search-result-meta">
+              <Link to={`/profile/${result.authorUsername}`}>{result.authorUsername}</Link>
+              {' · '}
+              {new Date(result.createdAt).toLocaleDateString()}
+              {' · '}
+              {result.likesCount} likes
+            </div>
+          </div>
+        ))}
+      </div>
+
+      {nextCursor && !isLoading && (
+        <button type="button" className="load-more-button" onClick={handleLoadMore} disabled={isLoadingMore}>
+          {isLoadingMore ? 'Loading...' : 'Load more'}
+        </button>
+      )}
+    </div>
+  );
+};
+
+export default SearchPage;
@@ -0,0 +1,74 @@
+import React, { useState, useEffect } from 'react';
+import { useParams } from 'react-router-dom';
+import { useAuth } from '../../context/AuthContext';
+import { fetchTagPosts } from '../../api/api';
+import PostList from '../Blog/PostList';
+import TrendingTags from './TrendingTags';
+import '../../styles/Tags/Tags.css';
+
+// Опубликованные посты с тегом, новые сверху
+const TagPage = () => {
+  const { user } = useAuth();
+  const { tag } = useParams();
+  const [posts, setPosts] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
+  const [isLoading, setIsLoading] = useState(true);
+  const [isLoadingMore, setIsLoadingMore] = useState(false);
+  const [error, setError] = useState(null);
+
+  useEffect(() => {
+    const loadPosts = async () => {
+      try {
+        setIsLoading(true);
+        setError(null);
+        const page = await fetchTagPosts(tag);
+        setPosts(page.items);
+        setNextCursor(page.next_cursor || '');
+      } catch (err) {
+        console.error('Failed to fetch tag posts:', err);
+        setError(err.response?.status === 400 ? 'Invalid tag.' : 'Failed to fetch posts.');
+      } finally {
+        setIsLoading(false);
+      }
+    };
+
+    loadPosts();
+  }, [tag]);
+
+  const handleLoadMore = async () => {
+    setIsLoadingMore(true);
+    try {
+      const page = await fetchTagPosts(tag, nextCursor);
+      setPosts((prev) => [...prev, ...page.items]);
+      setNextCursor(page.next_cursor || '');
+    } catch (err) {
+      console.error('Failed to fetch tag posts:', err);
+      alert('Failed to load more posts. Please try again.');
+    } finally {
+      setIsLoadingMore(false);
+    }
+  };
+
+  return (
+    <div className="tag-page-container">
+      <h2 className="tag-page-title">#{tag}</h2>
+      <TrendingTags />
+      {isLoading ? (
+        <div className="spinner">Loading...</div>
+      ) : error ? (
+        <p>{error}</p>
+      ) : (
+        <>
+          <PostList posts={posts} currentUserId={user?.id} canDelete={false} isOwnProfile={false} />
+          {nextCursor && (
+            <button type="button" className="tag-load-more" onClick={handleLoadMore} disabled={isLoadingMore}>
+              {isLoadingMore ? 'Loading...' : 'Load more'}
+            </button>
+          )}
+        </>
+      )}
+    </div>
+  );
+};
+
+export default TagPage;
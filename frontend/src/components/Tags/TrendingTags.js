@@ -0,0 +1,54 @@
+import React, { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import { fetchTrendingTags } from '../../api/api';
+import '../../styles/Tags/Tags.css';
+
+const PERIODS = [
+  { value: '24h', label: 'День' },
+  { value: '72h', label: '3 дня' },
+  { value: '168h', label: 'Неделя' },
+];
+
+// Популярные теги за выбранный период; пока тегов нет, блок не показывается
+const TrendingTags = () => {
+  const [period, setPeriod] = useState('72h');
+  const [tags, setTags] = useState([]);
+
+  useEffect(() => {
+    fetchTrendingTags(period)
+      .then(setTags)
+      .catch((err) => console.error('Failed to fetch trending tags:', err));
+  }, [period]);
+
+  if (tags.length === 0 && period === '72h') return null;
+
+  return (
+    <div className="trending-tags">
+      <div className="trending-tags-header">
+        <span>В тренде</span>
+        <select value={period} onChange={(e) => setPeriod(e.target.value)} aria-label="Period">
+          {PERIODS.map((p) => (
+            <option key={p.value} value={p.value}>
+              {p.label}
+            </option>
+          ))}
+        </select>
+      </div>
+      <div className="trending-tags-list">
+        {tags.length === 0 && <span className="trending-tags-empty">Нет тегов за этот период</span>}
+        {tags.map((tag) => (
+          <Link
+            key={tag.name}
+            to={`/tags/${encodeURIComponent(tag.name)}`}
+            className="trending-tag"
+            title={`${tag.posts} posts`}
+          >
+            #{tag.name}
+          </Link>
+        ))}
+      </div>
+    </div>
+  );
+};
+
+export default TrendingTags;
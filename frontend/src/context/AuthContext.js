@@ -1,5 +1,6 @@
 import React, { createContext, useContext, useState, useEffect } from 'react';
 import { jwtDecode } from 'jwt-decode';
+import { logout as apiLogout, refreshSession } from '../api/api';
 
 const AuthContext = createContext();
 
@@ -9,7 +10,7 @@ export const AuthProvider = ({ children }) => {
   const [isLoading, setIsLoading] = useState(true);
 
   useEffect(() => {
-    const initializeAuth = () => {
+    const initializeAuth = async () => {
       const storedUser = localStorage.getItem('user');
       const storedToken = localStorage.getItem('token');
   
@@ -23,12 +24,14 @@ export const AuthProvider = ({ children }) => {
             setUser(JSON.parse(storedUser));
             setIsAuthenticated(true);
           } else {
-            console.warn('Token expired, logging out...');
-            logout();
+            console.warn('Token expired, refreshing...');
+            await refreshSession();
+            setUser(JSON.parse(storedUser));
+            setIsAuthenticated(true);
           }
         } catch (error) {
-          console.error('Error decoding token:', error);
-          logout();
+          console.error('Failed to restore session:', error);
+          clearSession();
         }
       }
   
@@ -38,18 +41,35 @@ export const AuthProvider = ({ children }) => {
     initializeAuth();
   }, []);
 
-  const login = ({ user: userData, token }) => {
+  const login = ({ user: userData, token, refresh_token: refreshToken }) => {
     setIsAuthenticated(true);
     setUser(userData);
     localStorage.setItem('user', JSON.stringify(userData));
     localStorage.setItem('token', token);
+    if (refreshToken) {
+      localStorage.setItem('refreshToken', refreshToken);
+    }
   };
 
-  const logout = () => {
+  const clearSession = () => {
     setIsAuthenticated(false);
     setUser(null);
     localStorage.removeItem('user');
     localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
+  };
+
+  // Отзываем сессию на сервере, чтобы refresh-токен нельзя было использовать повторно
+  const logout = async () => {
+    const refreshToken = localStorage.getItem('refreshToken');
+    clearSession();
+    if (refreshToken) {
+      try {
+        await apiLogout(refreshToken);
+      } catch (error) {
+        console.error('Failed to revoke session:', error);
+      }
+    }
   };
 
   const setAuthToken = (token) => {
@@ -59,11 +79,11 @@ export const AuthProvider = ({ children }) => {
       if (decodedToken.exp * 1000 > Date.now()) {
         setIsAuthenticated(true);
       } else {
-        logout();
+        clearSession();
       }
     } catch (error) {
       console.error('Invalid token:', error);
-      logout();
+      clearSession();
     }
   };
 